@@ -61,6 +61,8 @@ func GetRunner(factory cmdutil.Factory, invFactory inventory.ClientFactory,
 	cmd.Flags().BoolVar(&r.printStatusEvents, "status-events", false,
 		"Print status events (always enabled for table output)")
 
+	cmd.Flags().BoolVar(&r.lock, flagutils.LockFlag, false, flagutils.LockFlagUsage)
+
 	r.Command = cmd
 	return r
 }
@@ -86,6 +88,7 @@ type Runner struct {
 	inventoryPolicy        string
 	timeout                time.Duration
 	printStatusEvents      bool
+	lock                   bool
 }
 
 func (r *Runner) RunE(cmd *cobra.Command, args []string) error {
@@ -162,6 +165,7 @@ func (r *Runner) RunE(cmd *cobra.Command, args []string) error {
 		PrunePropagationPolicy: prunePropPolicy,
 		PruneTimeout:           r.pruneTimeout,
 		InventoryPolicy:        inventoryPolicy,
+		Lock:                   flagutils.ConvertLock(r.lock),
 	})
 
 	// The printer will print updates from the channel. It will block
@@ -50,6 +50,15 @@ func GetRunner(factory cmdutil.Factory, invFactory inventory.ClientFactory,
 		"How long to wait before exiting")
 	cmd.Flags().BoolVar(&r.printStatusEvents, "status-events", false,
 		"Print status events (always enabled for table output)")
+	cmd.Flags().BoolVar(&r.abandon, "abandon", false,
+		"Remove the inventory annotation from the resources instead of deleting them, and delete only the inventory")
+	cmd.Flags().StringVar(&r.namespaceContentsPolicy, flagutils.NamespaceContentsPolicyFlag,
+		flagutils.NamespaceContentsPolicyIgnore,
+		"It determines the behavior when a namespace to delete still contains resources not in the inventory. "+
+			fmt.Sprintf("Available options %q, %q and %q.", flagutils.NamespaceContentsPolicyIgnore,
+				flagutils.NamespaceContentsPolicySkip, flagutils.NamespaceContentsPolicyProceed))
+
+	cmd.Flags().BoolVar(&r.lock, flagutils.LockFlag, false, flagutils.LockFlagUsage)
 
 	r.Command = cmd
 	return r
@@ -75,6 +84,9 @@ type Runner struct {
 	inventoryPolicy         string
 	timeout                 time.Duration
 	printStatusEvents       bool
+	abandon                 bool
+	namespaceContentsPolicy string
+	lock                    bool
 }
 
 func (r *Runner) RunE(cmd *cobra.Command, args []string) error {
@@ -94,6 +106,10 @@ func (r *Runner) RunE(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	namespaceContentsPolicy, err := flagutils.ConvertNamespaceContentsPolicy(r.namespaceContentsPolicy)
+	if err != nil {
+		return err
+	}
 
 	if found := printers.ValidatePrinterType(r.output); !found {
 		return fmt.Errorf("unknown output type %q", r.output)
@@ -138,6 +154,9 @@ func (r *Runner) RunE(cmd *cobra.Command, args []string) error {
 		DeletePropagationPolicy: deletePropPolicy,
 		InventoryPolicy:         inventoryPolicy,
 		EmitStatusEvents:        r.printStatusEvents,
+		Abandon:                 r.abandon,
+		NamespaceContentsPolicy: namespaceContentsPolicy,
+		Lock:                    flagutils.ConvertLock(r.lock),
 	})
 
 	// The printer will print updates from the channel. It will block
@@ -5,7 +5,9 @@ package flagutils
 
 import (
 	"fmt"
+	"os"
 
+	"github.com/fluxcd/cli-utils/pkg/apply/filter"
 	"github.com/fluxcd/cli-utils/pkg/inventory"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -15,8 +17,33 @@ const (
 	InventoryPolicyStrict     = "strict"
 	InventoryPolicyAdopt      = "adopt"
 	InventoryPolicyForceAdopt = "force-adopt"
+
+	NamespaceContentsPolicyFlag    = "namespace-contents-policy"
+	NamespaceContentsPolicyIgnore  = "ignore"
+	NamespaceContentsPolicySkip    = "skip"
+	NamespaceContentsPolicyProceed = "proceed"
+
+	LockFlag      = "lock"
+	LockFlagUsage = "If true, hold a lock on the inventory while running, and fail if another run holds it."
 )
 
+// ConvertLock returns the inventory LockOptions for the lock flag. The holder
+// names the host and process running the command for diagnostics only. It may
+// collide, e.g. in containers where the pid is 1, but the lock appends a
+// random token to it for each acquisition.
+func ConvertLock(lock bool) inventory.LockOptions {
+	if !lock {
+		return inventory.LockOptions{}
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return inventory.LockOptions{
+		Holder: fmt.Sprintf("kapply@%s/%d", hostname, os.Getpid()),
+	}
+}
+
 // ConvertPropagationPolicy converts a propagationPolicy described as a
 // string to a DeletionPropagation type that is passed into the Applier.
 func ConvertPropagationPolicy(propagationPolicy string) (metav1.DeletionPropagation, error) {
@@ -47,6 +74,23 @@ func ConvertInventoryPolicy(policy string) (inventory.Policy, error) {
 	}
 }
 
+// ConvertNamespaceContentsPolicy converts a namespace contents policy
+// described as a string to a NamespaceContentsPolicy that is passed into the
+// Destroyer.
+func ConvertNamespaceContentsPolicy(policy string) (filter.NamespaceContentsPolicy, error) {
+	switch policy {
+	case NamespaceContentsPolicyIgnore:
+		return filter.NamespaceContentsIgnore, nil
+	case NamespaceContentsPolicySkip:
+		return filter.NamespaceContentsSkip, nil
+	case NamespaceContentsPolicyProceed:
+		return filter.NamespaceContentsProceed, nil
+	default:
+		return filter.NamespaceContentsIgnore, fmt.Errorf(
+			"namespace contents policy must be one of ignore, skip, proceed")
+	}
+}
+
 // PathFromArgs returns the path which is a positional arg from args list
 // returns "-" if there is length of args is 0, which implies no path is provided
 func PathFromArgs(args []string) string {
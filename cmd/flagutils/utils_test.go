@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/fluxcd/cli-utils/pkg/apply/filter"
 	"github.com/fluxcd/cli-utils/pkg/inventory"
 )
 
@@ -50,3 +51,44 @@ func TestConvertInventoryPolicy(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertNamespaceContentsPolicy(t *testing.T) {
+	testcases := []struct {
+		value  string
+		policy filter.NamespaceContentsPolicy
+		err    error
+	}{
+		{
+			value:  "ignore",
+			policy: filter.NamespaceContentsIgnore,
+		},
+		{
+			value:  "skip",
+			policy: filter.NamespaceContentsSkip,
+		},
+		{
+			value:  "proceed",
+			policy: filter.NamespaceContentsProceed,
+		},
+		{
+			value: "random",
+			err:   fmt.Errorf("namespace contents policy must be one of ignore, skip, proceed"),
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.value, func(t *testing.T) {
+			policy, err := ConvertNamespaceContentsPolicy(tc.value)
+			if tc.err == nil {
+				if err != nil {
+					t.Errorf("unexpected error %v", err)
+				}
+				if policy != tc.policy {
+					t.Errorf("expected %v but got %v", tc.policy, policy)
+				}
+			}
+			if err == nil && tc.err != nil {
+				t.Errorf("expected an error, but not happened")
+			}
+		})
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fluxcd/cli-utils/pkg/inventory"
+	"github.com/fluxcd/cli-utils/pkg/manifestreader"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+// Command returns the inventory command, which groups the commands operating
+// on the inventory of a configuration.
+func Command(f cmdutil.Factory, invFactory inventory.ClientFactory, loader manifestreader.ManifestLoader,
+	ioStreams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inventory",
+		Short: i18n.T("Inspect the inventory of a configuration"),
+	}
+	cmd.AddCommand(GetHistoryRunner(f, invFactory, loader, ioStreams).Command)
+	cmd.AddCommand(GetUnlockRunner(f, loader, ioStreams).Command)
+	return cmd
+}
+
+// GetHistoryRunner creates and returns the HistoryRunner which stores the
+// cobra command.
+func GetHistoryRunner(factory cmdutil.Factory, invFactory inventory.ClientFactory,
+	loader manifestreader.ManifestLoader, ioStreams genericclioptions.IOStreams) *HistoryRunner {
+	r := &HistoryRunner{
+		ioStreams:  ioStreams,
+		factory:    factory,
+		invFactory: invFactory,
+		loader:     loader,
+	}
+	cmd := &cobra.Command{
+		Use:                   "history (DIRECTORY | STDIN)",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Show the retained revisions of the inventory of a configuration"),
+		Args:                  cobra.MaximumNArgs(1),
+		RunE:                  r.RunE,
+	}
+	cmd.Flags().IntVar(&r.revision, "revision", 0,
+		"If set, show the objects added and removed by the revision.")
+
+	r.Command = cmd
+	return r
+}
+
+// HistoryRunner encapsulates data necessary to run the history command.
+type HistoryRunner struct {
+	Command    *cobra.Command
+	ioStreams  genericclioptions.IOStreams
+	factory    cmdutil.Factory
+	invFactory inventory.ClientFactory
+	loader     manifestreader.ManifestLoader
+
+	revision int
+}
+
+// RunE prints the revisions of the inventory of the configuration.
+func (r *HistoryRunner) RunE(cmd *cobra.Command, args []string) error {
+	invInfo, err := readInventory(cmd, r.loader, args)
+	if err != nil {
+		return err
+	}
+
+	invClient, err := r.invFactory.NewClient(r.factory)
+	if err != nil {
+		return err
+	}
+	historyClient, ok := invClient.(inventory.HistoryClient)
+	if !ok {
+		return fmt.Errorf("inventory client does not support history")
+	}
+
+	if r.revision != 0 {
+		rev, err := historyClient.GetRevision(invInfo, r.revision)
+		if err != nil {
+			return err
+		}
+		return printRevision(r.ioStreams.Out, rev)
+	}
+	revisions, err := historyClient.ListRevisions(invInfo)
+	if err != nil {
+		return err
+	}
+	if len(revisions) == 0 {
+		fmt.Fprintln(r.ioStreams.Out, "No revisions found")
+		return nil
+	}
+	return printRevisions(r.ioStreams.Out, revisions)
+}
+
+// printRevisions prints a table of the revisions.
+func printRevisions(out io.Writer, revisions []inventory.Revision) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REVISION\tTIMESTAMP\tFIELD MANAGER\tOBJECTS\tADDED\tREMOVED")
+	for _, rev := range revisions {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%d\n", rev.Number, rev.Timestamp.Format(time.RFC3339),
+			rev.FieldManager, len(rev.Objects), len(rev.Added), len(rev.Removed))
+	}
+	return w.Flush()
+}
+
+// printRevision prints the details of the revision.
+func printRevision(out io.Writer, rev *inventory.Revision) error {
+	fmt.Fprintf(out, "Revision:      %d\n", rev.Number)
+	fmt.Fprintf(out, "Timestamp:     %s\n", rev.Timestamp.Format(time.RFC3339))
+	fmt.Fprintf(out, "Field Manager: %s\n", rev.FieldManager)
+	fmt.Fprintf(out, "Objects:       %d\n", len(rev.Objects))
+	printObjects(out, "Added", rev.Added)
+	printObjects(out, "Removed", rev.Removed)
+	return nil
+}
+
+func printObjects(out io.Writer, title string, objs object.ObjMetadataSet) {
+	fmt.Fprintf(out, "%s:\n", title)
+	if len(objs) == 0 {
+		fmt.Fprintln(out, "  <none>")
+		return
+	}
+	for _, obj := range objs {
+		fmt.Fprintf(out, "  %s\n", obj)
+	}
+}
+
+// GetUnlockRunner creates and returns the UnlockRunner which stores the cobra
+// command.
+func GetUnlockRunner(factory cmdutil.Factory, loader manifestreader.ManifestLoader,
+	ioStreams genericclioptions.IOStreams) *UnlockRunner {
+	r := &UnlockRunner{
+		ioStreams: ioStreams,
+		factory:   factory,
+		loader:    loader,
+	}
+	cmd := &cobra.Command{
+		Use:                   "unlock (DIRECTORY | STDIN)",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Force release the lock on the inventory of a configuration"),
+		Long: i18n.T("Force release the lock on the inventory of a configuration, held by " +
+			"an apply or destroy with --lock. Only use it if the holder exited without releasing the lock, " +
+			"because a concurrent apply or destroy may prune the objects of the holder."),
+		Args: cobra.MaximumNArgs(1),
+		RunE: r.RunE,
+	}
+
+	r.Command = cmd
+	return r
+}
+
+// UnlockRunner encapsulates data necessary to run the unlock command.
+type UnlockRunner struct {
+	Command   *cobra.Command
+	ioStreams genericclioptions.IOStreams
+	factory   cmdutil.Factory
+	loader    manifestreader.ManifestLoader
+}
+
+// RunE deletes the lock on the inventory of the configuration.
+func (r *UnlockRunner) RunE(cmd *cobra.Command, args []string) error {
+	invInfo, err := readInventory(cmd, r.loader, args)
+	if err != nil {
+		return err
+	}
+	dc, err := r.factory.DynamicClient()
+	if err != nil {
+		return err
+	}
+	mapper, err := r.factory.ToRESTMapper()
+	if err != nil {
+		return err
+	}
+	holder, err := inventory.ForceUnlock(cmd.Context(), dc, mapper, invInfo)
+	if err != nil {
+		return err
+	}
+	if holder == "" {
+		fmt.Fprintln(r.ioStreams.Out, "Inventory is not locked")
+		return nil
+	}
+	fmt.Fprintf(r.ioStreams.Out, "Inventory unlocked (was held by %q)\n", holder)
+	return nil
+}
+
+// readInventory returns the inventory of the configuration in the directory
+// passed as argument, or the current working directory.
+func readInventory(cmd *cobra.Command, loader manifestreader.ManifestLoader, args []string) (inventory.Info, error) {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+	reader, err := loader.ManifestReader(cmd.InOrStdin(), dir)
+	if err != nil {
+		return nil, err
+	}
+	objs, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	invObj, _, err := inventory.SplitUnstructureds(objs)
+	if err != nil {
+		return nil, err
+	}
+	return inventory.WrapInventoryInfoObj(invObj), nil
+}
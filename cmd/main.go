@@ -15,8 +15,10 @@ import (
 	"github.com/fluxcd/cli-utils/cmd/destroy"
 	"github.com/fluxcd/cli-utils/cmd/diff"
 	"github.com/fluxcd/cli-utils/cmd/initcmd"
+	cmdinventory "github.com/fluxcd/cli-utils/cmd/inventory"
 	"github.com/fluxcd/cli-utils/cmd/preview"
 	"github.com/fluxcd/cli-utils/cmd/status"
+	"github.com/fluxcd/cli-utils/cmd/transfer"
 	"github.com/fluxcd/cli-utils/pkg/flowcontrol"
 	"github.com/fluxcd/cli-utils/pkg/inventory"
 	"github.com/fluxcd/cli-utils/pkg/manifestreader"
@@ -62,9 +64,11 @@ func main() {
 	}
 
 	loader := manifestreader.NewManifestLoader(f)
-	invFactory := inventory.ClusterClientFactory{StatusPolicy: inventory.StatusPolicyNone}
+	invFactory := &inventory.ClusterClientFactory{StatusPolicy: inventory.StatusPolicyNone}
+	flags.IntVar(&invFactory.History.Limit, "inventory-history-limit", 0,
+		"Number of inventory revisions to retain. If zero, no revisions are recorded.")
 
-	names := []string{"init", "apply", "destroy", "diff", "preview", "status"}
+	names := []string{"init", "apply", "destroy", "diff", "preview", "status", "transfer", "inventory"}
 	subCmds := []*cobra.Command{
 		initcmd.NewCmdInit(f, ioStreams),
 		apply.Command(f, invFactory, loader, ioStreams),
@@ -72,6 +76,8 @@ func main() {
 		diff.NewCommand(f, ioStreams),
 		preview.Command(f, invFactory, loader, ioStreams),
 		status.Command(context.TODO(), f, invFactory, status.NewInventoryLoader(loader)),
+		transfer.Command(f, invFactory, loader, ioStreams),
+		cmdinventory.Command(f, invFactory, loader, ioStreams),
 	}
 	for _, subCmd := range subCmds {
 		subCmd.PreRunE = preRunE
@@ -0,0 +1,155 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fluxcd/cli-utils/cmd/flagutils"
+	"github.com/fluxcd/cli-utils/pkg/apply"
+	"github.com/fluxcd/cli-utils/pkg/common"
+	"github.com/fluxcd/cli-utils/pkg/inventory"
+	"github.com/fluxcd/cli-utils/pkg/manifestreader"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/cli-utils/pkg/printers"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+)
+
+// GetRunner creates and returns the Runner which stores the cobra command.
+func GetRunner(factory cmdutil.Factory, invFactory inventory.ClientFactory,
+	loader manifestreader.ManifestLoader, ioStreams genericclioptions.IOStreams) *Runner {
+	r := &Runner{
+		ioStreams:  ioStreams,
+		factory:    factory,
+		invFactory: invFactory,
+		loader:     loader,
+	}
+	cmd := &cobra.Command{
+		Use:                   "transfer SOURCE_DIRECTORY TARGET_DIRECTORY",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Transfer the resources of a configuration from the inventory of another configuration"),
+		Args:                  cobra.ExactArgs(2),
+		RunE:                  r.RunE,
+	}
+
+	cmd.Flags().StringVar(&r.output, "output", printers.DefaultPrinter(),
+		fmt.Sprintf("Output format, must be one of %s", strings.Join(printers.SupportedPrinters(), ",")))
+	cmd.Flags().StringVar(&r.inventoryPolicy, flagutils.InventoryPolicyFlag, flagutils.InventoryPolicyStrict,
+		"It determines the behavior when the resources don't belong to the source inventory. Available options "+
+			fmt.Sprintf("%q, %q and %q.", flagutils.InventoryPolicyStrict, flagutils.InventoryPolicyAdopt, flagutils.InventoryPolicyForceAdopt))
+	cmd.Flags().BoolVar(&r.dryRun, "dry-run", false,
+		"If true, only print the resources that would be transferred, without changing them.")
+	cmd.Flags().BoolVar(&r.serverSide, "server-side", false,
+		"If true during dry-run, the changes are validated by the server.")
+	cmd.Flags().DurationVar(&r.timeout, "timeout", 0,
+		"How long to wait before exiting")
+
+	r.Command = cmd
+	return r
+}
+
+// Command creates the Runner, returning the cobra command associated with it.
+func Command(f cmdutil.Factory, invFactory inventory.ClientFactory, loader manifestreader.ManifestLoader,
+	ioStreams genericclioptions.IOStreams) *cobra.Command {
+	return GetRunner(f, invFactory, loader, ioStreams).Command
+}
+
+// Runner encapsulates data necessary to run the transfer command.
+type Runner struct {
+	Command    *cobra.Command
+	ioStreams  genericclioptions.IOStreams
+	factory    cmdutil.Factory
+	invFactory inventory.ClientFactory
+	loader     manifestreader.ManifestLoader
+
+	output          string
+	inventoryPolicy string
+	dryRun          bool
+	serverSide      bool
+	timeout         time.Duration
+}
+
+// RunE transfers the resources in the target directory from the inventory
+// of the source directory to the inventory of the target directory.
+func (r *Runner) RunE(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	// If specified, cancel with timeout.
+	if r.timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	inventoryPolicy, err := flagutils.ConvertInventoryPolicy(r.inventoryPolicy)
+	if err != nil {
+		return err
+	}
+
+	if found := printers.ValidatePrinterType(r.output); !found {
+		return fmt.Errorf("unknown output type %q", r.output)
+	}
+
+	drs := common.DryRunNone
+	if r.dryRun {
+		drs = common.DryRunClient
+		if r.serverSide {
+			drs = common.DryRunServer
+		}
+	}
+
+	sourceInvObj, _, err := r.readPackage(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	targetInvObj, targetObjs, err := r.readPackage(cmd, args[1])
+	if err != nil {
+		return err
+	}
+
+	invClient, err := r.invFactory.NewClient(r.factory)
+	if err != nil {
+		return err
+	}
+	t, err := apply.NewTransfererBuilder().
+		WithFactory(r.factory).
+		WithInventoryClient(invClient).
+		Build()
+	if err != nil {
+		return err
+	}
+
+	// Run the transferer. It will return a channel where we can receive
+	// updates to keep track of progress and any issues.
+	ch := t.Run(ctx, inventory.WrapInventoryInfoObj(sourceInvObj), inventory.WrapInventoryInfoObj(targetInvObj),
+		object.UnstructuredSetToObjMetadataSet(targetObjs), apply.TransfererOptions{
+			InventoryPolicy: inventoryPolicy,
+			DryRunStrategy:  drs,
+		})
+
+	// The printer will print updates from the channel. It will block
+	// until the channel is closed.
+	printer := printers.GetPrinter(r.output, r.ioStreams)
+	return printer.Print(ch, drs, false)
+}
+
+// readPackage returns the inventory object template and the other objects
+// of the package in the directory.
+func (r *Runner) readPackage(cmd *cobra.Command, dir string) (*unstructured.Unstructured, object.UnstructuredSet, error) {
+	reader, err := r.loader.ManifestReader(cmd.InOrStdin(), dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	objs, err := reader.Read()
+	if err != nil {
+		return nil, nil, err
+	}
+	return inventory.SplitUnstructureds(objs)
+}
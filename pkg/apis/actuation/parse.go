@@ -0,0 +1,53 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package actuation
+
+import "fmt"
+
+// ParseActuationStrategy returns the ActuationStrategy with the specified
+// String value, or an error if there is none.
+func ParseActuationStrategy(s string) (ActuationStrategy, error) {
+	for _, v := range []ActuationStrategy{
+		ActuationStrategyApply,
+		ActuationStrategyDelete,
+	} {
+		if v.String() == s {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid actuation strategy: %q", s)
+}
+
+// ParseActuationStatus returns the ActuationStatus with the specified
+// String value, or an error if there is none.
+func ParseActuationStatus(s string) (ActuationStatus, error) {
+	for _, v := range []ActuationStatus{
+		ActuationPending,
+		ActuationSucceeded,
+		ActuationSkipped,
+		ActuationFailed,
+	} {
+		if v.String() == s {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid actuation status: %q", s)
+}
+
+// ParseReconcileStatus returns the ReconcileStatus with the specified
+// String value, or an error if there is none.
+func ParseReconcileStatus(s string) (ReconcileStatus, error) {
+	for _, v := range []ReconcileStatus{
+		ReconcilePending,
+		ReconcileSucceeded,
+		ReconcileSkipped,
+		ReconcileFailed,
+		ReconcileTimeout,
+	} {
+		if v.String() == s {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid reconcile status: %q", s)
+}
@@ -77,6 +77,16 @@ type ObjectStatus struct {
 	// Generation is not available for deleted objects.
 	// +optional
 	Generation int64 `json:"generation,omitempty"`
+
+	// Hash is a hash of the content of the object, when it was last applied.
+	// This can help identify if the object needs to be applied again.
+	// +optional
+	Hash string `json:"hash,omitempty"`
+
+	// AppliedTime is when the object was last applied. Objects skipped,
+	// because they were unchanged, retain the time of the previous apply.
+	// +optional
+	AppliedTime *metav1.Time `json:"appliedTime,omitempty"`
 }
 
 //nolint:revive // consistent prefix improves tab-completion for enums
@@ -55,7 +55,9 @@ func (in *InventoryStatus) DeepCopyInto(out *InventoryStatus) {
 	if in.Objects != nil {
 		in, out := &in.Objects, &out.Objects
 		*out = make([]ObjectStatus, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	return
 }
@@ -90,6 +92,10 @@ func (in *ObjectReference) DeepCopy() *ObjectReference {
 func (in *ObjectStatus) DeepCopyInto(out *ObjectStatus) {
 	*out = *in
 	out.ObjectReference = in.ObjectReference
+	if in.AppliedTime != nil {
+		in, out := &in.AppliedTime, &out.AppliedTime
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -15,6 +15,7 @@ import (
 	"github.com/fluxcd/cli-utils/pkg/apply/info"
 	"github.com/fluxcd/cli-utils/pkg/apply/mutator"
 	"github.com/fluxcd/cli-utils/pkg/apply/prune"
+	"github.com/fluxcd/cli-utils/pkg/apply/retry"
 	"github.com/fluxcd/cli-utils/pkg/apply/solver"
 	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
 	"github.com/fluxcd/cli-utils/pkg/common"
@@ -22,6 +23,7 @@ import (
 	"github.com/fluxcd/cli-utils/pkg/kstatus/watcher"
 	"github.com/fluxcd/cli-utils/pkg/object"
 	"github.com/fluxcd/cli-utils/pkg/object/validation"
+	"github.com/fluxcd/cli-utils/pkg/object/waitfor"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -97,16 +99,30 @@ func (a *Applier) prepareObjects(localInv inventory.Info, localObjs object.Unstr
 // on progress and any errors reported back on the event channel.
 // Cancelling the operation or setting timeout on how long to Wait
 // for it complete can be done with the passed in context.
-// Note: There isn't currently any way to interrupt the operation
-// before all the given resources have been applied to the cluster. Any
-// cancellation or timeout will only affect how long we Wait for the
-// resources to become current.
+// Once the context is done, the remaining objects are not applied or
+// pruned, but reported as skipped, and the inventory is still updated
+// to reflect the objects that were actuated. An apply request that is
+// already in flight is not interrupted.
 func (a *Applier) Run(ctx context.Context, invInfo inventory.Info, objects object.UnstructuredSet, options ApplierOptions) <-chan event.Event {
+	return a.run(ctx, invInfo, objects, options, nil)
+}
+
+// run performs the Apply step. If hook is not nil, it is called once the
+// task queue is built. The tasks are only run, if the hook returns nil.
+func (a *Applier) run(ctx context.Context, invInfo inventory.Info, objects object.UnstructuredSet,
+	options ApplierOptions, hook runHook) <-chan event.Event {
 	klog.V(4).Infof("apply run for %d objects", len(objects))
 	eventChannel := make(chan event.Event)
 	setDefaults(&options)
 	go func() {
 		defer close(eventChannel)
+		ctx, unlock, err := lockInventory(ctx, a.client, a.mapper, invInfo, options.Lock, options.DryRunStrategy)
+		if err != nil {
+			handleError(eventChannel, err)
+			return
+		}
+		defer unlock()
+
 		// Validate the resources to make sure we catch those problems early
 		// before anything has been updated in the cluster.
 		vCollector := &validation.Collector{}
@@ -124,9 +140,26 @@ func (a *Applier) Run(ctx context.Context, invInfo inventory.Info, objects objec
 		}
 		klog.V(4).Infof("calculated %d apply objs; %d prune objs", len(applyObjs), len(pruneObjs))
 
+		// Load the checkpoint before the inventory status is reset.
+		var checkpoint, lastApplied map[object.ObjMetadata]actuation.ObjectStatus
+		if options.Resume || options.ApplyOnlyChanged {
+			status, err := a.loadCheckpoint(invInfo)
+			if err != nil {
+				handleError(eventChannel, err)
+				return
+			}
+			klog.V(4).Infof("loaded checkpoint for %d objs", len(status))
+			if options.Resume {
+				checkpoint = status
+			}
+			if options.ApplyOnlyChanged {
+				lastApplied = status
+			}
+		}
+
 		// Build a TaskContext for passing info between tasks
 		resourceCache := cache.NewResourceCacheMap()
-		taskContext := taskrunner.NewTaskContext(eventChannel, resourceCache)
+		taskContext := taskrunner.NewTaskContext(ctx, eventChannel, resourceCache)
 
 		// Fetch the queue (channel) of tasks that should be executed.
 		klog.V(4).Infoln("applier building task queue...")
@@ -189,6 +222,18 @@ func (a *Applier) Run(ctx context.Context, invInfo inventory.Info, objects objec
 			PrunePropagationPolicy: options.PrunePropagationPolicy,
 			PruneTimeout:           options.PruneTimeout,
 			InventoryPolicy:        options.InventoryPolicy,
+			Concurrency:            options.Concurrency,
+			Checkpoint:             checkpoint,
+			SaveCheckpoints:        options.Resume,
+			WaitConditions:         options.WaitConditions,
+			Rollback:               options.Rollback,
+			RetryPolicy:            options.RetryPolicy,
+			Batch:                  options.Batch,
+			MigrateClientSideApply: options.MigrateClientSideApply,
+			LastApplied:            lastApplied,
+			FullApplyInterval:      options.FullApplyInterval,
+			RecordHashes:           options.Resume || options.ApplyOnlyChanged,
+			Finalizers:             options.Finalizers,
 		}
 
 		// Build the ordered set of tasks to execute.
@@ -218,6 +263,19 @@ func (a *Applier) Run(ctx context.Context, invInfo inventory.Info, objects objec
 			return
 		}
 
+		if hook != nil {
+			state, err := a.planState(invInfo, applyObjs, taskQueue)
+			if err == nil {
+				err = hook(state)
+			}
+			if err != nil {
+				if err != errPlanned {
+					handleError(eventChannel, err)
+				}
+				return
+			}
+		}
+
 		// Register invalid objects to be retained in the inventory, if present.
 		for _, id := range vCollector.InvalidIds {
 			taskContext.AddInvalidObject(id)
@@ -244,6 +302,7 @@ func (a *Applier) Run(ctx context.Context, invInfo inventory.Info, objects objec
 		err = runner.Run(ctx, taskContext, taskQueue.ToChannel(), taskrunner.Options{
 			EmitStatusEvents:         options.EmitStatusEvents,
 			WatcherRESTScopeStrategy: options.WatcherRESTScopeStrategy,
+			FailurePolicy:            failurePolicy(options),
 		})
 		if err != nil {
 			handleError(eventChannel, err)
@@ -253,13 +312,26 @@ func (a *Applier) Run(ctx context.Context, invInfo inventory.Info, objects objec
 	return eventChannel
 }
 
+// failurePolicy returns the FailurePolicy of the TaskStatusRunner. Rollback
+// stops actuating after the phase in which an object failed, unless the
+// FailurePolicy already stops actuating.
+func failurePolicy(options ApplierOptions) taskrunner.FailurePolicy {
+	if options.Rollback && options.FailurePolicy == taskrunner.ContinueOnError &&
+		!options.DryRunStrategy.ClientOrServerDryRun() {
+		return taskrunner.StopAfterPhase
+	}
+	return options.FailurePolicy
+}
+
 type ApplierOptions struct {
 	// Encapsulates the fields for server-side apply.
 	ServerSideOptions common.ServerSideOptions
 
 	// ReconcileTimeout defines whether the applier should wait
 	// until all applied resources have been reconciled, and if so,
-	// how long to wait.
+	// how long to wait. The reconcile-timeout annotation overrides the
+	// timeout of individual objects, which time out on their own, while
+	// the others are still waited on.
 	ReconcileTimeout time.Duration
 
 	// EmitStatusEvents defines whether status events should be
@@ -281,7 +353,8 @@ type ApplierOptions struct {
 
 	// PruneTimeout defines whether we should wait for all resources
 	// to be fully deleted after pruning, and if so, how long we should
-	// wait.
+	// wait. The reconcile-timeout annotation overrides the timeout of
+	// individual objects.
 	PruneTimeout time.Duration
 
 	// InventoryPolicy defines the inventory policy of apply.
@@ -293,6 +366,116 @@ type ApplierOptions struct {
 	// RESTScopeStrategy specifies which strategy to use when listing and
 	// watching resources. By default, the strategy is selected automatically.
 	WatcherRESTScopeStrategy watcher.RESTScopeStrategy
+
+	// Concurrency defines the maximum number of objects in the same
+	// apply or prune phase that are actuated in parallel. Values less
+	// than two actuate the objects serially.
+	Concurrency int
+
+	// Resume defines whether to resume from the object status persisted
+	// in the cluster inventory by a previous run, e.g. after the process
+	// running it was restarted. The status is persisted as a checkpoint
+	// after each apply phase, so Resume should be set for every run that
+	// may be interrupted. Objects that were applied and reconciled with
+	// the same content, and whose UID and generation in the cluster did
+	// not change since, are not applied again and reported as Unchanged.
+	// Requires an inventory client that stores status (StatusPolicyAll).
+	Resume bool
+
+	// WaitConditions defines custom conditions that applied objects must
+	// meet to be considered reconciled, overriding the wait-for annotation.
+	// By default, applied objects are waited on until they are Current.
+	WaitConditions map[object.ObjMetadata]waitfor.Condition
+
+	// FailurePolicy defines whether to stop actuating after objects fail
+	// to be applied or pruned, fail to reconcile, or time out reconciling.
+	// Skipped objects remain in the inventory.
+	// By default, all phases are actuated and only the dependents of
+	// failed objects are skipped, unless Rollback is set.
+	FailurePolicy taskrunner.FailurePolicy
+
+	// Rollback defines whether to roll back the applied objects, if any of
+	// them fail to apply, fail to reconcile, or time out reconciling.
+	// The state of each object in the cluster is recorded before it is
+	// applied. On failure, objects that existed are restored to that state
+	// and objects that were created are deleted, in reverse apply order.
+	// Restored objects that were adopted by the apply are removed from the
+	// inventory. Pruning is skipped after a rollback, which is reported as
+	// an error.
+	// With the default ContinueOnError FailurePolicy, Rollback implies the
+	// StopAfterPhase policy, so that no later phase is applied before the
+	// rollback. With StopOnFirstFailure, the rollback starts after the task
+	// in which an object failed.
+	// Ignored for dry-run.
+	Rollback bool
+
+	// RetryPolicy defines how often to retry applying or pruning an
+	// object, if the cluster returns a transient error, such as a conflict,
+	// throttling, or a webhook timeout. A retrying event is sent before each
+	// retry. By default, objects are not retried.
+	RetryPolicy retry.Policy
+
+	// Batch defines whether to apply the objects of each phase in batches,
+	// e.g. to roll out changes to many similar objects progressively.
+	// Each batch is applied in its own action group and waited on until
+	// Current, before the next batch is applied. If more objects of a batch
+	// fail than the FailureThreshold, actuation halts and the remaining
+	// apply and prune tasks are skipped. By default, phases are not split.
+	Batch common.BatchOptions
+
+	// MigrateClientSideApply defines whether to transfer the fields managed
+	// by kubectl client-side apply to the server-side apply FieldManager,
+	// before each object is applied. Without it, switching to server-side
+	// apply leaves the fields owned by client-side apply behind, so fields
+	// removed from the objects are never removed from the cluster. Migrated
+	// objects are reported by apply events. Ignored for dry-run and
+	// client-side apply.
+	MigrateClientSideApply bool
+
+	// ApplyOnlyChanged defines whether to skip applying objects that are
+	// unchanged since the previous run. A hash of the content of each
+	// applied object is recorded in the cluster inventory. Objects whose
+	// hash matches, and whose UID and generation in the cluster did not
+	// change since, are not applied again and reported as Unchanged.
+	// Requires an inventory client that stores status (StatusPolicyAll).
+	ApplyOnlyChanged bool
+
+	// Lock defines whether to hold a lock on the inventory while running,
+	// so that concurrent runs for the same inventory do not prune each
+	// other's objects. The lock is a Lease in the namespace of the
+	// inventory, which is acquired before the inventory is updated and
+	// released after the inventory is stored. If another holder holds the
+	// lock, an error event with a LockHeldError is sent and nothing is
+	// applied. Disabled by default. Ignored for dry-run.
+	Lock inventory.LockOptions
+
+	// FullApplyInterval defines how long unchanged objects are skipped
+	// for, if ApplyOnlyChanged, after they were last applied. Changes in
+	// the cluster that do not modify the generation, e.g. to labels, are
+	// only corrected when the objects are applied again. Zero skips
+	// unchanged objects indefinitely.
+	FullApplyInterval time.Duration
+
+	// Finalizers configures the detection of pruned objects stuck
+	// terminating on finalizers, while waiting for them to be deleted.
+	// Stuck objects are reported by wait events, with their blocking
+	// finalizers. Optionally, named finalizers are removed from objects
+	// stuck for longer than a grace period. Disabled by default.
+	Finalizers common.FinalizerOptions
+}
+
+// loadCheckpoint returns the object status persisted in the cluster
+// inventory, indexed by object.
+func (a *Applier) loadCheckpoint(invInfo inventory.Info) (map[object.ObjMetadata]actuation.ObjectStatus, error) {
+	status, err := inventory.GetClusterObjStatus(a.invClient, invInfo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+	checkpoint := make(map[object.ObjMetadata]actuation.ObjectStatus, len(status))
+	for _, objStatus := range status {
+		checkpoint[inventory.ObjMetadataFromObjectReference(objStatus.ObjectReference)] = objStatus
+	}
+	return checkpoint, nil
 }
 
 // setDefaults set the options to the default values if they
@@ -303,6 +486,27 @@ func setDefaults(o *ApplierOptions) {
 	}
 }
 
+// lockInventory acquires the inventory lock, if enabled. Returns the context
+// to run with, which is cancelled if the lock is lost, and a function that
+// releases the lock.
+func lockInventory(ctx context.Context, client dynamic.Interface, mapper meta.RESTMapper, invInfo inventory.Info,
+	opts inventory.LockOptions, dryRun common.DryRunStrategy) (context.Context, func(), error) {
+	if !opts.Enabled() || dryRun.ClientOrServerDryRun() {
+		return ctx, func() {}, nil
+	}
+	lock, err := inventory.AcquireLock(ctx, client, mapper, invInfo, opts)
+	if err != nil {
+		return ctx, nil, err
+	}
+	unlock := func() {
+		if err := lock.Release(); err != nil {
+			// The lock expires, if it is not renewed.
+			klog.Warningf("failed to release inventory lock: %v", err)
+		}
+	}
+	return lock.Context(), unlock, nil
+}
+
 func handleError(eventChannel chan event.Event, err error) {
 	eventChannel <- event.Event{
 		Type: event.ErrorType,
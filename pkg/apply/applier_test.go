@@ -11,6 +11,8 @@ import (
 
 	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
 	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
+	"github.com/fluxcd/cli-utils/pkg/common"
 	"github.com/fluxcd/cli-utils/pkg/inventory"
 	pollevent "github.com/fluxcd/cli-utils/pkg/kstatus/polling/event"
 	"github.com/fluxcd/cli-utils/pkg/kstatus/status"
@@ -1649,7 +1651,16 @@ func TestApplierCancel(t *testing.T) {
 				},
 				// Deployment never becomes Current.
 				// WaitTask is expected to be cancelled before ReconcileTimeout.
-				// Cancelled WaitTask do not sent individual timeout WaitEvents
+				// Cancelled WaitTask sends skipped WaitEvents for pending objects.
+				{
+					// Deployment reconcile skipped.
+					EventType: event.WaitType,
+					WaitEvent: &testutil.ExpWaitEvent{
+						GroupName:  "wait-0",
+						Identifier: testutil.ToIdentifier(t, resources["deployment"]),
+						Status:     event.ReconcileSkipped,
+					},
+				},
 				{
 					// WaitTask finished
 					EventType: event.ActionGroupType,
@@ -1659,25 +1670,24 @@ func TestApplierCancel(t *testing.T) {
 						Type:      event.Finished, // TODO: add Cancelled event type
 					},
 				},
-				// TODO: Update the inventory after cancellation
-				// {
-				// 	// InvSetTask start
-				// 	EventType: event.ActionGroupType,
-				// 	ActionGroupEvent: &testutil.ExpActionGroupEvent{
-				// 		Action:    event.InventoryAction,
-				// 		GroupName: "inventory-set-0",
-				// 		Type:      event.Started,
-				// 	},
-				// },
-				// {
-				// 	// InvSetTask finished
-				// 	EventType: event.ActionGroupType,
-				// 	ActionGroupEvent: &testutil.ExpActionGroupEvent{
-				// 		Action:    event.InventoryAction,
-				// 		GroupName: "inventory-set-0",
-				// 		Type:      event.Finished,
-				// 	},
-				// },
+				{
+					// InvSetTask start
+					EventType: event.ActionGroupType,
+					ActionGroupEvent: &testutil.ExpActionGroupEvent{
+						Action:    event.InventoryAction,
+						GroupName: "inventory-set-0",
+						Type:      event.Started,
+					},
+				},
+				{
+					// InvSetTask finished
+					EventType: event.ActionGroupType,
+					ActionGroupEvent: &testutil.ExpActionGroupEvent{
+						Action:    event.InventoryAction,
+						GroupName: "inventory-set-0",
+						Type:      event.Finished,
+					},
+				},
 				{
 					// Error
 					EventType: event.ErrorType,
@@ -1941,6 +1951,35 @@ func TestApplierCancel(t *testing.T) {
 	}
 }
 
+func TestFailurePolicy(t *testing.T) {
+	testCases := map[string]struct {
+		options  ApplierOptions
+		expected taskrunner.FailurePolicy
+	}{
+		"default": {
+			expected: taskrunner.ContinueOnError,
+		},
+		"rollback stops after phase": {
+			options:  ApplierOptions{Rollback: true},
+			expected: taskrunner.StopAfterPhase,
+		},
+		"rollback keeps stop on first failure": {
+			options:  ApplierOptions{Rollback: true, FailurePolicy: taskrunner.StopOnFirstFailure},
+			expected: taskrunner.StopOnFirstFailure,
+		},
+		"rollback ignored for dry-run": {
+			options:  ApplierOptions{Rollback: true, DryRunStrategy: common.DryRunClient},
+			expected: taskrunner.ContinueOnError,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			assert.Equal(t, tc.expected, failurePolicy(tc.options))
+		})
+	}
+}
+
 func TestReadAndPrepareObjectsNilInv(t *testing.T) {
 	applier := Applier{}
 	_, _, err := applier.prepareObjects(nil, object.UnstructuredSet{}, ApplierOptions{})
@@ -14,6 +14,7 @@ import (
 	"github.com/fluxcd/cli-utils/pkg/apply/filter"
 	"github.com/fluxcd/cli-utils/pkg/apply/info"
 	"github.com/fluxcd/cli-utils/pkg/apply/prune"
+	"github.com/fluxcd/cli-utils/pkg/apply/retry"
 	"github.com/fluxcd/cli-utils/pkg/apply/solver"
 	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
 	"github.com/fluxcd/cli-utils/pkg/common"
@@ -37,6 +38,7 @@ type Destroyer struct {
 	mapper        meta.RESTMapper
 	client        dynamic.Interface
 	openAPIGetter discovery.OpenAPISchemaInterface
+	discoClient   discovery.ServerResourcesInterface
 	infoHelper    info.Helper
 }
 
@@ -49,7 +51,8 @@ type DestroyerOptions struct {
 	DryRunStrategy common.DryRunStrategy
 
 	// DeleteTimeout defines how long we should wait for resources
-	// to be fully deleted.
+	// to be fully deleted. The reconcile-timeout annotation overrides the
+	// timeout of individual objects.
 	DeleteTimeout time.Duration
 
 	// DeletePropagationPolicy defines the deletion propagation policy
@@ -63,6 +66,50 @@ type DestroyerOptions struct {
 
 	// ValidationPolicy defines how to handle invalid objects.
 	ValidationPolicy validation.Policy
+
+	// Concurrency defines the maximum number of objects in the same
+	// delete phase that are deleted in parallel. Values less than two
+	// delete the objects serially.
+	Concurrency int
+
+	// RetryPolicy defines how often to retry deleting an object, if the
+	// cluster returns a transient error, such as a conflict, throttling, or
+	// a webhook timeout. A retrying event is sent before each retry.
+	// By default, objects are not retried.
+	RetryPolicy retry.Policy
+
+	// Selector selects the objects to delete, if set. The other objects in
+	// the inventory are neither deleted nor removed from the inventory, so
+	// the inventory is updated instead of deleted. Selected objects that a
+	// retained object depends on are skipped, like on prune.
+	Selector *object.Selector
+
+	// Abandon removes the inventory annotation from the objects, instead of
+	// deleting them, and deletes only the inventory object. This hands over
+	// the objects to another tool or inventory.
+	Abandon bool
+
+	// Finalizers configures the detection of objects stuck terminating on
+	// finalizers, while waiting for them to be deleted. Stuck objects are
+	// reported by wait events, with their blocking finalizers. Optionally,
+	// named finalizers are removed from objects stuck for longer than a
+	// grace period. Disabled by default.
+	Finalizers common.FinalizerOptions
+
+	// NamespaceContentsPolicy defines how to handle namespaces that still
+	// contain objects not in the inventory, when they are deleted. The
+	// namespaced types are discovered and listed in each namespace, before
+	// it is deleted. With NamespaceContentsSkip, the deletion is skipped and
+	// the unmanaged objects are reported by the delete event. By default,
+	// the contents of namespaces are not checked. Ignored with Abandon.
+	NamespaceContentsPolicy filter.NamespaceContentsPolicy
+
+	// Lock defines whether to hold a lock on the inventory while running,
+	// so that concurrent applies or destroys of the same inventory do not
+	// interfere. If another holder holds the lock, an error event with a
+	// LockHeldError is sent and nothing is deleted. Disabled by default.
+	// Ignored for dry-run.
+	Lock inventory.LockOptions
 }
 
 func setDestroyerDefaults(o *DestroyerOptions) {
@@ -79,6 +126,13 @@ func (d *Destroyer) Run(ctx context.Context, invInfo inventory.Info, options Des
 	setDestroyerDefaults(&options)
 	go func() {
 		defer close(eventChannel)
+		ctx, unlock, err := lockInventory(ctx, d.client, d.mapper, invInfo, options.Lock, options.DryRunStrategy)
+		if err != nil {
+			handleError(eventChannel, err)
+			return
+		}
+		defer unlock()
+
 		// Retrieve the objects to be deleted from the cluster. Second parameter is empty
 		// because no local objects returns all inventory objects for deletion.
 		emptyLocalObjs := object.UnstructuredSet{}
@@ -90,6 +144,18 @@ func (d *Destroyer) Run(ctx context.Context, invInfo inventory.Info, options Des
 			return
 		}
 
+		// Delete only the selected objects, if any, and keep the others in
+		// the inventory.
+		var retained object.ObjMetadataSet
+		var retainedObjs object.UnstructuredSet
+		if options.Selector != nil {
+			deleteObjs, retainedObjs, retained, err = d.selectObjects(invInfo, deleteObjs, *options.Selector)
+			if err != nil {
+				handleError(eventChannel, err)
+				return
+			}
+		}
+
 		// Validate the resources to make sure we catch those problems early
 		// before anything has been updated in the cluster.
 		vCollector := &validation.Collector{}
@@ -101,7 +167,7 @@ func (d *Destroyer) Run(ctx context.Context, invInfo inventory.Info, options Des
 
 		// Build a TaskContext for passing info between tasks
 		resourceCache := cache.NewResourceCacheMap()
-		taskContext := taskrunner.NewTaskContext(eventChannel, resourceCache)
+		taskContext := taskrunner.NewTaskContext(ctx, eventChannel, resourceCache)
 
 		klog.V(4).Infoln("destroyer building task queue...")
 		deleteFilters := []filter.ValidationFilter{
@@ -110,11 +176,31 @@ func (d *Destroyer) Run(ctx context.Context, invInfo inventory.Info, options Des
 				Inv:       invInfo,
 				InvPolicy: options.InventoryPolicy,
 			},
-			filter.DependencyFilter{
+		}
+		if options.Abandon {
+			// Nothing is deleted, so dependencies do not need to be checked.
+			deleteFilters = append(deleteFilters, filter.AbandonFilter{})
+		} else {
+			deleteFilters = append(deleteFilters, filter.DependencyFilter{
 				TaskContext:       taskContext,
 				ActuationStrategy: actuation.ActuationStrategyDelete,
 				DryRunStrategy:    options.DryRunStrategy,
-			},
+				Retained:          retained,
+			})
+			if options.NamespaceContentsPolicy != filter.NamespaceContentsIgnore {
+				invIds, err := d.invClient.GetClusterObjs(invInfo)
+				if err != nil {
+					handleError(eventChannel, err)
+					return
+				}
+				deleteFilters = append(deleteFilters, &filter.NamespaceContentsFilter{
+					TaskContext: taskContext,
+					Client:      d.client,
+					Discovery:   d.discoClient,
+					Inventory:   invIds,
+					Policy:      options.NamespaceContentsPolicy,
+				})
+			}
 		}
 		taskBuilder := &solver.TaskQueueBuilder{
 			Pruner:        d.pruner,
@@ -133,6 +219,11 @@ func (d *Destroyer) Run(ctx context.Context, invInfo inventory.Info, options Des
 			PrunePropagationPolicy: options.DeletePropagationPolicy,
 			PruneTimeout:           options.DeleteTimeout,
 			InventoryPolicy:        options.InventoryPolicy,
+			Concurrency:            options.Concurrency,
+			RetryPolicy:            options.RetryPolicy,
+			Retained:               retained,
+			RetainedObjects:        retainedObjs,
+			Finalizers:             options.Finalizers,
 		}
 
 		// Build the ordered set of tasks to execute.
@@ -194,3 +285,39 @@ func (d *Destroyer) Run(ctx context.Context, invInfo inventory.Info, options Des
 	}()
 	return eventChannel
 }
+
+// selectObjects returns the objects to delete that match the selector, the
+// live objects that do not match it, and the identifiers of the other objects
+// in the inventory, which are retained.
+// Inventory objects that are not found in the cluster are removed from the
+// inventory, like on a full destroy, if their identifier is selected and the
+// selector has no LabelSelector.
+func (d *Destroyer) selectObjects(invInfo inventory.Info, objs object.UnstructuredSet,
+	selector object.Selector) (object.UnstructuredSet, object.UnstructuredSet, object.ObjMetadataSet, error) {
+	invIds, err := d.invClient.GetClusterObjs(invInfo)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var selected, retainedObjs object.UnstructuredSet
+	for _, obj := range objs {
+		if selector.Matches(obj) {
+			selected = append(selected, obj)
+		} else {
+			retainedObjs = append(retainedObjs, obj)
+		}
+	}
+	selectedIds := object.UnstructuredSetToObjMetadataSet(selected)
+	liveIds := object.UnstructuredSetToObjMetadataSet(objs)
+	var retained object.ObjMetadataSet
+	for _, id := range invIds {
+		switch {
+		case selectedIds.Contains(id):
+		case !liveIds.Contains(id) && selector.LabelSelector == nil && selector.MatchesIdentifier(id):
+			klog.V(4).Infof("destroyer removing missing object from inventory: %s", id)
+		default:
+			retained = append(retained, id)
+		}
+	}
+	klog.V(4).Infof("destroyer selected %d objects, retained %d objects", len(selected), len(retained))
+	return selected, retainedObjs, retained, nil
+}
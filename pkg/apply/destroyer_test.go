@@ -16,6 +16,12 @@ import (
 	"github.com/fluxcd/cli-utils/pkg/object"
 	"github.com/fluxcd/cli-utils/pkg/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clienttesting "k8s.io/client-go/testing"
 )
 
 func TestDestroyerCancel(t *testing.T) {
@@ -142,6 +148,15 @@ func TestDestroyerCancel(t *testing.T) {
 				},
 				// Deployment never becomes NotFound.
 				// WaitTask is expected to be cancelled before DeleteTimeout.
+				{
+					// Deployment reconcile skipped.
+					EventType: event.WaitType,
+					WaitEvent: &testutil.ExpWaitEvent{
+						GroupName:  "wait-0",
+						Status:     event.ReconcileSkipped,
+						Identifier: testutil.ToIdentifier(t, resources["deployment"]),
+					},
+				},
 				{
 					// WaitTask finished
 					EventType: event.ActionGroupType,
@@ -153,6 +168,25 @@ func TestDestroyerCancel(t *testing.T) {
 				},
 				// Inventory cannot be deleted, because the objects still exist,
 				// even tho they've been deleted (ex: blocked by finalizer).
+				// The inventory is updated instead.
+				{
+					// DeleteOrUpdateInvTask start
+					EventType: event.ActionGroupType,
+					ActionGroupEvent: &testutil.ExpActionGroupEvent{
+						Action:    event.InventoryAction,
+						GroupName: "inventory-delete-or-update-0",
+						Type:      event.Started,
+					},
+				},
+				{
+					// DeleteOrUpdateInvTask finished
+					EventType: event.ActionGroupType,
+					ActionGroupEvent: &testutil.ExpActionGroupEvent{
+						Action:    event.InventoryAction,
+						GroupName: "inventory-delete-or-update-0",
+						Type:      event.Finished,
+					},
+				},
 				{
 					// Error
 					EventType: event.ErrorType,
@@ -393,3 +427,120 @@ func TestDestroyerCancel(t *testing.T) {
 		})
 	}
 }
+
+func TestDestroyerSelectObjects(t *testing.T) {
+	deploymentID := testutil.ToIdentifier(t, resources["deployment"])
+	secretID := testutil.ToIdentifier(t, resources["secret"])
+	// missing from the cluster
+	clusterScopedID := testutil.ToIdentifier(t, resources["clusterScopedObj"])
+
+	testCases := map[string]struct {
+		selector             object.Selector
+		expectedSelected     object.ObjMetadataSet
+		expectedRetainedObjs object.ObjMetadataSet
+		expectedRetained     object.ObjMetadataSet
+	}{
+		"select by kind": {
+			selector: object.Selector{
+				GroupKinds: []schema.GroupKind{deploymentID.GroupKind},
+			},
+			expectedSelected:     object.ObjMetadataSet{deploymentID},
+			expectedRetainedObjs: object.ObjMetadataSet{secretID},
+			expectedRetained:     object.ObjMetadataSet{secretID, clusterScopedID},
+		},
+		"select objects, including missing object": {
+			selector: object.Selector{
+				Objects: object.ObjMetadataSet{secretID, clusterScopedID},
+			},
+			expectedSelected:     object.ObjMetadataSet{secretID},
+			expectedRetainedObjs: object.ObjMetadataSet{deploymentID},
+			expectedRetained:     object.ObjMetadataSet{deploymentID},
+		},
+		"select by labels retains missing object": {
+			selector: object.Selector{
+				LabelSelector: labels.Everything(),
+			},
+			expectedSelected:     object.ObjMetadataSet{deploymentID, secretID},
+			expectedRetainedObjs: object.ObjMetadataSet{},
+			expectedRetained:     object.ObjMetadataSet{clusterScopedID},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			destroyer := &Destroyer{
+				invClient: inventory.NewFakeClient(object.ObjMetadataSet{deploymentID, secretID, clusterScopedID}),
+			}
+			objs := object.UnstructuredSet{
+				testutil.Unstructured(t, resources["deployment"]),
+				testutil.Unstructured(t, resources["secret"]),
+			}
+			selected, retainedObjs, retained, err := destroyer.selectObjects(nil, objs, tc.selector)
+			require.NoError(t, err)
+			testutil.AssertEqual(t, tc.expectedSelected, object.UnstructuredSetToObjMetadataSet(selected))
+			testutil.AssertEqual(t, tc.expectedRetainedObjs, object.UnstructuredSetToObjMetadataSet(retainedObjs))
+			testutil.AssertEqual(t, tc.expectedRetained, retained)
+		})
+	}
+}
+
+func TestDestroyerAbandon(t *testing.T) {
+	invInfo := inventoryInfo{
+		name:      "abc-123",
+		namespace: "test",
+		id:        "test",
+		set: object.ObjMetadataSet{
+			testutil.ToIdentifier(t, resources["deployment"]),
+			testutil.ToIdentifier(t, resources["secret"]),
+		},
+	}
+	clusterObjs := object.UnstructuredSet{
+		testutil.Unstructured(t, resources["deployment"], testutil.AddOwningInv(t, "test")),
+		testutil.Unstructured(t, resources["secret"], testutil.AddOwningInv(t, "test")),
+		inventory.InvInfoToConfigMap(invInfo.toWrapped()),
+	}
+
+	tf := newTestFactory(t, invInfo, object.UnstructuredSet{}, clusterObjs)
+	defer tf.Cleanup()
+	// Accept the updates that remove the inventory annotation.
+	tf.FakeDynamicClient.PrependReactor("update", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		return true, action.(clienttesting.UpdateAction).GetObject(), nil
+	})
+
+	destroyer, err := NewDestroyerBuilder().
+		WithFactory(tf).
+		WithInventoryClient(newTestInventory(t, tf)).
+		Build()
+	require.NoError(t, err)
+	statusWatcher := newFakeWatcher(nil)
+	statusWatcher.Start()
+	destroyer.statusWatcher = statusWatcher
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for e := range destroyer.Run(ctx, invInfo.toWrapped(), DestroyerOptions{Abandon: true}) {
+		require.NotEqual(t, event.ErrorType, e.Type, "unexpected error event: %v", e)
+	}
+	require.NoError(t, ctx.Err())
+
+	var deleted []string
+	abandoned := map[string]bool{}
+	for _, action := range tf.FakeDynamicClient.Actions() {
+		switch a := action.(type) {
+		case clienttesting.DeleteAction:
+			deleted = append(deleted, a.GetResource().Resource+"/"+a.GetName())
+		case clienttesting.UpdateAction:
+			obj := a.GetObject().(*unstructured.Unstructured)
+			_, owned := obj.GetAnnotations()[inventory.OwningInventoryKey]
+			abandoned[obj.GetKind()+"/"+obj.GetName()] = !owned
+		}
+	}
+	// Only the inventory object is deleted.
+	assert.Equal(t, []string{"configmaps/abc-123"}, deleted)
+	// The inventory annotation is removed from each object.
+	assert.Equal(t, map[string]bool{
+		"Deployment/foo": true,
+		"Secret/secret":  true,
+	}, abandoned)
+}
@@ -2,6 +2,12 @@
 // SPDX-License-Identifier: Apache-2.0
 package error
 
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
 type UnknownTypeError struct {
 	err error
 }
@@ -37,3 +43,109 @@ func (e *InitializeApplyOptionError) Error() string {
 func NewInitializeApplyOptionError(err error) *InitializeApplyOptionError {
 	return &InitializeApplyOptionError{err: err}
 }
+
+// RolledBackError indicates that the remaining objects were not actuated,
+// because the applied objects were rolled back after a failure.
+type RolledBackError struct {
+	Failures int
+}
+
+func (e *RolledBackError) Error() string {
+	return fmt.Sprintf("rolled back after %d object(s) failed", e.Failures)
+}
+
+// HookFailedError indicates that the remaining objects were not actuated,
+// because hook objects of a phase failed to apply, reconcile, or be deleted
+// before creation.
+type HookFailedError struct {
+	Phase    string
+	Failures int
+}
+
+func (e *HookFailedError) Error() string {
+	return fmt.Sprintf("%d %s hook(s) failed", e.Failures, e.Phase)
+}
+
+// SnapshotError indicates that an object was not applied, because its state
+// in the cluster could not be recorded for rollback.
+type SnapshotError struct {
+	err error
+}
+
+func (e *SnapshotError) Error() string {
+	return fmt.Sprintf("failed to snapshot object for rollback: %v", e.err)
+}
+
+func (e *SnapshotError) Unwrap() error {
+	return e.err
+}
+
+func NewSnapshotError(err error) *SnapshotError {
+	return &SnapshotError{err: err}
+}
+
+// MigrationError indicates that an object was not applied, because its
+// managed fields could not be migrated from client-side apply.
+type MigrationError struct {
+	err error
+}
+
+func (e *MigrationError) Error() string {
+	return fmt.Sprintf("failed to migrate managed fields from client-side apply: %v", e.err)
+}
+
+func (e *MigrationError) Unwrap() error {
+	return e.err
+}
+
+func NewMigrationError(err error) *MigrationError {
+	return &MigrationError{err: err}
+}
+
+// FieldConflict is a field of an object that is owned by another field
+// manager.
+type FieldConflict struct {
+	// Field is the path of the field, e.g. ".spec.replicas".
+	Field string
+	// Manager is the field manager that owns the field.
+	Manager string
+}
+
+// ConflictError indicates that an object was not applied with server-side
+// apply, because fields of the object are owned by other field managers.
+type ConflictError struct {
+	Conflicts []FieldConflict
+	err       error
+}
+
+func (e *ConflictError) Error() string {
+	fields := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		fields[i] = fmt.Sprintf("%s (manager: %q)", c.Field, c.Manager)
+	}
+	return fmt.Sprintf("%d field(s) owned by other field managers: %s",
+		len(e.Conflicts), strings.Join(fields, ", "))
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.err
+}
+
+// Managers returns the field managers that own the fields in conflict,
+// sorted and without duplicates.
+func (e *ConflictError) Managers() []string {
+	managers := make(map[string]struct{})
+	for _, c := range e.Conflicts {
+		managers[c.Manager] = struct{}{}
+	}
+	sorted := make([]string, 0, len(managers))
+	for m := range managers {
+		sorted = append(sorted, m)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func NewConflictError(conflicts []FieldConflict, err error) *ConflictError {
+	return &ConflictError{Conflicts: conflicts, err: err}
+}
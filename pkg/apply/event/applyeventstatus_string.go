@@ -12,11 +12,14 @@ func _() {
 	_ = x[ApplySuccessful-1]
 	_ = x[ApplySkipped-2]
 	_ = x[ApplyFailed-3]
+	_ = x[ApplyRetrying-4]
+	_ = x[ApplyConflict-5]
+	_ = x[ApplyUnchanged-6]
 }
 
-const _ApplyEventStatus_name = "PendingSuccessfulSkippedFailed"
+const _ApplyEventStatus_name = "PendingSuccessfulSkippedFailedRetryingConflictUnchanged"
 
-var _ApplyEventStatus_index = [...]uint8{0, 7, 17, 24, 30}
+var _ApplyEventStatus_index = [...]uint8{0, 7, 17, 24, 30, 38, 46, 55}
 
 func (i ApplyEventStatus) String() string {
 	if i < 0 || i >= ApplyEventStatus(len(_ApplyEventStatus_index)-1) {
@@ -12,11 +12,12 @@ func _() {
 	_ = x[DeleteSuccessful-1]
 	_ = x[DeleteSkipped-2]
 	_ = x[DeleteFailed-3]
+	_ = x[DeleteRetrying-4]
 }
 
-const _DeleteEventStatus_name = "PendingSuccessfulSkippedFailed"
+const _DeleteEventStatus_name = "PendingSuccessfulSkippedFailedRetrying"
 
-var _DeleteEventStatus_index = [...]uint8{0, 7, 17, 24, 30}
+var _DeleteEventStatus_index = [...]uint8{0, 7, 17, 24, 30, 38}
 
 func (i DeleteEventStatus) String() string {
 	if i < 0 || i >= DeleteEventStatus(len(_DeleteEventStatus_index)-1) {
@@ -6,6 +6,7 @@ package event
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	pollevent "github.com/fluxcd/cli-utils/pkg/kstatus/polling/event"
 	"github.com/fluxcd/cli-utils/pkg/object"
@@ -27,6 +28,8 @@ const (
 	DeleteType
 	WaitType
 	ValidationType
+	RollbackType
+	TransferType
 )
 
 // Event is the type of the objects that will be returned through
@@ -70,6 +73,14 @@ type Event struct {
 
 	// ValidationEvent contains information about validation errors.
 	ValidationEvent ValidationEvent
+
+	// RollbackEvent contains information about objects that have been
+	// restored or deleted, to roll back a failed apply.
+	RollbackEvent RollbackEvent
+
+	// TransferEvent contains information about objects that have been
+	// transferred from one inventory to another.
+	TransferEvent TransferEvent
 }
 
 // String returns a string suitable for logging
@@ -94,10 +105,38 @@ func (e Event) String() string {
 		sb.WriteString(e.WaitEvent.String())
 	case ValidationType:
 		sb.WriteString(e.ValidationEvent.String())
+	case RollbackType:
+		sb.WriteString(e.RollbackEvent.String())
+	case TransferType:
+		sb.WriteString(e.TransferEvent.String())
 	}
 	return sb.String()
 }
 
+// Timing records when the operation on a single object started and ended.
+//
+// For apply, prune and delete events, it covers the actuation of the object,
+// including retries. For wait events, it starts when the object was actuated
+// and ends when the object was first reconciled, failed, or timed out.
+type Timing struct {
+	// Start is when the operation started.
+	Start time.Time
+	// End is when the operation ended. Zero while the operation is pending.
+	End time.Time
+	// Latency is the time spent waiting for the cluster to respond to
+	// requests, excluding the delays between retries. Zero for wait events.
+	Latency time.Duration
+}
+
+// Duration returns the time between Start and End, or zero if either is
+// unknown.
+func (t Timing) Duration() time.Duration {
+	if t.Start.IsZero() || t.End.IsZero() {
+		return 0
+	}
+	return t.End.Sub(t.Start)
+}
+
 type InitEvent struct {
 	ActionGroups ActionGroupList
 }
@@ -116,6 +155,8 @@ const (
 	DeleteAction                          // Delete
 	WaitAction                            // Wait
 	InventoryAction                       // Inventory
+	RollbackAction                        // Rollback
+	TransferAction                        // Transfer
 )
 
 type ActionGroupList []ActionGroup
@@ -140,6 +181,9 @@ type ActionGroup struct {
 	Name        string
 	Action      ResourceAction
 	Identifiers object.ObjMetadataSet
+	// Timeouts are the effective reconcile timeouts of the objects of a
+	// wait group. Objects without timeout are omitted.
+	Timeouts map[object.ObjMetadata]time.Duration
 }
 
 // String returns a string suitable for logging
@@ -172,10 +216,31 @@ type WaitEvent struct {
 	GroupName  string
 	Identifier object.ObjMetadata
 	Status     WaitEventStatus
+	// Condition is the custom condition the object is waiting on, in the
+	// format of the wait-for annotation. Empty if the object is waiting on
+	// the default condition of the wait task.
+	Condition string
+	// Timing is the time from actuation to reconciliation. The End is only
+	// set on the first ReconcileSuccessful, ReconcileFailed or
+	// ReconcileTimeout event of the object in the group. Zero if the object
+	// was not actuated.
+	Timing Timing
+	// Finalizers are the finalizers blocking the deletion of an object,
+	// whose finalizers have not changed for longer than the stuck threshold.
+	Finalizers []string
+	// StuckFor is how long the Finalizers have not changed.
+	StuckFor time.Duration
+	// RemovedFinalizers are the finalizers removed from an object stuck
+	// terminating.
+	RemovedFinalizers []string
 }
 
 // String returns a string suitable for logging
 func (we WaitEvent) String() string {
+	if we.Condition != "" {
+		return fmt.Sprintf("WaitEvent{ GroupName: %q, Status: %q, Identifier: %q, Condition: %q }",
+			we.GroupName, we.Status, we.Identifier, we.Condition)
+	}
 	return fmt.Sprintf("WaitEvent{ GroupName: %q, Status: %q, Identifier: %q }",
 		we.GroupName, we.Status, we.Identifier)
 }
@@ -186,6 +251,11 @@ type ActionGroupEventStatus int
 const (
 	Started ActionGroupEventStatus = iota
 	Finished
+	// Skipped is sent instead of Started and Finished, when the runner
+	// stopped actuating because of the FailurePolicy, a halted batch or
+	// cancellation. The objects of the group are reported as skipped after
+	// it.
+	Skipped
 )
 
 type ActionGroupEvent struct {
@@ -208,6 +278,15 @@ const (
 	ApplySuccessful                         // Successful
 	ApplySkipped                            // Skipped
 	ApplyFailed                             // Failed
+	ApplyRetrying                           // Retrying
+	// ApplyConflict means the object was not applied, because fields of
+	// the object are owned by other field managers. The Error is an
+	// *applyerror.ConflictError with the fields in conflict.
+	ApplyConflict // Conflict
+	// ApplyUnchanged means the object was not applied, because its content
+	// and its generation in the cluster are unchanged since the last apply.
+	// The Resource is the object in the cluster.
+	ApplyUnchanged // Unchanged
 )
 
 type ApplyEvent struct {
@@ -216,10 +295,27 @@ type ApplyEvent struct {
 	Status     ApplyEventStatus
 	Resource   *unstructured.Unstructured
 	Error      error
+	// Attempt is the number of the next attempt, if the Status is
+	// ApplyRetrying.
+	Attempt int
+	// MaxAttempts is the maximum number of attempts, if the Status is
+	// ApplyRetrying.
+	MaxAttempts int
+	// Timing is the time spent actuating the object. Zero if the object
+	// was not sent to the cluster, or while retrying.
+	Timing Timing
+	// Migrated is true if the fields of the object managed by client-side
+	// apply were transferred to the server-side apply field manager, before
+	// the object was applied.
+	Migrated bool
 }
 
 // String returns a string suitable for logging
 func (ae ApplyEvent) String() string {
+	if ae.Status == ApplyRetrying {
+		return fmt.Sprintf("ApplyEvent{ GroupName: %q, Status: %q, Identifier: %q, Attempt: %d/%d, Error: %q }",
+			ae.GroupName, ae.Status, ae.Identifier, ae.Attempt, ae.MaxAttempts, ae.Error)
+	}
 	if ae.Error != nil {
 		return fmt.Sprintf("ApplyEvent{ GroupName: %q, Status: %q, Identifier: %q, Error: %q }",
 			ae.GroupName, ae.Status, ae.Identifier, ae.Error)
@@ -261,6 +357,7 @@ const (
 	PruneSuccessful                         // Successful
 	PruneSkipped                            // Skipped
 	PruneFailed                             // Failed
+	PruneRetrying                           // Retrying
 )
 
 type PruneEvent struct {
@@ -269,10 +366,23 @@ type PruneEvent struct {
 	Status     PruneEventStatus
 	Object     *unstructured.Unstructured
 	Error      error
+	// Attempt is the number of the next attempt, if the Status is
+	// PruneRetrying.
+	Attempt int
+	// MaxAttempts is the maximum number of attempts, if the Status is
+	// PruneRetrying.
+	MaxAttempts int
+	// Timing is the time spent actuating the object. Zero if the object
+	// was not sent to the cluster, or while retrying.
+	Timing Timing
 }
 
 // String returns a string suitable for logging
 func (pe PruneEvent) String() string {
+	if pe.Status == PruneRetrying {
+		return fmt.Sprintf("PruneEvent{ GroupName: %q, Status: %q, Identifier: %q, Attempt: %d/%d, Error: %q }",
+			pe.GroupName, pe.Status, pe.Identifier, pe.Attempt, pe.MaxAttempts, pe.Error)
+	}
 	if pe.Error != nil {
 		return fmt.Sprintf("PruneEvent{ GroupName: %q, Status: %q, Identifier: %q, Error: %q }",
 			pe.GroupName, pe.Status, pe.Identifier, pe.Error)
@@ -289,6 +399,7 @@ const (
 	DeleteSuccessful                          // Successful
 	DeleteSkipped                             // Skipped
 	DeleteFailed                              // Failed
+	DeleteRetrying                            // Retrying
 )
 
 type DeleteEvent struct {
@@ -297,10 +408,23 @@ type DeleteEvent struct {
 	Status     DeleteEventStatus
 	Object     *unstructured.Unstructured
 	Error      error
+	// Attempt is the number of the next attempt, if the Status is
+	// DeleteRetrying.
+	Attempt int
+	// MaxAttempts is the maximum number of attempts, if the Status is
+	// DeleteRetrying.
+	MaxAttempts int
+	// Timing is the time spent actuating the object. Zero if the object
+	// was not sent to the cluster, or while retrying.
+	Timing Timing
 }
 
 // String returns a string suitable for logging
 func (de DeleteEvent) String() string {
+	if de.Status == DeleteRetrying {
+		return fmt.Sprintf("DeleteEvent{ GroupName: %q, Status: %q, Identifier: %q, Attempt: %d/%d, Error: %q }",
+			de.GroupName, de.Status, de.Identifier, de.Attempt, de.MaxAttempts, de.Error)
+	}
 	if de.Error != nil {
 		return fmt.Sprintf("DeleteEvent{ GroupName: %q, Status: %q, Identifier: %q, Error: %q }",
 			de.GroupName, de.Status, de.Identifier, de.Error)
@@ -323,3 +447,70 @@ func (ve ValidationEvent) String() string {
 	return fmt.Sprintf("ValidationEvent{ Identifiers: %+v }",
 		ve.Identifiers)
 }
+
+//go:generate stringer -type=RollbackEventStatus -linecomment
+type RollbackEventStatus int
+
+const (
+	RollbackSuccessful RollbackEventStatus = iota // Successful
+	RollbackSkipped                               // Skipped
+	RollbackFailed                                // Failed
+)
+
+//go:generate stringer -type=RollbackOperation -linecomment
+type RollbackOperation int
+
+const (
+	// RollbackRestore re-applies the state of an object from before it
+	// was applied.
+	RollbackRestore RollbackOperation = iota // Restore
+	// RollbackDelete deletes an object that did not exist before it was
+	// applied.
+	RollbackDelete // Delete
+)
+
+type RollbackEvent struct {
+	GroupName  string
+	Identifier object.ObjMetadata
+	Operation  RollbackOperation
+	Status     RollbackEventStatus
+	Object     *unstructured.Unstructured
+	Error      error
+}
+
+// String returns a string suitable for logging
+func (re RollbackEvent) String() string {
+	if re.Error != nil {
+		return fmt.Sprintf("RollbackEvent{ GroupName: %q, Operation: %q, Status: %q, Identifier: %q, Error: %q }",
+			re.GroupName, re.Operation, re.Status, re.Identifier, re.Error)
+	}
+	return fmt.Sprintf("RollbackEvent{ GroupName: %q, Operation: %q, Status: %q, Identifier: %q }",
+		re.GroupName, re.Operation, re.Status, re.Identifier)
+}
+
+//go:generate stringer -type=TransferEventStatus -linecomment
+type TransferEventStatus int
+
+const (
+	TransferSuccessful TransferEventStatus = iota // Successful
+	TransferSkipped                               // Skipped
+	TransferFailed                                // Failed
+)
+
+type TransferEvent struct {
+	GroupName  string
+	Identifier object.ObjMetadata
+	Status     TransferEventStatus
+	Object     *unstructured.Unstructured
+	Error      error
+}
+
+// String returns a string suitable for logging
+func (te TransferEvent) String() string {
+	if te.Error != nil {
+		return fmt.Sprintf("TransferEvent{ GroupName: %q, Status: %q, Identifier: %q, Error: %q }",
+			te.GroupName, te.Status, te.Identifier, te.Error)
+	}
+	return fmt.Sprintf("TransferEvent{ GroupName: %q, Status: %q, Identifier: %q }",
+		te.GroupName, te.Status, te.Identifier)
+}
@@ -13,11 +13,13 @@ func _() {
 	_ = x[DeleteAction-2]
 	_ = x[WaitAction-3]
 	_ = x[InventoryAction-4]
+	_ = x[RollbackAction-5]
+	_ = x[TransferAction-6]
 }
 
-const _ResourceAction_name = "ApplyPruneDeleteWaitInventory"
+const _ResourceAction_name = "ApplyPruneDeleteWaitInventoryRollbackTransfer"
 
-var _ResourceAction_index = [...]uint8{0, 5, 10, 16, 20, 29}
+var _ResourceAction_index = [...]uint8{0, 5, 10, 16, 20, 29, 37, 45}
 
 func (i ResourceAction) String() string {
 	if i < 0 || i >= ResourceAction(len(_ResourceAction_index)-1) {
@@ -17,11 +17,13 @@ func _() {
 	_ = x[DeleteType-6]
 	_ = x[WaitType-7]
 	_ = x[ValidationType-8]
+	_ = x[RollbackType-9]
+	_ = x[TransferType-10]
 }
 
-const _Type_name = "InitTypeErrorTypeActionGroupTypeApplyTypeStatusTypePruneTypeDeleteTypeWaitTypeValidationType"
+const _Type_name = "InitTypeErrorTypeActionGroupTypeApplyTypeStatusTypePruneTypeDeleteTypeWaitTypeValidationTypeRollbackTypeTransferType"
 
-var _Type_index = [...]uint8{0, 8, 17, 32, 41, 51, 60, 70, 78, 92}
+var _Type_index = [...]uint8{0, 8, 17, 32, 41, 51, 60, 70, 78, 92, 104, 116}
 
 func (i Type) String() string {
 	if i < 0 || i >= Type(len(_Type_index)-1) {
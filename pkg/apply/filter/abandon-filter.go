@@ -0,0 +1,41 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// AbandonFilter implements ValidationFilter interface to prevent the
+// deletion of every object, so that the objects are abandoned instead:
+// the inventory annotation is removed from the objects and the objects are
+// removed from the inventory.
+type AbandonFilter struct{}
+
+const AbandonFilterName = "AbandonFilter"
+
+// Name returns the preferred name for the filter. Usually
+// used for logging.
+func (af AbandonFilter) Name() string {
+	return AbandonFilterName
+}
+
+// Filter returns an AbandonPreventedDeletionError for every object.
+func (af AbandonFilter) Filter(*unstructured.Unstructured) error {
+	return &AbandonPreventedDeletionError{}
+}
+
+type AbandonPreventedDeletionError struct{}
+
+func (e *AbandonPreventedDeletionError) Error() string {
+	return "abandon prevents deletion"
+}
+
+func (e *AbandonPreventedDeletionError) Is(err error) bool {
+	if err == nil {
+		return false
+	}
+	_, ok := err.(*AbandonPreventedDeletionError)
+	return ok
+}
@@ -0,0 +1,18 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"testing"
+
+	"github.com/fluxcd/cli-utils/pkg/testutil"
+)
+
+func TestAbandonFilter(t *testing.T) {
+	obj := defaultObj.DeepCopy()
+	obj.SetAnnotations(map[string]string{"foo": "bar"})
+	abandonFilter := AbandonFilter{}
+	err := abandonFilter.Filter(obj)
+	testutil.AssertEqual(t, &AbandonPreventedDeletionError{}, err)
+}
@@ -36,6 +36,10 @@ type DependencyFilter struct {
 	TaskContext       *taskrunner.TaskContext
 	ActuationStrategy actuation.ActuationStrategy
 	DryRunStrategy    common.DryRunStrategy
+	// Retained are the objects kept in the cluster and in the inventory,
+	// when deleting a subset of the inventory. Objects that a retained object
+	// depends on are not deleted.
+	Retained object.ObjMetadataSet
 }
 
 const DependencyFilterName = "DependencyFilter"
@@ -50,6 +54,7 @@ func (dnrf DependencyFilter) Name() string {
 // Typed Errors:
 // - DependencyPreventedActuationError
 // - DependencyActuationMismatchError
+// - DependencyRetainedError
 func (dnrf DependencyFilter) Filter(obj *unstructured.Unstructured) error {
 	id := object.UnstructuredToObjMetadata(obj)
 
@@ -88,6 +93,18 @@ func (dnrf DependencyFilter) filterByRelationship(aID, bID object.ObjMetadata, r
 			bID))
 	}
 
+	// Retained objects are not actuated, so they have no status.
+	// Don't delete something that a retained object depends on.
+	if dnrf.Retained.Contains(bID) {
+		// Skip!
+		return &DependencyRetainedError{
+			Object:       aID,
+			Strategy:     dnrf.ActuationStrategy,
+			Relationship: relationship,
+			Relation:     bID,
+		}
+	}
+
 	status, found := dnrf.TaskContext.InventoryManager().ObjectStatus(bID)
 	if !found {
 		// Status is registered during planning.
@@ -259,3 +276,31 @@ func (e *DependencyActuationMismatchError) Is(err error) bool {
 		e.Relation == tErr.Relation &&
 		e.RelationStrategy == tErr.RelationStrategy
 }
+
+type DependencyRetainedError struct {
+	Object       object.ObjMetadata
+	Strategy     actuation.ActuationStrategy
+	Relationship Relationship
+
+	Relation object.ObjMetadata
+}
+
+func (e *DependencyRetainedError) Error() string {
+	return fmt.Sprintf("%s retained: %s",
+		strings.ToLower(e.Relationship.String()),
+		e.Relation)
+}
+
+func (e *DependencyRetainedError) Is(err error) bool {
+	if err == nil {
+		return false
+	}
+	tErr, ok := err.(*DependencyRetainedError)
+	if !ok {
+		return false
+	}
+	return e.Object == tErr.Object &&
+		e.Strategy == tErr.Strategy &&
+		e.Relationship == tErr.Relationship &&
+		e.Relation == tErr.Relation
+}
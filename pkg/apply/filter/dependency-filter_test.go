@@ -4,6 +4,7 @@
 package filter
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -46,6 +47,7 @@ func TestDependencyFilter(t *testing.T) {
 		dryRunStrategy    common.DryRunStrategy
 		actuationStrategy actuation.ActuationStrategy
 		contextSetup      func(*taskrunner.TaskContext)
+		retained          object.ObjMetadataSet
 		id                object.ObjMetadata
 		expectedError     error
 	}{
@@ -489,6 +491,23 @@ func TestDependencyFilter(t *testing.T) {
 				RelationStrategy: actuation.ActuationStrategyApply,
 			},
 		},
+		"delete B (A -> B) when A is retained": {
+			actuationStrategy: actuation.ActuationStrategyDelete,
+			contextSetup: func(taskContext *taskrunner.TaskContext) {
+				taskContext.Graph().AddVertex(idA)
+				taskContext.Graph().AddVertex(idB)
+				taskContext.Graph().AddEdge(idA, idB)
+				taskContext.InventoryManager().AddPendingDelete(idB)
+			},
+			retained: object.ObjMetadataSet{idA},
+			id:       idB,
+			expectedError: &DependencyRetainedError{
+				Object:       idB,
+				Strategy:     actuation.ActuationStrategyDelete,
+				Relationship: RelationshipDependent,
+				Relation:     idA,
+			},
+		},
 		"DryRun: apply A (A -> B) when B apply reconcile pending": {
 			dryRunStrategy:    common.DryRunClient,
 			actuationStrategy: actuation.ActuationStrategyApply,
@@ -529,13 +548,14 @@ func TestDependencyFilter(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			taskContext := taskrunner.NewTaskContext(nil, nil)
+			taskContext := taskrunner.NewTaskContext(context.TODO(), nil, nil)
 			tc.contextSetup(taskContext)
 
 			filter := DependencyFilter{
 				TaskContext:       taskContext,
 				ActuationStrategy: tc.actuationStrategy,
 				DryRunStrategy:    tc.dryRunStrategy,
+				Retained:          tc.retained,
 			}
 			obj := defaultObj.DeepCopy()
 			obj.SetGroupVersionKind(tc.id.GroupKind.WithVersion("v1"))
@@ -0,0 +1,239 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+// NamespaceContentsPolicy defines how to handle namespaces that still
+// contain objects not managed by the inventory, when they are deleted.
+type NamespaceContentsPolicy int
+
+const (
+	// NamespaceContentsIgnore deletes namespaces without checking their
+	// contents.
+	NamespaceContentsIgnore NamespaceContentsPolicy = iota
+
+	// NamespaceContentsSkip skips the deletion of namespaces that still
+	// contain unmanaged objects.
+	NamespaceContentsSkip
+
+	// NamespaceContentsProceed deletes namespaces that still contain
+	// unmanaged objects, after logging the objects.
+	NamespaceContentsProceed
+)
+
+// unmanagedObjectsShown is the maximum number of unmanaged objects listed in
+// the message of a NamespaceNotEmptyError.
+const unmanagedObjectsShown = 5
+
+var (
+	// ignoredNamespaceContents are the objects created in every namespace
+	// by the cluster.
+	ignoredNamespaceContents = []struct {
+		gk   schema.GroupKind
+		name string
+	}{
+		{gk: schema.GroupKind{Kind: "ServiceAccount"}, name: "default"},
+		{gk: schema.GroupKind{Kind: "ConfigMap"}, name: "kube-root-ca.crt"},
+	}
+	// ignoredServiceContents are the objects created by the cluster for
+	// every Service, with the name of the Service, but without an owner
+	// reference.
+	ignoredServiceContents = sets.New(
+		schema.GroupKind{Kind: "Endpoints"},
+	)
+	// ignoredNamespaceKinds are the kinds of objects that do not prevent
+	// the deletion of a namespace.
+	ignoredNamespaceKinds = sets.New(
+		schema.GroupKind{Kind: "Event"},
+		schema.GroupKind{Group: "events.k8s.io", Kind: "Event"},
+	)
+)
+
+// NamespaceContentsFilter implements ValidationFilter interface to verify
+// that a namespace no longer contains objects, before it is deleted. Objects
+// in the inventory, objects owned by other objects, and the objects created
+// in every namespace or for every managed Service by the cluster are not
+// considered. Namespaces with unmanaged objects would otherwise be deleted
+// together with these objects, or hang terminating on their finalizers.
+type NamespaceContentsFilter struct {
+	// TaskContext provides the context of the run, used to list the
+	// contents of the namespaces.
+	TaskContext *taskrunner.TaskContext
+	Client      dynamic.Interface
+	Discovery   discovery.ServerResourcesInterface
+	// Inventory is the set of objects managed by the inventory.
+	Inventory object.ObjMetadataSet
+	Policy    NamespaceContentsPolicy
+
+	// resourceLists are the namespaced resources that can be listed. They
+	// are discovered once per run, when the first namespace is checked.
+	resourceLists []*metav1.APIResourceList
+}
+
+// Name returns a filter identifier for logging.
+func (ncf *NamespaceContentsFilter) Name() string {
+	return "NamespaceContentsFilter"
+}
+
+// Filter returns a NamespaceNotEmptyError if the object is a namespace,
+// which still contains unmanaged objects, and the policy is to skip its
+// deletion.
+func (ncf *NamespaceContentsFilter) Filter(obj *unstructured.Unstructured) error {
+	if ncf.Policy == NamespaceContentsIgnore || !object.IsKindNamespace(obj) {
+		return nil
+	}
+	namespace := obj.GetName()
+	unmanaged, err := ncf.unmanagedObjects(namespace)
+	if err != nil {
+		return NewFatalError(fmt.Errorf("failed to list contents of namespace %q: %w", namespace, err))
+	}
+	if len(unmanaged) == 0 {
+		return nil
+	}
+	err = &NamespaceNotEmptyError{
+		Namespace: namespace,
+		Objects:   unmanaged,
+	}
+	if ncf.Policy == NamespaceContentsProceed {
+		klog.Warningf("Deleting namespace with unmanaged objects: %v", err)
+		return nil
+	}
+	return err
+}
+
+// namespacedResources returns the namespaced resources that can be listed,
+// discovering them on the first call. The types of API groups that fail
+// discovery, e.g. because their aggregated API server is unavailable, are
+// not returned.
+func (ncf *NamespaceContentsFilter) namespacedResources() ([]*metav1.APIResourceList, error) {
+	if ncf.resourceLists != nil {
+		return ncf.resourceLists, nil
+	}
+	resourceLists, err := ncf.Discovery.ServerPreferredNamespacedResources()
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return nil, err
+		}
+		klog.Warningf("Checking contents of namespaces without the API groups that failed discovery: %v", err)
+	}
+	resourceLists = discovery.FilteredBy(discovery.SupportsAllVerbs{Verbs: []string{"list"}}, resourceLists)
+	if resourceLists == nil {
+		resourceLists = []*metav1.APIResourceList{}
+	}
+	ncf.resourceLists = resourceLists
+	return resourceLists, nil
+}
+
+// unmanagedObjects lists the objects of every discovered namespaced type in
+// the namespace, that are not in the inventory.
+func (ncf *NamespaceContentsFilter) unmanagedObjects(namespace string) (object.ObjMetadataSet, error) {
+	resourceLists, err := ncf.namespacedResources()
+	if err != nil {
+		return nil, err
+	}
+	var unmanaged object.ObjMetadataSet
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			return nil, err
+		}
+		for _, resource := range resourceList.APIResources {
+			gk := schema.GroupKind{Group: gv.Group, Kind: resource.Kind}
+			if ignoredNamespaceKinds.Has(gk) {
+				continue
+			}
+			list, err := ncf.Client.Resource(gv.WithResource(resource.Name)).Namespace(namespace).
+				List(ncf.TaskContext.Context(), metav1.ListOptions{})
+			if err != nil {
+				if apierrors.IsNotFound(err) || apierrors.IsMethodNotSupported(err) {
+					continue
+				}
+				return nil, err
+			}
+			for i := range list.Items {
+				item := &list.Items[i]
+				id := object.ObjMetadata{
+					GroupKind: gk,
+					Namespace: namespace,
+					Name:      item.GetName(),
+				}
+				if ncf.Inventory.Contains(id) || len(item.GetOwnerReferences()) > 0 || isIgnoredContent(id) ||
+					ncf.isManagedServiceContent(id) {
+					continue
+				}
+				unmanaged = append(unmanaged, id)
+			}
+		}
+	}
+	return unmanaged, nil
+}
+
+func isIgnoredContent(id object.ObjMetadata) bool {
+	for _, ignored := range ignoredNamespaceContents {
+		if ignored.gk == id.GroupKind && ignored.name == id.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// isManagedServiceContent returns true if the object was created by the
+// cluster for a Service in the inventory.
+func (ncf *NamespaceContentsFilter) isManagedServiceContent(id object.ObjMetadata) bool {
+	if !ignoredServiceContents.Has(id.GroupKind) {
+		return false
+	}
+	return ncf.Inventory.Contains(object.ObjMetadata{
+		GroupKind: schema.GroupKind{Kind: "Service"},
+		Namespace: id.Namespace,
+		Name:      id.Name,
+	})
+}
+
+// NamespaceNotEmptyError is returned for a namespace that still contains
+// objects not managed by the inventory.
+type NamespaceNotEmptyError struct {
+	Namespace string
+	// Objects are the unmanaged objects in the namespace.
+	Objects object.ObjMetadataSet
+}
+
+func (e *NamespaceNotEmptyError) Error() string {
+	var names []string
+	for i, id := range e.Objects {
+		if i == unmanagedObjectsShown {
+			names = append(names, "...")
+			break
+		}
+		names = append(names, fmt.Sprintf("%s/%s", strings.ToLower(id.GroupKind.String()), id.Name))
+	}
+	return fmt.Sprintf("namespace %s still contains %d unmanaged objects: %s",
+		e.Namespace, len(e.Objects), strings.Join(names, ", "))
+}
+
+func (e *NamespaceNotEmptyError) Is(err error) bool {
+	if err == nil {
+		return false
+	}
+	tErr, ok := err.(*NamespaceNotEmptyError)
+	if !ok {
+		return false
+	}
+	return e.Namespace == tErr.Namespace && e.Objects.Equal(tErr.Objects)
+}
@@ -0,0 +1,280 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package filter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/cli-utils/pkg/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestNamespaceContentsFilter(t *testing.T) {
+	configMap := newNamespacedObject("v1", "ConfigMap", "test-namespace", "cm")
+	secret := newNamespacedObject("v1", "Secret", "test-namespace", "secret")
+	otherSecret := newNamespacedObject("v1", "Secret", "other-namespace", "secret")
+	ownedSecret := newNamespacedObject("v1", "Secret", "test-namespace", "owned")
+	ownedSecret.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "v1", Kind: "ConfigMap", Name: "cm"}})
+	deployment := newNamespacedObject("apps/v1", "Deployment", "test-namespace", "dep")
+	defaultSA := newNamespacedObject("v1", "ServiceAccount", "test-namespace", "default")
+	rootCA := newNamespacedObject("v1", "ConfigMap", "test-namespace", "kube-root-ca.crt")
+	evt := newNamespacedObject("v1", "Event", "test-namespace", "evt")
+	service := newNamespacedObject("v1", "Service", "test-namespace", "svc")
+	endpoints := newNamespacedObject("v1", "Endpoints", "test-namespace", "svc")
+
+	tests := map[string]struct {
+		policy        NamespaceContentsPolicy
+		obj           *unstructured.Unstructured
+		clusterObjs   []runtime.Object
+		inventory     object.ObjMetadataSet
+		listErr       error
+		discoveryErr  error
+		expectedError error
+	}{
+		"ignore policy, namespace is not filtered": {
+			policy:      NamespaceContentsIgnore,
+			obj:         testNamespace,
+			clusterObjs: []runtime.Object{configMap},
+		},
+		"namespace without objects is not filtered": {
+			policy:      NamespaceContentsSkip,
+			obj:         testNamespace,
+			clusterObjs: []runtime.Object{otherSecret},
+		},
+		"other kinds are not filtered": {
+			policy:      NamespaceContentsSkip,
+			obj:         configMap,
+			clusterObjs: []runtime.Object{secret},
+		},
+		"managed, owned, and default objects are not considered": {
+			policy: NamespaceContentsSkip,
+			obj:    testNamespace,
+			clusterObjs: []runtime.Object{
+				configMap, ownedSecret, defaultSA, rootCA, evt,
+			},
+			inventory: object.ObjMetadataSet{
+				object.UnstructuredToObjMetadata(configMap),
+			},
+		},
+		"endpoints of managed service are not considered": {
+			policy:      NamespaceContentsSkip,
+			obj:         testNamespace,
+			clusterObjs: []runtime.Object{service, endpoints},
+			inventory: object.ObjMetadataSet{
+				object.UnstructuredToObjMetadata(service),
+			},
+		},
+		"endpoints of unmanaged service are considered": {
+			policy:      NamespaceContentsSkip,
+			obj:         testNamespace,
+			clusterObjs: []runtime.Object{service, endpoints},
+			expectedError: &NamespaceNotEmptyError{
+				Namespace: "test-namespace",
+				Objects: object.ObjMetadataSet{
+					object.UnstructuredToObjMetadata(service),
+					object.UnstructuredToObjMetadata(endpoints),
+				},
+			},
+		},
+		"namespace with unmanaged objects is filtered": {
+			policy:      NamespaceContentsSkip,
+			obj:         testNamespace,
+			clusterObjs: []runtime.Object{configMap, secret, deployment, otherSecret},
+			inventory: object.ObjMetadataSet{
+				object.UnstructuredToObjMetadata(configMap),
+			},
+			expectedError: &NamespaceNotEmptyError{
+				Namespace: "test-namespace",
+				Objects: object.ObjMetadataSet{
+					object.UnstructuredToObjMetadata(secret),
+					object.UnstructuredToObjMetadata(deployment),
+				},
+			},
+		},
+		"proceed policy, namespace with unmanaged objects is not filtered": {
+			policy:      NamespaceContentsProceed,
+			obj:         testNamespace,
+			clusterObjs: []runtime.Object{secret},
+		},
+		"list error is fatal": {
+			policy:  NamespaceContentsSkip,
+			obj:     testNamespace,
+			listErr: errors.New("list failed"),
+			expectedError: testutil.EqualError(
+				NewFatalError(errors.New(`failed to list contents of namespace "test-namespace": list failed`)),
+			),
+		},
+		"partial discovery failure lists discovered types": {
+			policy:      NamespaceContentsSkip,
+			obj:         testNamespace,
+			clusterObjs: []runtime.Object{secret},
+			discoveryErr: &discovery.ErrGroupDiscoveryFailed{
+				Groups: map[schema.GroupVersion]error{
+					{Group: "metrics.k8s.io", Version: "v1beta1"}: errors.New("service unavailable"),
+				},
+			},
+			expectedError: &NamespaceNotEmptyError{
+				Namespace: "test-namespace",
+				Objects: object.ObjMetadataSet{
+					object.UnstructuredToObjMetadata(secret),
+				},
+			},
+		},
+		"discovery error is fatal": {
+			policy:       NamespaceContentsSkip,
+			obj:          testNamespace,
+			discoveryErr: errors.New("discovery failed"),
+			expectedError: testutil.EqualError(
+				NewFatalError(errors.New(`failed to list contents of namespace "test-namespace": discovery failed`)),
+			),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{
+					{Version: "v1", Resource: "configmaps"}:                 "ConfigMapList",
+					{Version: "v1", Resource: "secrets"}:                    "SecretList",
+					{Version: "v1", Resource: "serviceaccounts"}:            "ServiceAccountList",
+					{Version: "v1", Resource: "events"}:                     "EventList",
+					{Version: "v1", Resource: "services"}:                   "ServiceList",
+					{Version: "v1", Resource: "endpoints"}:                  "EndpointsList",
+					{Group: "apps", Version: "v1", Resource: "deployments"}: "DeploymentList",
+				}, tc.clusterObjs...)
+			if tc.listErr != nil {
+				client.PrependReactor("list", "*", func(clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, tc.listErr
+				})
+			}
+			discoveryClient := &fakeResourcesDiscovery{
+				FakeDiscovery: &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}},
+				err:           tc.discoveryErr,
+			}
+			discoveryClient.Resources = []*metav1.APIResourceList{
+				{
+					GroupVersion: "v1",
+					APIResources: []metav1.APIResource{
+						{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: []string{"list"}},
+						{Name: "secrets", Kind: "Secret", Namespaced: true, Verbs: []string{"list"}},
+						{Name: "serviceaccounts", Kind: "ServiceAccount", Namespaced: true, Verbs: []string{"list"}},
+						{Name: "events", Kind: "Event", Namespaced: true, Verbs: []string{"list"}},
+						{Name: "services", Kind: "Service", Namespaced: true, Verbs: []string{"list"}},
+						{Name: "endpoints", Kind: "Endpoints", Namespaced: true, Verbs: []string{"list"}},
+						{Name: "bindings", Kind: "Binding", Namespaced: true, Verbs: []string{"create"}},
+						{Name: "namespaces", Kind: "Namespace", Namespaced: false, Verbs: []string{"list"}},
+					},
+				},
+				{
+					GroupVersion: "apps/v1",
+					APIResources: []metav1.APIResource{
+						{Name: "deployments", Kind: "Deployment", Namespaced: true, Verbs: []string{"list"}},
+					},
+				},
+			}
+
+			filter := &NamespaceContentsFilter{
+				TaskContext: taskrunner.NewTaskContext(context.TODO(), nil, nil),
+				Client:      client,
+				Discovery:   discoveryClient,
+				Inventory:   tc.inventory,
+				Policy:      tc.policy,
+			}
+			err := filter.Filter(tc.obj.DeepCopy())
+			testutil.AssertEqual(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestNamespaceContentsFilterDiscoversOnce(t *testing.T) {
+	namespaces := []*unstructured.Unstructured{
+		newNamespacedObject("v1", "Namespace", "", "ns-1"),
+		newNamespacedObject("v1", "Namespace", "", "ns-2"),
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			{Version: "v1", Resource: "configmaps"}: "ConfigMapList",
+		},
+		newNamespacedObject("v1", "ConfigMap", "ns-2", "cm"))
+	discoveryClient := &fakeResourcesDiscovery{
+		FakeDiscovery: &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}},
+	}
+	discoveryClient.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: []string{"list"}},
+			},
+		},
+	}
+
+	filter := &NamespaceContentsFilter{
+		TaskContext: taskrunner.NewTaskContext(context.TODO(), nil, nil),
+		Client:      client,
+		Discovery:   discoveryClient,
+		Policy:      NamespaceContentsSkip,
+	}
+	testutil.AssertEqual(t, nil, filter.Filter(namespaces[0]))
+	testutil.AssertEqual(t, &NamespaceNotEmptyError{
+		Namespace: "ns-2",
+		Objects: object.ObjMetadataSet{
+			{GroupKind: schema.GroupKind{Kind: "ConfigMap"}, Namespace: "ns-2", Name: "cm"},
+		},
+	}, filter.Filter(namespaces[1]))
+	testutil.AssertEqual(t, 1, discoveryClient.calls)
+}
+
+func TestNamespaceNotEmptyError(t *testing.T) {
+	var objs object.ObjMetadataSet
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		objs = append(objs, object.ObjMetadata{
+			GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+			Namespace: "test-namespace",
+			Name:      name,
+		})
+	}
+	err := &NamespaceNotEmptyError{
+		Namespace: "test-namespace",
+		Objects:   objs,
+	}
+	testutil.AssertEqual(t, "namespace test-namespace still contains 6 unmanaged objects: "+
+		"deployment.apps/a, deployment.apps/b, deployment.apps/c, deployment.apps/d, deployment.apps/e, ...",
+		err.Error())
+}
+
+// fakeResourcesDiscovery returns the namespaced resources of the
+// FakeDiscovery as preferred resources, and the discovery error, if set.
+type fakeResourcesDiscovery struct {
+	*fakediscovery.FakeDiscovery
+	err   error
+	calls int
+}
+
+func (d *fakeResourcesDiscovery) ServerPreferredNamespacedResources() ([]*metav1.APIResourceList, error) {
+	d.calls++
+	return discovery.FilteredBy(discovery.ResourcePredicateFunc(
+		func(_ string, r *metav1.APIResource) bool {
+			return r.Namespaced
+		}), d.Resources), d.err
+}
+
+func newNamespacedObject(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
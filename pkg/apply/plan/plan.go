@@ -0,0 +1,147 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package plan defines the execution plan of an apply, which can be
+// serialized, reviewed, and later executed with Applier.RunPlan.
+package plan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/inventory"
+	"github.com/fluxcd/cli-utils/pkg/object"
+)
+
+// Action is the intended action for an object.
+type Action string
+
+const (
+	// Create means the object does not exist and will be created.
+	Create Action = "Create"
+	// Update means the object exists and will be changed.
+	Update Action = "Update"
+	// Unchanged means the object exists and will be applied without change.
+	Unchanged Action = "Unchanged"
+	// Prune means the object is no longer in the inputs and will be deleted.
+	Prune Action = "Prune"
+	// Skip means the object will be neither applied nor deleted.
+	Skip Action = "Skip"
+)
+
+// Plan is the serializable execution plan of an apply.
+type Plan struct {
+	// InputHash is the hash of the objects to apply.
+	InputHash string `json:"inputHash"`
+	// InventoryHash is the hash of the objects in the cluster inventory.
+	InventoryHash string `json:"inventoryHash"`
+	// ActionGroups are the tasks that will be executed, in order.
+	ActionGroups []ActionGroup `json:"actionGroups"`
+	// Objects are the intended actions for each object, in the order they
+	// are actuated.
+	Objects []Object `json:"objects"`
+}
+
+// ActionGroup is a task that will be executed.
+type ActionGroup struct {
+	Name    string                      `json:"name"`
+	Action  string                      `json:"action"`
+	Objects []actuation.ObjectReference `json:"objects,omitempty"`
+}
+
+// Object is the intended action for an object.
+type Object struct {
+	actuation.ObjectReference `json:",inline"`
+	Action                    Action `json:"action"`
+	// Reason explains why the object will be skipped.
+	Reason string `json:"reason,omitempty"`
+	// ResourceVersion is the version of the object in the cluster, when
+	// the plan was made. Empty if the object did not exist.
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+// NewActionGroups converts the action groups of a task queue.
+func NewActionGroups(ags []event.ActionGroup) []ActionGroup {
+	groups := make([]ActionGroup, 0, len(ags))
+	for _, ag := range ags {
+		group := ActionGroup{
+			Name:   ag.Name,
+			Action: ag.Action.String(),
+		}
+		for _, id := range ag.Identifiers {
+			group.Objects = append(group.Objects, inventory.ObjectReferenceFromObjMetadata(id))
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// EqualActionGroups returns true if both lists contain the same tasks for
+// the same objects, in the same order.
+func EqualActionGroups(x, y []ActionGroup) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i].Name != y[i].Name || x[i].Action != y[i].Action ||
+			len(x[i].Objects) != len(y[i].Objects) {
+			return false
+		}
+		for j := range x[i].Objects {
+			if x[i].Objects[j] != y[i].Objects[j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// HashObjects returns a hash of the objects, independent of their order.
+func HashObjects(objs object.UnstructuredSet) (string, error) {
+	entries := make([]string, 0, len(objs))
+	for _, obj := range objs {
+		data, err := json.Marshal(obj.Object)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash object %s: %w",
+				object.UnstructuredToObjMetadata(obj), err)
+		}
+		entries = append(entries, string(data))
+	}
+	return hashStrings(entries), nil
+}
+
+// HashInventory returns a hash of the object references in an inventory,
+// independent of their order.
+func HashInventory(ids object.ObjMetadataSet) string {
+	entries := make([]string, 0, len(ids))
+	for _, id := range ids {
+		entries = append(entries, id.String())
+	}
+	return hashStrings(entries)
+}
+
+func hashStrings(entries []string) string {
+	sort.Strings(entries)
+	h := sha256.New()
+	for _, entry := range entries {
+		// Hash.Write never returns an error
+		_, _ = h.Write([]byte(entry))
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// DriftError indicates that a plan was not executed, because the inputs or
+// the cluster changed since it was made.
+type DriftError struct {
+	Reason string
+}
+
+func (e *DriftError) Error() string {
+	return fmt.Sprintf("plan is out of date: %s", e.Reason)
+}
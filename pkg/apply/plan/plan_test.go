@@ -0,0 +1,182 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package plan
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/cli-utils/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var deployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: foo
+  namespace: default
+spec:
+  replicas: 1
+`
+
+var secret = `
+apiVersion: v1
+kind: Secret
+metadata:
+  name: bar
+  namespace: default
+type: Opaque
+`
+
+func TestHashObjects(t *testing.T) {
+	dep := testutil.Unstructured(t, deployment)
+	sec := testutil.Unstructured(t, secret)
+
+	hash, err := HashObjects(object.UnstructuredSet{dep, sec})
+	require.NoError(t, err)
+
+	reordered, err := HashObjects(object.UnstructuredSet{sec, dep})
+	require.NoError(t, err)
+	assert.Equal(t, hash, reordered, "order must not change the hash")
+
+	changed := dep.DeepCopy()
+	changed.Object["spec"] = map[string]interface{}{"replicas": int64(2)}
+	modified, err := HashObjects(object.UnstructuredSet{changed, sec})
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, modified, "content must change the hash")
+
+	fewer, err := HashObjects(object.UnstructuredSet{dep})
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, fewer, "removed objects must change the hash")
+}
+
+func TestHashInventory(t *testing.T) {
+	foo := object.ObjMetadata{
+		GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+		Namespace: "default",
+		Name:      "foo",
+	}
+	bar := object.ObjMetadata{
+		GroupKind: schema.GroupKind{Kind: "Secret"},
+		Namespace: "default",
+		Name:      "bar",
+	}
+
+	assert.Equal(t,
+		HashInventory(object.ObjMetadataSet{foo, bar}),
+		HashInventory(object.ObjMetadataSet{bar, foo}))
+	assert.NotEqual(t,
+		HashInventory(object.ObjMetadataSet{foo, bar}),
+		HashInventory(object.ObjMetadataSet{foo}))
+	assert.NotEqual(t,
+		HashInventory(object.ObjMetadataSet{}),
+		HashInventory(object.ObjMetadataSet{foo}))
+}
+
+func TestEqualActionGroups(t *testing.T) {
+	foo := actuation.ObjectReference{Group: "apps", Kind: "Deployment", Namespace: "default", Name: "foo"}
+	bar := actuation.ObjectReference{Kind: "Secret", Namespace: "default", Name: "bar"}
+
+	groups := []ActionGroup{
+		{Name: "apply-0", Action: "Apply", Objects: []actuation.ObjectReference{foo, bar}},
+		{Name: "wait-0", Action: "Wait", Objects: []actuation.ObjectReference{foo, bar}},
+	}
+
+	testCases := map[string]struct {
+		other    []ActionGroup
+		expected bool
+	}{
+		"equal": {
+			other: []ActionGroup{
+				{Name: "apply-0", Action: "Apply", Objects: []actuation.ObjectReference{foo, bar}},
+				{Name: "wait-0", Action: "Wait", Objects: []actuation.ObjectReference{foo, bar}},
+			},
+			expected: true,
+		},
+		"missing group": {
+			other: []ActionGroup{
+				{Name: "apply-0", Action: "Apply", Objects: []actuation.ObjectReference{foo, bar}},
+			},
+			expected: false,
+		},
+		"different action": {
+			other: []ActionGroup{
+				{Name: "apply-0", Action: "Apply", Objects: []actuation.ObjectReference{foo, bar}},
+				{Name: "wait-0", Action: "Delete", Objects: []actuation.ObjectReference{foo, bar}},
+			},
+			expected: false,
+		},
+		"different object order": {
+			other: []ActionGroup{
+				{Name: "apply-0", Action: "Apply", Objects: []actuation.ObjectReference{bar, foo}},
+				{Name: "wait-0", Action: "Wait", Objects: []actuation.ObjectReference{foo, bar}},
+			},
+			expected: false,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			assert.Equal(t, tc.expected, EqualActionGroups(groups, tc.other))
+		})
+	}
+}
+
+func TestNewActionGroups(t *testing.T) {
+	id := testutil.ToIdentifier(t, deployment)
+	groups := NewActionGroups([]event.ActionGroup{
+		{
+			Name:        "apply-0",
+			Action:      event.ApplyAction,
+			Identifiers: object.ObjMetadataSet{id},
+		},
+	})
+	assert.Equal(t, []ActionGroup{
+		{
+			Name:   "apply-0",
+			Action: "Apply",
+			Objects: []actuation.ObjectReference{
+				{Group: "apps", Kind: "Deployment", Namespace: "default", Name: "foo"},
+			},
+		},
+	}, groups)
+}
+
+func TestPlan_JSON(t *testing.T) {
+	p := &Plan{
+		InputHash:     "input",
+		InventoryHash: "inventory",
+		ActionGroups: []ActionGroup{
+			{
+				Name:   "apply-0",
+				Action: "Apply",
+				Objects: []actuation.ObjectReference{
+					{Group: "apps", Kind: "Deployment", Namespace: "default", Name: "foo"},
+				},
+			},
+		},
+		Objects: []Object{
+			{
+				ObjectReference: actuation.ObjectReference{
+					Group: "apps", Kind: "Deployment", Namespace: "default", Name: "foo",
+				},
+				Action:          Update,
+				ResourceVersion: "42",
+			},
+		},
+	}
+
+	data, err := json.Marshal(p)
+	require.NoError(t, err)
+
+	var decoded Plan
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, p, &decoded)
+}
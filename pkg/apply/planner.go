@@ -0,0 +1,254 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/apply/plan"
+	"github.com/fluxcd/cli-utils/pkg/apply/solver"
+	"github.com/fluxcd/cli-utils/pkg/common"
+	"github.com/fluxcd/cli-utils/pkg/inventory"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+)
+
+// errPlanned is returned by a runHook to end the run without an error,
+// before the tasks are run.
+var errPlanned = errors.New("planned")
+
+// runHook is called by the Applier once the task queue is built, before
+// the tasks are run. If it returns an error, the tasks are not run.
+type runHook func(state planState) error
+
+// planState is the state of a run that a plan is verified against.
+type planState struct {
+	inputHash     string
+	inventoryHash string
+	actionGroups  []plan.ActionGroup
+}
+
+// planState returns the state of a run, with the objects to apply and the
+// task queue built from them.
+func (a *Applier) planState(invInfo inventory.Info, applyObjs object.UnstructuredSet,
+	taskQueue *solver.TaskQueue) (planState, error) {
+	inputHash, err := plan.HashObjects(applyObjs)
+	if err != nil {
+		return planState{}, err
+	}
+	invIds, err := a.invClient.GetClusterObjs(invInfo)
+	if err != nil {
+		return planState{}, err
+	}
+	return planState{
+		inputHash:     inputHash,
+		inventoryHash: plan.HashInventory(invIds),
+		actionGroups:  plan.NewActionGroups(taskQueue.ToActionGroups()),
+	}, nil
+}
+
+// Plan returns the execution plan of applying the objects with the passed
+// options, without changing the cluster. The intended action for each object
+// is determined by a server-side dry-run. The plan can be executed later with
+// RunPlan, using the same objects and options.
+func (a *Applier) Plan(ctx context.Context, invInfo inventory.Info, objects object.UnstructuredSet,
+	options ApplierOptions) (*plan.Plan, error) {
+	if options.DryRunStrategy.ClientOrServerDryRun() {
+		return nil, fmt.Errorf("invalid DryRunStrategy for plan: %q", options.DryRunStrategy)
+	}
+	klog.V(4).Infof("apply plan for %d objects", len(objects))
+	// Planning must not create the Lease of the inventory lock. The lock
+	// is held by RunPlan.
+	options.Lock = inventory.LockOptions{}
+
+	// Capture the tasks of the run, without running them.
+	var state *planState
+	for e := range a.run(ctx, invInfo, objects, options, func(s planState) error {
+		state = &s
+		return errPlanned
+	}) {
+		if e.Type == event.ErrorType {
+			return nil, e.ErrorEvent.Err
+		}
+	}
+	if state == nil {
+		return nil, fmt.Errorf("failed to build the task queue")
+	}
+	p := &plan.Plan{
+		InputHash:     state.inputHash,
+		InventoryHash: state.inventoryHash,
+		ActionGroups:  state.actionGroups,
+	}
+
+	// Dry-run the tasks, to find the intended action for each object.
+	dryRunOptions := options
+	dryRunOptions.DryRunStrategy = common.DryRunServer
+	var failures []error
+	for e := range a.Run(ctx, invInfo, objects, dryRunOptions) {
+		switch e.Type {
+		case event.ErrorType:
+			return nil, e.ErrorEvent.Err
+		case event.ValidationType:
+			for _, id := range e.ValidationEvent.Identifiers {
+				p.Objects = append(p.Objects, planObject(id, plan.Skip, e.ValidationEvent.Error, nil))
+			}
+		case event.ApplyType:
+			ae := e.ApplyEvent
+			switch ae.Status {
+			case event.ApplySuccessful:
+				obj, err := a.planApply(ctx, ae.Identifier, ae.Resource)
+				if err != nil {
+					return nil, err
+				}
+				p.Objects = append(p.Objects, obj)
+			case event.ApplyUnchanged:
+				p.Objects = append(p.Objects, planObject(ae.Identifier, plan.Unchanged, nil, ae.Resource))
+			case event.ApplySkipped:
+				p.Objects = append(p.Objects, planObject(ae.Identifier, plan.Skip, ae.Error, nil))
+			case event.ApplyFailed, event.ApplyConflict:
+				failures = append(failures, fmt.Errorf("%s: %w", ae.Identifier, ae.Error))
+			}
+		case event.PruneType:
+			pe := e.PruneEvent
+			switch pe.Status {
+			case event.PruneSuccessful:
+				p.Objects = append(p.Objects, planObject(pe.Identifier, plan.Prune, nil, pe.Object))
+			case event.PruneSkipped:
+				p.Objects = append(p.Objects, planObject(pe.Identifier, plan.Skip, pe.Error, nil))
+			case event.PruneFailed:
+				failures = append(failures, fmt.Errorf("%s: %w", pe.Identifier, pe.Error))
+			}
+		}
+	}
+	if len(failures) > 0 {
+		return nil, fmt.Errorf("dry-run failed for %d object(s): %w",
+			len(failures), errors.Join(failures...))
+	}
+	return p, nil
+}
+
+// RunPlan executes a plan returned by Plan, like Run. The objects and options
+// must be the same as when the plan was made. Before any task is run, the
+// plan is verified to still be accurate: if the objects, the cluster
+// inventory, the tasks, or any of the planned objects in the cluster changed
+// since, a plan.DriftError is sent on the event channel and nothing is
+// applied or pruned. Drift is only checked once, before the first task:
+// changes to the cluster made while the tasks are running are not detected.
+// Set the Lock option to exclude concurrent runs of the same inventory.
+func (a *Applier) RunPlan(ctx context.Context, invInfo inventory.Info, objects object.UnstructuredSet,
+	p *plan.Plan, options ApplierOptions) <-chan event.Event {
+	return a.run(ctx, invInfo, objects, options, func(state planState) error {
+		return a.verifyPlan(ctx, p, state)
+	})
+}
+
+// verifyPlan returns a plan.DriftError, if the plan does not match the state
+// of the run or the objects in the cluster.
+func (a *Applier) verifyPlan(ctx context.Context, p *plan.Plan, state planState) error {
+	if p == nil {
+		return fmt.Errorf("the plan can't be nil")
+	}
+	switch {
+	case p.InputHash != state.inputHash:
+		return &plan.DriftError{Reason: "objects changed"}
+	case p.InventoryHash != state.inventoryHash:
+		return &plan.DriftError{Reason: "cluster inventory changed"}
+	case !plan.EqualActionGroups(p.ActionGroups, state.actionGroups):
+		return &plan.DriftError{Reason: "tasks changed"}
+	}
+	for _, obj := range p.Objects {
+		if obj.Action == plan.Skip {
+			continue
+		}
+		id := inventory.ObjMetadataFromObjectReference(obj.ObjectReference)
+		live, err := a.getObject(ctx, id)
+		if err != nil {
+			return err
+		}
+		var resourceVersion string
+		if live != nil {
+			resourceVersion = live.GetResourceVersion()
+		}
+		if resourceVersion != obj.ResourceVersion {
+			return &plan.DriftError{
+				Reason: fmt.Sprintf("object changed in the cluster: %s", id),
+			}
+		}
+	}
+	return nil
+}
+
+// planApply returns the intended action for an applied object, by comparing
+// the result of the dry-run with the object in the cluster.
+func (a *Applier) planApply(ctx context.Context, id object.ObjMetadata,
+	dryRun *unstructured.Unstructured) (plan.Object, error) {
+	live, err := a.getObject(ctx, id)
+	if err != nil {
+		return plan.Object{}, err
+	}
+	switch {
+	case live == nil:
+		return planObject(id, plan.Create, nil, nil), nil
+	case dryRun != nil &&
+		equality.Semantic.DeepEqual(withoutVersion(live), withoutVersion(dryRun)):
+		return planObject(id, plan.Unchanged, nil, live), nil
+	default:
+		return planObject(id, plan.Update, nil, live), nil
+	}
+}
+
+// getObject returns the object from the cluster, or nil if it does not
+// exist.
+func (a *Applier) getObject(ctx context.Context, id object.ObjMetadata) (*unstructured.Unstructured, error) {
+	mapping, err := a.mapper.RESTMapping(id.GroupKind)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			// CRD not yet established, so the object can not exist
+			return nil, nil
+		}
+		return nil, err
+	}
+	live, err := a.client.Resource(mapping.Resource).Namespace(id.Namespace).
+		Get(ctx, id.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return live, nil
+}
+
+// withoutVersion returns a copy of the object without the fields that the
+// server changes on every write.
+func withoutVersion(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	obj = obj.DeepCopy()
+	obj.SetResourceVersion("")
+	obj.SetGeneration(0)
+	obj.SetManagedFields(nil)
+	return obj
+}
+
+func planObject(id object.ObjMetadata, action plan.Action, reason error,
+	live *unstructured.Unstructured) plan.Object {
+	obj := plan.Object{
+		ObjectReference: inventory.ObjectReferenceFromObjMetadata(id),
+		Action:          action,
+	}
+	if reason != nil {
+		obj.Reason = reason.Error()
+	}
+	if live != nil {
+		obj.ResourceVersion = live.GetResourceVersion()
+	}
+	return obj
+}
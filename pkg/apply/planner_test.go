@@ -0,0 +1,148 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/apply/plan"
+	"github.com/fluxcd/cli-utils/pkg/common"
+	"github.com/fluxcd/cli-utils/pkg/inventory"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/cli-utils/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplierPlan(t *testing.T) {
+	invInfo := inventoryInfo{
+		name:      "abc-123",
+		namespace: "default",
+		id:        "test",
+	}
+	deployment := testutil.Unstructured(t, resources["deployment"])
+	secret := testutil.Unstructured(t, resources["secret"])
+	liveSecret := secret.DeepCopy()
+	liveSecret.SetResourceVersion("7")
+
+	options := ApplierOptions{
+		NoPrune:         true,
+		InventoryPolicy: inventory.PolicyAdoptIfNoInventory,
+	}
+
+	applier := newTestApplier(t, invInfo,
+		object.UnstructuredSet{deployment},
+		object.UnstructuredSet{liveSecret},
+		newFakeWatcher(nil))
+
+	p, err := applier.Plan(context.TODO(), invInfo.toWrapped(),
+		object.UnstructuredSet{deployment, secret}, options)
+	require.NoError(t, err)
+
+	// The live secret differs from the dry-run result only in its version.
+	assert.Equal(t, []plan.Object{
+		planObject(testutil.ToIdentifier(t, resources["secret"]), plan.Unchanged, nil, liveSecret),
+		planObject(testutil.ToIdentifier(t, resources["deployment"]), plan.Create, nil, nil),
+	}, p.Objects)
+	assert.NotEmpty(t, p.InputHash)
+	assert.NotEmpty(t, p.InventoryHash)
+	if assert.NotEmpty(t, p.ActionGroups) {
+		assert.Equal(t, "inventory-add-0", p.ActionGroups[0].Name)
+	}
+
+	_, err = applier.Plan(context.TODO(), invInfo.toWrapped(),
+		object.UnstructuredSet{deployment, secret},
+		ApplierOptions{DryRunStrategy: common.DryRunClient})
+	assert.EqualError(t, err, `invalid DryRunStrategy for plan: "DryRunClient"`)
+}
+
+func TestApplierRunPlan_Drift(t *testing.T) {
+	invInfo := inventoryInfo{
+		name:      "abc-123",
+		namespace: "default",
+		id:        "test",
+	}
+	deployment := testutil.Unstructured(t, resources["deployment"])
+	secret := testutil.Unstructured(t, resources["secret"])
+	liveSecret := secret.DeepCopy()
+	liveSecret.SetResourceVersion("7")
+
+	options := ApplierOptions{
+		NoPrune:         true,
+		InventoryPolicy: inventory.PolicyAdoptIfNoInventory,
+	}
+
+	testCases := map[string]struct {
+		objects       object.UnstructuredSet
+		mutate        func(p *plan.Plan)
+		expectedError error
+	}{
+		"objects changed": {
+			objects: object.UnstructuredSet{deployment},
+			expectedError: &plan.DriftError{
+				Reason: "objects changed",
+			},
+		},
+		"cluster inventory changed": {
+			objects: object.UnstructuredSet{deployment, secret},
+			mutate: func(p *plan.Plan) {
+				p.InventoryHash = "stale"
+			},
+			expectedError: &plan.DriftError{
+				Reason: "cluster inventory changed",
+			},
+		},
+		"tasks changed": {
+			objects: object.UnstructuredSet{deployment, secret},
+			mutate: func(p *plan.Plan) {
+				p.ActionGroups = p.ActionGroups[1:]
+			},
+			expectedError: &plan.DriftError{
+				Reason: "tasks changed",
+			},
+		},
+		"object changed in the cluster": {
+			objects: object.UnstructuredSet{deployment, secret},
+			mutate: func(p *plan.Plan) {
+				for i := range p.Objects {
+					if p.Objects[i].Kind == "Secret" {
+						p.Objects[i].ResourceVersion = "6"
+					}
+				}
+			},
+			expectedError: &plan.DriftError{
+				Reason: "object changed in the cluster: default_secret__Secret",
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			applier := newTestApplier(t, invInfo,
+				object.UnstructuredSet{deployment},
+				object.UnstructuredSet{liveSecret},
+				newFakeWatcher(nil))
+
+			p, err := applier.Plan(context.TODO(), invInfo.toWrapped(),
+				object.UnstructuredSet{deployment, secret}, options)
+			require.NoError(t, err)
+			if tc.mutate != nil {
+				tc.mutate(p)
+			}
+
+			var events []event.Event
+			for e := range applier.RunPlan(context.TODO(), invInfo.toWrapped(),
+				tc.objects, p, options) {
+				events = append(events, e)
+			}
+
+			// Nothing is run if the plan is out of date.
+			require.Len(t, events, 1)
+			assert.Equal(t, event.ErrorType, events[0].Type)
+			assert.Equal(t, tc.expectedError, events[0].ErrorEvent.Err)
+		})
+	}
+}
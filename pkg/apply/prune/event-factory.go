@@ -16,6 +16,7 @@ type EventFactory interface {
 	CreateSuccessEvent(obj *unstructured.Unstructured) event.Event
 	CreateSkippedEvent(obj *unstructured.Unstructured, err error) event.Event
 	CreateFailedEvent(id object.ObjMetadata, err error) event.Event
+	CreateRetryingEvent(id object.ObjMetadata, err error, attempt, maxAttempts int) event.Event
 }
 
 // CreateEventFactory returns the correct concrete version of
@@ -76,6 +77,20 @@ func (pef PruneEventFactory) CreateFailedEvent(id object.ObjMetadata, err error)
 	}
 }
 
+func (pef PruneEventFactory) CreateRetryingEvent(id object.ObjMetadata, err error, attempt, maxAttempts int) event.Event {
+	return event.Event{
+		Type: event.PruneType,
+		PruneEvent: event.PruneEvent{
+			GroupName:   pef.groupName,
+			Status:      event.PruneRetrying,
+			Identifier:  id,
+			Error:       err,
+			Attempt:     attempt,
+			MaxAttempts: maxAttempts,
+		},
+	}
+}
+
 // DeleteEventFactory implements EventFactory interface as a concrete
 // representation of for delete events.
 type DeleteEventFactory struct {
@@ -118,3 +133,29 @@ func (def DeleteEventFactory) CreateFailedEvent(id object.ObjMetadata, err error
 		},
 	}
 }
+
+func (def DeleteEventFactory) CreateRetryingEvent(id object.ObjMetadata, err error, attempt, maxAttempts int) event.Event {
+	return event.Event{
+		Type: event.DeleteType,
+		DeleteEvent: event.DeleteEvent{
+			GroupName:   def.groupName,
+			Status:      event.DeleteRetrying,
+			Identifier:  id,
+			Error:       err,
+			Attempt:     attempt,
+			MaxAttempts: maxAttempts,
+		},
+	}
+}
+
+// withTiming returns the prune or delete event with the timing of the
+// deletion.
+func withTiming(e event.Event, timing event.Timing) event.Event {
+	switch e.Type {
+	case event.PruneType:
+		e.PruneEvent.Timing = timing
+	case event.DeleteType:
+		e.DeleteEvent.Timing = timing
+	}
+	return e
+}
@@ -14,8 +14,12 @@ package prune
 import (
 	"context"
 	"errors"
+	"sync"
+	"time"
 
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
 	"github.com/fluxcd/cli-utils/pkg/apply/filter"
+	"github.com/fluxcd/cli-utils/pkg/apply/retry"
 	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
 	"github.com/fluxcd/cli-utils/pkg/common"
 	"github.com/fluxcd/cli-utils/pkg/inventory"
@@ -68,6 +72,14 @@ type Options struct {
 	// True if we are destroying, which deletes the inventory object
 	// as well (possibly) the inventory namespace.
 	Destroy bool
+
+	// Concurrency is the maximum number of objects to delete in parallel.
+	// Values less than two delete the objects serially.
+	Concurrency int
+
+	// RetryPolicy defines when to retry the deletion of an object, if it
+	// failed with a transient error. The zero value does not retry.
+	RetryPolicy retry.Policy
 }
 
 // Prune deletes the set of passed objects. A prune skip/failure is
@@ -77,7 +89,8 @@ type Options struct {
 // of a prune filter is PreventDeleteFilter, which checks if an
 // annotation exists on the object to ensure the objects is not
 // deleted (e.g. a PersistentVolume that we do no want to
-// automatically prune/delete).
+// automatically prune/delete). Once the context of the TaskContext
+// is done, the remaining objects are skipped.
 //
 // Parameters:
 //
@@ -96,88 +109,139 @@ func (p *Pruner) Prune(
 	eventFactory := CreateEventFactory(opts.Destroy, taskName)
 	// Iterate through objects to prune (delete). If an object is not pruned
 	// and we need to keep it in the inventory, we must capture the prune failure.
+	if opts.Concurrency < 2 {
+		for _, obj := range objs {
+			p.pruneObject(obj, pruneFilters, taskContext, eventFactory, opts)
+		}
+		return nil
+	}
+	sem := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
 	for _, obj := range objs {
-		id := object.UnstructuredToObjMetadata(obj)
-		klog.V(5).Infof("evaluating prune filters (object: %q)", id)
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(obj *unstructured.Unstructured) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			p.pruneObject(obj, pruneFilters, taskContext, eventFactory, opts)
+		}(obj)
+	}
+	wg.Wait()
+	return nil
+}
 
-		// UID will change if the object is deleted and re-created.
-		uid := obj.GetUID()
-		if uid == "" {
-			err := object.NotFound([]interface{}{"metadata", "uid"}, "")
-			if klog.V(4).Enabled() {
-				// only log event emitted errors if the verbosity > 4
-				klog.Errorf("prune uid lookup errored (object: %s): %v", id, err)
-			}
-			taskContext.SendEvent(eventFactory.CreateFailedEvent(id, err))
-			taskContext.InventoryManager().AddFailedDelete(id)
-			continue
+// pruneObject deletes a single object and records the result in the
+// taskContext. Events for the object are sent in order.
+func (p *Pruner) pruneObject(
+	obj *unstructured.Unstructured,
+	pruneFilters []filter.ValidationFilter,
+	taskContext *taskrunner.TaskContext,
+	eventFactory EventFactory,
+	opts Options,
+) {
+	ctx := taskContext.Context()
+	id := object.UnstructuredToObjMetadata(obj)
+
+	// Stop deleting if the caller cancelled or the runner stopped.
+	if ctx.Err() != nil {
+		ctxErr := context.Cause(ctx)
+		klog.V(4).Infof("prune cancelled (object: %q): %v", id, ctxErr)
+		taskContext.SendEvent(eventFactory.CreateSkippedEvent(obj, ctxErr))
+		taskContext.InventoryManager().AddSkippedDelete(id)
+		return
+	}
+
+	klog.V(5).Infof("evaluating prune filters (object: %q)", id)
+
+	// UID will change if the object is deleted and re-created.
+	uid := obj.GetUID()
+	if uid == "" {
+		err := object.NotFound([]interface{}{"metadata", "uid"}, "")
+		if klog.V(4).Enabled() {
+			// only log event emitted errors if the verbosity > 4
+			klog.Errorf("prune uid lookup errored (object: %s): %v", id, err)
 		}
+		taskContext.SendEvent(eventFactory.CreateFailedEvent(id, err))
+		taskContext.InventoryManager().AddFailedDelete(id)
+		return
+	}
 
-		// Check filters to see if we're prevented from pruning/deleting object.
-		var filterErr error
-		for _, pruneFilter := range pruneFilters {
-			klog.V(6).Infof("prune filter evaluating (filter: %s, object: %s)", pruneFilter.Name(), id)
-			filterErr = pruneFilter.Filter(obj)
-			if filterErr != nil {
-				var fatalErr *filter.FatalError
-				if errors.As(filterErr, &fatalErr) {
-					if klog.V(4).Enabled() {
-						// only log event emitted errors if the verbosity > 4
-						klog.Errorf("prune filter errored (filter: %s, object: %s): %v", pruneFilter.Name(), id, fatalErr.Err)
-					}
-					taskContext.SendEvent(eventFactory.CreateFailedEvent(id, fatalErr.Err))
-					taskContext.InventoryManager().AddFailedDelete(id)
-					break
+	// Check filters to see if we're prevented from pruning/deleting object.
+	var filterErr error
+	for _, pruneFilter := range pruneFilters {
+		klog.V(6).Infof("prune filter evaluating (filter: %s, object: %s)", pruneFilter.Name(), id)
+		filterErr = pruneFilter.Filter(obj)
+		if filterErr != nil {
+			var fatalErr *filter.FatalError
+			if errors.As(filterErr, &fatalErr) {
+				if klog.V(4).Enabled() {
+					// only log event emitted errors if the verbosity > 4
+					klog.Errorf("prune filter errored (filter: %s, object: %s): %v", pruneFilter.Name(), id, fatalErr.Err)
 				}
-				klog.V(4).Infof("prune filtered (filter: %s, object: %s): %v", pruneFilter.Name(), id, filterErr)
+				taskContext.SendEvent(eventFactory.CreateFailedEvent(id, fatalErr.Err))
+				taskContext.InventoryManager().AddFailedDelete(id)
+				break
+			}
+			klog.V(4).Infof("prune filtered (filter: %s, object: %s): %v", pruneFilter.Name(), id, filterErr)
 
-				// Remove the inventory annotation if deletion was prevented.
-				// This abandons the object so it won't be pruned by future applier runs.
-				var abandonErr *filter.AnnotationPreventedDeletionError
-				if errors.As(filterErr, &abandonErr) {
-					if !opts.DryRunStrategy.ClientOrServerDryRun() {
-						var err error
-						obj, err = p.removeInventoryAnnotation(obj)
-						if err != nil {
-							if klog.V(4).Enabled() {
-								// only log event emitted errors if the verbosity > 4
-								klog.Errorf("error removing annotation (object: %q, annotation: %q): %v", id, inventory.OwningInventoryKey, err)
-							}
-							taskContext.SendEvent(eventFactory.CreateFailedEvent(id, err))
-							taskContext.InventoryManager().AddFailedDelete(id)
-							break
+			// Remove the inventory annotation if deletion was prevented by
+			// annotation or by abandoning all objects.
+			// This abandons the object so it won't be pruned by future applier runs.
+			var annotationErr *filter.AnnotationPreventedDeletionError
+			var abandonErr *filter.AbandonPreventedDeletionError
+			if errors.As(filterErr, &annotationErr) || errors.As(filterErr, &abandonErr) {
+				if !opts.DryRunStrategy.ClientOrServerDryRun() {
+					var err error
+					obj, err = p.removeInventoryAnnotation(ctx, obj)
+					if err != nil {
+						if klog.V(4).Enabled() {
+							// only log event emitted errors if the verbosity > 4
+							klog.Errorf("error removing annotation (object: %q, annotation: %q): %v", id, inventory.OwningInventoryKey, err)
 						}
-						// Inventory annotation was successfully removed from the object.
-						// Register for removal from the inventory.
-						taskContext.AddAbandonedObject(id)
+						taskContext.SendEvent(eventFactory.CreateFailedEvent(id, err))
+						taskContext.InventoryManager().AddFailedDelete(id)
+						break
 					}
+					// Inventory annotation was successfully removed from the object.
+					// Register for removal from the inventory.
+					taskContext.AddAbandonedObject(id)
 				}
+			}
 
-				// Remove the object from inventory if it was determined that the object should not be pruned,
-				// because it had recently been applied. This probably means that the object is in the inventory
-				// more than one time with a different group (e.g. kind Ingress and apiGroups networking.k8s.io & extensions)
-				// due to being cohabitated: https://github.com/kubernetes/kubernetes/blob/v1.25.0/pkg/kubeapiserver/default_storage_factory_builder.go#L124-L131
-				var deleteAfterApplyErr *filter.ApplyPreventedDeletionError
-				if errors.As(filterErr, &deleteAfterApplyErr) {
-					if !opts.DryRunStrategy.ClientOrServerDryRun() {
-						// Register for removal from the inventory.
-						taskContext.AddAbandonedObject(id)
-					}
+			// Remove the object from inventory if it was determined that the object should not be pruned,
+			// because it had recently been applied. This probably means that the object is in the inventory
+			// more than one time with a different group (e.g. kind Ingress and apiGroups networking.k8s.io & extensions)
+			// due to being cohabitated: https://github.com/kubernetes/kubernetes/blob/v1.25.0/pkg/kubeapiserver/default_storage_factory_builder.go#L124-L131
+			var deleteAfterApplyErr *filter.ApplyPreventedDeletionError
+			if errors.As(filterErr, &deleteAfterApplyErr) {
+				if !opts.DryRunStrategy.ClientOrServerDryRun() {
+					// Register for removal from the inventory.
+					taskContext.AddAbandonedObject(id)
 				}
-
-				taskContext.SendEvent(eventFactory.CreateSkippedEvent(obj, filterErr))
-				taskContext.InventoryManager().AddSkippedDelete(id)
-				break
 			}
+
+			taskContext.SendEvent(eventFactory.CreateSkippedEvent(obj, filterErr))
+			taskContext.InventoryManager().AddSkippedDelete(id)
+			break
 		}
-		if filterErr != nil {
-			continue
-		}
+	}
+	if filterErr != nil {
+		return
+	}
 
-		// Filters passed--actually delete object if not dry run.
-		if !opts.DryRunStrategy.ClientOrServerDryRun() {
-			klog.V(4).Infof("deleting object (object: %q)", id)
-			err := p.deleteObject(id, metav1.DeleteOptions{
+	// Filters passed--actually delete object if not dry run.
+	var timing event.Timing
+	if !opts.DryRunStrategy.ClientOrServerDryRun() {
+		klog.V(4).Infof("deleting object (object: %q)", id)
+		timing.Start = time.Now()
+		err := opts.RetryPolicy.Do(ctx, func() error {
+			attemptStart := time.Now()
+			defer func() {
+				timing.Latency += time.Since(attemptStart)
+			}()
+			return p.deleteObject(ctx, id, metav1.DeleteOptions{
 				// Only delete the resource if it hasn't already been deleted
 				// and recreated since the last GET. Otherwise error.
 				Preconditions: &metav1.Preconditions{
@@ -185,29 +249,35 @@ func (p *Pruner) Prune(
 				},
 				PropagationPolicy: &opts.PropagationPolicy,
 			})
-			if err != nil {
-				if apierrors.IsNotFound(err) {
-					klog.Warningf("error deleting object (object: %q): object not found: object may have been deleted asynchronously by another client", id)
-					// treat this as successful idempotent deletion
-				} else {
-					if klog.V(4).Enabled() {
-						// only log event emitted errors if the verbosity > 4
-						klog.Errorf("error deleting object (object: %q): %v", id, err)
-					}
-					taskContext.SendEvent(eventFactory.CreateFailedEvent(id, err))
-					taskContext.InventoryManager().AddFailedDelete(id)
-					continue
+		}, func(err error, attempt int) {
+			klog.V(4).Infof("retrying delete (object: %q, attempt: %d/%d): %v",
+				id, attempt, opts.RetryPolicy.MaxAttempts, err)
+			taskContext.SendEvent(eventFactory.CreateRetryingEvent(id, err,
+				attempt, opts.RetryPolicy.MaxAttempts))
+		})
+		timing.End = time.Now()
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				klog.Warningf("error deleting object (object: %q): object not found: object may have been deleted asynchronously by another client", id)
+				// treat this as successful idempotent deletion
+			} else {
+				if klog.V(4).Enabled() {
+					// only log event emitted errors if the verbosity > 4
+					klog.Errorf("error deleting object (object: %q): %v", id, err)
 				}
+				taskContext.SendEvent(withTiming(eventFactory.CreateFailedEvent(id, err), timing))
+				taskContext.InventoryManager().AddFailedDelete(id)
+				return
 			}
 		}
-		taskContext.InventoryManager().AddSuccessfulDelete(id, obj.GetUID())
-		taskContext.SendEvent(eventFactory.CreateSuccessEvent(obj))
+		taskContext.AddActuationTime(id, timing.End)
 	}
-	return nil
+	taskContext.InventoryManager().AddSuccessfulDelete(id, obj.GetUID())
+	taskContext.SendEvent(withTiming(eventFactory.CreateSuccessEvent(obj), timing))
 }
 
 // removeInventoryAnnotation removes the `config.k8s.io/owning-inventory` annotation from pruneObj.
-func (p *Pruner) removeInventoryAnnotation(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+func (p *Pruner) removeInventoryAnnotation(ctx context.Context, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
 	// Make a copy of the input object to avoid modifying the input.
 	// This prevents race conditions when writing to the underlying map.
 	obj = obj.DeepCopy()
@@ -222,7 +292,7 @@ func (p *Pruner) removeInventoryAnnotation(obj *unstructured.Unstructured) (*uns
 			if err != nil {
 				return obj, err
 			}
-			_, err = namespacedClient.Update(context.TODO(), obj, metav1.UpdateOptions{})
+			_, err = namespacedClient.Update(ctx, obj, metav1.UpdateOptions{})
 			return obj, err
 		}
 	}
@@ -272,12 +342,12 @@ func (p *Pruner) getObject(id object.ObjMetadata) (*unstructured.Unstructured, e
 	return namespacedClient.Get(context.TODO(), id.Name, metav1.GetOptions{})
 }
 
-func (p *Pruner) deleteObject(id object.ObjMetadata, opts metav1.DeleteOptions) error {
+func (p *Pruner) deleteObject(ctx context.Context, id object.ObjMetadata, opts metav1.DeleteOptions) error {
 	namespacedClient, err := p.namespacedClient(id)
 	if err != nil {
 		return err
 	}
-	return namespacedClient.Delete(context.TODO(), id.Name, opts)
+	return namespacedClient.Delete(ctx, id.Name, opts)
 }
 
 func (p *Pruner) namespacedClient(id object.ObjMetadata) (dynamic.ResourceInterface, error) {
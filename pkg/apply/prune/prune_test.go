@@ -5,13 +5,16 @@ package prune
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/fluxcd/cli-utils/pkg/apply/cache"
 	"github.com/fluxcd/cli-utils/pkg/apply/event"
 	"github.com/fluxcd/cli-utils/pkg/apply/filter"
+	"github.com/fluxcd/cli-utils/pkg/apply/retry"
 	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
 	"github.com/fluxcd/cli-utils/pkg/common"
 	"github.com/fluxcd/cli-utils/pkg/inventory"
@@ -29,6 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
 	"k8s.io/kubectl/pkg/scheme"
 )
 
@@ -585,7 +589,7 @@ func TestPrune(t *testing.T) {
 			// the events that can be put on it.
 			eventChannel := make(chan event.Event, len(tc.pruneObjs)+1)
 			resourceCache := cache.NewResourceCacheMap()
-			taskContext := taskrunner.NewTaskContext(eventChannel, resourceCache)
+			taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
 			taskName := "test-0"
 			err := func() error {
 				defer close(eventChannel)
@@ -600,6 +604,8 @@ func TestPrune(t *testing.T) {
 			for e := range eventChannel {
 				actualEvents = append(actualEvents, e)
 			}
+			// Timing is not deterministic
+			clearTiming(actualEvents)
 			// Inject expected GroupName for event comparison
 			for i := range tc.expectedEvents {
 				switch tc.expectedEvents[i].Type {
@@ -641,10 +647,262 @@ func TestPrune(t *testing.T) {
 	}
 }
 
+// Tests that objects are skipped, and not deleted, once the context
+// of the TaskContext is cancelled.
+func TestPruneCancelled(t *testing.T) {
+	pruneObjs := object.UnstructuredSet{pod, pdb}
+	pruneIds := object.UnstructuredSetToObjMetadataSet(pruneObjs)
+	po := Pruner{
+		InvClient: inventory.NewFakeClient(pruneIds),
+		Client:    fake.NewSimpleDynamicClient(scheme.Scheme, pod, pdb),
+		Mapper: testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme,
+			scheme.Scheme.PrioritizedVersionsAllGroups()...),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// The event channel can not block; make sure its bigger than all
+	// the events that can be put on it.
+	eventChannel := make(chan event.Event, len(pruneObjs)+1)
+	resourceCache := cache.NewResourceCacheMap()
+	taskContext := taskrunner.NewTaskContext(ctx, eventChannel, resourceCache)
+	err := func() error {
+		defer close(eventChannel)
+		return po.Prune(pruneObjs, nil, taskContext, "test-0", defaultOptions)
+	}()
+	require.NoError(t, err)
+
+	var actualEvents []event.Event
+	for e := range eventChannel {
+		actualEvents = append(actualEvents, e)
+	}
+	expectedEvents := []event.Event{
+		{
+			Type: event.PruneType,
+			PruneEvent: event.PruneEvent{
+				GroupName:  "test-0",
+				Identifier: object.UnstructuredToObjMetadata(pod),
+				Status:     event.PruneSkipped,
+				Object:     pod,
+				Error:      context.Canceled,
+			},
+		},
+		{
+			Type: event.PruneType,
+			PruneEvent: event.PruneEvent{
+				GroupName:  "test-0",
+				Identifier: object.UnstructuredToObjMetadata(pdb),
+				Status:     event.PruneSkipped,
+				Object:     pdb,
+				Error:      context.Canceled,
+			},
+		},
+	}
+	testutil.AssertEqual(t, expectedEvents, actualEvents)
+
+	im := taskContext.InventoryManager()
+	assert.Equal(t, pruneIds, im.SkippedDeletes())
+
+	// Objects must still exist in the cluster
+	for _, id := range pruneIds {
+		_, err := po.getObject(id)
+		assert.NoErrorf(t, err, "object should not have been deleted: %s", id)
+	}
+}
+
+func TestPruneConcurrent(t *testing.T) {
+	pruneObjs := object.UnstructuredSet{pod, pdb, podDeletionPrevention}
+	pruneIds := object.UnstructuredSetToObjMetadataSet(pruneObjs)
+	po := Pruner{
+		InvClient: inventory.NewFakeClient(pruneIds),
+		Client:    fake.NewSimpleDynamicClient(scheme.Scheme, pod, pdb, podDeletionPrevention),
+		Mapper: testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme,
+			scheme.Scheme.PrioritizedVersionsAllGroups()...),
+	}
+
+	// The event channel can not block; make sure its bigger than all
+	// the events that can be put on it.
+	eventChannel := make(chan event.Event, len(pruneObjs)+1)
+	resourceCache := cache.NewResourceCacheMap()
+	taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
+	opts := defaultOptions
+	opts.Concurrency = 2
+	err := func() error {
+		defer close(eventChannel)
+		return po.Prune(pruneObjs, []filter.ValidationFilter{filter.PreventRemoveFilter{}}, taskContext, "test-0", opts)
+	}()
+	require.NoError(t, err)
+
+	actualStatus := map[object.ObjMetadata]event.PruneEventStatus{}
+	for e := range eventChannel {
+		require.Equal(t, event.PruneType, e.Type)
+		actualStatus[e.PruneEvent.Identifier] = e.PruneEvent.Status
+	}
+	expectedStatus := map[object.ObjMetadata]event.PruneEventStatus{
+		object.UnstructuredToObjMetadata(pod):                   event.PruneSuccessful,
+		object.UnstructuredToObjMetadata(pdb):                   event.PruneSuccessful,
+		object.UnstructuredToObjMetadata(podDeletionPrevention): event.PruneSkipped,
+	}
+	testutil.AssertEqual(t, expectedStatus, actualStatus)
+
+	im := taskContext.InventoryManager()
+	preventedID := object.UnstructuredToObjMetadata(podDeletionPrevention)
+	assert.True(t, im.IsSkippedDelete(preventedID))
+	assert.True(t, taskContext.IsAbandonedObject(preventedID))
+	// Objects are deleted in parallel, so the order is not deterministic.
+	assert.ElementsMatch(t, object.ObjMetadataSet{
+		object.UnstructuredToObjMetadata(pod),
+		object.UnstructuredToObjMetadata(pdb),
+	}, im.SuccessfulDeletes())
+}
+
+// Tests that deletions failing with a transient error are retried.
+func TestPruneRetry(t *testing.T) {
+	pruneObjs := object.UnstructuredSet{pod}
+	pruneIds := object.UnstructuredSetToObjMetadataSet(pruneObjs)
+	client := fake.NewSimpleDynamicClient(scheme.Scheme, pod)
+	throttleErr := apierrors.NewTooManyRequests("slow down", 1)
+	deletes := 0
+	client.PrependReactor("delete", "pods", func(clienttesting.Action) (bool, runtime.Object, error) {
+		deletes++
+		if deletes == 1 {
+			return true, nil, throttleErr
+		}
+		// fall through to the object tracker
+		return false, nil, nil
+	})
+	po := Pruner{
+		InvClient: inventory.NewFakeClient(pruneIds),
+		Client:    client,
+		Mapper: testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme,
+			scheme.Scheme.PrioritizedVersionsAllGroups()...),
+	}
+
+	// The event channel can not block; make sure its bigger than all
+	// the events that can be put on it.
+	eventChannel := make(chan event.Event, 2*len(pruneObjs))
+	resourceCache := cache.NewResourceCacheMap()
+	taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
+	opts := defaultOptions
+	opts.RetryPolicy = retry.Policy{
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+	}
+	err := func() error {
+		defer close(eventChannel)
+		return po.Prune(pruneObjs, nil, taskContext, "test-0", opts)
+	}()
+	require.NoError(t, err)
+
+	var actualEvents []event.Event
+	for e := range eventChannel {
+		actualEvents = append(actualEvents, e)
+	}
+	// The timing covers both attempts.
+	if assert.Len(t, actualEvents, 2) {
+		timing := actualEvents[1].PruneEvent.Timing
+		assert.False(t, timing.Start.IsZero())
+		assert.False(t, timing.End.IsZero())
+		assert.Positive(t, timing.Latency)
+		assert.GreaterOrEqual(t, timing.Duration(), timing.Latency)
+	}
+	clearTiming(actualEvents)
+	expectedEvents := []event.Event{
+		{
+			Type: event.PruneType,
+			PruneEvent: event.PruneEvent{
+				GroupName:   "test-0",
+				Identifier:  object.UnstructuredToObjMetadata(pod),
+				Status:      event.PruneRetrying,
+				Error:       throttleErr,
+				Attempt:     2,
+				MaxAttempts: 3,
+			},
+		},
+		{
+			Type: event.PruneType,
+			PruneEvent: event.PruneEvent{
+				GroupName:  "test-0",
+				Identifier: object.UnstructuredToObjMetadata(pod),
+				Status:     event.PruneSuccessful,
+				Object:     pod,
+			},
+		},
+	}
+	testutil.AssertEqual(t, expectedEvents, actualEvents)
+	assert.Equal(t, 2, deletes)
+
+	im := taskContext.InventoryManager()
+	assert.Equal(t, pruneIds, im.SuccessfulDeletes())
+	_, err = po.getObject(pruneIds[0])
+	assert.True(t, apierrors.IsNotFound(err))
+}
+
+// Tests that deletions failing with a failed UID precondition are not retried,
+// because the object was recreated.
+func TestPruneRetryPreconditionFailed(t *testing.T) {
+	pruneObjs := object.UnstructuredSet{pod}
+	pruneIds := object.UnstructuredSetToObjMetadataSet(pruneObjs)
+	client := fake.NewSimpleDynamicClient(scheme.Scheme, pod)
+	preconditionErr := apierrors.NewConflict(schema.GroupResource{Resource: "pods"}, "pod-name",
+		errors.New("Precondition failed: UID in precondition: uid1, UID in object meta: uid2"))
+	deletes := 0
+	client.PrependReactor("delete", "pods", func(clienttesting.Action) (bool, runtime.Object, error) {
+		deletes++
+		return true, nil, preconditionErr
+	})
+	po := Pruner{
+		InvClient: inventory.NewFakeClient(pruneIds),
+		Client:    client,
+		Mapper: testrestmapper.TestOnlyStaticRESTMapper(scheme.Scheme,
+			scheme.Scheme.PrioritizedVersionsAllGroups()...),
+	}
+
+	// The event channel can not block; make sure its bigger than all
+	// the events that can be put on it.
+	eventChannel := make(chan event.Event, 2*len(pruneObjs))
+	resourceCache := cache.NewResourceCacheMap()
+	taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
+	opts := defaultOptions
+	opts.RetryPolicy = retry.Policy{
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+	}
+	err := func() error {
+		defer close(eventChannel)
+		return po.Prune(pruneObjs, nil, taskContext, "test-0", opts)
+	}()
+	require.NoError(t, err)
+
+	var actualEvents []event.Event
+	for e := range eventChannel {
+		actualEvents = append(actualEvents, e)
+	}
+	clearTiming(actualEvents)
+	expectedEvents := []event.Event{
+		{
+			Type: event.PruneType,
+			PruneEvent: event.PruneEvent{
+				GroupName:  "test-0",
+				Identifier: object.UnstructuredToObjMetadata(pod),
+				Status:     event.PruneFailed,
+				Error:      preconditionErr,
+			},
+		},
+	}
+	testutil.AssertEqual(t, expectedEvents, actualEvents)
+	assert.Equal(t, 1, deletes)
+
+	im := taskContext.InventoryManager()
+	assert.Equal(t, pruneIds, im.FailedDeletes())
+}
+
 func TestPruneDeletionPrevention(t *testing.T) {
 	tests := map[string]struct {
-		pruneObj *unstructured.Unstructured
-		options  Options
+		pruneObj     *unstructured.Unstructured
+		pruneFilters []filter.ValidationFilter
+		options      Options
 	}{
 		"an object with the cli-utils.sigs.k8s.io/on-remove annotation (prune)": {
 			pruneObj: podDeletionPrevention,
@@ -662,6 +920,11 @@ func TestPruneDeletionPrevention(t *testing.T) {
 			pruneObj: testutil.Unstructured(t, pdbDeletePreventionManifest),
 			options:  defaultOptionsDestroy,
 		},
+		"an object without annotation (abandon)": {
+			pruneObj:     pod,
+			pruneFilters: []filter.ValidationFilter{filter.PreventRemoveFilter{}, filter.AbandonFilter{}},
+			options:      defaultOptionsDestroy,
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -676,11 +939,15 @@ func TestPruneDeletionPrevention(t *testing.T) {
 			// the events that can be put on it.
 			eventChannel := make(chan event.Event, 2)
 			resourceCache := cache.NewResourceCacheMap()
-			taskContext := taskrunner.NewTaskContext(eventChannel, resourceCache)
+			taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
 			err := func() error {
 				defer close(eventChannel)
 				// Run the prune and validate.
-				return po.Prune([]*unstructured.Unstructured{tc.pruneObj}, []filter.ValidationFilter{filter.PreventRemoveFilter{}}, taskContext, "test-0", tc.options)
+				pruneFilters := tc.pruneFilters
+				if pruneFilters == nil {
+					pruneFilters = []filter.ValidationFilter{filter.PreventRemoveFilter{}}
+				}
+				return po.Prune([]*unstructured.Unstructured{tc.pruneObj}, pruneFilters, taskContext, "test-0", tc.options)
 			}()
 			require.NoError(t, err)
 
@@ -775,7 +1042,7 @@ func TestPruneWithErrors(t *testing.T) {
 			// the events that can be put on it.
 			eventChannel := make(chan event.Event, len(tc.pruneObjs))
 			resourceCache := cache.NewResourceCacheMap()
-			taskContext := taskrunner.NewTaskContext(eventChannel, resourceCache)
+			taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
 			err := func() error {
 				defer close(eventChannel)
 				var opts Options
@@ -915,7 +1182,7 @@ func TestHandleDeletePrevention(t *testing.T) {
 			scheme.Scheme.PrioritizedVersionsAllGroups()...),
 	}
 	var err error
-	obj, err = po.removeInventoryAnnotation(obj)
+	obj, err = po.removeInventoryAnnotation(context.TODO(), obj)
 	if err != nil {
 		t.Fatalf("unexpected error %s returned", err)
 	}
@@ -978,7 +1245,7 @@ func TestPrune_PropagationPolicy(t *testing.T) {
 
 			eventChannel := make(chan event.Event, 1)
 			resourceCache := cache.NewResourceCacheMap()
-			taskContext := taskrunner.NewTaskContext(eventChannel, resourceCache)
+			taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
 			err := po.Prune([]*unstructured.Unstructured{pdb}, []filter.ValidationFilter{}, taskContext, "test-0", Options{
 				PropagationPolicy: tc.propagationPolicy,
 			})
@@ -1010,3 +1277,12 @@ type fakeDynamicResourceClient struct {
 func (c *fakeDynamicResourceClient) Namespace(ns string) dynamic.ResourceInterface {
 	return c.resourceInterface
 }
+
+// clearTiming removes the timing from prune and delete events, which is not
+// deterministic.
+func clearTiming(events []event.Event) {
+	for i := range events {
+		events[i].PruneEvent.Timing = event.Timing{}
+		events[i].DeleteEvent.Timing = event.Timing{}
+	}
+}
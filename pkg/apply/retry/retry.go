@@ -0,0 +1,138 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+// Package retry provides a policy to retry the actuation of an object, when
+// the cluster returns a transient error.
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultBackoff is the delay before the first retry, if not specified.
+	DefaultBackoff = 1 * time.Second
+	// DefaultMaxBackoff is the maximum delay between retries, if not
+	// specified.
+	DefaultMaxBackoff = 30 * time.Second
+)
+
+// Policy defines how often and when to retry an operation that failed.
+// The zero value does not retry.
+type Policy struct {
+	// MaxAttempts is the maximum number of attempts, including the first.
+	// Values less than two disable retries.
+	MaxAttempts int
+	// Backoff is the delay before the first retry. The delay is doubled
+	// for every subsequent retry, up to MaxBackoff.
+	// Defaults to DefaultBackoff.
+	Backoff time.Duration
+	// MaxBackoff is the maximum delay between retries.
+	// Defaults to DefaultMaxBackoff.
+	MaxBackoff time.Duration
+	// Retryable returns true if the error is worth retrying.
+	// Defaults to IsTransient.
+	Retryable func(error) bool
+}
+
+// Do calls fn until it succeeds, returns an error that is not retryable, or
+// the maximum number of attempts is reached. The last error is returned.
+//
+// Before every retry, onRetry is called with the error and the number of the
+// next attempt, then Do waits for the backoff delay. If the context is done
+// while waiting, the last error is returned without retrying.
+func (p Policy) Do(ctx context.Context, fn func() error, onRetry func(err error, attempt int)) error {
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsTransient
+	}
+	delay := p.Backoff
+	if delay <= 0 {
+		delay = DefaultBackoff
+	}
+	maxDelay := p.MaxBackoff
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxBackoff
+	}
+
+	attempt := 1
+	for {
+		err := fn()
+		if err == nil || attempt >= p.MaxAttempts || !retryable(err) {
+			return err
+		}
+		attempt++
+		if onRetry != nil {
+			onRetry(err, attempt)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		delay *= 2
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// IsTransient returns true if the error returned by the cluster is likely to
+// go away on retry: conflicts, throttling, server errors and timeouts, which
+// include timeouts calling admission webhooks. Server-side apply conflicts
+// between field managers and failed preconditions, e.g. deleting an object
+// that was recreated with another UID, are not transient.
+func IsTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiStatus apierrors.APIStatus
+	if !errors.As(err, &apiStatus) {
+		return false
+	}
+	status := apiStatus.Status()
+	switch {
+	case status.Reason == metav1.StatusReasonConflict:
+		return !isFieldManagerConflict(status) && !isPreconditionFailure(status)
+	case status.Reason == metav1.StatusReasonTooManyRequests,
+		status.Reason == metav1.StatusReasonServerTimeout,
+		status.Reason == metav1.StatusReasonTimeout,
+		status.Reason == metav1.StatusReasonInternalError,
+		status.Reason == metav1.StatusReasonServiceUnavailable:
+		return true
+	case status.Code == http.StatusTooManyRequests,
+		status.Code >= http.StatusInternalServerError:
+		return true
+	}
+	return false
+}
+
+// isFieldManagerConflict returns true if the status describes a server-side
+// apply conflict, which requires the conflicting fields to be forced.
+func isFieldManagerConflict(status metav1.Status) bool {
+	if status.Details == nil {
+		return false
+	}
+	for _, cause := range status.Details.Causes {
+		if cause.Type == metav1.CauseTypeFieldManagerConflict {
+			return true
+		}
+	}
+	return false
+}
+
+// isPreconditionFailure returns true if the status describes a conflict with
+// the UID or resourceVersion precondition of the request. The apiserver only
+// reports these in the message of the Conflict status.
+func isPreconditionFailure(status metav1.Status) bool {
+	return strings.Contains(status.Message, "Precondition failed")
+}
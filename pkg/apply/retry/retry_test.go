@@ -0,0 +1,176 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var deploymentsGR = schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+func TestIsTransient(t *testing.T) {
+	testCases := map[string]struct {
+		err      error
+		expected bool
+	}{
+		"nil": {
+			err:      nil,
+			expected: false,
+		},
+		"not an api error": {
+			err:      errors.New("boom"),
+			expected: false,
+		},
+		"not found": {
+			err:      apierrors.NewNotFound(deploymentsGR, "foo"),
+			expected: false,
+		},
+		"invalid": {
+			err:      apierrors.NewBadRequest("invalid"),
+			expected: false,
+		},
+		"conflict": {
+			err:      apierrors.NewConflict(deploymentsGR, "foo", errors.New("modified")),
+			expected: true,
+		},
+		"wrapped conflict": {
+			err: fmt.Errorf("apply failed: %w",
+				apierrors.NewConflict(deploymentsGR, "foo", errors.New("modified"))),
+			expected: true,
+		},
+		"server-side apply conflict": {
+			err: apierrors.NewApplyConflict([]metav1.StatusCause{
+				{
+					Type:    metav1.CauseTypeFieldManagerConflict,
+					Message: `conflict with "kubectl"`,
+					Field:   ".spec.replicas",
+				},
+			}, "Apply failed with 1 conflict"),
+			expected: false,
+		},
+		"failed precondition": {
+			err: apierrors.NewConflict(deploymentsGR, "foo",
+				errors.New("Precondition failed: UID in precondition: 123, UID in object meta: 456")),
+			expected: false,
+		},
+		"too many requests": {
+			err:      apierrors.NewTooManyRequests("slow down", 1),
+			expected: true,
+		},
+		"internal error": {
+			err:      apierrors.NewInternalError(errors.New(`failed calling webhook "foo": context deadline exceeded`)),
+			expected: true,
+		},
+		"service unavailable": {
+			err:      apierrors.NewServiceUnavailable("unavailable"),
+			expected: true,
+		},
+		"timeout": {
+			err:      apierrors.NewTimeoutError("timeout", 1),
+			expected: true,
+		},
+		"bad gateway": {
+			err:      apierrors.NewGenericServerResponse(502, "PATCH", deploymentsGR, "foo", "", 0, true),
+			expected: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			assert.Equal(t, tc.expected, IsTransient(tc.err))
+		})
+	}
+}
+
+func TestPolicy_Do(t *testing.T) {
+	transientErr := apierrors.NewTooManyRequests("slow down", 1)
+	permanentErr := apierrors.NewBadRequest("invalid")
+
+	testCases := map[string]struct {
+		policy           Policy
+		errs             []error
+		expectedError    error
+		expectedAttempts int
+		expectedRetries  []int
+	}{
+		"zero policy does not retry": {
+			policy:           Policy{},
+			errs:             []error{transientErr, nil},
+			expectedError:    transientErr,
+			expectedAttempts: 1,
+		},
+		"success after retries": {
+			policy:           Policy{MaxAttempts: 5, Backoff: time.Millisecond},
+			errs:             []error{transientErr, transientErr, nil},
+			expectedError:    nil,
+			expectedAttempts: 3,
+			expectedRetries:  []int{2, 3},
+		},
+		"max attempts reached": {
+			policy:           Policy{MaxAttempts: 3, Backoff: time.Millisecond},
+			errs:             []error{transientErr, transientErr, transientErr, nil},
+			expectedError:    transientErr,
+			expectedAttempts: 3,
+			expectedRetries:  []int{2, 3},
+		},
+		"error not retryable": {
+			policy:           Policy{MaxAttempts: 3, Backoff: time.Millisecond},
+			errs:             []error{permanentErr, nil},
+			expectedError:    permanentErr,
+			expectedAttempts: 1,
+		},
+		"custom classifier": {
+			policy: Policy{
+				MaxAttempts: 3,
+				Backoff:     time.Millisecond,
+				Retryable:   apierrors.IsBadRequest,
+			},
+			errs:             []error{permanentErr, nil},
+			expectedError:    nil,
+			expectedAttempts: 2,
+			expectedRetries:  []int{2},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			attempts := 0
+			var retries []int
+			err := tc.policy.Do(context.Background(), func() error {
+				err := tc.errs[attempts]
+				attempts++
+				return err
+			}, func(_ error, attempt int) {
+				retries = append(retries, attempt)
+			})
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedAttempts, attempts)
+			assert.Equal(t, tc.expectedRetries, retries)
+		})
+	}
+}
+
+func TestPolicy_Do_Cancelled(t *testing.T) {
+	transientErr := apierrors.NewTooManyRequests("slow down", 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	attempts := 0
+	policy := Policy{MaxAttempts: 5, Backoff: time.Hour}
+	err := policy.Do(ctx, func() error {
+		attempts++
+		return transientErr
+	}, nil)
+	assert.Equal(t, transientErr, err)
+	assert.Equal(t, 1, attempts)
+}
@@ -15,21 +15,27 @@
 package solver
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
 	"github.com/fluxcd/cli-utils/pkg/apply/event"
 	"github.com/fluxcd/cli-utils/pkg/apply/filter"
 	"github.com/fluxcd/cli-utils/pkg/apply/info"
 	"github.com/fluxcd/cli-utils/pkg/apply/mutator"
 	"github.com/fluxcd/cli-utils/pkg/apply/prune"
+	"github.com/fluxcd/cli-utils/pkg/apply/retry"
 	"github.com/fluxcd/cli-utils/pkg/apply/task"
 	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
 	"github.com/fluxcd/cli-utils/pkg/common"
 	"github.com/fluxcd/cli-utils/pkg/inventory"
+	"github.com/fluxcd/cli-utils/pkg/multierror"
 	"github.com/fluxcd/cli-utils/pkg/object"
 	"github.com/fluxcd/cli-utils/pkg/object/graph"
+	"github.com/fluxcd/cli-utils/pkg/object/hook"
 	"github.com/fluxcd/cli-utils/pkg/object/validation"
+	"github.com/fluxcd/cli-utils/pkg/object/waitfor"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/discovery"
@@ -52,9 +58,10 @@ type TaskQueueBuilder struct {
 	PruneFilters  []filter.ValidationFilter
 
 	// The accumulated tasks and counter variables to name tasks.
-	applyCounter int
-	pruneCounter int
-	waitCounter  int
+	applyCounter      int
+	pruneCounter      int
+	waitCounter       int
+	checkpointCounter int
 
 	invInfo   inventory.Info
 	applyObjs object.UnstructuredSet
@@ -77,11 +84,22 @@ func (tq *TaskQueue) ToActionGroups() []event.ActionGroup {
 	var ags []event.ActionGroup
 
 	for _, t := range tq.tasks {
-		ags = append(ags, event.ActionGroup{
+		ag := event.ActionGroup{
 			Name:        t.Name(),
 			Action:      t.Action(),
 			Identifiers: t.Identifiers(),
-		})
+		}
+		if waitTask, ok := t.(*taskrunner.WaitTask); ok {
+			for _, id := range waitTask.Ids {
+				if timeout := waitTask.ObjectTimeout(id); timeout > 0 {
+					if ag.Timeouts == nil {
+						ag.Timeouts = make(map[object.ObjMetadata]time.Duration)
+					}
+					ag.Timeouts[id] = timeout
+				}
+			}
+		}
+		ags = append(ags, ag)
 	}
 	return ags
 }
@@ -98,6 +116,53 @@ type Options struct {
 	PrunePropagationPolicy metav1.DeletionPropagation
 	PruneTimeout           time.Duration
 	InventoryPolicy        inventory.Policy
+	// Concurrency is the maximum number of objects actuated in parallel
+	// within a single apply or prune task.
+	Concurrency int
+	// Checkpoint is the object status persisted by a previous run, used to
+	// skip objects that are unchanged since.
+	Checkpoint map[object.ObjMetadata]actuation.ObjectStatus
+	// SaveCheckpoints persists the object status in the inventory after
+	// each apply phase, so that an interrupted run can be resumed.
+	// Ignored for dry-run.
+	SaveCheckpoints bool
+	// WaitConditions are the conditions applied objects are waited on,
+	// overriding the wait-for annotation and the default (Current).
+	WaitConditions map[object.ObjMetadata]waitfor.Condition
+	// Rollback snapshots the applied objects and rolls them back, if any
+	// fail to apply or reconcile. The rollback task follows the last apply
+	// phase, so the runner must stop actuating after a failed phase.
+	// Ignored for dry-run.
+	Rollback bool
+	// RetryPolicy defines when to retry the actuation of an object, if it
+	// failed with a transient error.
+	RetryPolicy retry.Policy
+	// Batch splits each apply phase into batches, gated by a wait task
+	// that halts actuation if too many objects in the batch failed.
+	Batch common.BatchOptions
+	// MigrateClientSideApply transfers the fields managed by client-side
+	// apply to the server-side apply field manager, before each object is
+	// applied. Ignored for dry-run and client-side apply.
+	MigrateClientSideApply bool
+	// LastApplied is the object status persisted by a previous run, used to
+	// skip objects that are unchanged since they were last applied.
+	LastApplied map[object.ObjMetadata]actuation.ObjectStatus
+	// FullApplyInterval is how long unchanged objects are skipped for.
+	FullApplyInterval time.Duration
+	// RecordHashes records the content hash of each applied object in the
+	// inventory, so that Checkpoint and LastApplied of later runs can be
+	// used to skip unchanged objects.
+	RecordHashes bool
+	// Retained are the objects kept in the inventory without being actuated,
+	// when destroying a subset of the inventory.
+	Retained object.ObjMetadataSet
+	// RetainedObjects are the live objects of Retained. They are graphed
+	// with the deleted objects, so that the objects they depend on are not
+	// deleted.
+	RetainedObjects object.UnstructuredSet
+	// Finalizers configures the detection and removal of stuck finalizers,
+	// while waiting for pruned or deleted objects to be deleted.
+	Finalizers common.FinalizerOptions
 }
 
 // WithInventory sets the inventory info and returns the builder for chaining.
@@ -126,19 +191,29 @@ func (t *TaskQueueBuilder) Build(taskContext *taskrunner.TaskContext, o Options)
 	t.applyCounter = 0
 	t.pruneCounter = 0
 	t.waitCounter = 0
+	t.checkpointCounter = 0
 
 	// Filter objects that failed earlier validation
 	applyObjs := t.Collector.FilterInvalidObjects(t.applyObjs)
 	pruneObjs := t.Collector.FilterInvalidObjects(t.pruneObjs)
 
+	// Separate the hook objects from the apply objects. Hooks run in their
+	// own task groups, and are neither graphed nor stored in the inventory.
+	// Invalid hook annotations will be treated as validation errors.
+	applyObjs, hookObjs, hookPhases := t.hookObjects(applyObjs)
+
 	// Merge applyObjs & pruneObjs and graph them together.
 	// This detects implicit and explicit dependencies.
 	// Invalid dependency annotations will be treated as validation errors.
-	allObjs := make(object.UnstructuredSet, 0, len(applyObjs)+len(pruneObjs))
+	// Retained objects are graphed too, but not actuated, so errors that only
+	// concern retained objects are ignored.
+	allObjs := make(object.UnstructuredSet, 0, len(applyObjs)+len(pruneObjs)+len(o.RetainedObjects))
 	allObjs = append(allObjs, applyObjs...)
 	allObjs = append(allObjs, pruneObjs...)
+	allObjs = append(allObjs, o.RetainedObjects...)
+	retainedIds := object.UnstructuredSetToObjMetadataSet(o.RetainedObjects)
 	g, err := graph.DependencyGraph(allObjs)
-	if err != nil {
+	if err = withoutRetainedErrors(err, retainedIds); err != nil {
 		t.Collector.Collect(err)
 	}
 	// Store graph for use by DependencyFilter
@@ -146,26 +221,58 @@ func (t *TaskQueueBuilder) Build(taskContext *taskrunner.TaskContext, o Options)
 	// Sort objects into phases (apply order).
 	// Cycles will be treated as validation errors.
 	idSetList, err := g.Sort()
-	if err != nil {
+	if err = withoutRetainedErrors(err, retainedIds); err != nil {
 		t.Collector.Collect(err)
 	}
 
+	// Read the wait conditions of the apply objects.
+	// Invalid wait-for annotations will be treated as validation errors.
+	waitConditions := t.waitConditions(applyObjs, o.WaitConditions)
+
+	// Read the reconcile timeouts of the apply and prune objects.
+	// Invalid reconcile-timeout annotations will be treated as validation
+	// errors.
+	applyTimeouts := t.waitTimeouts(applyObjs)
+	pruneTimeouts := t.waitTimeouts(pruneObjs)
+
+	// Read the wait conditions and reconcile timeouts of the hook objects.
+	// Hooks are waited on until they complete, unless overridden.
+	hookConditions := t.waitConditions(hookObjs, o.WaitConditions)
+	for _, id := range object.UnstructuredSetToObjMetadataSet(hookObjs) {
+		if _, found := hookConditions[id]; !found {
+			hookConditions[id] = waitfor.Complete{}
+		}
+	}
+	hookTimeouts := t.waitTimeouts(hookObjs)
+
 	// Filter objects with cycles or invalid dependency annotations
 	applyObjs = t.Collector.FilterInvalidObjects(applyObjs)
 	pruneObjs = t.Collector.FilterInvalidObjects(pruneObjs)
 
+	// The inventory before the pipeline has run
+	prevInvIds, _ := t.InvClient.GetClusterObjs(t.invInfo)
+
 	if !o.Destroy {
 		// InvAddTask creates the inventory and adds any objects being applied
 		klog.V(2).Infof("adding inventory add task (%d objects)", len(applyObjs))
 		tasks = append(tasks, &task.InvAddTask{
-			TaskName:  "inventory-add-0",
-			InvClient: t.InvClient,
-			InvInfo:   t.invInfo,
-			Objects:   applyObjs,
-			DryRun:    o.DryRunStrategy,
+			TaskName:   "inventory-add-0",
+			InvClient:  t.InvClient,
+			InvInfo:    t.invInfo,
+			Objects:    applyObjs,
+			DryRun:     o.DryRunStrategy,
+			KeepStatus: o.SaveCheckpoints,
 		})
 	}
 
+	// Register actuation plan of the hooks in the inventory
+	for _, id := range object.UnstructuredSetToObjMetadataSet(hookObjs) {
+		taskContext.InventoryManager().AddPendingApply(id)
+	}
+
+	tasks = append(tasks, t.newHookTasks(hook.PreApply, hookPhases[hook.PreApply],
+		hookConditions, hookTimeouts, o)...)
+
 	if len(applyObjs) > 0 {
 		// Register actuation plan in the inventory
 		for _, id := range object.UnstructuredSetToObjMetadataSet(applyObjs) {
@@ -175,19 +282,50 @@ func (t *TaskQueueBuilder) Build(taskContext *taskrunner.TaskContext, o Options)
 		// Filter idSetList down to just apply objects
 		applySets := graph.HydrateSetList(idSetList, applyObjs)
 
+		var rollbackIds object.ObjMetadataSet
 		for _, applySet := range applySets {
-			tasks = append(tasks,
-				t.newApplyTask(applySet, t.ApplyFilters, t.ApplyMutators, o))
-			// dry-run skips wait tasks
-			if !o.DryRunStrategy.ClientOrServerDryRun() {
-				applyIds := object.UnstructuredSetToObjMetadataSet(applySet)
+			for _, batch := range splitBatches(applySet, o.Batch) {
 				tasks = append(tasks,
-					t.newWaitTask(applyIds, taskrunner.AllCurrent, o.ReconcileTimeout))
+					t.newApplyTask(batch, t.ApplyFilters, t.ApplyMutators, o))
+				// dry-run skips wait tasks
+				if !o.DryRunStrategy.ClientOrServerDryRun() {
+					applyIds := object.UnstructuredSetToObjMetadataSet(batch)
+					waitTask := t.newWaitTask(applyIds, taskrunner.AllCurrent, waitConditions,
+						o.ReconcileTimeout, applyTimeouts)
+					if o.Batch.Enabled() {
+						// Gate the next batch on the health of this one
+						waitTask.HaltOnFailure = true
+						waitTask.FailureThreshold = o.Batch.FailureThreshold
+					}
+					tasks = append(tasks, waitTask)
+					rollbackIds = append(rollbackIds, applyIds...)
+					if o.SaveCheckpoints {
+						tasks = append(tasks, t.newCheckpointTask(o))
+					}
+				}
 			}
 		}
+
+		// Roll back after all the apply phases, before pruning
+		if o.Rollback && len(rollbackIds) > 0 {
+			klog.V(2).Infof("adding rollback task (%d objects)", len(rollbackIds))
+			tasks = append(tasks, &task.RollbackTask{
+				TaskName:      "rollback-0",
+				DynamicClient: t.DynamicClient,
+				Mapper:        t.Mapper,
+				Ids:           t.Collector.FilterInvalidIds(rollbackIds),
+				PrevInventory: prevInvIds,
+			})
+		}
 	}
 
+	tasks = append(tasks, t.newHookTasks(hook.PostApply, hookPhases[hook.PostApply],
+		hookConditions, hookTimeouts, o)...)
+
 	if o.Prune && len(pruneObjs) > 0 {
+		tasks = append(tasks, t.newHookTasks(hook.PrePrune, hookPhases[hook.PrePrune],
+			hookConditions, hookTimeouts, o)...)
+
 		// Register actuation plan in the inventory
 		for _, id := range object.UnstructuredSetToObjMetadataSet(pruneObjs) {
 			taskContext.InventoryManager().AddPendingDelete(id)
@@ -205,13 +343,14 @@ func (t *TaskQueueBuilder) Build(taskContext *taskrunner.TaskContext, o Options)
 			// dry-run skips wait tasks
 			if !o.DryRunStrategy.ClientOrServerDryRun() {
 				pruneIds := object.UnstructuredSetToObjMetadataSet(pruneSet)
-				tasks = append(tasks,
-					t.newWaitTask(pruneIds, taskrunner.AllNotFound, o.PruneTimeout))
+				waitTask := t.newWaitTask(pruneIds, taskrunner.AllNotFound, nil, o.PruneTimeout, pruneTimeouts)
+				waitTask.Finalizers = o.Finalizers
+				waitTask.DynamicClient = t.DynamicClient
+				tasks = append(tasks, waitTask)
 			}
 		}
 	}
 
-	prevInvIds, _ := t.InvClient.GetClusterObjs(t.invInfo)
 	klog.V(2).Infoln("adding delete/update inventory task")
 	var taskName string
 	if o.Destroy {
@@ -226,6 +365,9 @@ func (t *TaskQueueBuilder) Build(taskContext *taskrunner.TaskContext, o Options)
 		PrevInventory: prevInvIds,
 		DryRun:        o.DryRunStrategy,
 		Destroy:       o.Destroy,
+		Retained:      o.Retained,
+		Hooks:         object.UnstructuredSetToObjMetadataSet(hookObjs),
+		FieldManager:  o.ServerSideOptions.FieldManager,
 	})
 
 	return &TaskQueue{tasks: tasks}
@@ -248,15 +390,41 @@ func (t *TaskQueueBuilder) newApplyTask(applyObjs object.UnstructuredSet,
 		OpenAPIGetter:     t.OpenAPIGetter,
 		InfoHelper:        t.InfoHelper,
 		Mapper:            t.Mapper,
+		Concurrency:       o.Concurrency,
+		Checkpoint:        o.Checkpoint,
+		Snapshot:          o.Rollback && !o.DryRunStrategy.ClientOrServerDryRun(),
+		RetryPolicy:       o.RetryPolicy,
+		MigrateClientSideApply: o.MigrateClientSideApply &&
+			o.ServerSideOptions.ServerSideApply && !o.DryRunStrategy.ClientOrServerDryRun(),
+		LastApplied:       o.LastApplied,
+		FullApplyInterval: o.FullApplyInterval,
+		RecordHash:        o.RecordHashes,
 	}
 	t.applyCounter++
 	return task
 }
 
+// newCheckpointTask returns a task to persist the object status in the
+// inventory.
+func (t *TaskQueueBuilder) newCheckpointTask(o Options) taskrunner.Task {
+	klog.V(2).Infoln("adding inventory checkpoint task")
+	task := &task.InvCheckpointTask{
+		TaskName:  fmt.Sprintf("inventory-checkpoint-%d", t.checkpointCounter),
+		InvClient: t.InvClient,
+		InvInfo:   t.invInfo,
+		DryRun:    o.DryRunStrategy,
+		// The field manager is recorded in the inventory history.
+		FieldManager: o.ServerSideOptions.FieldManager,
+	}
+	t.checkpointCounter++
+	return task
+}
+
 // AppendWaitTask appends a task to wait on the passed objects to the task queue.
 // Returns a pointer to the Builder to chain function calls.
 func (t *TaskQueueBuilder) newWaitTask(waitIds object.ObjMetadataSet, condition taskrunner.Condition,
-	waitTimeout time.Duration) taskrunner.Task {
+	conditions map[object.ObjMetadata]waitfor.Condition, waitTimeout time.Duration,
+	timeouts map[object.ObjMetadata]time.Duration) *taskrunner.WaitTask {
 	waitIds = t.Collector.FilterInvalidIds(waitIds)
 	klog.V(2).Infoln("adding wait task")
 	task := taskrunner.NewWaitTask(
@@ -266,10 +434,192 @@ func (t *TaskQueueBuilder) newWaitTask(waitIds object.ObjMetadataSet, condition
 		waitTimeout,
 		t.Mapper,
 	)
+	for _, id := range waitIds {
+		if cond, found := conditions[id]; found {
+			if task.Conditions == nil {
+				task.Conditions = make(map[object.ObjMetadata]waitfor.Condition)
+			}
+			task.Conditions[id] = cond
+		}
+		if timeout, found := timeouts[id]; found {
+			if task.Timeouts == nil {
+				task.Timeouts = make(map[object.ObjMetadata]time.Duration)
+			}
+			task.Timeouts[id] = timeout
+		}
+	}
 	t.waitCounter++
 	return task
 }
 
+// newHookTasks returns the tasks to run the hook objects of a phase: delete
+// the hooks left by a previous run, apply the hooks, wait until they
+// complete, and delete them according to their delete policy.
+// Returns no tasks, if the phase has no hooks.
+func (t *TaskQueueBuilder) newHookTasks(phase hook.Phase, hookObjs object.UnstructuredSet,
+	conditions map[object.ObjMetadata]waitfor.Condition, timeouts map[object.ObjMetadata]time.Duration,
+	o Options) []taskrunner.Task {
+	hookObjs = t.Collector.FilterInvalidObjects(hookObjs)
+	if len(hookObjs) == 0 {
+		return nil
+	}
+	klog.V(2).Infof("adding %s hook tasks (%d objects)", phase, len(hookObjs))
+	var tasks []taskrunner.Task
+	for _, obj := range hookObjs {
+		policies, _ := hook.ReadDeletePolicyAnnotation(obj)
+		if policies.Contains(hook.BeforeHookCreation) {
+			tasks = append(tasks, &task.HookDeleteTask{
+				TaskName:       fmt.Sprintf("%s-hook-cleanup-0", phase),
+				DynamicClient:  t.DynamicClient,
+				Mapper:         t.Mapper,
+				Phase:          phase,
+				Objects:        hookObjs,
+				BeforeCreation: true,
+				Timeout:        o.PruneTimeout,
+				DryRunStrategy: o.DryRunStrategy,
+			})
+			break
+		}
+	}
+	tasks = append(tasks, t.newApplyTask(hookObjs, t.ApplyFilters, t.ApplyMutators, o))
+	// dry-run skips wait tasks
+	if !o.DryRunStrategy.ClientOrServerDryRun() {
+		hookIds := object.UnstructuredSetToObjMetadataSet(hookObjs)
+		tasks = append(tasks, t.newWaitTask(hookIds, taskrunner.AllCurrent, conditions,
+			o.ReconcileTimeout, timeouts))
+	}
+	tasks = append(tasks, &task.HookDeleteTask{
+		TaskName:       fmt.Sprintf("%s-hook-delete-0", phase),
+		DynamicClient:  t.DynamicClient,
+		Mapper:         t.Mapper,
+		Phase:          phase,
+		Objects:        hookObjs,
+		DryRunStrategy: o.DryRunStrategy,
+	})
+	return tasks
+}
+
+// hookObjects separates the hook objects from the passed objects. Returns
+// the remaining objects, the hook objects, and the hook objects of each
+// phase. Invalid hook annotations are collected as validation errors.
+func (t *TaskQueueBuilder) hookObjects(objs object.UnstructuredSet) (
+	object.UnstructuredSet, object.UnstructuredSet, map[hook.Phase]object.UnstructuredSet) {
+	var remaining, hookObjs object.UnstructuredSet
+	hookPhases := make(map[hook.Phase]object.UnstructuredSet)
+	for _, obj := range objs {
+		if !hook.IsHook(obj) {
+			remaining = append(remaining, obj)
+			continue
+		}
+		id := object.UnstructuredToObjMetadata(obj)
+		phases, err := hook.ReadAnnotation(obj)
+		if err == nil {
+			_, err = hook.ReadDeletePolicyAnnotation(obj)
+		}
+		if err != nil {
+			klog.V(3).Infof("failed to read hook: %s: %v", id, err)
+			t.Collector.Collect(validation.NewError(err, id))
+			continue
+		}
+		hookObjs = append(hookObjs, obj)
+		for _, phase := range phases {
+			hookPhases[phase] = append(hookPhases[phase], obj)
+		}
+	}
+	return remaining, hookObjs, hookPhases
+}
+
+// splitBatches splits the objects of a phase into batches, in order.
+// Returns a single batch, if batching is disabled.
+func splitBatches(objs object.UnstructuredSet, o common.BatchOptions) []object.UnstructuredSet {
+	if !o.Enabled() || len(objs) == 0 {
+		return []object.UnstructuredSet{objs}
+	}
+	size := o.BatchSize(len(objs))
+	var batches []object.UnstructuredSet
+	for start := 0; start < len(objs); start += size {
+		end := start + size
+		if end > len(objs) {
+			end = len(objs)
+		}
+		batches = append(batches, objs[start:end])
+	}
+	return batches
+}
+
+// waitConditions returns the custom wait conditions of the passed objects,
+// read from the wait-for annotation, unless overridden by the passed
+// conditions. Invalid annotations are collected as validation errors.
+func (t *TaskQueueBuilder) waitConditions(objs object.UnstructuredSet,
+	overrides map[object.ObjMetadata]waitfor.Condition) map[object.ObjMetadata]waitfor.Condition {
+	conditions := make(map[object.ObjMetadata]waitfor.Condition)
+	for _, obj := range objs {
+		id := object.UnstructuredToObjMetadata(obj)
+		if cond, found := overrides[id]; found {
+			// Parse the JSONPath expression once, not on every status update.
+			if jp, ok := cond.(waitfor.JSONPath); ok {
+				parsed, err := waitfor.NewJSONPath(jp.Expression, jp.Value)
+				if err != nil {
+					klog.V(3).Infof("invalid wait condition: %s: %v", id, err)
+					t.Collector.Collect(validation.NewError(err, id))
+					continue
+				}
+				cond = parsed
+			}
+			conditions[id] = cond
+			continue
+		}
+		cond, err := waitfor.ReadAnnotation(obj)
+		if err != nil {
+			klog.V(3).Infof("failed to read wait condition: %s: %v", id, err)
+			t.Collector.Collect(validation.NewError(err, id))
+			continue
+		}
+		if cond != nil {
+			conditions[id] = cond
+		}
+	}
+	return conditions
+}
+
+// waitTimeouts returns the reconcile timeouts of the passed objects, read
+// from the reconcile-timeout annotation. Invalid annotations are collected as
+// validation errors.
+func (t *TaskQueueBuilder) waitTimeouts(objs object.UnstructuredSet) map[object.ObjMetadata]time.Duration {
+	timeouts := make(map[object.ObjMetadata]time.Duration)
+	for _, obj := range objs {
+		id := object.UnstructuredToObjMetadata(obj)
+		timeout, err := waitfor.ReadTimeoutAnnotation(obj)
+		if err != nil {
+			klog.V(3).Infof("failed to read reconcile timeout: %s: %v", id, err)
+			t.Collector.Collect(validation.NewError(err, id))
+			continue
+		}
+		if timeout > 0 {
+			timeouts[id] = timeout
+		}
+	}
+	return timeouts
+}
+
+// withoutRetainedErrors returns the errors, except the validation errors of
+// which all objects are retained.
+func withoutRetainedErrors(err error, retained object.ObjMetadataSet) error {
+	if err == nil || len(retained) == 0 {
+		return err
+	}
+	var errs []error
+	for _, e := range multierror.Unwrap(err) {
+		var vErr *validation.Error
+		if errors.As(e, &vErr) && len(vErr.Identifiers().Diff(retained)) == 0 {
+			klog.V(4).Infof("ignoring error of retained objects: %v", e)
+			continue
+		}
+		errs = append(errs, e)
+	}
+	return multierror.Wrap(errs...)
+}
+
 // AppendPruneTask appends a task to delete objects from the cluster to the task queue.
 // Returns a pointer to the Builder to chain function calls.
 func (t *TaskQueueBuilder) newPruneTask(pruneObjs object.UnstructuredSet,
@@ -284,6 +634,8 @@ func (t *TaskQueueBuilder) newPruneTask(pruneObjs object.UnstructuredSet,
 		PropagationPolicy: o.PrunePropagationPolicy,
 		DryRunStrategy:    o.DryRunStrategy,
 		Destroy:           o.Destroy,
+		Concurrency:       o.Concurrency,
+		RetryPolicy:       o.RetryPolicy,
 	}
 	t.pruneCounter++
 	return task
@@ -4,10 +4,13 @@
 package solver
 
 import (
+	"context"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
 	"github.com/fluxcd/cli-utils/pkg/apply/prune"
 	"github.com/fluxcd/cli-utils/pkg/apply/task"
 	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
@@ -15,11 +18,14 @@ import (
 	"github.com/fluxcd/cli-utils/pkg/inventory"
 	"github.com/fluxcd/cli-utils/pkg/object"
 	"github.com/fluxcd/cli-utils/pkg/object/graph"
+	"github.com/fluxcd/cli-utils/pkg/object/hook"
 	"github.com/fluxcd/cli-utils/pkg/object/validation"
+	"github.com/fluxcd/cli-utils/pkg/object/waitfor"
 	"github.com/fluxcd/cli-utils/pkg/testutil"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
@@ -203,6 +209,257 @@ func TestTaskQueueBuilder_ApplyBuild(t *testing.T) {
 				},
 			},
 		},
+		"field manager is passed to the inventory task": {
+			applyObjs: []*unstructured.Unstructured{
+				testutil.Unstructured(t, resources["deployment"]),
+			},
+			options: Options{
+				ServerSideOptions: common.ServerSideOptions{
+					ServerSideApply: true,
+					FieldManager:    "my-manager",
+				},
+			},
+			expectedTasks: []taskrunner.Task{
+				&task.InvAddTask{
+					TaskName:  "inventory-add-0",
+					InvClient: &inventory.FakeClient{},
+					InvInfo:   invInfo,
+					Objects: object.UnstructuredSet{
+						testutil.Unstructured(t, resources["deployment"]),
+					},
+				},
+				&task.ApplyTask{
+					TaskName: "apply-0",
+					Objects: []*unstructured.Unstructured{
+						testutil.Unstructured(t, resources["deployment"]),
+					},
+					ServerSideOptions: common.ServerSideOptions{
+						ServerSideApply: true,
+						FieldManager:    "my-manager",
+					},
+				},
+				&taskrunner.WaitTask{
+					TaskName: "wait-0",
+					Ids: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["deployment"]),
+					},
+					Condition: taskrunner.AllCurrent,
+				},
+				&task.DeleteOrUpdateInvTask{
+					TaskName:  "inventory-set-0",
+					InvClient: &inventory.FakeClient{},
+					InvInfo:   invInfo,
+					PrevInventory: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["deployment"]),
+					},
+					FieldManager: "my-manager",
+				},
+			},
+			expectedStatus: []actuation.ObjectStatus{
+				{
+					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
+						testutil.ToIdentifier(t, resources["deployment"]),
+					),
+					Strategy:  actuation.ActuationStrategyApply,
+					Actuation: actuation.ActuationPending,
+					Reconcile: actuation.ReconcilePending,
+				},
+			},
+		},
+		"rollback adds rollback task after apply phases": {
+			applyObjs: []*unstructured.Unstructured{
+				testutil.Unstructured(t, resources["deployment"]),
+			},
+			options: Options{
+				Rollback: true,
+			},
+			expectedTasks: []taskrunner.Task{
+				&task.InvAddTask{
+					TaskName:  "inventory-add-0",
+					InvClient: &inventory.FakeClient{},
+					InvInfo:   invInfo,
+					Objects: object.UnstructuredSet{
+						testutil.Unstructured(t, resources["deployment"]),
+					},
+				},
+				&task.ApplyTask{
+					TaskName: "apply-0",
+					Objects: []*unstructured.Unstructured{
+						testutil.Unstructured(t, resources["deployment"]),
+					},
+					Snapshot: true,
+				},
+				&taskrunner.WaitTask{
+					TaskName: "wait-0",
+					Ids: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["deployment"]),
+					},
+					Condition: taskrunner.AllCurrent,
+				},
+				&task.RollbackTask{
+					TaskName: "rollback-0",
+					Ids: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["deployment"]),
+					},
+					PrevInventory: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["deployment"]),
+					},
+				},
+				&task.DeleteOrUpdateInvTask{
+					TaskName:  "inventory-set-0",
+					InvClient: &inventory.FakeClient{},
+					InvInfo:   invInfo,
+					PrevInventory: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["deployment"]),
+					},
+				},
+			},
+			expectedStatus: []actuation.ObjectStatus{
+				{
+					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
+						testutil.ToIdentifier(t, resources["deployment"]),
+					),
+					Strategy:  actuation.ActuationStrategyApply,
+					Actuation: actuation.ActuationPending,
+					Reconcile: actuation.ReconcilePending,
+				},
+			},
+		},
+		"checkpoints added after apply phases": {
+			applyObjs: []*unstructured.Unstructured{
+				testutil.Unstructured(t, resources["deployment"]),
+			},
+			options: Options{
+				SaveCheckpoints: true,
+			},
+			expectedTasks: []taskrunner.Task{
+				&task.InvAddTask{
+					TaskName:  "inventory-add-0",
+					InvClient: &inventory.FakeClient{},
+					InvInfo:   invInfo,
+					Objects: object.UnstructuredSet{
+						testutil.Unstructured(t, resources["deployment"]),
+					},
+					KeepStatus: true,
+				},
+				&task.ApplyTask{
+					TaskName: "apply-0",
+					Objects: []*unstructured.Unstructured{
+						testutil.Unstructured(t, resources["deployment"]),
+					},
+				},
+				&taskrunner.WaitTask{
+					TaskName: "wait-0",
+					Ids: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["deployment"]),
+					},
+					Condition: taskrunner.AllCurrent,
+				},
+				&task.InvCheckpointTask{
+					TaskName:  "inventory-checkpoint-0",
+					InvClient: &inventory.FakeClient{},
+					InvInfo:   invInfo,
+				},
+				&task.DeleteOrUpdateInvTask{
+					TaskName:  "inventory-set-0",
+					InvClient: &inventory.FakeClient{},
+					InvInfo:   invInfo,
+					PrevInventory: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["deployment"]),
+					},
+				},
+			},
+			expectedStatus: []actuation.ObjectStatus{
+				{
+					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
+						testutil.ToIdentifier(t, resources["deployment"]),
+					),
+					Strategy:  actuation.ActuationStrategyApply,
+					Actuation: actuation.ActuationPending,
+					Reconcile: actuation.ReconcilePending,
+				},
+			},
+		},
+		"batches split apply phase with gated wait tasks": {
+			applyObjs: []*unstructured.Unstructured{
+				testutil.Unstructured(t, resources["deployment"]),
+				testutil.Unstructured(t, resources["secret"]),
+			},
+			options: Options{
+				Batch: common.BatchOptions{
+					Percent:          50,
+					FailureThreshold: 1,
+				},
+			},
+			expectedTasks: []taskrunner.Task{
+				&task.InvAddTask{
+					TaskName:  "inventory-add-0",
+					InvClient: &inventory.FakeClient{},
+					InvInfo:   invInfo,
+					Objects: object.UnstructuredSet{
+						testutil.Unstructured(t, resources["deployment"]),
+						testutil.Unstructured(t, resources["secret"]),
+					},
+				},
+				&task.ApplyTask{
+					TaskName: "apply-0",
+					Objects: []*unstructured.Unstructured{
+						testutil.Unstructured(t, resources["secret"]),
+					},
+				},
+				&taskrunner.WaitTask{
+					TaskName: "wait-0",
+					Ids: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["secret"]),
+					},
+					Condition:        taskrunner.AllCurrent,
+					HaltOnFailure:    true,
+					FailureThreshold: 1,
+				},
+				&task.ApplyTask{
+					TaskName: "apply-1",
+					Objects: []*unstructured.Unstructured{
+						testutil.Unstructured(t, resources["deployment"]),
+					},
+				},
+				&taskrunner.WaitTask{
+					TaskName: "wait-1",
+					Ids: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["deployment"]),
+					},
+					Condition:        taskrunner.AllCurrent,
+					HaltOnFailure:    true,
+					FailureThreshold: 1,
+				},
+				&task.DeleteOrUpdateInvTask{
+					TaskName:  "inventory-set-0",
+					InvClient: &inventory.FakeClient{},
+					InvInfo:   invInfo,
+					PrevInventory: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["deployment"]),
+						testutil.ToIdentifier(t, resources["secret"]),
+					},
+				},
+			},
+			expectedStatus: []actuation.ObjectStatus{
+				{
+					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
+						testutil.ToIdentifier(t, resources["deployment"]),
+					),
+					Strategy:  actuation.ActuationStrategyApply,
+					Actuation: actuation.ActuationPending,
+					Reconcile: actuation.ReconcilePending,
+				},
+				{
+					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
+						testutil.ToIdentifier(t, resources["secret"]),
+					),
+					Strategy:  actuation.ActuationStrategyApply,
+					Actuation: actuation.ActuationPending,
+					Reconcile: actuation.ReconcilePending,
+				},
+			},
+		},
 		"multiple resource with no timeout": {
 			applyObjs: []*unstructured.Unstructured{
 				testutil.Unstructured(t, resources["deployment"]),
@@ -447,9 +704,255 @@ func TestTaskQueueBuilder_ApplyBuild(t *testing.T) {
 		},
 		"multiple resources including CRD": {
 			applyObjs: []*unstructured.Unstructured{
-				testutil.Unstructured(t, resources["crontab1"]),
-				testutil.Unstructured(t, resources["crd"]),
-				testutil.Unstructured(t, resources["crontab2"]),
+				testutil.Unstructured(t, resources["crontab1"]),
+				testutil.Unstructured(t, resources["crd"]),
+				testutil.Unstructured(t, resources["crontab2"]),
+			},
+			expectedTasks: []taskrunner.Task{
+				&task.InvAddTask{
+					TaskName:  "inventory-add-0",
+					InvClient: &inventory.FakeClient{},
+					InvInfo:   invInfo,
+					Objects: object.UnstructuredSet{
+						testutil.Unstructured(t, resources["crontab1"]),
+						testutil.Unstructured(t, resources["crd"]),
+						testutil.Unstructured(t, resources["crontab2"]),
+					},
+				},
+				&task.ApplyTask{
+					TaskName: "apply-0",
+					Objects: []*unstructured.Unstructured{
+						testutil.Unstructured(t, resources["crd"]),
+					},
+					DryRunStrategy: common.DryRunNone,
+				},
+				&taskrunner.WaitTask{
+					TaskName: "wait-0",
+					Ids: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["crd"]),
+					},
+					Condition: taskrunner.AllCurrent,
+				},
+				&task.ApplyTask{
+					TaskName: "apply-1",
+					Objects: []*unstructured.Unstructured{
+						testutil.Unstructured(t, resources["crontab1"]),
+						testutil.Unstructured(t, resources["crontab2"]),
+					},
+					DryRunStrategy: common.DryRunNone,
+				},
+				&taskrunner.WaitTask{
+					TaskName: "wait-1",
+					Ids: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["crontab1"]),
+						testutil.ToIdentifier(t, resources["crontab2"]),
+					},
+					Condition: taskrunner.AllCurrent,
+				},
+				&task.DeleteOrUpdateInvTask{
+					TaskName:  "inventory-set-0",
+					InvClient: &inventory.FakeClient{},
+					InvInfo:   invInfo,
+					PrevInventory: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["crontab1"]),
+						testutil.ToIdentifier(t, resources["crd"]),
+						testutil.ToIdentifier(t, resources["crontab2"]),
+					},
+				},
+			},
+			expectedStatus: []actuation.ObjectStatus{
+				{
+					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
+						testutil.ToIdentifier(t, resources["crontab1"]),
+					),
+					Strategy:  actuation.ActuationStrategyApply,
+					Actuation: actuation.ActuationPending,
+					Reconcile: actuation.ReconcilePending,
+				},
+				{
+					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
+						testutil.ToIdentifier(t, resources["crd"]),
+					),
+					Strategy:  actuation.ActuationStrategyApply,
+					Actuation: actuation.ActuationPending,
+					Reconcile: actuation.ReconcilePending,
+				},
+				{
+					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
+						testutil.ToIdentifier(t, resources["crontab2"]),
+					),
+					Strategy:  actuation.ActuationStrategyApply,
+					Actuation: actuation.ActuationPending,
+					Reconcile: actuation.ReconcilePending,
+				},
+			},
+		},
+		"no wait with CRDs if it is a dryrun": {
+			applyObjs: []*unstructured.Unstructured{
+				testutil.Unstructured(t, resources["crontab1"]),
+				testutil.Unstructured(t, resources["crd"]),
+				testutil.Unstructured(t, resources["crontab2"]),
+			},
+			options: Options{
+				ReconcileTimeout: time.Minute,
+				DryRunStrategy:   common.DryRunClient,
+			},
+			expectedTasks: []taskrunner.Task{
+				&task.InvAddTask{
+					TaskName:  "inventory-add-0",
+					InvClient: &inventory.FakeClient{},
+					InvInfo:   invInfo,
+					Objects: object.UnstructuredSet{
+						testutil.Unstructured(t, resources["crontab1"]),
+						testutil.Unstructured(t, resources["crd"]),
+						testutil.Unstructured(t, resources["crontab2"]),
+					},
+					DryRun: common.DryRunClient,
+				},
+				&task.ApplyTask{
+					TaskName: "apply-0",
+					Objects: []*unstructured.Unstructured{
+						testutil.Unstructured(t, resources["crd"]),
+					},
+					DryRunStrategy: common.DryRunClient,
+				},
+				&task.ApplyTask{
+					TaskName: "apply-1",
+					Objects: []*unstructured.Unstructured{
+						testutil.Unstructured(t, resources["crontab1"]),
+						testutil.Unstructured(t, resources["crontab2"]),
+					},
+					DryRunStrategy: common.DryRunClient,
+				},
+				&task.DeleteOrUpdateInvTask{
+					TaskName:  "inventory-set-0",
+					InvClient: &inventory.FakeClient{},
+					InvInfo:   invInfo,
+					PrevInventory: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["crontab1"]),
+						testutil.ToIdentifier(t, resources["crd"]),
+						testutil.ToIdentifier(t, resources["crontab2"]),
+					},
+					DryRun: common.DryRunClient,
+				},
+			},
+			expectedStatus: []actuation.ObjectStatus{
+				{
+					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
+						testutil.ToIdentifier(t, resources["crontab1"]),
+					),
+					Strategy:  actuation.ActuationStrategyApply,
+					Actuation: actuation.ActuationPending,
+					Reconcile: actuation.ReconcilePending,
+				},
+				{
+					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
+						testutil.ToIdentifier(t, resources["crd"]),
+					),
+					Strategy:  actuation.ActuationStrategyApply,
+					Actuation: actuation.ActuationPending,
+					Reconcile: actuation.ReconcilePending,
+				},
+				{
+					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
+						testutil.ToIdentifier(t, resources["crontab2"]),
+					),
+					Strategy:  actuation.ActuationStrategyApply,
+					Actuation: actuation.ActuationPending,
+					Reconcile: actuation.ReconcilePending,
+				},
+			},
+		},
+		"resources in namespace creates multiple apply tasks": {
+			applyObjs: []*unstructured.Unstructured{
+				testutil.Unstructured(t, resources["namespace"]),
+				testutil.Unstructured(t, resources["pod"]),
+				testutil.Unstructured(t, resources["secret"]),
+			},
+			expectedTasks: []taskrunner.Task{
+				&task.InvAddTask{
+					TaskName:  "inventory-add-0",
+					InvClient: &inventory.FakeClient{},
+					InvInfo:   invInfo,
+					Objects: object.UnstructuredSet{
+						testutil.Unstructured(t, resources["namespace"]),
+						testutil.Unstructured(t, resources["pod"]),
+						testutil.Unstructured(t, resources["secret"]),
+					},
+				},
+				&task.ApplyTask{
+					TaskName: "apply-0",
+					Objects: []*unstructured.Unstructured{
+						testutil.Unstructured(t, resources["namespace"]),
+					},
+					DryRunStrategy: common.DryRunNone,
+				},
+				&taskrunner.WaitTask{
+					TaskName: "wait-0",
+					Ids: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["namespace"]),
+					},
+					Condition: taskrunner.AllCurrent,
+				},
+				&task.ApplyTask{
+					TaskName: "apply-1",
+					Objects: []*unstructured.Unstructured{
+						testutil.Unstructured(t, resources["secret"]),
+						testutil.Unstructured(t, resources["pod"]),
+					},
+					DryRunStrategy: common.DryRunNone,
+				},
+				&taskrunner.WaitTask{
+					TaskName: "wait-1",
+					Ids: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["secret"]),
+						testutil.ToIdentifier(t, resources["pod"]),
+					},
+					Condition: taskrunner.AllCurrent,
+				},
+				&task.DeleteOrUpdateInvTask{
+					TaskName:  "inventory-set-0",
+					InvClient: &inventory.FakeClient{},
+					InvInfo:   invInfo,
+					PrevInventory: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["namespace"]),
+						testutil.ToIdentifier(t, resources["pod"]),
+						testutil.ToIdentifier(t, resources["secret"]),
+					},
+				},
+			},
+			expectedStatus: []actuation.ObjectStatus{
+				{
+					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
+						testutil.ToIdentifier(t, resources["namespace"]),
+					),
+					Strategy:  actuation.ActuationStrategyApply,
+					Actuation: actuation.ActuationPending,
+					Reconcile: actuation.ReconcilePending,
+				},
+				{
+					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
+						testutil.ToIdentifier(t, resources["pod"]),
+					),
+					Strategy:  actuation.ActuationStrategyApply,
+					Actuation: actuation.ActuationPending,
+					Reconcile: actuation.ReconcilePending,
+				},
+				{
+					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
+						testutil.ToIdentifier(t, resources["secret"]),
+					),
+					Strategy:  actuation.ActuationStrategyApply,
+					Actuation: actuation.ActuationPending,
+					Reconcile: actuation.ReconcilePending,
+				},
+			},
+		},
+		"deployment depends on secret creates multiple tasks": {
+			applyObjs: []*unstructured.Unstructured{
+				testutil.Unstructured(t, resources["deployment"],
+					testutil.AddDependsOn(t, testutil.ToIdentifier(t, resources["secret"]))),
+				testutil.Unstructured(t, resources["secret"]),
 			},
 			expectedTasks: []taskrunner.Task{
 				&task.InvAddTask{
@@ -457,38 +960,37 @@ func TestTaskQueueBuilder_ApplyBuild(t *testing.T) {
 					InvClient: &inventory.FakeClient{},
 					InvInfo:   invInfo,
 					Objects: object.UnstructuredSet{
-						testutil.Unstructured(t, resources["crontab1"]),
-						testutil.Unstructured(t, resources["crd"]),
-						testutil.Unstructured(t, resources["crontab2"]),
+						testutil.Unstructured(t, resources["deployment"],
+							testutil.AddDependsOn(t, testutil.ToIdentifier(t, resources["secret"]))),
+						testutil.Unstructured(t, resources["secret"]),
 					},
 				},
 				&task.ApplyTask{
 					TaskName: "apply-0",
 					Objects: []*unstructured.Unstructured{
-						testutil.Unstructured(t, resources["crd"]),
+						testutil.Unstructured(t, resources["secret"]),
 					},
 					DryRunStrategy: common.DryRunNone,
 				},
 				&taskrunner.WaitTask{
 					TaskName: "wait-0",
 					Ids: object.ObjMetadataSet{
-						testutil.ToIdentifier(t, resources["crd"]),
+						testutil.ToIdentifier(t, resources["secret"]),
 					},
 					Condition: taskrunner.AllCurrent,
 				},
 				&task.ApplyTask{
 					TaskName: "apply-1",
 					Objects: []*unstructured.Unstructured{
-						testutil.Unstructured(t, resources["crontab1"]),
-						testutil.Unstructured(t, resources["crontab2"]),
+						testutil.Unstructured(t, resources["deployment"],
+							testutil.AddDependsOn(t, testutil.ToIdentifier(t, resources["secret"]))),
 					},
 					DryRunStrategy: common.DryRunNone,
 				},
 				&taskrunner.WaitTask{
 					TaskName: "wait-1",
 					Ids: object.ObjMetadataSet{
-						testutil.ToIdentifier(t, resources["crontab1"]),
-						testutil.ToIdentifier(t, resources["crontab2"]),
+						testutil.ToIdentifier(t, resources["deployment"]),
 					},
 					Condition: taskrunner.AllCurrent,
 				},
@@ -497,24 +999,15 @@ func TestTaskQueueBuilder_ApplyBuild(t *testing.T) {
 					InvClient: &inventory.FakeClient{},
 					InvInfo:   invInfo,
 					PrevInventory: object.ObjMetadataSet{
-						testutil.ToIdentifier(t, resources["crontab1"]),
-						testutil.ToIdentifier(t, resources["crd"]),
-						testutil.ToIdentifier(t, resources["crontab2"]),
+						testutil.ToIdentifier(t, resources["deployment"]),
+						testutil.ToIdentifier(t, resources["secret"]),
 					},
 				},
 			},
 			expectedStatus: []actuation.ObjectStatus{
 				{
 					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
-						testutil.ToIdentifier(t, resources["crontab1"]),
-					),
-					Strategy:  actuation.ActuationStrategyApply,
-					Actuation: actuation.ActuationPending,
-					Reconcile: actuation.ReconcilePending,
-				},
-				{
-					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
-						testutil.ToIdentifier(t, resources["crd"]),
+						testutil.ToIdentifier(t, resources["deployment"]),
 					),
 					Strategy:  actuation.ActuationStrategyApply,
 					Actuation: actuation.ActuationPending,
@@ -522,7 +1015,7 @@ func TestTaskQueueBuilder_ApplyBuild(t *testing.T) {
 				},
 				{
 					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
-						testutil.ToIdentifier(t, resources["crontab2"]),
+						testutil.ToIdentifier(t, resources["secret"]),
 					),
 					Strategy:  actuation.ActuationStrategyApply,
 					Actuation: actuation.ActuationPending,
@@ -530,15 +1023,17 @@ func TestTaskQueueBuilder_ApplyBuild(t *testing.T) {
 				},
 			},
 		},
-		"no wait with CRDs if it is a dryrun": {
+		"wait-for annotation and option set wait conditions": {
 			applyObjs: []*unstructured.Unstructured{
-				testutil.Unstructured(t, resources["crontab1"]),
-				testutil.Unstructured(t, resources["crd"]),
-				testutil.Unstructured(t, resources["crontab2"]),
+				testutil.Unstructured(t, resources["deployment"],
+					testutil.AddWaitFor("condition=Available")),
+				testutil.Unstructured(t, resources["secret"],
+					testutil.AddWaitFor("condition=Ready")),
 			},
 			options: Options{
-				ReconcileTimeout: time.Minute,
-				DryRunStrategy:   common.DryRunClient,
+				WaitConditions: map[object.ObjMetadata]waitfor.Condition{
+					testutil.ToIdentifier(t, resources["secret"]): waitfor.None{},
+				},
 			},
 			expectedTasks: []taskrunner.Task{
 				&task.InvAddTask{
@@ -546,51 +1041,50 @@ func TestTaskQueueBuilder_ApplyBuild(t *testing.T) {
 					InvClient: &inventory.FakeClient{},
 					InvInfo:   invInfo,
 					Objects: object.UnstructuredSet{
-						testutil.Unstructured(t, resources["crontab1"]),
-						testutil.Unstructured(t, resources["crd"]),
-						testutil.Unstructured(t, resources["crontab2"]),
+						testutil.Unstructured(t, resources["deployment"],
+							testutil.AddWaitFor("condition=Available")),
+						testutil.Unstructured(t, resources["secret"],
+							testutil.AddWaitFor("condition=Ready")),
 					},
-					DryRun: common.DryRunClient,
 				},
 				&task.ApplyTask{
 					TaskName: "apply-0",
 					Objects: []*unstructured.Unstructured{
-						testutil.Unstructured(t, resources["crd"]),
+						testutil.Unstructured(t, resources["deployment"],
+							testutil.AddWaitFor("condition=Available")),
+						testutil.Unstructured(t, resources["secret"],
+							testutil.AddWaitFor("condition=Ready")),
 					},
-					DryRunStrategy: common.DryRunClient,
 				},
-				&task.ApplyTask{
-					TaskName: "apply-1",
-					Objects: []*unstructured.Unstructured{
-						testutil.Unstructured(t, resources["crontab1"]),
-						testutil.Unstructured(t, resources["crontab2"]),
+				&taskrunner.WaitTask{
+					TaskName: "wait-0",
+					Ids: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["deployment"]),
+						testutil.ToIdentifier(t, resources["secret"]),
+					},
+					Condition: taskrunner.AllCurrent,
+					Conditions: map[object.ObjMetadata]waitfor.Condition{
+						testutil.ToIdentifier(t, resources["deployment"]): waitfor.StatusCondition{
+							Type:   "Available",
+							Status: "True",
+						},
+						testutil.ToIdentifier(t, resources["secret"]): waitfor.None{},
 					},
-					DryRunStrategy: common.DryRunClient,
 				},
 				&task.DeleteOrUpdateInvTask{
 					TaskName:  "inventory-set-0",
 					InvClient: &inventory.FakeClient{},
 					InvInfo:   invInfo,
 					PrevInventory: object.ObjMetadataSet{
-						testutil.ToIdentifier(t, resources["crontab1"]),
-						testutil.ToIdentifier(t, resources["crd"]),
-						testutil.ToIdentifier(t, resources["crontab2"]),
+						testutil.ToIdentifier(t, resources["deployment"]),
+						testutil.ToIdentifier(t, resources["secret"]),
 					},
-					DryRun: common.DryRunClient,
 				},
 			},
 			expectedStatus: []actuation.ObjectStatus{
 				{
 					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
-						testutil.ToIdentifier(t, resources["crontab1"]),
-					),
-					Strategy:  actuation.ActuationStrategyApply,
-					Actuation: actuation.ActuationPending,
-					Reconcile: actuation.ReconcilePending,
-				},
-				{
-					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
-						testutil.ToIdentifier(t, resources["crd"]),
+						testutil.ToIdentifier(t, resources["deployment"]),
 					),
 					Strategy:  actuation.ActuationStrategyApply,
 					Actuation: actuation.ActuationPending,
@@ -598,7 +1092,7 @@ func TestTaskQueueBuilder_ApplyBuild(t *testing.T) {
 				},
 				{
 					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
-						testutil.ToIdentifier(t, resources["crontab2"]),
+						testutil.ToIdentifier(t, resources["secret"]),
 					),
 					Strategy:  actuation.ActuationStrategyApply,
 					Actuation: actuation.ActuationPending,
@@ -606,60 +1100,52 @@ func TestTaskQueueBuilder_ApplyBuild(t *testing.T) {
 				},
 			},
 		},
-		"resources in namespace creates multiple apply tasks": {
+		"reconcile-timeout annotation sets object timeout": {
 			applyObjs: []*unstructured.Unstructured{
-				testutil.Unstructured(t, resources["namespace"]),
-				testutil.Unstructured(t, resources["pod"]),
+				testutil.Unstructured(t, resources["deployment"],
+					testutil.AddReconcileTimeout("10m")),
 				testutil.Unstructured(t, resources["secret"]),
 			},
+			options: Options{
+				ReconcileTimeout: time.Minute,
+			},
 			expectedTasks: []taskrunner.Task{
 				&task.InvAddTask{
 					TaskName:  "inventory-add-0",
 					InvClient: &inventory.FakeClient{},
 					InvInfo:   invInfo,
 					Objects: object.UnstructuredSet{
-						testutil.Unstructured(t, resources["namespace"]),
-						testutil.Unstructured(t, resources["pod"]),
+						testutil.Unstructured(t, resources["deployment"],
+							testutil.AddReconcileTimeout("10m")),
 						testutil.Unstructured(t, resources["secret"]),
 					},
 				},
 				&task.ApplyTask{
 					TaskName: "apply-0",
 					Objects: []*unstructured.Unstructured{
-						testutil.Unstructured(t, resources["namespace"]),
-					},
-					DryRunStrategy: common.DryRunNone,
-				},
-				&taskrunner.WaitTask{
-					TaskName: "wait-0",
-					Ids: object.ObjMetadataSet{
-						testutil.ToIdentifier(t, resources["namespace"]),
-					},
-					Condition: taskrunner.AllCurrent,
-				},
-				&task.ApplyTask{
-					TaskName: "apply-1",
-					Objects: []*unstructured.Unstructured{
+						testutil.Unstructured(t, resources["deployment"],
+							testutil.AddReconcileTimeout("10m")),
 						testutil.Unstructured(t, resources["secret"]),
-						testutil.Unstructured(t, resources["pod"]),
 					},
-					DryRunStrategy: common.DryRunNone,
 				},
 				&taskrunner.WaitTask{
-					TaskName: "wait-1",
+					TaskName: "wait-0",
 					Ids: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["deployment"]),
 						testutil.ToIdentifier(t, resources["secret"]),
-						testutil.ToIdentifier(t, resources["pod"]),
 					},
 					Condition: taskrunner.AllCurrent,
+					Timeout:   time.Minute,
+					Timeouts: map[object.ObjMetadata]time.Duration{
+						testutil.ToIdentifier(t, resources["deployment"]): 10 * time.Minute,
+					},
 				},
 				&task.DeleteOrUpdateInvTask{
 					TaskName:  "inventory-set-0",
 					InvClient: &inventory.FakeClient{},
 					InvInfo:   invInfo,
 					PrevInventory: object.ObjMetadataSet{
-						testutil.ToIdentifier(t, resources["namespace"]),
-						testutil.ToIdentifier(t, resources["pod"]),
+						testutil.ToIdentifier(t, resources["deployment"]),
 						testutil.ToIdentifier(t, resources["secret"]),
 					},
 				},
@@ -667,15 +1153,7 @@ func TestTaskQueueBuilder_ApplyBuild(t *testing.T) {
 			expectedStatus: []actuation.ObjectStatus{
 				{
 					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
-						testutil.ToIdentifier(t, resources["namespace"]),
-					),
-					Strategy:  actuation.ActuationStrategyApply,
-					Actuation: actuation.ActuationPending,
-					Reconcile: actuation.ReconcilePending,
-				},
-				{
-					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
-						testutil.ToIdentifier(t, resources["pod"]),
+						testutil.ToIdentifier(t, resources["deployment"]),
 					),
 					Strategy:  actuation.ActuationStrategyApply,
 					Actuation: actuation.ActuationPending,
@@ -691,11 +1169,27 @@ func TestTaskQueueBuilder_ApplyBuild(t *testing.T) {
 				},
 			},
 		},
-		"deployment depends on secret creates multiple tasks": {
+		"invalid reconcile-timeout annotation returns error": {
 			applyObjs: []*unstructured.Unstructured{
 				testutil.Unstructured(t, resources["deployment"],
-					testutil.AddDependsOn(t, testutil.ToIdentifier(t, resources["secret"]))),
-				testutil.Unstructured(t, resources["secret"]),
+					testutil.AddReconcileTimeout("10")),
+			},
+			expectedTasks: []taskrunner.Task{},
+			expectedError: validation.NewError(
+				object.InvalidAnnotationError{
+					Annotation: waitfor.TimeoutAnnotation,
+					Cause:      fmt.Errorf(`time: missing unit in duration "10"`),
+				},
+				testutil.ToIdentifier(t, resources["deployment"]),
+			),
+		},
+		"hook objects run before and after the apply phase": {
+			applyObjs: []*unstructured.Unstructured{
+				testutil.Unstructured(t, resources["deployment"]),
+				testutil.Unstructured(t, resources["pod"],
+					testutil.AddHook("pre-apply")),
+				testutil.Unstructured(t, resources["secret"],
+					testutil.AddHook("post-apply")),
 			},
 			expectedTasks: []taskrunner.Task{
 				&task.InvAddTask{
@@ -703,32 +1197,48 @@ func TestTaskQueueBuilder_ApplyBuild(t *testing.T) {
 					InvClient: &inventory.FakeClient{},
 					InvInfo:   invInfo,
 					Objects: object.UnstructuredSet{
-						testutil.Unstructured(t, resources["deployment"],
-							testutil.AddDependsOn(t, testutil.ToIdentifier(t, resources["secret"]))),
-						testutil.Unstructured(t, resources["secret"]),
+						testutil.Unstructured(t, resources["deployment"]),
+					},
+				},
+				&task.HookDeleteTask{
+					TaskName: "pre-apply-hook-cleanup-0",
+					Phase:    hook.PreApply,
+					Objects: object.UnstructuredSet{
+						testutil.Unstructured(t, resources["pod"],
+							testutil.AddHook("pre-apply")),
 					},
+					BeforeCreation: true,
 				},
 				&task.ApplyTask{
 					TaskName: "apply-0",
 					Objects: []*unstructured.Unstructured{
-						testutil.Unstructured(t, resources["secret"]),
+						testutil.Unstructured(t, resources["pod"],
+							testutil.AddHook("pre-apply")),
 					},
-					DryRunStrategy: common.DryRunNone,
 				},
 				&taskrunner.WaitTask{
 					TaskName: "wait-0",
 					Ids: object.ObjMetadataSet{
-						testutil.ToIdentifier(t, resources["secret"]),
+						testutil.ToIdentifier(t, resources["pod"]),
 					},
 					Condition: taskrunner.AllCurrent,
+					Conditions: map[object.ObjMetadata]waitfor.Condition{
+						testutil.ToIdentifier(t, resources["pod"]): waitfor.Complete{},
+					},
+				},
+				&task.HookDeleteTask{
+					TaskName: "pre-apply-hook-delete-0",
+					Phase:    hook.PreApply,
+					Objects: object.UnstructuredSet{
+						testutil.Unstructured(t, resources["pod"],
+							testutil.AddHook("pre-apply")),
+					},
 				},
 				&task.ApplyTask{
 					TaskName: "apply-1",
 					Objects: []*unstructured.Unstructured{
-						testutil.Unstructured(t, resources["deployment"],
-							testutil.AddDependsOn(t, testutil.ToIdentifier(t, resources["secret"]))),
+						testutil.Unstructured(t, resources["deployment"]),
 					},
-					DryRunStrategy: common.DryRunNone,
 				},
 				&taskrunner.WaitTask{
 					TaskName: "wait-1",
@@ -737,12 +1247,51 @@ func TestTaskQueueBuilder_ApplyBuild(t *testing.T) {
 					},
 					Condition: taskrunner.AllCurrent,
 				},
+				&task.HookDeleteTask{
+					TaskName: "post-apply-hook-cleanup-0",
+					Phase:    hook.PostApply,
+					Objects: object.UnstructuredSet{
+						testutil.Unstructured(t, resources["secret"],
+							testutil.AddHook("post-apply")),
+					},
+					BeforeCreation: true,
+				},
+				&task.ApplyTask{
+					TaskName: "apply-2",
+					Objects: []*unstructured.Unstructured{
+						testutil.Unstructured(t, resources["secret"],
+							testutil.AddHook("post-apply")),
+					},
+				},
+				&taskrunner.WaitTask{
+					TaskName: "wait-2",
+					Ids: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["secret"]),
+					},
+					Condition: taskrunner.AllCurrent,
+					Conditions: map[object.ObjMetadata]waitfor.Condition{
+						testutil.ToIdentifier(t, resources["secret"]): waitfor.Complete{},
+					},
+				},
+				&task.HookDeleteTask{
+					TaskName: "post-apply-hook-delete-0",
+					Phase:    hook.PostApply,
+					Objects: object.UnstructuredSet{
+						testutil.Unstructured(t, resources["secret"],
+							testutil.AddHook("post-apply")),
+					},
+				},
 				&task.DeleteOrUpdateInvTask{
 					TaskName:  "inventory-set-0",
 					InvClient: &inventory.FakeClient{},
 					InvInfo:   invInfo,
 					PrevInventory: object.ObjMetadataSet{
 						testutil.ToIdentifier(t, resources["deployment"]),
+						testutil.ToIdentifier(t, resources["pod"]),
+						testutil.ToIdentifier(t, resources["secret"]),
+					},
+					Hooks: object.ObjMetadataSet{
+						testutil.ToIdentifier(t, resources["pod"]),
 						testutil.ToIdentifier(t, resources["secret"]),
 					},
 				},
@@ -750,7 +1299,7 @@ func TestTaskQueueBuilder_ApplyBuild(t *testing.T) {
 			expectedStatus: []actuation.ObjectStatus{
 				{
 					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
-						testutil.ToIdentifier(t, resources["deployment"]),
+						testutil.ToIdentifier(t, resources["pod"]),
 					),
 					Strategy:  actuation.ActuationStrategyApply,
 					Actuation: actuation.ActuationPending,
@@ -764,7 +1313,43 @@ func TestTaskQueueBuilder_ApplyBuild(t *testing.T) {
 					Actuation: actuation.ActuationPending,
 					Reconcile: actuation.ReconcilePending,
 				},
+				{
+					ObjectReference: inventory.ObjectReferenceFromObjMetadata(
+						testutil.ToIdentifier(t, resources["deployment"]),
+					),
+					Strategy:  actuation.ActuationStrategyApply,
+					Actuation: actuation.ActuationPending,
+					Reconcile: actuation.ReconcilePending,
+				},
+			},
+		},
+		"invalid hook annotation returns error": {
+			applyObjs: []*unstructured.Unstructured{
+				testutil.Unstructured(t, resources["pod"],
+					testutil.AddHook("post-delete")),
+			},
+			expectedTasks: []taskrunner.Task{},
+			expectedError: validation.NewError(
+				object.InvalidAnnotationError{
+					Annotation: hook.Annotation,
+					Cause:      fmt.Errorf("unknown hook phase: %q", "post-delete"),
+				},
+				testutil.ToIdentifier(t, resources["pod"]),
+			),
+		},
+		"invalid wait-for annotation returns error": {
+			applyObjs: []*unstructured.Unstructured{
+				testutil.Unstructured(t, resources["deployment"],
+					testutil.AddWaitFor("ready")),
 			},
+			expectedTasks: []taskrunner.Task{},
+			expectedError: validation.NewError(
+				object.InvalidAnnotationError{
+					Annotation: waitfor.Annotation,
+					Cause:      fmt.Errorf("unknown wait condition: %q", "ready"),
+				},
+				testutil.ToIdentifier(t, resources["deployment"]),
+			),
 		},
 		"cyclic dependency returns error": {
 			applyObjs: []*unstructured.Unstructured{
@@ -803,6 +1388,10 @@ func TestTaskQueueBuilder_ApplyBuild(t *testing.T) {
 					typedTask.Mapper = mapper
 				case *taskrunner.WaitTask:
 					typedTask.Mapper = mapper
+				case *task.RollbackTask:
+					typedTask.Mapper = mapper
+				case *task.HookDeleteTask:
+					typedTask.Mapper = mapper
 				}
 			}
 
@@ -815,7 +1404,7 @@ func TestTaskQueueBuilder_ApplyBuild(t *testing.T) {
 				InvClient: fakeInvClient,
 				Collector: vCollector,
 			}
-			taskContext := taskrunner.NewTaskContext(nil, nil)
+			taskContext := taskrunner.NewTaskContext(context.TODO(), nil, nil)
 			tq := tqb.WithInventory(invInfo).
 				WithApplyObjects(tc.applyObjs).
 				Build(taskContext, tc.options)
@@ -1489,7 +2078,7 @@ func TestTaskQueueBuilder_PruneBuild(t *testing.T) {
 				InvClient: fakeInvClient,
 				Collector: vCollector,
 			}
-			taskContext := taskrunner.NewTaskContext(nil, nil)
+			taskContext := taskrunner.NewTaskContext(context.TODO(), nil, nil)
 			tq := tqb.WithInventory(invInfo).
 				WithPruneObjects(tc.pruneObjs).
 				Build(taskContext, tc.options)
@@ -1507,6 +2096,80 @@ func TestTaskQueueBuilder_PruneBuild(t *testing.T) {
 	}
 }
 
+func TestTaskQueueBuilder_RetainedObjects(t *testing.T) {
+	invInfo := inventory.WrapInventoryInfoObj(newInvObject(
+		"abc-123", "default", "test"))
+	namespaceID := testutil.ToIdentifier(t, resources["namespace"])
+	// The external dependency of the retained secret is not validated.
+	externalID := testutil.ToIdentifier(t, resources["default-pod"])
+	deleteObjs := object.UnstructuredSet{
+		testutil.Unstructured(t, resources["namespace"]),
+	}
+	retainedObjs := object.UnstructuredSet{
+		testutil.Unstructured(t, resources["pod"]),
+		testutil.Unstructured(t, resources["secret"], testutil.AddDependsOn(t, externalID)),
+	}
+	retained := object.UnstructuredSetToObjMetadataSet(retainedObjs)
+
+	vCollector := &validation.Collector{}
+	tqb := TaskQueueBuilder{
+		Pruner:    pruner,
+		Mapper:    testutil.NewFakeRESTMapper(),
+		InvClient: inventory.NewFakeClient(retained.Union(object.ObjMetadataSet{namespaceID})),
+		Collector: vCollector,
+	}
+	taskContext := taskrunner.NewTaskContext(context.TODO(), nil, nil)
+	tq := tqb.WithInventory(invInfo).
+		WithPruneObjects(deleteObjs).
+		Build(taskContext, Options{
+			Destroy:         true,
+			Prune:           true,
+			Retained:        retained,
+			RetainedObjects: retainedObjs,
+		})
+	require.NoError(t, vCollector.ToError())
+
+	// The retained objects are graphed as dependents of their namespace.
+	assert.ElementsMatch(t, retained, taskContext.Graph().Dependents(namespaceID))
+
+	// Retained objects are neither deleted nor registered.
+	for _, tsk := range tq.tasks {
+		if pruneTask, ok := tsk.(*task.PruneTask); ok {
+			testutil.AssertEqual(t, deleteObjs, pruneTask.Objects)
+		}
+	}
+	testutil.AssertEqual(t, []actuation.ObjectStatus{
+		{
+			ObjectReference: inventory.ObjectReferenceFromObjMetadata(namespaceID),
+			Strategy:        actuation.ActuationStrategyDelete,
+			Actuation:       actuation.ActuationPending,
+			Reconcile:       actuation.ReconcilePending,
+		},
+	}, taskContext.InventoryManager().Inventory().Status.Objects)
+}
+
+func TestTaskQueueBuilder_WaitConditionsParsed(t *testing.T) {
+	deploymentID := testutil.ToIdentifier(t, resources["deployment"])
+	secretID := testutil.ToIdentifier(t, resources["secret"])
+	applyObjs := object.UnstructuredSet{
+		testutil.Unstructured(t, resources["deployment"]),
+		testutil.Unstructured(t, resources["secret"]),
+	}
+
+	vCollector := &validation.Collector{}
+	tqb := TaskQueueBuilder{Collector: vCollector}
+	conditions := tqb.waitConditions(applyObjs, map[object.ObjMetadata]waitfor.Condition{
+		deploymentID: waitfor.JSONPath{Expression: "{.status.readyReplicas}", Value: "1"},
+		secretID:     waitfor.JSONPath{Expression: "{.data[}"},
+	})
+
+	// JSONPath options are parsed once, when the options are read.
+	expected, err := waitfor.NewJSONPath("{.status.readyReplicas}", "1")
+	require.NoError(t, err)
+	assert.Equal(t, map[object.ObjMetadata]waitfor.Condition{deploymentID: expected}, conditions)
+	assert.Equal(t, object.ObjMetadataSet{secretID}, vCollector.InvalidIds)
+}
+
 func TestTaskQueueBuilder_ApplyPruneBuild(t *testing.T) {
 	// Use a custom Asserter to customize the comparison options
 	asserter := testutil.NewAsserter(
@@ -1842,7 +2505,7 @@ func TestTaskQueueBuilder_ApplyPruneBuild(t *testing.T) {
 				InvClient: fakeInvClient,
 				Collector: vCollector,
 			}
-			taskContext := taskrunner.NewTaskContext(nil, nil)
+			taskContext := taskrunner.NewTaskContext(context.TODO(), nil, nil)
 			tq := tqb.WithInventory(invInfo).
 				WithApplyObjects(tc.applyObjs).
 				WithPruneObjects(tc.pruneObjs).
@@ -1875,7 +2538,10 @@ func waitTaskComparer() cmp.Option {
 		return x.TaskName == y.TaskName &&
 			x.Ids.Hash() == y.Ids.Hash() && // exact order match
 			x.Condition == y.Condition &&
+			cmp.Equal(x.Conditions, y.Conditions) &&
 			x.Timeout == y.Timeout &&
+			x.HaltOnFailure == y.HaltOnFailure &&
+			x.FailureThreshold == y.FailureThreshold &&
 			cmp.Equal(x.Mapper, y.Mapper)
 	})
 }
@@ -1902,3 +2568,40 @@ func inventoryInfoComparer() cmp.Option {
 			x.Strategy() == y.Strategy()
 	})
 }
+
+func TestTaskQueue_ToActionGroups(t *testing.T) {
+	deploymentID := testutil.ToIdentifier(t, resources["deployment"])
+	secretID := testutil.ToIdentifier(t, resources["secret"])
+	waitTask := taskrunner.NewWaitTask("wait-0", object.ObjMetadataSet{deploymentID, secretID},
+		taskrunner.AllCurrent, time.Minute, testutil.NewFakeRESTMapper())
+	waitTask.Timeouts = map[object.ObjMetadata]time.Duration{
+		deploymentID: 10 * time.Minute,
+	}
+	tq := &TaskQueue{tasks: []taskrunner.Task{
+		&task.ApplyTask{
+			TaskName: "apply-0",
+			Objects: object.UnstructuredSet{
+				testutil.Unstructured(t, resources["deployment"]),
+				testutil.Unstructured(t, resources["secret"]),
+			},
+		},
+		waitTask,
+	}}
+
+	testutil.AssertEqual(t, []event.ActionGroup{
+		{
+			Name:        "apply-0",
+			Action:      event.ApplyAction,
+			Identifiers: object.ObjMetadataSet{deploymentID, secretID},
+		},
+		{
+			Name:        "wait-0",
+			Action:      event.WaitAction,
+			Identifiers: object.ObjMetadataSet{deploymentID, secretID},
+			Timeouts: map[object.ObjMetadata]time.Duration{
+				deploymentID: 10 * time.Minute,
+				secretID:     time.Minute,
+			},
+		},
+	}, tq.ToActionGroups())
+}
@@ -9,8 +9,12 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"sync"
+	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -22,11 +26,14 @@ import (
 	"k8s.io/kubectl/pkg/cmd/apply"
 	cmddelete "k8s.io/kubectl/pkg/cmd/delete"
 
+	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
 	applyerror "github.com/fluxcd/cli-utils/pkg/apply/error"
 	"github.com/fluxcd/cli-utils/pkg/apply/event"
 	"github.com/fluxcd/cli-utils/pkg/apply/filter"
 	"github.com/fluxcd/cli-utils/pkg/apply/info"
 	"github.com/fluxcd/cli-utils/pkg/apply/mutator"
+	"github.com/fluxcd/cli-utils/pkg/apply/plan"
+	"github.com/fluxcd/cli-utils/pkg/apply/retry"
 	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
 	"github.com/fluxcd/cli-utils/pkg/common"
 	"github.com/fluxcd/cli-utils/pkg/object"
@@ -59,6 +66,33 @@ type ApplyTask struct {
 	Mutators          []mutator.Interface
 	DryRunStrategy    common.DryRunStrategy
 	ServerSideOptions common.ServerSideOptions
+	// Concurrency is the maximum number of objects to apply in parallel.
+	// Values less than two apply the objects serially.
+	Concurrency int
+	// Checkpoint is the object status persisted by an interrupted run.
+	// Objects that are unchanged since, and were reconciled, are not applied
+	// again.
+	Checkpoint map[object.ObjMetadata]actuation.ObjectStatus
+	// Snapshot records the state of each object in the cluster in the
+	// TaskContext, before it is applied, so it can be rolled back.
+	Snapshot bool
+	// RetryPolicy defines when to retry the apply of an object, if it
+	// failed with a transient error. The zero value does not retry.
+	RetryPolicy retry.Policy
+	// MigrateClientSideApply transfers the fields of each object managed by
+	// kubectl client-side apply to the server-side apply FieldManager,
+	// before the object is applied. Requires server-side apply.
+	MigrateClientSideApply bool
+	// LastApplied is the object status persisted by the previous run.
+	// Objects that are unchanged since are not applied again.
+	LastApplied map[object.ObjMetadata]actuation.ObjectStatus
+	// RecordHash records the content hash and apply time of each applied
+	// object in the inventory, so that later runs can skip unchanged
+	// objects. Objects are only skipped, if it is set.
+	RecordHash bool
+	// FullApplyInterval is how long unchanged objects are skipped for,
+	// after they were last applied. Zero skips them indefinitely.
+	FullApplyInterval time.Duration
 }
 
 // applyOptionsFactoryFunc is a factory function for creating a new
@@ -85,102 +119,331 @@ func (a *ApplyTask) Identifiers() object.ObjMetadataSet {
 // after the Run function has completed. This information is then added
 // to the taskContext. The generation is increased every time
 // the desired state of a resource is changed.
+// Once the context of the taskContext is done, the remaining objects
+// are skipped.
+// If Concurrency is greater than one, up to that many objects are
+// applied in parallel.
 func (a *ApplyTask) Start(taskContext *taskrunner.TaskContext) {
 	go func() {
-		// TODO: pipe Context through TaskContext
-		ctx := context.TODO()
 		objects := a.Objects
 		klog.V(2).Infof("apply task starting (name: %q, objects: %d)",
 			a.Name(), len(objects))
-		for _, obj := range objects {
-			// Set the client and mapping fields on the provided
-			// info so they can be applied to the cluster.
-			info, err := a.InfoHelper.BuildInfo(obj)
-			// BuildInfo strips path annotations.
-			// Use modified object for filters, mutations, and events.
-			obj = info.Object.(*unstructured.Unstructured)
-			id := object.UnstructuredToObjMetadata(obj)
-			if err != nil {
-				err = applyerror.NewUnknownTypeError(err)
-				if klog.V(4).Enabled() {
-					// only log event emitted errors if the verbosity > 4
-					klog.Errorf("apply task errored (object: %s): unable to convert obj to info: %v", id, err)
-				}
-				taskContext.SendEvent(a.createApplyFailedEvent(id, err))
-				taskContext.InventoryManager().AddFailedApply(id)
-				continue
-			}
-
-			// Check filters to see if we're prevented from applying.
-			var filterErr error
-			for _, applyFilter := range a.Filters {
-				klog.V(6).Infof("apply filter evaluating (filter: %s, object: %s)", applyFilter.Name(), id)
-				filterErr = applyFilter.Filter(obj)
-				if filterErr != nil {
-					var fatalErr *filter.FatalError
-					if errors.As(filterErr, &fatalErr) {
-						if klog.V(4).Enabled() {
-							// only log event emitted errors if the verbosity > 4
-							klog.Errorf("apply filter errored (filter: %s, object: %s): %v", applyFilter.Name(), id, fatalErr.Err)
-						}
-						taskContext.SendEvent(a.createApplyFailedEvent(id, fatalErr))
-						taskContext.InventoryManager().AddFailedApply(id)
-						break
-					}
-					klog.V(4).Infof("apply filtered (filter: %s, object: %s): %v", applyFilter.Name(), id, filterErr)
-					taskContext.SendEvent(a.createApplySkippedEvent(id, obj, filterErr))
-					taskContext.InventoryManager().AddSkippedApply(id)
-					break
-				}
+		if a.Concurrency > 1 {
+			// Objects in the same task are independent of each other,
+			// so they can be applied in any order.
+			sem := make(chan struct{}, a.Concurrency)
+			var wg sync.WaitGroup
+			for _, obj := range objects {
+				sem <- struct{}{}
+				wg.Add(1)
+				go func(obj *unstructured.Unstructured) {
+					defer func() {
+						<-sem
+						wg.Done()
+					}()
+					a.applyObject(taskContext, obj)
+				}(obj)
 			}
-			if filterErr != nil {
-				continue
+			wg.Wait()
+		} else {
+			for _, obj := range objects {
+				a.applyObject(taskContext, obj)
 			}
+		}
+		a.sendTaskResult(taskContext)
+	}()
+}
+
+// applyObject applies a single object and records the result in the
+// taskContext. Events for the object are sent in order.
+func (a *ApplyTask) applyObject(taskContext *taskrunner.TaskContext, obj *unstructured.Unstructured) {
+	ctx := taskContext.Context()
+	// Stop applying if the caller cancelled or the runner stopped.
+	if ctx.Err() != nil {
+		ctxErr := context.Cause(ctx)
+		id := object.UnstructuredToObjMetadata(obj)
+		klog.V(4).Infof("apply cancelled (object: %s): %v", id, ctxErr)
+		taskContext.SendEvent(a.createApplySkippedEvent(id, obj, ctxErr))
+		taskContext.InventoryManager().AddSkippedApply(id)
+		return
+	}
 
-			// Execute mutators, if any apply
-			err = a.mutate(ctx, obj)
-			if err != nil {
+	// Set the client and mapping fields on the provided
+	// info so they can be applied to the cluster.
+	info, err := a.InfoHelper.BuildInfo(obj)
+	// BuildInfo strips path annotations.
+	// Use modified object for filters, mutations, and events.
+	obj = info.Object.(*unstructured.Unstructured)
+	id := object.UnstructuredToObjMetadata(obj)
+	if err != nil {
+		err = applyerror.NewUnknownTypeError(err)
+		if klog.V(4).Enabled() {
+			// only log event emitted errors if the verbosity > 4
+			klog.Errorf("apply task errored (object: %s): unable to convert obj to info: %v", id, err)
+		}
+		taskContext.SendEvent(a.createApplyFailedEvent(id, err))
+		taskContext.InventoryManager().AddFailedApply(id)
+		return
+	}
+
+	// Check filters to see if we're prevented from applying.
+	var filterErr error
+	for _, applyFilter := range a.Filters {
+		klog.V(6).Infof("apply filter evaluating (filter: %s, object: %s)", applyFilter.Name(), id)
+		filterErr = applyFilter.Filter(obj)
+		if filterErr != nil {
+			var fatalErr *filter.FatalError
+			if errors.As(filterErr, &fatalErr) {
 				if klog.V(4).Enabled() {
 					// only log event emitted errors if the verbosity > 4
-					klog.Errorf("apply mutation errored (object: %s): %v", id, err)
+					klog.Errorf("apply filter errored (filter: %s, object: %s): %v", applyFilter.Name(), id, fatalErr.Err)
 				}
-				taskContext.SendEvent(a.createApplyFailedEvent(id, err))
+				taskContext.SendEvent(a.createApplyFailedEvent(id, fatalErr))
 				taskContext.InventoryManager().AddFailedApply(id)
-				continue
+				break
 			}
+			klog.V(4).Infof("apply filtered (filter: %s, object: %s): %v", applyFilter.Name(), id, filterErr)
+			taskContext.SendEvent(a.createApplySkippedEvent(id, obj, filterErr))
+			taskContext.InventoryManager().AddSkippedApply(id)
+			break
+		}
+	}
+	if filterErr != nil {
+		return
+	}
 
-			// Create a new instance of the applyOptions interface and use it
-			// to apply the objects.
-			ao := applyOptionsFactoryFunc(a.Name(), taskContext.EventChannel(),
-				a.ServerSideOptions, a.DryRunStrategy, a.DynamicClient, a.OpenAPIGetter)
-			ao.SetObjects([]*resource.Info{info})
-			klog.V(5).Infof("applying object: %v", id)
-			err = ao.Run()
-			if err != nil && a.ServerSideOptions.ServerSideApply && isAPIService(obj) && isStreamError(err) {
-				// Server-side Apply doesn't work with APIService before k8s 1.21
-				// https://github.com/kubernetes/kubernetes/issues/89264
-				// Thus APIService is handled specially using client-side apply.
-				err = a.clientSideApply(info, taskContext.EventChannel())
+	// Execute mutators, if any apply
+	err = a.mutate(ctx, obj)
+	if err != nil {
+		if klog.V(4).Enabled() {
+			// only log event emitted errors if the verbosity > 4
+			klog.Errorf("apply mutation errored (object: %s): %v", id, err)
+		}
+		taskContext.SendEvent(a.createApplyFailedEvent(id, err))
+		taskContext.InventoryManager().AddFailedApply(id)
+		return
+	}
+
+	// Hash the content of the object, to skip objects that are unchanged.
+	var hash string
+	if a.RecordHash {
+		hash, err = plan.HashObjects(object.UnstructuredSet{obj})
+		if err != nil {
+			klog.V(4).Infof("apply hash failed (object: %s): %v", id, err)
+			hash = ""
+		}
+	}
+
+	// Skip objects that are unchanged since the checkpoint of an interrupted
+	// run, or since they were last applied.
+	live, status, ok := a.unchangedSince(ctx, a.Checkpoint, id, hash, true, 0)
+	if ok {
+		klog.V(4).Infof("apply resumed (object: %s): unchanged since checkpoint", id)
+	} else if live, status, ok = a.unchangedSince(ctx, a.LastApplied, id, hash, false, a.FullApplyInterval); ok {
+		klog.V(4).Infof("apply skipped (object: %s): unchanged since last apply", id)
+	}
+	if ok {
+		im := taskContext.InventoryManager()
+		im.AddSuccessfulApply(id, live.GetUID(), live.GetGeneration())
+		if err := im.SetAppliedHash(id, hash, status.AppliedTime); err != nil {
+			klog.Errorf("apply hash not recorded (object: %s): %v", id, err)
+		}
+		taskContext.SendEvent(a.createApplyUnchangedEvent(id, live))
+		return
+	}
+
+	// Record the state of the object before changing it, for rollback.
+	if a.Snapshot {
+		if err := a.snapshot(ctx, taskContext, id); err != nil {
+			err = applyerror.NewSnapshotError(err)
+			if klog.V(4).Enabled() {
+				// only log event emitted errors if the verbosity > 4
+				klog.Errorf("apply snapshot errored (object: %s): %v", id, err)
 			}
-			if err != nil {
-				err = applyerror.NewApplyRunError(err)
-				if klog.V(4).Enabled() {
-					// only log event emitted errors if the verbosity > 4
-					klog.Errorf("apply errored (object: %s): %v", id, err)
-				}
-				taskContext.SendEvent(a.createApplyFailedEvent(id, err))
-				taskContext.InventoryManager().AddFailedApply(id)
-			} else if info.Object != nil {
-				acc, err := meta.Accessor(info.Object)
-				if err == nil {
-					uid := acc.GetUID()
-					gen := acc.GetGeneration()
-					taskContext.InventoryManager().AddSuccessfulApply(id, uid, gen)
+			taskContext.SendEvent(a.createApplyFailedEvent(id, err))
+			taskContext.InventoryManager().AddFailedApply(id)
+			return
+		}
+	}
+
+	// Transfer the fields managed by client-side apply, so the server-side
+	// apply removes the fields that were removed from the object.
+	var migrated bool
+	if a.MigrateClientSideApply {
+		migrated, err = a.migrateClientSideApply(ctx, id)
+		if err != nil {
+			err = applyerror.NewMigrationError(err)
+			if klog.V(4).Enabled() {
+				// only log event emitted errors if the verbosity > 4
+				klog.Errorf("apply migration errored (object: %s): %v", id, err)
+			}
+			taskContext.SendEvent(a.createApplyFailedEvent(id, err))
+			taskContext.InventoryManager().AddFailedApply(id)
+			return
+		}
+	}
+
+	// Apply the object, retrying transient errors.
+	timing := event.Timing{Start: time.Now()}
+	var applied []event.Event
+	err = a.RetryPolicy.Do(ctx, func() error {
+		attemptStart := time.Now()
+		var err error
+		applied, err = a.apply(info, obj, a.ServerSideOptions)
+		if err != nil {
+			applied, err = a.resolveConflicts(ctx, info, obj, err)
+		}
+		timing.Latency += time.Since(attemptStart)
+		return err
+	}, func(err error, attempt int) {
+		klog.V(4).Infof("apply retrying (object: %s, attempt: %d/%d): %v",
+			id, attempt, a.RetryPolicy.MaxAttempts, err)
+		taskContext.SendEvent(a.createApplyRetryingEvent(id, err, attempt))
+	})
+	timing.End = time.Now()
+	for _, e := range applied {
+		if e.Type == event.ApplyType {
+			e.ApplyEvent.Timing = timing
+			e.ApplyEvent.Migrated = migrated
+		}
+		taskContext.SendEvent(e)
+	}
+	var conflictErr *applyerror.ConflictError
+	if errors.As(err, &conflictErr) {
+		if klog.V(4).Enabled() {
+			// only log event emitted errors if the verbosity > 4
+			klog.Errorf("apply conflicted (object: %s): %v", id, conflictErr)
+		}
+		e := a.createApplyConflictEvent(id, conflictErr)
+		e.ApplyEvent.Timing = timing
+		e.ApplyEvent.Migrated = migrated
+		taskContext.SendEvent(e)
+		taskContext.InventoryManager().AddFailedApply(id)
+	} else if err != nil {
+		err = applyerror.NewApplyRunError(err)
+		if klog.V(4).Enabled() {
+			// only log event emitted errors if the verbosity > 4
+			klog.Errorf("apply errored (object: %s): %v", id, err)
+		}
+		e := a.createApplyFailedEvent(id, err)
+		e.ApplyEvent.Timing = timing
+		e.ApplyEvent.Migrated = migrated
+		taskContext.SendEvent(e)
+		taskContext.InventoryManager().AddFailedApply(id)
+	} else if info.Object != nil {
+		acc, err := meta.Accessor(info.Object)
+		if err == nil {
+			uid := acc.GetUID()
+			gen := acc.GetGeneration()
+			im := taskContext.InventoryManager()
+			im.AddSuccessfulApply(id, uid, gen)
+			if hash != "" {
+				if err := im.SetAppliedHash(id, hash, &metav1.Time{Time: timing.End}); err != nil {
+					klog.Errorf("apply hash not recorded (object: %s): %v", id, err)
 				}
 			}
+			taskContext.AddActuationTime(id, timing.End)
 		}
-		a.sendTaskResult(taskContext)
+	}
+}
+
+// apply applies a single object to the cluster. The events sent by the
+// applyOptions are returned, instead of sent, so that the caller can add
+// the timing of all the attempts.
+func (a *ApplyTask) apply(info *resource.Info, obj *unstructured.Unstructured,
+	serverSideOptions common.ServerSideOptions) ([]event.Event, error) {
+	eventChannel := make(chan event.Event)
+	collected := make(chan []event.Event)
+	go func() {
+		var events []event.Event
+		for e := range eventChannel {
+			events = append(events, e)
+		}
+		collected <- events
 	}()
+
+	// Create a new instance of the applyOptions interface and use it
+	// to apply the objects.
+	ao := applyOptionsFactoryFunc(a.Name(), eventChannel,
+		serverSideOptions, a.DryRunStrategy, a.DynamicClient, a.OpenAPIGetter)
+	ao.SetObjects([]*resource.Info{info})
+	klog.V(5).Infof("applying object: %v", object.UnstructuredToObjMetadata(obj))
+	err := ao.Run()
+	if err != nil && serverSideOptions.ServerSideApply && isAPIService(obj) && isStreamError(err) {
+		// Server-side Apply doesn't work with APIService before k8s 1.21
+		// https://github.com/kubernetes/kubernetes/issues/89264
+		// Thus APIService is handled specially using client-side apply.
+		err = a.clientSideApply(info, eventChannel)
+	}
+	close(eventChannel)
+	return <-collected, err
+}
+
+// unchangedSince returns the object from the cluster, its status in the
+// passed object status, and true, if the status records the object as applied
+// with the same content hash, and the UID and generation of the object in the
+// cluster still match. If requireReconciled, the object must have been
+// reconciled too. If maxAge is positive, the object must have been applied
+// less than maxAge ago.
+func (a *ApplyTask) unchangedSince(ctx context.Context, objStatus map[object.ObjMetadata]actuation.ObjectStatus,
+	id object.ObjMetadata, hash string, requireReconciled bool,
+	maxAge time.Duration) (*unstructured.Unstructured, actuation.ObjectStatus, bool) {
+	status, found := objStatus[id]
+	if !found ||
+		hash == "" || status.Hash != hash ||
+		status.Strategy != actuation.ActuationStrategyApply ||
+		status.Actuation != actuation.ActuationSucceeded ||
+		(requireReconciled && status.Reconcile != actuation.ReconcileSucceeded) ||
+		status.UID == "" {
+		return nil, status, false
+	}
+	if maxAge > 0 && (status.AppliedTime == nil || time.Since(status.AppliedTime.Time) >= maxAge) {
+		return nil, status, false
+	}
+	live, err := a.getObject(ctx, id)
+	if err != nil {
+		klog.V(4).Infof("unchanged object lookup failed (object: %s): %v", id, err)
+		return nil, status, false
+	}
+	if live.GetUID() != status.UID || live.GetGeneration() != status.Generation {
+		return nil, status, false
+	}
+	return live, status, true
+}
+
+// getObject returns the object from the cluster.
+func (a *ApplyTask) getObject(ctx context.Context, id object.ObjMetadata) (*unstructured.Unstructured, error) {
+	mapping, err := a.Mapper.RESTMapping(id.GroupKind)
+	if err != nil {
+		return nil, err
+	}
+	return a.DynamicClient.Resource(mapping.Resource).Namespace(id.Namespace).
+		Get(ctx, id.Name, metav1.GetOptions{})
+}
+
+// snapshot records the object from the cluster in the TaskContext, or nil if
+// the object does not exist.
+func (a *ApplyTask) snapshot(ctx context.Context, taskContext *taskrunner.TaskContext, id object.ObjMetadata) error {
+	mapping, err := a.Mapper.RESTMapping(id.GroupKind)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			// CRD not yet established, so the object can not exist
+			taskContext.AddSnapshot(id, nil)
+			return nil
+		}
+		return err
+	}
+	live, err := a.DynamicClient.Resource(mapping.Resource).Namespace(id.Namespace).
+		Get(ctx, id.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			taskContext.AddSnapshot(id, nil)
+			return nil
+		}
+		return err
+	}
+	taskContext.AddSnapshot(id, live)
+	return nil
 }
 
 func newApplyOptions(taskName string, eventChannel chan<- event.Event, serverSideOptions common.ServerSideOptions,
@@ -257,6 +520,44 @@ func (a *ApplyTask) createApplyFailedEvent(id object.ObjMetadata, err error) eve
 	}
 }
 
+func (a *ApplyTask) createApplyRetryingEvent(id object.ObjMetadata, err error, attempt int) event.Event {
+	return event.Event{
+		Type: event.ApplyType,
+		ApplyEvent: event.ApplyEvent{
+			GroupName:   a.Name(),
+			Identifier:  id,
+			Status:      event.ApplyRetrying,
+			Error:       err,
+			Attempt:     attempt,
+			MaxAttempts: a.RetryPolicy.MaxAttempts,
+		},
+	}
+}
+
+func (a *ApplyTask) createApplyConflictEvent(id object.ObjMetadata, err *applyerror.ConflictError) event.Event {
+	return event.Event{
+		Type: event.ApplyType,
+		ApplyEvent: event.ApplyEvent{
+			GroupName:  a.Name(),
+			Identifier: id,
+			Status:     event.ApplyConflict,
+			Error:      err,
+		},
+	}
+}
+
+func (a *ApplyTask) createApplyUnchangedEvent(id object.ObjMetadata, resource *unstructured.Unstructured) event.Event {
+	return event.Event{
+		Type: event.ApplyType,
+		ApplyEvent: event.ApplyEvent{
+			GroupName:  a.Name(),
+			Identifier: id,
+			Status:     event.ApplyUnchanged,
+			Resource:   resource,
+		},
+	}
+}
+
 func (a *ApplyTask) createApplySkippedEvent(id object.ObjMetadata, resource *unstructured.Unstructured, err error) event.Event {
 	return event.Event{
 		Type: event.ApplyType,
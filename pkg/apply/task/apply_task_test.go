@@ -4,24 +4,34 @@
 package task
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
+	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
 	"github.com/fluxcd/cli-utils/pkg/apply/cache"
 	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/apply/plan"
+	"github.com/fluxcd/cli-utils/pkg/apply/retry"
 	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
 	"github.com/fluxcd/cli-utils/pkg/common"
 	"github.com/fluxcd/cli-utils/pkg/object"
 	"github.com/fluxcd/cli-utils/pkg/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 )
 
 type resourceInfo struct {
@@ -81,7 +91,7 @@ func TestApplyTask_BasicAppliedObjects(t *testing.T) {
 			eventChannel := make(chan event.Event)
 			defer close(eventChannel)
 			resourceCache := cache.NewResourceCacheMap()
-			taskContext := taskrunner.NewTaskContext(eventChannel, resourceCache)
+			taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
 
 			objs := toUnstructureds(tc.applied)
 
@@ -173,7 +183,7 @@ func TestApplyTask_FetchGeneration(t *testing.T) {
 			eventChannel := make(chan event.Event)
 			defer close(eventChannel)
 			resourceCache := cache.NewResourceCacheMap()
-			taskContext := taskrunner.NewTaskContext(eventChannel, resourceCache)
+			taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
 
 			objs := toUnstructureds(tc.rss)
 
@@ -210,6 +220,679 @@ func TestApplyTask_FetchGeneration(t *testing.T) {
 	}
 }
 
+// Tests that objects are skipped, and not applied, once the context
+// of the TaskContext is cancelled.
+func TestApplyTask_Cancelled(t *testing.T) {
+	rss := []resourceInfo{
+		{
+			group:      "apps",
+			apiVersion: "apps/v1",
+			kind:       "Deployment",
+			name:       "foo",
+			namespace:  "default",
+			uid:        types.UID("uid-1"),
+			generation: int64(1),
+		},
+		{
+			group:      "apps",
+			apiVersion: "apps/v1",
+			kind:       "Deployment",
+			name:       "bar",
+			namespace:  "default",
+			uid:        types.UID("uid-2"),
+			generation: int64(1),
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	eventChannel := make(chan event.Event)
+	resourceCache := cache.NewResourceCacheMap()
+	taskContext := taskrunner.NewTaskContext(ctx, eventChannel, resourceCache)
+
+	objs := toUnstructureds(rss)
+
+	ao := &fakeApplyOptions{}
+	oldAO := applyOptionsFactoryFunc
+	applyOptionsFactoryFunc = func(string, chan<- event.Event, common.ServerSideOptions, common.DryRunStrategy,
+		dynamic.Interface, discovery.OpenAPISchemaInterface) applyOptions {
+		return ao
+	}
+	defer func() { applyOptionsFactoryFunc = oldAO }()
+
+	applyTask := &ApplyTask{
+		TaskName:   "apply-0",
+		Objects:    objs,
+		InfoHelper: &fakeInfoHelper{},
+	}
+
+	var events []event.Event
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for msg := range eventChannel {
+			events = append(events, msg)
+		}
+	}()
+
+	applyTask.Start(taskContext)
+	<-taskContext.TaskChannel()
+	close(eventChannel)
+	wg.Wait()
+
+	assert.Empty(t, ao.passedObjects)
+
+	im := taskContext.InventoryManager()
+	expectedIDs := object.UnstructuredSetToObjMetadataSet(objs)
+	assert.Equal(t, expectedIDs, im.SkippedApplies())
+
+	if assert.Len(t, events, len(expectedIDs)) {
+		for i, e := range events {
+			assert.Equal(t, event.ApplyType, e.Type)
+			assert.Equal(t, expectedIDs[i], e.ApplyEvent.Identifier)
+			assert.Equal(t, event.ApplySkipped, e.ApplyEvent.Status)
+			assert.ErrorIs(t, e.ApplyEvent.Error, context.Canceled)
+		}
+	}
+}
+
+func TestApplyTask_Concurrent(t *testing.T) {
+	var rss []resourceInfo
+	for i, name := range []string{"foo", "bar", "failure-1", "baz", "failure-2", "qux"} {
+		rss = append(rss, resourceInfo{
+			group:      "apps",
+			apiVersion: "apps/v1",
+			kind:       "Deployment",
+			name:       name,
+			namespace:  "default",
+			uid:        types.UID(fmt.Sprintf("uid-%d", i)),
+			generation: int64(1),
+		})
+	}
+
+	eventChannel := make(chan event.Event)
+	resourceCache := cache.NewResourceCacheMap()
+	taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
+
+	objs := toUnstructureds(rss)
+
+	oldAO := applyOptionsFactoryFunc
+	applyOptionsFactoryFunc = func(string, chan<- event.Event, common.ServerSideOptions, common.DryRunStrategy,
+		dynamic.Interface, discovery.OpenAPISchemaInterface) applyOptions {
+		return &fakeApplyOptions{}
+	}
+	defer func() { applyOptionsFactoryFunc = oldAO }()
+
+	applyTask := &ApplyTask{
+		TaskName:    "apply-0",
+		Objects:     objs,
+		InfoHelper:  &fakeInfoHelper{},
+		Concurrency: 3,
+	}
+
+	var events []event.Event
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for msg := range eventChannel {
+			events = append(events, msg)
+		}
+	}()
+
+	applyTask.Start(taskContext)
+	<-taskContext.TaskChannel()
+	close(eventChannel)
+	wg.Wait()
+
+	var expectedSucceeded, expectedFailed object.ObjMetadataSet
+	for _, obj := range objs {
+		id := object.UnstructuredToObjMetadata(obj)
+		if strings.Contains(id.Name, "failure") {
+			expectedFailed = append(expectedFailed, id)
+		} else {
+			expectedSucceeded = append(expectedSucceeded, id)
+		}
+	}
+
+	im := taskContext.InventoryManager()
+	// Objects are applied in parallel, so the order is not deterministic.
+	assert.ElementsMatch(t, expectedSucceeded, im.SuccessfulApplies())
+	assert.ElementsMatch(t, expectedFailed, im.FailedApplies())
+
+	var failedEvents object.ObjMetadataSet
+	for _, e := range events {
+		if e.Type == event.ApplyType && e.ApplyEvent.Status == event.ApplyFailed {
+			failedEvents = append(failedEvents, e.ApplyEvent.Identifier)
+		}
+	}
+	assert.ElementsMatch(t, expectedFailed, failedEvents)
+}
+
+func TestApplyTask_Checkpoint(t *testing.T) {
+	rs := resourceInfo{
+		group:      "apps",
+		apiVersion: "apps/v1",
+		kind:       "Deployment",
+		name:       "foo",
+		namespace:  "default",
+		uid:        types.UID("uid-1"),
+		generation: int64(2),
+	}
+	id := object.ObjMetadata{
+		GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+		Name:      "foo",
+		Namespace: "default",
+	}
+	objs := toUnstructureds([]resourceInfo{rs})
+	hash, err := plan.HashObjects(objs)
+	require.NoError(t, err)
+
+	testCases := map[string]struct {
+		checkpoint      map[object.ObjMetadata]actuation.ObjectStatus
+		expectedApplied bool
+	}{
+		"no checkpoint": {
+			checkpoint:      nil,
+			expectedApplied: true,
+		},
+		"unchanged since checkpoint": {
+			checkpoint: map[object.ObjMetadata]actuation.ObjectStatus{
+				id: {
+					Strategy:   actuation.ActuationStrategyApply,
+					Actuation:  actuation.ActuationSucceeded,
+					Reconcile:  actuation.ReconcileSucceeded,
+					UID:        "uid-1",
+					Generation: 2,
+					Hash:       hash,
+				},
+			},
+			expectedApplied: false,
+		},
+		"generation changed since checkpoint": {
+			checkpoint: map[object.ObjMetadata]actuation.ObjectStatus{
+				id: {
+					Strategy:   actuation.ActuationStrategyApply,
+					Actuation:  actuation.ActuationSucceeded,
+					Reconcile:  actuation.ReconcileSucceeded,
+					UID:        "uid-1",
+					Generation: 1,
+					Hash:       hash,
+				},
+			},
+			expectedApplied: true,
+		},
+		"uid changed since checkpoint": {
+			checkpoint: map[object.ObjMetadata]actuation.ObjectStatus{
+				id: {
+					Strategy:   actuation.ActuationStrategyApply,
+					Actuation:  actuation.ActuationSucceeded,
+					Reconcile:  actuation.ReconcileSucceeded,
+					UID:        "uid-0",
+					Generation: 2,
+					Hash:       hash,
+				},
+			},
+			expectedApplied: true,
+		},
+		"content changed since checkpoint": {
+			checkpoint: map[object.ObjMetadata]actuation.ObjectStatus{
+				id: {
+					Strategy:   actuation.ActuationStrategyApply,
+					Actuation:  actuation.ActuationSucceeded,
+					Reconcile:  actuation.ReconcileSucceeded,
+					UID:        "uid-1",
+					Generation: 2,
+					Hash:       "other",
+				},
+			},
+			expectedApplied: true,
+		},
+		"no hash at checkpoint": {
+			checkpoint: map[object.ObjMetadata]actuation.ObjectStatus{
+				id: {
+					Strategy:   actuation.ActuationStrategyApply,
+					Actuation:  actuation.ActuationSucceeded,
+					Reconcile:  actuation.ReconcileSucceeded,
+					UID:        "uid-1",
+					Generation: 2,
+				},
+			},
+			expectedApplied: true,
+		},
+		"not reconciled at checkpoint": {
+			checkpoint: map[object.ObjMetadata]actuation.ObjectStatus{
+				id: {
+					Strategy:   actuation.ActuationStrategyApply,
+					Actuation:  actuation.ActuationSucceeded,
+					Reconcile:  actuation.ReconcileTimeout,
+					UID:        "uid-1",
+					Generation: 2,
+					Hash:       hash,
+				},
+			},
+			expectedApplied: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			eventChannel := make(chan event.Event)
+			resourceCache := cache.NewResourceCacheMap()
+			taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
+
+			ao := &fakeApplyOptions{}
+			oldAO := applyOptionsFactoryFunc
+			applyOptionsFactoryFunc = func(string, chan<- event.Event, common.ServerSideOptions, common.DryRunStrategy,
+				dynamic.Interface, discovery.OpenAPISchemaInterface) applyOptions {
+				return ao
+			}
+			defer func() { applyOptionsFactoryFunc = oldAO }()
+
+			applyTask := &ApplyTask{
+				TaskName:      "apply-0",
+				Objects:       objs,
+				InfoHelper:    &fakeInfoHelper{},
+				DynamicClient: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objs[0].DeepCopy()),
+				Mapper: testutil.NewFakeRESTMapper(schema.GroupVersionKind{
+					Group:   "apps",
+					Version: "v1",
+					Kind:    "Deployment",
+				}),
+				Checkpoint: tc.checkpoint,
+				RecordHash: true,
+			}
+
+			var events []event.Event
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for msg := range eventChannel {
+					events = append(events, msg)
+				}
+			}()
+
+			applyTask.Start(taskContext)
+			<-taskContext.TaskChannel()
+			close(eventChannel)
+			wg.Wait()
+
+			im := taskContext.InventoryManager()
+			assert.True(t, im.IsSuccessfulApply(id))
+			gen, _ := im.AppliedGeneration(id)
+			assert.Equal(t, rs.generation, gen)
+
+			if tc.expectedApplied {
+				assert.Len(t, ao.passedObjects, 1)
+				assert.Empty(t, events)
+				return
+			}
+			assert.Empty(t, ao.passedObjects)
+			if assert.Len(t, events, 1) {
+				assert.Equal(t, event.ApplyUnchanged, events[0].ApplyEvent.Status)
+			}
+		})
+	}
+}
+
+func TestApplyTask_ApplyOnlyChanged(t *testing.T) {
+	rs := resourceInfo{
+		group:      "apps",
+		apiVersion: "apps/v1",
+		kind:       "Deployment",
+		name:       "foo",
+		namespace:  "default",
+		uid:        types.UID("uid-1"),
+		generation: int64(2),
+	}
+	id := object.ObjMetadata{
+		GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+		Name:      "foo",
+		Namespace: "default",
+	}
+	objs := toUnstructureds([]resourceInfo{rs})
+	hash, err := plan.HashObjects(objs)
+	require.NoError(t, err)
+	lastWeek := &metav1.Time{Time: time.Now().Add(-7 * 24 * time.Hour)}
+
+	lastApplied := func(hash string, generation int64) map[object.ObjMetadata]actuation.ObjectStatus {
+		return map[object.ObjMetadata]actuation.ObjectStatus{
+			id: {
+				Strategy:    actuation.ActuationStrategyApply,
+				Actuation:   actuation.ActuationSucceeded,
+				Reconcile:   actuation.ReconcileSucceeded,
+				UID:         "uid-1",
+				Generation:  generation,
+				Hash:        hash,
+				AppliedTime: lastWeek,
+			},
+		}
+	}
+
+	testCases := map[string]struct {
+		lastApplied       map[object.ObjMetadata]actuation.ObjectStatus
+		fullApplyInterval time.Duration
+		noRecordHash      bool
+		expectedApplied   bool
+	}{
+		"never applied": {
+			lastApplied:     nil,
+			expectedApplied: true,
+		},
+		"unchanged since last apply": {
+			lastApplied:     lastApplied(hash, 2),
+			expectedApplied: false,
+		},
+		"hash not recorded": {
+			lastApplied:     lastApplied(hash, 2),
+			noRecordHash:    true,
+			expectedApplied: true,
+		},
+		"unchanged within full apply interval": {
+			lastApplied:       lastApplied(hash, 2),
+			fullApplyInterval: 30 * 24 * time.Hour,
+			expectedApplied:   false,
+		},
+		"unchanged after full apply interval": {
+			lastApplied:       lastApplied(hash, 2),
+			fullApplyInterval: 24 * time.Hour,
+			expectedApplied:   true,
+		},
+		"content changed since last apply": {
+			lastApplied:     lastApplied("stale", 2),
+			expectedApplied: true,
+		},
+		"generation changed since last apply": {
+			lastApplied:     lastApplied(hash, 1),
+			expectedApplied: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			eventChannel := make(chan event.Event)
+			resourceCache := cache.NewResourceCacheMap()
+			taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
+
+			ao := &fakeApplyOptions{}
+			oldAO := applyOptionsFactoryFunc
+			applyOptionsFactoryFunc = func(string, chan<- event.Event, common.ServerSideOptions, common.DryRunStrategy,
+				dynamic.Interface, discovery.OpenAPISchemaInterface) applyOptions {
+				return ao
+			}
+			defer func() { applyOptionsFactoryFunc = oldAO }()
+
+			applyTask := &ApplyTask{
+				TaskName:      "apply-0",
+				Objects:       objs,
+				InfoHelper:    &fakeInfoHelper{},
+				DynamicClient: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objs[0].DeepCopy()),
+				Mapper: testutil.NewFakeRESTMapper(schema.GroupVersionKind{
+					Group:   "apps",
+					Version: "v1",
+					Kind:    "Deployment",
+				}),
+				LastApplied:       tc.lastApplied,
+				FullApplyInterval: tc.fullApplyInterval,
+				RecordHash:        !tc.noRecordHash,
+			}
+
+			var events []event.Event
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for msg := range eventChannel {
+					events = append(events, msg)
+				}
+			}()
+
+			applyTask.Start(taskContext)
+			<-taskContext.TaskChannel()
+			close(eventChannel)
+			wg.Wait()
+
+			im := taskContext.InventoryManager()
+			assert.True(t, im.IsSuccessfulApply(id))
+			status, found := im.ObjectStatus(id)
+			require.True(t, found)
+			if tc.noRecordHash {
+				assert.Len(t, ao.passedObjects, 1)
+				assert.Empty(t, status.Hash)
+				assert.Nil(t, status.AppliedTime)
+				return
+			}
+			// The hash of the applied content is recorded for the next run.
+			assert.Equal(t, hash, status.Hash)
+			require.NotNil(t, status.AppliedTime)
+
+			if tc.expectedApplied {
+				assert.Len(t, ao.passedObjects, 1)
+				assert.Empty(t, events)
+				assert.True(t, status.AppliedTime.After(lastWeek.Time))
+				return
+			}
+			assert.Empty(t, ao.passedObjects)
+			if assert.Len(t, events, 1) {
+				assert.Equal(t, event.ApplyUnchanged, events[0].ApplyEvent.Status)
+				assert.NoError(t, events[0].ApplyEvent.Error)
+				assert.Equal(t, "uid-1", string(events[0].ApplyEvent.Resource.GetUID()))
+			}
+			// Skipped objects retain the time of the previous apply.
+			assert.Equal(t, lastWeek, status.AppliedTime)
+		})
+	}
+}
+
+func TestApplyTask_Snapshot(t *testing.T) {
+	rs := resourceInfo{
+		group:      "apps",
+		apiVersion: "apps/v1",
+		kind:       "Deployment",
+		name:       "foo",
+		namespace:  "default",
+		uid:        types.UID("uid-1"),
+		generation: int64(1),
+	}
+	id := object.ObjMetadata{
+		GroupKind: schema.GroupKind{Group: "apps", Kind: "Deployment"},
+		Name:      "foo",
+		Namespace: "default",
+	}
+
+	testCases := map[string]struct {
+		exists bool
+	}{
+		"existing object": {
+			exists: true,
+		},
+		"new object": {
+			exists: false,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			eventChannel := make(chan event.Event)
+			resourceCache := cache.NewResourceCacheMap()
+			taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
+
+			objs := toUnstructureds([]resourceInfo{rs})
+			var clusterObjs []runtime.Object
+			if tc.exists {
+				clusterObjs = append(clusterObjs, objs[0].DeepCopy())
+			}
+
+			ao := &fakeApplyOptions{}
+			oldAO := applyOptionsFactoryFunc
+			applyOptionsFactoryFunc = func(string, chan<- event.Event, common.ServerSideOptions, common.DryRunStrategy,
+				dynamic.Interface, discovery.OpenAPISchemaInterface) applyOptions {
+				return ao
+			}
+			defer func() { applyOptionsFactoryFunc = oldAO }()
+
+			applyTask := &ApplyTask{
+				TaskName:      "apply-0",
+				Objects:       objs,
+				InfoHelper:    &fakeInfoHelper{},
+				DynamicClient: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), clusterObjs...),
+				Mapper: testutil.NewFakeRESTMapper(schema.GroupVersionKind{
+					Group:   "apps",
+					Version: "v1",
+					Kind:    "Deployment",
+				}),
+				Snapshot: true,
+			}
+
+			var events []event.Event
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for msg := range eventChannel {
+					events = append(events, msg)
+				}
+			}()
+
+			applyTask.Start(taskContext)
+			<-taskContext.TaskChannel()
+			close(eventChannel)
+			wg.Wait()
+
+			assert.Empty(t, events)
+			assert.Len(t, ao.passedObjects, 1)
+			snapshot, found := taskContext.Snapshot(id)
+			assert.True(t, found)
+			if tc.exists {
+				if assert.NotNil(t, snapshot) {
+					assert.Equal(t, rs.uid, snapshot.GetUID())
+				}
+			} else {
+				assert.Nil(t, snapshot)
+			}
+		})
+	}
+}
+
+func TestApplyTask_Retry(t *testing.T) {
+	rs := resourceInfo{
+		group:      "apps",
+		apiVersion: "apps/v1",
+		kind:       "Deployment",
+		name:       "foo",
+		namespace:  "default",
+		uid:        types.UID("uid-1"),
+		generation: int64(1),
+	}
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+	conflictErr := apierrors.NewConflict(gr, "foo", fmt.Errorf("modified"))
+	invalidErr := apierrors.NewBadRequest("invalid")
+
+	testCases := map[string]struct {
+		errs             []error
+		expectedStatuses []event.ApplyEventStatus
+		expectedAttempts []int
+		expectedSuccess  bool
+	}{
+		"succeeds after retry": {
+			errs:             []error{conflictErr, nil},
+			expectedStatuses: []event.ApplyEventStatus{event.ApplyRetrying, event.ApplySuccessful},
+			expectedAttempts: []int{2, 0},
+			expectedSuccess:  true,
+		},
+		"fails after max attempts": {
+			errs:             []error{conflictErr, conflictErr, conflictErr},
+			expectedStatuses: []event.ApplyEventStatus{event.ApplyRetrying, event.ApplyRetrying, event.ApplyFailed},
+			expectedAttempts: []int{2, 3, 0},
+			expectedSuccess:  false,
+		},
+		"permanent error not retried": {
+			errs:             []error{invalidErr},
+			expectedStatuses: []event.ApplyEventStatus{event.ApplyFailed},
+			expectedAttempts: []int{0},
+			expectedSuccess:  false,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			eventChannel := make(chan event.Event)
+			resourceCache := cache.NewResourceCacheMap()
+			taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
+
+			objs := toUnstructureds([]resourceInfo{rs})
+
+			ao := &flakyApplyOptions{errs: tc.errs}
+			oldAO := applyOptionsFactoryFunc
+			applyOptionsFactoryFunc = func(_ string, eventChannel chan<- event.Event, _ common.ServerSideOptions, _ common.DryRunStrategy,
+				_ dynamic.Interface, _ discovery.OpenAPISchemaInterface) applyOptions {
+				ao.eventChannel = eventChannel
+				return ao
+			}
+			defer func() { applyOptionsFactoryFunc = oldAO }()
+
+			applyTask := &ApplyTask{
+				TaskName:   "apply-0",
+				Objects:    objs,
+				InfoHelper: &fakeInfoHelper{},
+				RetryPolicy: retry.Policy{
+					MaxAttempts: 3,
+					Backoff:     time.Millisecond,
+				},
+			}
+
+			var events []event.Event
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for msg := range eventChannel {
+					events = append(events, msg)
+				}
+			}()
+
+			applyTask.Start(taskContext)
+			<-taskContext.TaskChannel()
+			close(eventChannel)
+			wg.Wait()
+
+			assert.Equal(t, len(tc.errs), ao.calls)
+
+			var statuses []event.ApplyEventStatus
+			var attempts []int
+			for _, e := range events {
+				if assert.Equal(t, event.ApplyType, e.Type) {
+					statuses = append(statuses, e.ApplyEvent.Status)
+					attempts = append(attempts, e.ApplyEvent.Attempt)
+					timing := e.ApplyEvent.Timing
+					if e.ApplyEvent.Status == event.ApplyRetrying {
+						assert.Equal(t, 3, e.ApplyEvent.MaxAttempts)
+						assert.Equal(t, conflictErr, e.ApplyEvent.Error)
+						assert.Equal(t, event.Timing{}, timing)
+					} else {
+						// The timing covers all the attempts.
+						assert.False(t, timing.Start.IsZero())
+						assert.False(t, timing.End.IsZero())
+						assert.GreaterOrEqual(t, timing.Duration(), timing.Latency)
+					}
+				}
+			}
+			assert.Equal(t, tc.expectedStatuses, statuses)
+			assert.Equal(t, tc.expectedAttempts, attempts)
+
+			id := object.UnstructuredToObjMetadata(objs[0])
+			im := taskContext.InventoryManager()
+			assert.Equal(t, tc.expectedSuccess, im.IsSuccessfulApply(id))
+			assert.Equal(t, !tc.expectedSuccess, im.IsFailedApply(id))
+			_, found := taskContext.ActuationTime(id)
+			assert.Equal(t, tc.expectedSuccess, found)
+		})
+	}
+}
+
 func TestApplyTask_DryRun(t *testing.T) {
 	testCases := map[string]struct {
 		objs            []*unstructured.Unstructured
@@ -286,7 +969,7 @@ func TestApplyTask_DryRun(t *testing.T) {
 			t.Run(tn, func(t *testing.T) {
 				eventChannel := make(chan event.Event)
 				resourceCache := cache.NewResourceCacheMap()
-				taskContext := taskrunner.NewTaskContext(eventChannel, resourceCache)
+				taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
 
 				restMapper := testutil.NewFakeRESTMapper(schema.GroupVersionKind{
 					Group:   "apps",
@@ -435,7 +1118,7 @@ func TestApplyTaskWithError(t *testing.T) {
 		t.Run(tn, func(t *testing.T) {
 			eventChannel := make(chan event.Event)
 			resourceCache := cache.NewResourceCacheMap()
-			taskContext := taskrunner.NewTaskContext(eventChannel, resourceCache)
+			taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
 
 			restMapper := testutil.NewFakeRESTMapper(schema.GroupVersionKind{
 				Group:   "apps",
@@ -564,6 +1247,38 @@ func (f *fakeApplyOptions) SetObjects(objects []*resource.Info) {
 	f.objects = objects
 }
 
+// flakyApplyOptions returns the next of the errs for each Run, and sends
+// a successful apply event like the KubectlPrinterAdapter if it is nil.
+type flakyApplyOptions struct {
+	errs         []error
+	calls        int
+	eventChannel chan<- event.Event
+	objects      []*resource.Info
+}
+
+func (f *flakyApplyOptions) Run() error {
+	err := f.errs[f.calls]
+	f.calls++
+	if err == nil {
+		for _, info := range f.objects {
+			obj := info.Object.(*unstructured.Unstructured)
+			f.eventChannel <- event.Event{
+				Type: event.ApplyType,
+				ApplyEvent: event.ApplyEvent{
+					Identifier: object.UnstructuredToObjMetadata(obj),
+					Status:     event.ApplySuccessful,
+					Resource:   obj,
+				},
+			}
+		}
+	}
+	return err
+}
+
+func (f *flakyApplyOptions) SetObjects(objects []*resource.Info) {
+	f.objects = objects
+}
+
 type fakeInfoHelper struct{}
 
 func (f *fakeInfoHelper) UpdateInfo(*resource.Info) error {
@@ -0,0 +1,138 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package task
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	applyerror "github.com/fluxcd/cli-utils/pkg/apply/error"
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/klog/v2"
+)
+
+// resolveConflicts handles an error from a server-side apply without
+// ForceConflicts. If the fields in conflict are all owned by one of the
+// ForceConflictManagers, the object is applied again with ForceConflicts.
+// Otherwise a ConflictError with the fields in conflict is returned.
+// Other errors are returned unchanged.
+func (a *ApplyTask) resolveConflicts(ctx context.Context, info *resource.Info,
+	obj *unstructured.Unstructured, err error) ([]event.Event, error) {
+	if !a.ServerSideOptions.ServerSideApply || a.ServerSideOptions.ForceConflicts ||
+		a.DynamicClient == nil || a.Mapper == nil || !isApplyConflict(err) {
+		return nil, err
+	}
+	id := object.UnstructuredToObjMetadata(obj)
+	conflicts, lookupErr := a.fieldConflicts(ctx, id, obj)
+	if lookupErr != nil {
+		klog.V(4).Infof("apply conflict lookup failed (object: %s): %v", id, lookupErr)
+		return nil, err
+	}
+	if len(conflicts) == 0 {
+		return nil, err
+	}
+	conflictErr := applyerror.NewConflictError(conflicts, err)
+	if !a.canForceConflicts(conflictErr) {
+		return nil, conflictErr
+	}
+	klog.V(4).Infof("apply forcing conflicts (object: %s, managers: %v)", id, conflictErr.Managers())
+	forced := a.ServerSideOptions
+	forced.ForceConflicts = true
+	return a.apply(info, obj, forced)
+}
+
+// canForceConflicts returns true if all the fields in conflict are owned by
+// one of the ForceConflictManagers.
+func (a *ApplyTask) canForceConflicts(err *applyerror.ConflictError) bool {
+	if len(a.ServerSideOptions.ForceConflictManagers) == 0 {
+		return false
+	}
+	allowed := make(map[string]struct{}, len(a.ServerSideOptions.ForceConflictManagers))
+	for _, m := range a.ServerSideOptions.ForceConflictManagers {
+		allowed[m] = struct{}{}
+	}
+	for _, m := range err.Managers() {
+		if _, found := allowed[m]; !found {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldConflicts returns the fields of the object in conflict with other
+// field managers. The error returned by kubectl does not retain the details
+// of the conflicts, so the apply is repeated as a dry-run.
+func (a *ApplyTask) fieldConflicts(ctx context.Context, id object.ObjMetadata,
+	obj *unstructured.Unstructured) ([]applyerror.FieldConflict, error) {
+	mapping, err := a.Mapper.RESTMapping(id.GroupKind)
+	if err != nil {
+		return nil, err
+	}
+	data, err := runtime.Encode(unstructured.UnstructuredJSONScheme, obj)
+	if err != nil {
+		return nil, err
+	}
+	_, err = a.DynamicClient.Resource(mapping.Resource).Namespace(id.Namespace).
+		Patch(ctx, id.Name, types.ApplyPatchType, data, metav1.PatchOptions{
+			DryRun:       []string{metav1.DryRunAll},
+			FieldManager: a.ServerSideOptions.FieldManager,
+		})
+	if err == nil {
+		// the conflict was resolved since
+		return nil, nil
+	}
+	var apiStatus apierrors.APIStatus
+	if !errors.As(err, &apiStatus) || !apierrors.IsConflict(err) {
+		return nil, err
+	}
+	return parseFieldConflicts(apiStatus.Status()), nil
+}
+
+// parseFieldConflicts returns the fields in conflict from the causes of a
+// server-side apply conflict.
+func parseFieldConflicts(status metav1.Status) []applyerror.FieldConflict {
+	if status.Details == nil {
+		return nil
+	}
+	var conflicts []applyerror.FieldConflict
+	for _, cause := range status.Details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		conflicts = append(conflicts, applyerror.FieldConflict{
+			Field:   cause.Field,
+			Manager: parseConflictManager(cause.Message),
+		})
+	}
+	return conflicts
+}
+
+// parseConflictManager returns the field manager from the message of a
+// conflict cause, e.g. `conflict with "kubectl" using apps/v1`.
+func parseConflictManager(message string) string {
+	quoted, err := strconv.QuotedPrefix(strings.TrimPrefix(message, "conflict with "))
+	if err != nil {
+		return message
+	}
+	manager, err := strconv.Unquote(quoted)
+	if err != nil {
+		return message
+	}
+	return manager
+}
+
+// isApplyConflict returns true if the error is a server-side apply conflict.
+// kubectl does not wrap the API error, so the message is checked too.
+func isApplyConflict(err error) bool {
+	return apierrors.IsConflict(err) || strings.Contains(err.Error(), "Apply failed with ")
+}
@@ -0,0 +1,218 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package task
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/fluxcd/cli-utils/pkg/apply/cache"
+	applyerror "github.com/fluxcd/cli-utils/pkg/apply/error"
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
+	"github.com/fluxcd/cli-utils/pkg/common"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/cli-utils/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestParseConflictManager(t *testing.T) {
+	testCases := map[string]struct {
+		message  string
+		expected string
+	}{
+		"manager with api version": {
+			message:  `conflict with "kubectl" using apps/v1`,
+			expected: "kubectl",
+		},
+		"manager without api version": {
+			message:  `conflict with "helm"`,
+			expected: "helm",
+		},
+		"manager with escaped quote": {
+			message:  `conflict with "my \"manager\"" using v1`,
+			expected: `my "manager"`,
+		},
+		"unknown format": {
+			message:  "conflict",
+			expected: "conflict",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			assert.Equal(t, tc.expected, parseConflictManager(tc.message))
+		})
+	}
+}
+
+func TestParseFieldConflicts(t *testing.T) {
+	status := metav1.Status{
+		Details: &metav1.StatusDetails{
+			Causes: []metav1.StatusCause{
+				{
+					Type:    metav1.CauseTypeFieldManagerConflict,
+					Message: `conflict with "kubectl" using apps/v1`,
+					Field:   ".spec.replicas",
+				},
+				{
+					Type:    metav1.CauseTypeFieldValueInvalid,
+					Message: "invalid",
+					Field:   ".spec.template",
+				},
+				{
+					Type:    metav1.CauseTypeFieldManagerConflict,
+					Message: `conflict with "helm"`,
+					Field:   ".metadata.labels.app",
+				},
+			},
+		},
+	}
+	assert.Equal(t, []applyerror.FieldConflict{
+		{Field: ".spec.replicas", Manager: "kubectl"},
+		{Field: ".metadata.labels.app", Manager: "helm"},
+	}, parseFieldConflicts(status))
+	assert.Empty(t, parseFieldConflicts(metav1.Status{}))
+}
+
+func TestApplyTask_Conflict(t *testing.T) {
+	rs := resourceInfo{
+		group:      "apps",
+		apiVersion: "apps/v1",
+		kind:       "Deployment",
+		name:       "foo",
+		namespace:  "default",
+		uid:        types.UID("uid-1"),
+		generation: int64(1),
+	}
+	// kubectl does not retain the API error of a server-side apply conflict
+	applyErr := errors.New(`Apply failed with 1 conflict: conflict with "kubectl" using apps/v1: .spec.replicas`)
+	dryRunErr := apierrors.NewApplyConflict([]metav1.StatusCause{
+		{
+			Type:    metav1.CauseTypeFieldManagerConflict,
+			Message: `conflict with "kubectl" using apps/v1`,
+			Field:   ".spec.replicas",
+		},
+	}, "Apply failed with 1 conflict")
+
+	testCases := map[string]struct {
+		forceConflictManagers []string
+		expectedForced        []bool
+		expectedStatus        event.ApplyEventStatus
+		expectedConflicts     []applyerror.FieldConflict
+	}{
+		"conflict reported": {
+			expectedForced: []bool{false},
+			expectedStatus: event.ApplyConflict,
+			expectedConflicts: []applyerror.FieldConflict{
+				{Field: ".spec.replicas", Manager: "kubectl"},
+			},
+		},
+		"conflict with other manager reported": {
+			forceConflictManagers: []string{"helm"},
+			expectedForced:        []bool{false},
+			expectedStatus:        event.ApplyConflict,
+			expectedConflicts: []applyerror.FieldConflict{
+				{Field: ".spec.replicas", Manager: "kubectl"},
+			},
+		},
+		"conflict with allowed manager forced": {
+			forceConflictManagers: []string{"kubectl"},
+			expectedForced:        []bool{false, true},
+			expectedStatus:        event.ApplySuccessful,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			eventChannel := make(chan event.Event)
+			resourceCache := cache.NewResourceCacheMap()
+			taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
+
+			objs := toUnstructureds([]resourceInfo{rs})
+
+			ao := &flakyApplyOptions{errs: []error{applyErr, nil}}
+			var forced []bool
+			oldAO := applyOptionsFactoryFunc
+			applyOptionsFactoryFunc = func(_ string, eventChannel chan<- event.Event, serverSideOptions common.ServerSideOptions,
+				_ common.DryRunStrategy, _ dynamic.Interface, _ discovery.OpenAPISchemaInterface) applyOptions {
+				forced = append(forced, serverSideOptions.ForceConflicts)
+				ao.eventChannel = eventChannel
+				return ao
+			}
+			defer func() { applyOptionsFactoryFunc = oldAO }()
+
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+			dynamicClient.PrependReactor("patch", "deployments", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				patchAction := action.(clienttesting.PatchAction)
+				assert.Equal(t, types.ApplyPatchType, patchAction.GetPatchType())
+				return true, nil, dryRunErr
+			})
+
+			applyTask := &ApplyTask{
+				TaskName:      "apply-0",
+				Objects:       objs,
+				InfoHelper:    &fakeInfoHelper{},
+				DynamicClient: dynamicClient,
+				Mapper: testutil.NewFakeRESTMapper(schema.GroupVersionKind{
+					Group:   "apps",
+					Version: "v1",
+					Kind:    "Deployment",
+				}),
+				ServerSideOptions: common.ServerSideOptions{
+					ServerSideApply:       true,
+					FieldManager:          "cli-utils",
+					ForceConflictManagers: tc.forceConflictManagers,
+				},
+			}
+
+			var events []event.Event
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for msg := range eventChannel {
+					events = append(events, msg)
+				}
+			}()
+
+			applyTask.Start(taskContext)
+			<-taskContext.TaskChannel()
+			close(eventChannel)
+			wg.Wait()
+
+			assert.Equal(t, tc.expectedForced, forced)
+			if !assert.Len(t, events, 1) {
+				return
+			}
+			e := events[0].ApplyEvent
+			assert.Equal(t, tc.expectedStatus, e.Status)
+
+			id := object.UnstructuredToObjMetadata(objs[0])
+			im := taskContext.InventoryManager()
+			if tc.expectedConflicts == nil {
+				assert.NoError(t, e.Error)
+				assert.True(t, im.IsSuccessfulApply(id))
+				return
+			}
+			assert.True(t, im.IsFailedApply(id))
+			var conflictErr *applyerror.ConflictError
+			if assert.ErrorAs(t, e.Error, &conflictErr) {
+				assert.Equal(t, tc.expectedConflicts, conflictErr.Conflicts)
+				assert.ErrorIs(t, conflictErr, applyErr)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@
 package task
 
 import (
+	"context"
 	"testing"
 
 	"github.com/fluxcd/cli-utils/pkg/apply/cache"
@@ -84,8 +85,8 @@ func TestDeleteInvTask(t *testing.T) {
 			client.Err = tc.err
 			eventChannel := make(chan event.Event)
 			resourceCache := cache.NewResourceCacheMap()
-			context := taskrunner.NewTaskContext(eventChannel, resourceCache)
-			im := context.InventoryManager()
+			taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
+			im := taskContext.InventoryManager()
 			for _, deleteObj := range tc.deletedObjs {
 				im.AddSuccessfulDelete(deleteObj, "unused-uid")
 			}
@@ -114,8 +115,8 @@ func TestDeleteInvTask(t *testing.T) {
 			if taskName != task.Name() {
 				t.Errorf("expected task name (%s), got (%s)", taskName, task.Name())
 			}
-			task.Start(context)
-			result := <-context.TaskChannel()
+			task.Start(taskContext)
+			result := <-taskContext.TaskChannel()
 			if tc.isError {
 				if tc.err != result.Err {
 					t.Errorf("running DeleteOrUpdateInvTask expected error (%s), got (%s)", tc.err, result.Err)
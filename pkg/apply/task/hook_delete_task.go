@@ -0,0 +1,309 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package task
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	applyerror "github.com/fluxcd/cli-utils/pkg/apply/error"
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
+	"github.com/fluxcd/cli-utils/pkg/common"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/cli-utils/pkg/object/hook"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+// hookDeletePollInterval is the interval at which the HookDeleteTask checks
+// whether the hook objects deleted before creation are gone.
+var hookDeletePollInterval = time.Second
+
+// HookDeleteTask deletes the hook objects of a phase, according to their
+// hook-delete-policy annotation.
+//
+// If BeforeCreation is set, the task runs before the hook objects are
+// applied. It deletes the hook objects left by a previous run, that have the
+// before-hook-creation policy, and waits until they are gone, so that they
+// are created again. Otherwise, the task runs after the hook objects were
+// applied and waited on. It deletes the hook objects that succeeded or failed,
+// if they have the hook-succeeded or hook-failed policy.
+//
+// If any hook object failed, the TaskContext is stopped, so that the
+// remaining tasks (e.g. apply or prune) skip actuation.
+type HookDeleteTask struct {
+	TaskName string
+
+	DynamicClient dynamic.Interface
+	Mapper        meta.RESTMapper
+	// Phase is the phase the hook objects run in.
+	Phase hook.Phase
+	// Objects are the hook objects of the phase.
+	Objects        object.UnstructuredSet
+	BeforeCreation bool
+	// Timeout is the maximum time to wait for the hook objects deleted
+	// before creation to be gone. Zero means no limit.
+	Timeout        time.Duration
+	DryRunStrategy common.DryRunStrategy
+}
+
+func (h *HookDeleteTask) Name() string {
+	return h.TaskName
+}
+
+func (h *HookDeleteTask) Action() event.ResourceAction {
+	return event.DeleteAction
+}
+
+func (h *HookDeleteTask) Identifiers() object.ObjMetadataSet {
+	return object.UnstructuredSetToObjMetadataSet(h.Objects)
+}
+
+// Start creates a new goroutine that deletes the hook objects. It will push
+// a TaskResult on the taskChannel to signal to the taskrunner that the task
+// has completed.
+func (h *HookDeleteTask) Start(taskContext *taskrunner.TaskContext) {
+	go func() {
+		klog.V(2).Infof("hook delete task starting (name: %q, objects: %d)",
+			h.Name(), len(h.Objects))
+		var failures int
+		if h.BeforeCreation {
+			failures = h.deleteBeforeCreation(taskContext)
+		} else {
+			failures = h.deleteAfterCompletion(taskContext)
+		}
+		if failures > 0 && taskContext.Context().Err() == nil {
+			taskContext.Stop(&applyerror.HookFailedError{
+				Phase:    string(h.Phase),
+				Failures: failures,
+			})
+		}
+		klog.V(2).Infof("hook delete task completing (name: %q)", h.Name())
+		taskContext.TaskChannel() <- taskrunner.TaskResult{}
+	}()
+}
+
+// deleteBeforeCreation deletes the hook objects left by a previous run and
+// waits until they are gone. Returns the number of objects that could not be
+// deleted.
+func (h *HookDeleteTask) deleteBeforeCreation(taskContext *taskrunner.TaskContext) int {
+	ctx := taskContext.Context()
+	im := taskContext.InventoryManager()
+	failures := 0
+	deleted := map[object.ObjMetadata]*unstructured.Unstructured{}
+	for _, obj := range h.Objects {
+		id := object.UnstructuredToObjMetadata(obj)
+		if !hasDeletePolicy(obj, hook.BeforeHookCreation) {
+			continue
+		}
+		if ctx.Err() != nil {
+			h.skip(taskContext, id, obj, context.Cause(ctx))
+			continue
+		}
+		live, err := h.getObject(ctx, id)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				// not left by a previous run
+				continue
+			}
+			h.fail(taskContext, id, err)
+			failures++
+			continue
+		}
+		if err := h.deleteObject(ctx, id, live.GetUID()); err != nil {
+			h.fail(taskContext, id, err)
+			failures++
+			continue
+		}
+		deleted[id] = live
+	}
+
+	remaining, err := h.waitForDeletion(ctx, deleted)
+	for _, obj := range h.Objects {
+		id := object.UnstructuredToObjMetadata(obj)
+		live, found := deleted[id]
+		if !found {
+			continue
+		}
+		if remaining[id] {
+			h.fail(taskContext, id, err)
+			failures++
+			continue
+		}
+		im.AddSuccessfulDelete(id, live.GetUID())
+		taskContext.SendEvent(h.createDeleteEvent(id, event.DeleteSuccessful, live, nil))
+	}
+	return failures
+}
+
+// deleteAfterCompletion deletes the hook objects that succeeded or failed,
+// according to their policy. Returns the number of hook objects that failed.
+func (h *HookDeleteTask) deleteAfterCompletion(taskContext *taskrunner.TaskContext) int {
+	ctx := taskContext.Context()
+	im := taskContext.InventoryManager()
+	failures := 0
+	for _, obj := range h.Objects {
+		id := object.UnstructuredToObjMetadata(obj)
+		failed := im.IsFailedApply(id) || im.IsFailedReconcile(id) || im.IsTimeoutReconcile(id)
+		if failed {
+			failures++
+		}
+		succeeded := im.IsSuccessfulReconcile(id)
+		if !(succeeded && hasDeletePolicy(obj, hook.HookSucceeded)) &&
+			!(failed && hasDeletePolicy(obj, hook.HookFailed)) {
+			continue
+		}
+		if ctx.Err() != nil {
+			h.skip(taskContext, id, obj, context.Cause(ctx))
+			continue
+		}
+		// Only delete the object that was created by the apply.
+		uid, _ := im.AppliedResourceUID(id)
+		if err := h.deleteObject(ctx, id, uid); err != nil {
+			h.fail(taskContext, id, err)
+			continue
+		}
+		im.AddSuccessfulDelete(id, uid)
+		taskContext.SendEvent(h.createDeleteEvent(id, event.DeleteSuccessful, obj, nil))
+	}
+	return failures
+}
+
+// waitForDeletion polls the deleted objects until they are gone, or were
+// replaced by another object. Returns the objects that remain, if the
+// Timeout expires or the context is done first.
+func (h *HookDeleteTask) waitForDeletion(ctx context.Context,
+	deleted map[object.ObjMetadata]*unstructured.Unstructured) (map[object.ObjMetadata]bool, error) {
+	if len(deleted) == 0 || h.DryRunStrategy.ClientOrServerDryRun() {
+		return nil, nil
+	}
+	remaining := make(map[object.ObjMetadata]bool, len(deleted))
+	for id := range deleted {
+		remaining[id] = true
+	}
+	var timeout <-chan time.Time
+	if h.Timeout > 0 {
+		timer := time.NewTimer(h.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	ticker := time.NewTicker(hookDeletePollInterval)
+	defer ticker.Stop()
+	for {
+		for id := range remaining {
+			live, err := h.getObject(ctx, id)
+			if apierrors.IsNotFound(err) || (err == nil && live.GetUID() != deleted[id].GetUID()) {
+				delete(remaining, id)
+			}
+		}
+		if len(remaining) == 0 {
+			return nil, nil
+		}
+		select {
+		case <-ctx.Done():
+			return remaining, context.Cause(ctx)
+		case <-timeout:
+			return remaining, fmt.Errorf("timed out waiting for hook object to be deleted after %s", h.Timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (h *HookDeleteTask) getObject(ctx context.Context, id object.ObjMetadata) (*unstructured.Unstructured, error) {
+	client, err := h.resourceClient(id)
+	if err != nil {
+		return nil, err
+	}
+	return client.Get(ctx, id.Name, metav1.GetOptions{})
+}
+
+// deleteObject deletes the object, unless in dry-run. If the uid is set, only
+// the object with that uid is deleted. Objects already deleted are ignored.
+func (h *HookDeleteTask) deleteObject(ctx context.Context, id object.ObjMetadata, uid types.UID) error {
+	if h.DryRunStrategy.ClientOrServerDryRun() {
+		return nil
+	}
+	client, err := h.resourceClient(id)
+	if err != nil {
+		return err
+	}
+	propagationPolicy := metav1.DeletePropagationBackground
+	opts := metav1.DeleteOptions{
+		PropagationPolicy: &propagationPolicy,
+	}
+	if uid != "" {
+		opts.Preconditions = &metav1.Preconditions{UID: &uid}
+	}
+	klog.V(4).Infof("deleting hook object (object: %q)", id)
+	err = client.Delete(ctx, id.Name, opts)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (h *HookDeleteTask) resourceClient(id object.ObjMetadata) (dynamic.ResourceInterface, error) {
+	mapping, err := h.Mapper.RESTMapping(id.GroupKind)
+	if err != nil {
+		return nil, err
+	}
+	return h.DynamicClient.Resource(mapping.Resource).Namespace(id.Namespace), nil
+}
+
+func (h *HookDeleteTask) skip(taskContext *taskrunner.TaskContext, id object.ObjMetadata,
+	obj *unstructured.Unstructured, err error) {
+	klog.V(4).Infof("hook delete cancelled (object: %q): %v", id, err)
+	taskContext.InventoryManager().AddSkippedDelete(id)
+	taskContext.SendEvent(h.createDeleteEvent(id, event.DeleteSkipped, obj, err))
+}
+
+func (h *HookDeleteTask) fail(taskContext *taskrunner.TaskContext, id object.ObjMetadata, err error) {
+	if klog.V(4).Enabled() {
+		// only log event emitted errors if the verbosity > 4
+		klog.Errorf("error deleting hook object (object: %q): %v", id, err)
+	}
+	taskContext.InventoryManager().AddFailedDelete(id)
+	taskContext.SendEvent(h.createDeleteEvent(id, event.DeleteFailed, nil, err))
+}
+
+func (h *HookDeleteTask) createDeleteEvent(
+	id object.ObjMetadata,
+	status event.DeleteEventStatus,
+	obj *unstructured.Unstructured,
+	err error,
+) event.Event {
+	return event.Event{
+		Type: event.DeleteType,
+		DeleteEvent: event.DeleteEvent{
+			GroupName:  h.Name(),
+			Identifier: id,
+			Status:     status,
+			Object:     obj,
+			Error:      err,
+		},
+	}
+}
+
+// hasDeletePolicy returns true if the hook object has the delete policy.
+// Invalid annotations are rejected when the task queue is built.
+func hasDeletePolicy(obj *unstructured.Unstructured, policy hook.DeletePolicy) bool {
+	policies, err := hook.ReadDeletePolicyAnnotation(obj)
+	if err != nil {
+		return false
+	}
+	return policies.Contains(policy)
+}
+
+// Cancel is not supported by the HookDeleteTask.
+func (h *HookDeleteTask) Cancel(_ *taskrunner.TaskContext) {}
+
+// StatusUpdate is not supported by the HookDeleteTask.
+func (h *HookDeleteTask) StatusUpdate(_ *taskrunner.TaskContext, _ object.ObjMetadata) {}
@@ -0,0 +1,217 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package task
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/fluxcd/cli-utils/pkg/apply/cache"
+	applyerror "github.com/fluxcd/cli-utils/pkg/apply/error"
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/cli-utils/pkg/object/hook"
+	"github.com/fluxcd/cli-utils/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func newHookJob(name string, uid types.UID, deletePolicy string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("batch/v1")
+	obj.SetKind("Job")
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	obj.SetUID(uid)
+	annotations := map[string]string{
+		hook.Annotation: string(hook.PostApply),
+	}
+	if deletePolicy != "" {
+		annotations[hook.DeletePolicyAnnotation] = deletePolicy
+	}
+	obj.SetAnnotations(annotations)
+	return obj
+}
+
+func TestHookDeleteTask(t *testing.T) {
+	// foo has the default before-hook-creation policy
+	foo := newHookJob("foo", "uid-1", "")
+	bar := newHookJob("bar", "uid-2", "hook-succeeded")
+	baz := newHookJob("baz", "uid-3", "hook-failed")
+	objs := object.UnstructuredSet{foo, bar, baz}
+	fooID := object.UnstructuredToObjMetadata(foo)
+	barID := object.UnstructuredToObjMetadata(bar)
+	bazID := object.UnstructuredToObjMetadata(baz)
+
+	testCases := map[string]struct {
+		beforeCreation    bool
+		clusterObjs       object.UnstructuredSet
+		failed            bool
+		cancelled         bool
+		expectedEvents    []event.DeleteEvent
+		expectedDeleted   object.ObjMetadataSet
+		expectedStopCause error
+	}{
+		"before creation deletes existing hooks": {
+			beforeCreation: true,
+			clusterObjs:    object.UnstructuredSet{foo, bar},
+			expectedEvents: []event.DeleteEvent{
+				{
+					GroupName:  "post-apply-hook-cleanup-0",
+					Identifier: fooID,
+					Status:     event.DeleteSuccessful,
+				},
+			},
+			expectedDeleted: object.ObjMetadataSet{fooID},
+		},
+		"before creation without existing hooks": {
+			beforeCreation: true,
+			clusterObjs:    object.UnstructuredSet{bar},
+		},
+		"after completion deletes succeeded hooks": {
+			clusterObjs: object.UnstructuredSet{foo, bar, baz},
+			expectedEvents: []event.DeleteEvent{
+				{
+					GroupName:  "post-apply-hook-delete-0",
+					Identifier: barID,
+					Status:     event.DeleteSuccessful,
+				},
+			},
+			expectedDeleted: object.ObjMetadataSet{barID},
+		},
+		"after completion deletes failed hooks": {
+			clusterObjs: object.UnstructuredSet{foo, bar, baz},
+			failed:      true,
+			expectedEvents: []event.DeleteEvent{
+				{
+					GroupName:  "post-apply-hook-delete-0",
+					Identifier: barID,
+					Status:     event.DeleteSuccessful,
+				},
+				{
+					GroupName:  "post-apply-hook-delete-0",
+					Identifier: bazID,
+					Status:     event.DeleteSuccessful,
+				},
+			},
+			expectedDeleted:   object.ObjMetadataSet{barID, bazID},
+			expectedStopCause: &applyerror.HookFailedError{Phase: "post-apply", Failures: 1},
+		},
+		"cancelled by caller": {
+			clusterObjs: object.UnstructuredSet{foo, bar, baz},
+			cancelled:   true,
+			expectedEvents: []event.DeleteEvent{
+				{
+					GroupName:  "post-apply-hook-delete-0",
+					Identifier: barID,
+					Status:     event.DeleteSkipped,
+					Error:      context.Canceled,
+				},
+			},
+			expectedStopCause: context.Canceled,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.cancelled {
+				cancel()
+			}
+
+			eventChannel := make(chan event.Event)
+			resourceCache := cache.NewResourceCacheMap()
+			taskContext := taskrunner.NewTaskContext(ctx, eventChannel, resourceCache)
+
+			var clusterObjs []runtime.Object
+			for _, obj := range tc.clusterObjs {
+				clusterObjs = append(clusterObjs, obj.DeepCopy())
+			}
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), clusterObjs...)
+
+			taskName := "post-apply-hook-delete-0"
+			if tc.beforeCreation {
+				taskName = "post-apply-hook-cleanup-0"
+			} else {
+				im := taskContext.InventoryManager()
+				for _, obj := range objs {
+					id := object.UnstructuredToObjMetadata(obj)
+					im.AddSuccessfulApply(id, obj.GetUID(), 1)
+					if err := im.SetSuccessfulReconcile(id); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if tc.failed {
+					if err := im.SetFailedReconcile(bazID); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+
+			hookDeleteTask := &HookDeleteTask{
+				TaskName:      taskName,
+				DynamicClient: dynamicClient,
+				Mapper: testutil.NewFakeRESTMapper(schema.GroupVersionKind{
+					Group:   "batch",
+					Version: "v1",
+					Kind:    "Job",
+				}),
+				Phase:          hook.PostApply,
+				Objects:        objs,
+				BeforeCreation: tc.beforeCreation,
+			}
+
+			var events []event.DeleteEvent
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for msg := range eventChannel {
+					if assert.Equal(t, event.DeleteType, msg.Type) {
+						msg.DeleteEvent.Object = nil
+						events = append(events, msg.DeleteEvent)
+					}
+				}
+			}()
+
+			hookDeleteTask.Start(taskContext)
+			<-taskContext.TaskChannel()
+			close(eventChannel)
+			wg.Wait()
+
+			assert.Equal(t, tc.expectedEvents, events)
+
+			jobs := dynamicClient.Resource(schema.GroupVersionResource{
+				Group:    "batch",
+				Version:  "v1",
+				Resource: "jobs",
+			}).Namespace("default")
+			for _, obj := range tc.clusterObjs {
+				id := object.UnstructuredToObjMetadata(obj)
+				_, err := jobs.Get(context.TODO(), id.Name, metav1.GetOptions{})
+				if tc.expectedDeleted.Contains(id) {
+					assert.True(t, apierrors.IsNotFound(err), "expected %s to be deleted", id)
+					assert.True(t, taskContext.InventoryManager().IsSuccessfulDelete(id))
+				} else {
+					assert.NoError(t, err)
+				}
+			}
+
+			if tc.expectedStopCause != nil {
+				assert.Equal(t, tc.expectedStopCause, context.Cause(taskContext.Context()))
+			} else {
+				assert.NoError(t, taskContext.Context().Err())
+			}
+		})
+	}
+}
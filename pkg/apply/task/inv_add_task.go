@@ -27,6 +27,9 @@ type InvAddTask struct {
 	InvInfo   inventory.Info
 	Objects   object.UnstructuredSet
 	DryRun    common.DryRunStrategy
+	// KeepStatus keeps the status of the objects already stored in the
+	// inventory, so that an interrupted run can be resumed from it.
+	KeepStatus bool
 }
 
 func (i *InvAddTask) Name() string {
@@ -42,7 +45,8 @@ func (i *InvAddTask) Identifiers() object.ObjMetadataSet {
 }
 
 // Start updates the inventory by merging the locally applied objects
-// into the current inventory.
+// into the current inventory. The merged inventory is the base later
+// inventory updates are rebased onto.
 func (i *InvAddTask) Start(taskContext *taskrunner.TaskContext) {
 	go func() {
 		klog.V(2).Infof("inventory add task starting (name: %q)", i.Name())
@@ -60,7 +64,11 @@ func (i *InvAddTask) Start(taskContext *taskrunner.TaskContext) {
 		}
 		klog.V(4).Infof("merging %d local objects into inventory", len(i.Objects))
 		currentObjs := object.UnstructuredSetToObjMetadataSet(i.Objects)
-		_, err := i.InvClient.Merge(i.InvInfo, currentObjs, i.DryRun)
+		_, base, err := inventory.MergeWithBase(i.InvClient, i.InvInfo, currentObjs,
+			inventory.MergeOptions{DryRun: i.DryRun, KeepStatus: i.KeepStatus})
+		if err == nil {
+			taskContext.SetInventoryBase(base)
+		}
 		i.sendTaskResult(taskContext, err)
 	}()
 }
@@ -4,6 +4,7 @@
 package task
 
 import (
+	"context"
 	"testing"
 
 	"github.com/fluxcd/cli-utils/pkg/apply/cache"
@@ -110,7 +111,7 @@ func TestInvAddTask(t *testing.T) {
 			client := inventory.NewFakeClient(tc.initialObjs)
 			eventChannel := make(chan event.Event)
 			resourceCache := cache.NewResourceCacheMap()
-			context := taskrunner.NewTaskContext(eventChannel, resourceCache)
+			taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
 
 			task := InvAddTask{
 				TaskName:  taskName,
@@ -125,8 +126,8 @@ func TestInvAddTask(t *testing.T) {
 			if !task.Identifiers().Equal(applyIds) {
 				t.Errorf("expected task ids (%s), got (%s)", applyIds, task.Identifiers())
 			}
-			task.Start(context)
-			result := <-context.TaskChannel()
+			task.Start(taskContext)
+			result := <-taskContext.TaskChannel()
 			if result.Err != nil {
 				t.Errorf("unexpected error running InvAddTask: %s", result.Err)
 			}
@@ -134,6 +135,10 @@ func TestInvAddTask(t *testing.T) {
 			if !tc.expectedObjs.Equal(actual) {
 				t.Errorf("expected merged inventory (%s), got (%s)", tc.expectedObjs, actual)
 			}
+			// Later inventory updates are rebased onto the merged inventory.
+			if base := taskContext.InventoryBase(); base == nil || !tc.expectedObjs.Equal(base.Objects) {
+				t.Errorf("expected inventory base (%s), got (%v)", tc.expectedObjs, base)
+			}
 		})
 	}
 }
@@ -0,0 +1,119 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package task
+
+import (
+	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
+	"github.com/fluxcd/cli-utils/pkg/common"
+	"github.com/fluxcd/cli-utils/pkg/inventory"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"k8s.io/klog/v2"
+)
+
+// InvCheckpointTask persists the object status tracked by the
+// InventoryManager in the inventory object, without changing the set of
+// objects stored in it. The status is the checkpoint an interrupted run is
+// resumed from.
+type InvCheckpointTask struct {
+	TaskName  string
+	InvClient inventory.Client
+	InvInfo   inventory.Info
+	DryRun    common.DryRunStrategy
+	// FieldManager is the field manager of the apply, recorded in the
+	// inventory history.
+	FieldManager string
+}
+
+func (i *InvCheckpointTask) Name() string {
+	return i.TaskName
+}
+
+func (i *InvCheckpointTask) Action() event.ResourceAction {
+	return event.InventoryAction
+}
+
+func (i *InvCheckpointTask) Identifiers() object.ObjMetadataSet {
+	return object.ObjMetadataSet{}
+}
+
+// Start stores the status of the objects actuated so far in the inventory.
+// Objects that are still pending keep the status stored by the previous run.
+// Failing to store the checkpoint does not fail the run, because the
+// inventory is still updated at the end of it.
+func (i *InvCheckpointTask) Start(taskContext *taskrunner.TaskContext) {
+	go func() {
+		klog.V(2).Infof("inventory checkpoint task starting (name: %q)", i.Name())
+		if err := i.checkpoint(taskContext); err != nil {
+			klog.Warningf("inventory checkpoint not stored (name: %q): %v", i.Name(), err)
+		}
+		klog.V(2).Infof("inventory checkpoint task completing (name: %q)", i.Name())
+		taskContext.TaskChannel() <- taskrunner.TaskResult{}
+	}()
+}
+
+// Cancel is not supported by the InvCheckpointTask.
+func (i *InvCheckpointTask) Cancel(_ *taskrunner.TaskContext) {}
+
+// StatusUpdate is not supported by the InvCheckpointTask.
+func (i *InvCheckpointTask) StatusUpdate(_ *taskrunner.TaskContext, _ object.ObjMetadata) {}
+
+func (i *InvCheckpointTask) checkpoint(taskContext *taskrunner.TaskContext) error {
+	if i.DryRun.ClientOrServerDryRun() {
+		klog.V(4).Infoln("dry-run inventory checkpoint: not stored")
+		return nil
+	}
+	// The objects last stored by the run are stored again, so that the
+	// changes of other writers are kept when rebasing.
+	base := taskContext.InventoryBase()
+	var objs object.ObjMetadataSet
+	if base != nil {
+		objs = base.Objects
+	} else {
+		var err error
+		if objs, err = i.InvClient.GetClusterObjs(i.InvInfo); err != nil {
+			return err
+		}
+	}
+	clusterStatus, err := inventory.GetClusterObjStatus(i.InvClient, i.InvInfo)
+	if err != nil {
+		return err
+	}
+	objStatus := checkpointStatus(clusterStatus, taskContext.InventoryManager().Inventory().Status.Objects)
+	klog.V(4).Infof("checkpoint inventory status of %d objects", len(objStatus))
+	base, err = inventory.ReplaceWithBase(i.InvClient, i.InvInfo, objs, objStatus, base,
+		inventory.ReplaceOptions{DryRun: i.DryRun, FieldManager: i.FieldManager})
+	if err != nil {
+		return err
+	}
+	taskContext.SetInventoryBase(base)
+	return nil
+}
+
+// checkpointStatus returns the cluster status, with the status of each object
+// replaced by the current status, unless the object is still pending.
+func checkpointStatus(clusterStatus, currentStatus []actuation.ObjectStatus) []actuation.ObjectStatus {
+	current := make(map[object.ObjMetadata]actuation.ObjectStatus, len(currentStatus))
+	for _, s := range currentStatus {
+		if s.Actuation != actuation.ActuationPending {
+			current[inventory.ObjMetadataFromObjectReference(s.ObjectReference)] = s
+		}
+	}
+	objStatus := make([]actuation.ObjectStatus, 0, len(clusterStatus)+len(current))
+	for _, s := range clusterStatus {
+		id := inventory.ObjMetadataFromObjectReference(s.ObjectReference)
+		if cs, found := current[id]; found {
+			s = cs
+			delete(current, id)
+		}
+		objStatus = append(objStatus, s)
+	}
+	for _, s := range currentStatus {
+		if _, found := current[inventory.ObjMetadataFromObjectReference(s.ObjectReference)]; found {
+			objStatus = append(objStatus, s)
+		}
+	}
+	return objStatus
+}
@@ -0,0 +1,67 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package task
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
+	"github.com/fluxcd/cli-utils/pkg/apply/cache"
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
+	"github.com/fluxcd/cli-utils/pkg/inventory"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvCheckpointTask(t *testing.T) {
+	id1 := object.UnstructuredToObjMetadata(obj1)
+	id2 := object.UnstructuredToObjMetadata(obj2)
+	id3 := object.UnstructuredToObjMetadata(obj3)
+	prevStatus := func(id object.ObjMetadata) actuation.ObjectStatus {
+		return actuation.ObjectStatus{
+			ObjectReference: inventory.ObjectReferenceFromObjMetadata(id),
+			Strategy:        actuation.ActuationStrategyApply,
+			Actuation:       actuation.ActuationSucceeded,
+			Reconcile:       actuation.ReconcileSucceeded,
+			UID:             "prev-uid",
+			Hash:            "prev-hash",
+		}
+	}
+
+	client := inventory.NewFakeClient(object.ObjMetadataSet{id1, id2, id3})
+	client.Status = []actuation.ObjectStatus{prevStatus(id1), prevStatus(id2)}
+	eventChannel := make(chan event.Event)
+	resourceCache := cache.NewResourceCacheMap()
+	taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
+
+	// id1 was applied, id2 and id3 are still pending.
+	im := taskContext.InventoryManager()
+	for _, id := range []object.ObjMetadata{id1, id2, id3} {
+		im.AddPendingApply(id)
+	}
+	im.AddSuccessfulApply(id1, "uid-1", 1)
+	require.NoError(t, im.SetSuccessfulReconcile(id1))
+	appliedStatus, _ := im.ObjectStatus(id1)
+
+	task := &InvCheckpointTask{
+		TaskName:  "inventory-checkpoint-0",
+		InvClient: client,
+		InvInfo:   nil,
+	}
+	assert.Equal(t, "inventory-checkpoint-0", task.Name())
+	task.Start(taskContext)
+	result := <-taskContext.TaskChannel()
+	require.NoError(t, result.Err)
+
+	actual, err := client.GetClusterObjs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, object.ObjMetadataSet{id1, id2, id3}, actual)
+	actualStatus, err := client.GetClusterObjStatus(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []actuation.ObjectStatus{*appliedStatus, prevStatus(id2)}, actualStatus)
+	assert.Equal(t, &inventory.Base{Objects: object.ObjMetadataSet{id1, id2, id3}}, taskContext.InventoryBase())
+}
@@ -4,6 +4,7 @@
 package task
 
 import (
+	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
 	"github.com/fluxcd/cli-utils/pkg/apply/event"
 	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
 	"github.com/fluxcd/cli-utils/pkg/common"
@@ -23,6 +24,15 @@ type DeleteOrUpdateInvTask struct {
 	DryRun        common.DryRunStrategy
 	// if Destroy is set, the inventory will be deleted if all objects were successfully pruned
 	Destroy bool
+	// Retained are the objects kept in the inventory without being actuated,
+	// e.g. when destroying a subset of the inventory. If any are in the
+	// previous inventory, the inventory is updated instead of deleted.
+	Retained object.ObjMetadataSet
+	// Hooks are the hook objects, which are never stored in the inventory.
+	Hooks object.ObjMetadataSet
+	// FieldManager is the field manager of the apply, recorded in the
+	// inventory history.
+	FieldManager string
 }
 
 func (i *DeleteOrUpdateInvTask) Name() string {
@@ -41,14 +51,15 @@ func (i *DeleteOrUpdateInvTask) Identifiers() object.ObjMetadataSet {
 // Destroy.
 //
 // If Destroy is set, the intent is to delete the inventory. The inventory will
-// only be deleted if all prunes were successful (none failed/skipped). If any
-// prunes were failed or skipped, the inventory will be updated.
+// only be deleted if all prunes were successful (none failed/skipped) and no
+// objects were retained. Otherwise, the inventory will be updated.
 //
 // If Destroy is false, the inventory will be updated.
 func (i *DeleteOrUpdateInvTask) Start(taskContext *taskrunner.TaskContext) {
 	go func() {
 		var err error
-		if i.Destroy && i.destroySuccessful(taskContext) {
+		if i.Destroy && i.destroySuccessful(taskContext) &&
+			len(i.PrevInventory.Intersection(i.Retained)) == 0 {
 			err = i.deleteInventory()
 		} else {
 			err = i.updateInventory(taskContext)
@@ -70,6 +81,9 @@ func (i *DeleteOrUpdateInvTask) StatusUpdate(_ *taskrunner.TaskContext, _ object
 //
 // This task must run after all the apply and prune tasks have completed.
 //
+// Changes of other writers since the inventory was last stored by the run
+// are kept, by rebasing the objects onto the cluster inventory.
+//
 // Added objects:
 // - Applied resources (successful)
 //
@@ -79,10 +93,12 @@ func (i *DeleteOrUpdateInvTask) StatusUpdate(_ *taskrunner.TaskContext, _ object
 // - Deleted resources (filtered/skipped) that were not abandoned
 // - Deleted resources (failed)
 // - Abandoned resources (failed)
+// - Retained resources
 //
 // Removed objects:
 // - Deleted resources (successful)
 // - Abandoned resources (successful)
+// - Hook resources
 func (i *DeleteOrUpdateInvTask) updateInventory(taskContext *taskrunner.TaskContext) error {
 	klog.V(2).Infof("inventory set task starting (name: %q)", i.TaskName)
 	invObjs := object.ObjMetadataSet{}
@@ -154,16 +170,55 @@ func (i *DeleteOrUpdateInvTask) updateInventory(taskContext *taskrunner.TaskCont
 	klog.V(4).Infof("keep in inventory %d invalid objects", len(invalidObjects))
 	invObjs = invObjs.Union(invalidObjects)
 
+	// If an object was retained and was previously stored in the inventory,
+	// then keep it in the inventory, with its previous status.
+	retained := i.PrevInventory.Intersection(i.Retained)
+	klog.V(4).Infof("keep in inventory %d retained objects", len(retained))
+	invObjs = invObjs.Union(retained)
+
+	// If an object is a hook, then remove it from the inventory, even if it
+	// was previously stored in the inventory.
+	klog.V(4).Infof("remove from inventory %d hook objects", len(i.Hooks))
+	invObjs = invObjs.Diff(i.Hooks)
+
 	klog.V(4).Infof("get the apply status for %d objects", len(invObjs))
 	objStatus := taskContext.InventoryManager().Inventory().Status.Objects
+	if len(retained) > 0 {
+		retainedStatus, err := i.retainedStatus(retained)
+		if err != nil {
+			return err
+		}
+		// copy to avoid appending to the InventoryManager's status
+		objStatus = append(append([]actuation.ObjectStatus{}, objStatus...), retainedStatus...)
+	}
 
 	klog.V(4).Infof("set inventory %d total objects", len(invObjs))
-	err := i.InvClient.Replace(i.InvInfo, invObjs, objStatus, i.DryRun)
+	base, err := inventory.ReplaceWithBase(i.InvClient, i.InvInfo, invObjs, objStatus,
+		taskContext.InventoryBase(), inventory.ReplaceOptions{DryRun: i.DryRun, FieldManager: i.FieldManager})
+	if err == nil {
+		taskContext.SetInventoryBase(base)
+	}
 
 	klog.V(2).Infof("inventory set task completing (name: %q)", i.TaskName)
 	return err
 }
 
+// retainedStatus returns the status of the retained objects stored in the
+// cluster inventory, because they are not tracked by the InventoryManager.
+func (i *DeleteOrUpdateInvTask) retainedStatus(retained object.ObjMetadataSet) ([]actuation.ObjectStatus, error) {
+	clusterStatus, err := inventory.GetClusterObjStatus(i.InvClient, i.InvInfo)
+	if err != nil {
+		return nil, err
+	}
+	var objStatus []actuation.ObjectStatus
+	for _, status := range clusterStatus {
+		if retained.Contains(inventory.ObjMetadataFromObjectReference(status.ObjectReference)) {
+			objStatus = append(objStatus, status)
+		}
+	}
+	return objStatus, nil
+}
+
 // deleteInventory deletes the inventory object from the cluster.
 func (i *DeleteOrUpdateInvTask) deleteInventory() error {
 	klog.V(2).Infof("delete inventory task starting (name: %q)", i.Name())
@@ -179,6 +234,11 @@ func (i *DeleteOrUpdateInvTask) deleteInventory() error {
 // destroySuccessful returns true when destroy actuation and reconciliation was
 // fully successful. When true, it's safe to delete the inventory.
 func (i *DeleteOrUpdateInvTask) destroySuccessful(taskContext *taskrunner.TaskContext) bool {
+	// if the caller cancelled or the runner stopped, some objects may not
+	// have been deleted
+	if taskContext.Context().Err() != nil {
+		return false
+	}
 	// if any deletes failed, the Destroy is considered failed
 	if len(taskContext.InventoryManager().FailedDeletes()) > 0 {
 		return false
@@ -4,15 +4,24 @@
 package task
 
 import (
+	"context"
 	"testing"
 
+	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
 	"github.com/fluxcd/cli-utils/pkg/apply/cache"
 	"github.com/fluxcd/cli-utils/pkg/apply/event"
 	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
+	"github.com/fluxcd/cli-utils/pkg/common"
 	"github.com/fluxcd/cli-utils/pkg/inventory"
 	"github.com/fluxcd/cli-utils/pkg/object"
 	"github.com/fluxcd/cli-utils/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
 )
 
 var objInvalid = &unstructured.Unstructured{
@@ -40,6 +49,7 @@ func TestInvSetTask(t *testing.T) {
 		timeoutReconciles object.ObjMetadataSet
 		abandonedObjs     object.ObjMetadataSet
 		invalidObjs       object.ObjMetadataSet
+		hooks             object.ObjMetadataSet
 		expectedObjs      object.ObjMetadataSet
 	}{
 		"no apply objs, no prune failures; no inventory": {
@@ -187,6 +197,12 @@ func TestInvSetTask(t *testing.T) {
 			timeoutReconciles: object.ObjMetadataSet{id3},
 			expectedObjs:      object.ObjMetadataSet{id3},
 		},
+		"hooks are removed from the inventory": {
+			prevInventory: object.ObjMetadataSet{id1, id2},
+			appliedObjs:   object.ObjMetadataSet{id1, id2, id3},
+			hooks:         object.ObjMetadataSet{id2, id3},
+			expectedObjs:  object.ObjMetadataSet{id1},
+		},
 	}
 
 	for name, tc := range tests {
@@ -194,7 +210,7 @@ func TestInvSetTask(t *testing.T) {
 			client := inventory.NewFakeClient(object.ObjMetadataSet{})
 			eventChannel := make(chan event.Event)
 			resourceCache := cache.NewResourceCacheMap()
-			context := taskrunner.NewTaskContext(eventChannel, resourceCache)
+			taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
 
 			task := DeleteOrUpdateInvTask{
 				TaskName:      taskName,
@@ -202,8 +218,9 @@ func TestInvSetTask(t *testing.T) {
 				InvInfo:       nil,
 				PrevInventory: tc.prevInventory,
 				Destroy:       false,
+				Hooks:         tc.hooks,
 			}
-			im := context.InventoryManager()
+			im := taskContext.InventoryManager()
 			for _, applyObj := range tc.appliedObjs {
 				im.AddSuccessfulApply(applyObj, "unusued-uid", int64(0))
 			}
@@ -223,10 +240,10 @@ func TestInvSetTask(t *testing.T) {
 				im.AddSkippedDelete(skippedDelete)
 			}
 			for _, abandonedObj := range tc.abandonedObjs {
-				context.AddAbandonedObject(abandonedObj)
+				taskContext.AddAbandonedObject(abandonedObj)
 			}
 			for _, invalidObj := range tc.invalidObjs {
-				context.AddInvalidObject(invalidObj)
+				taskContext.AddInvalidObject(invalidObj)
 			}
 			for _, failedReconcile := range tc.failedReconciles {
 				if err := im.SetFailedReconcile(failedReconcile); err != nil {
@@ -241,8 +258,8 @@ func TestInvSetTask(t *testing.T) {
 			if taskName != task.Name() {
 				t.Errorf("expected task name (%s), got (%s)", taskName, task.Name())
 			}
-			task.Start(context)
-			result := <-context.TaskChannel()
+			task.Start(taskContext)
+			result := <-taskContext.TaskChannel()
 			if result.Err != nil {
 				t.Errorf("unexpected error running InvAddTask: %s", result.Err)
 			}
@@ -253,3 +270,87 @@ func TestInvSetTask(t *testing.T) {
 		})
 	}
 }
+
+func TestInvSetTask_DestroyRetained(t *testing.T) {
+	id1 := object.UnstructuredToObjMetadata(obj1)
+	id2 := object.UnstructuredToObjMetadata(obj2)
+	retainedStatus := actuation.ObjectStatus{
+		ObjectReference: inventory.ObjectReferenceFromObjMetadata(id2),
+		Strategy:        actuation.ActuationStrategyApply,
+		Actuation:       actuation.ActuationSucceeded,
+		Reconcile:       actuation.ReconcileSucceeded,
+		Hash:            "abc",
+	}
+
+	client := inventory.NewFakeClient(object.ObjMetadataSet{id1, id2})
+	client.Status = []actuation.ObjectStatus{retainedStatus}
+	eventChannel := make(chan event.Event)
+	resourceCache := cache.NewResourceCacheMap()
+	taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
+
+	task := DeleteOrUpdateInvTask{
+		TaskName:      taskName,
+		InvClient:     client,
+		InvInfo:       nil,
+		PrevInventory: object.ObjMetadataSet{id1, id2},
+		Destroy:       true,
+		Retained:      object.ObjMetadataSet{id2},
+	}
+	taskContext.InventoryManager().AddSuccessfulDelete(id1, "unused-uid")
+
+	task.Start(taskContext)
+	result := <-taskContext.TaskChannel()
+	require.NoError(t, result.Err)
+
+	// inventory updated, not deleted
+	actual, err := client.GetClusterObjs(nil)
+	require.NoError(t, err)
+	testutil.AssertEqual(t, object.ObjMetadataSet{id2}, actual)
+	actualStatus, err := client.GetClusterObjStatus(nil)
+	require.NoError(t, err)
+	assert.Contains(t, actualStatus, retainedStatus)
+}
+
+func TestInvSetTask_HistoryFieldManager(t *testing.T) {
+	id1 := object.UnstructuredToObjMetadata(obj1)
+	tf := cmdtesting.NewTestFactory().WithNamespace("default")
+	defer tf.Cleanup()
+	tf.FakeDynamicClient = dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			{Version: "v1", Resource: "configmaps"}: "ConfigMapList",
+		})
+	client, err := inventory.ClusterClientFactory{
+		StatusPolicy: inventory.StatusPolicyAll,
+		History:      inventory.HistoryOptions{Limit: 10},
+	}.NewClient(tf)
+	require.NoError(t, err)
+
+	invObj := &unstructured.Unstructured{}
+	invObj.SetGroupVersionKind(inventory.ConfigMapGVK)
+	invObj.SetNamespace("default")
+	invObj.SetName("inventory")
+	invObj.SetLabels(map[string]string{common.InventoryLabel: "test-id"})
+	invInfo := inventory.WrapInventoryInfoObj(invObj)
+
+	eventChannel := make(chan event.Event)
+	resourceCache := cache.NewResourceCacheMap()
+	taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
+	task := DeleteOrUpdateInvTask{
+		TaskName:     taskName,
+		InvClient:    client,
+		InvInfo:      invInfo,
+		FieldManager: "my-manager",
+	}
+	taskContext.InventoryManager().AddSuccessfulApply(id1, "unused-uid", int64(0))
+
+	task.Start(taskContext)
+	result := <-taskContext.TaskChannel()
+	require.NoError(t, result.Err)
+
+	// The revision records the field manager of the apply.
+	revisions, err := client.(inventory.HistoryClient).ListRevisions(invInfo)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, "my-manager", revisions[0].FieldManager)
+	assert.Equal(t, object.ObjMetadataSet{id1}, revisions[0].Added)
+}
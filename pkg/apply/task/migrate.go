@@ -0,0 +1,72 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package task
+
+import (
+	"context"
+
+	"github.com/fluxcd/cli-utils/pkg/common"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/csaupgrade"
+	"k8s.io/klog/v2"
+	"k8s.io/kubectl/pkg/cmd/apply"
+)
+
+// maxMigrationAttempts is the number of times the managed fields of an
+// object are patched, if the object was modified concurrently.
+const maxMigrationAttempts = 3
+
+// migrateClientSideApply transfers the ownership of the fields managed by
+// kubectl client-side apply to the server-side apply FieldManager, so that
+// fields removed from the object are removed from the cluster by the next
+// server-side apply, including the last-applied-configuration annotation.
+// Returns true if the managed fields of the object were migrated, or false
+// if the object does not exist or has no fields managed by client-side apply.
+func (a *ApplyTask) migrateClientSideApply(ctx context.Context, id object.ObjMetadata) (bool, error) {
+	mapping, err := a.Mapper.RESTMapping(id.GroupKind)
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			// CRD not yet established, so the object can not exist
+			return false, nil
+		}
+		return false, err
+	}
+	fieldManager := a.ServerSideOptions.FieldManager
+	if fieldManager == "" {
+		fieldManager = common.DefaultFieldManager
+	}
+	client := a.DynamicClient.Resource(mapping.Resource).Namespace(id.Namespace)
+	for attempt := 1; ; attempt++ {
+		live, err := client.Get(ctx, id.Name, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		// The patch replaces the managed fields and the resourceVersion, so
+		// it fails with a conflict if the object was modified since.
+		patch, err := csaupgrade.UpgradeManagedFieldsPatch(live,
+			sets.New(apply.FieldManagerClientSideApply), fieldManager)
+		if err != nil {
+			return false, err
+		}
+		if patch == nil {
+			return false, nil
+		}
+		_, err = client.Patch(ctx, id.Name, types.JSONPatchType, patch, metav1.PatchOptions{})
+		if err == nil {
+			klog.V(4).Infof("apply migrated managed fields (object: %s, manager: %q)", id, fieldManager)
+			return true, nil
+		}
+		if !apierrors.IsConflict(err) || attempt >= maxMigrationAttempts {
+			return false, err
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package task
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/fluxcd/cli-utils/pkg/apply/cache"
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
+	"github.com/fluxcd/cli-utils/pkg/common"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/cli-utils/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestApplyTask_MigrateClientSideApply(t *testing.T) {
+	rs := resourceInfo{
+		group:      "apps",
+		apiVersion: "apps/v1",
+		kind:       "Deployment",
+		name:       "foo",
+		namespace:  "default",
+		uid:        types.UID("uid-1"),
+		generation: int64(1),
+	}
+	deploymentsGVR := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	fields := &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{}}}`)}
+
+	testCases := map[string]struct {
+		managedFields         []metav1.ManagedFieldsEntry
+		expectedMigrated      bool
+		expectedManagedFields []metav1.ManagedFieldsEntry
+	}{
+		"client-side apply fields migrated": {
+			managedFields: []metav1.ManagedFieldsEntry{
+				{
+					Manager:    "kubectl-client-side-apply",
+					Operation:  metav1.ManagedFieldsOperationUpdate,
+					APIVersion: "apps/v1",
+					FieldsType: "FieldsV1",
+					FieldsV1:   fields,
+				},
+			},
+			expectedMigrated: true,
+			expectedManagedFields: []metav1.ManagedFieldsEntry{
+				{
+					Manager:    "cli-utils",
+					Operation:  metav1.ManagedFieldsOperationApply,
+					APIVersion: "apps/v1",
+					FieldsType: "FieldsV1",
+					FieldsV1:   fields,
+				},
+			},
+		},
+		"server-side apply fields unchanged": {
+			managedFields: []metav1.ManagedFieldsEntry{
+				{
+					Manager:    "cli-utils",
+					Operation:  metav1.ManagedFieldsOperationApply,
+					APIVersion: "apps/v1",
+					FieldsType: "FieldsV1",
+					FieldsV1:   fields,
+				},
+			},
+			expectedMigrated: false,
+			expectedManagedFields: []metav1.ManagedFieldsEntry{
+				{
+					Manager:    "cli-utils",
+					Operation:  metav1.ManagedFieldsOperationApply,
+					APIVersion: "apps/v1",
+					FieldsType: "FieldsV1",
+					FieldsV1:   fields,
+				},
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			eventChannel := make(chan event.Event)
+			resourceCache := cache.NewResourceCacheMap()
+			taskContext := taskrunner.NewTaskContext(context.TODO(), eventChannel, resourceCache)
+
+			objs := toUnstructureds([]resourceInfo{rs})
+			live := objs[0].DeepCopy()
+			live.SetResourceVersion("1")
+			live.SetManagedFields(tc.managedFields)
+
+			ao := &flakyApplyOptions{errs: []error{nil}}
+			oldAO := applyOptionsFactoryFunc
+			applyOptionsFactoryFunc = func(_ string, eventChannel chan<- event.Event, _ common.ServerSideOptions,
+				_ common.DryRunStrategy, _ dynamic.Interface, _ discovery.OpenAPISchemaInterface) applyOptions {
+				ao.eventChannel = eventChannel
+				return ao
+			}
+			defer func() { applyOptionsFactoryFunc = oldAO }()
+
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), live)
+			var patches int
+			dynamicClient.PrependReactor("patch", "deployments", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				patches++
+				assert.Equal(t, types.JSONPatchType, action.(clienttesting.PatchAction).GetPatchType())
+				return false, nil, nil
+			})
+
+			applyTask := &ApplyTask{
+				TaskName:      "apply-0",
+				Objects:       objs,
+				InfoHelper:    &fakeInfoHelper{},
+				DynamicClient: dynamicClient,
+				Mapper: testutil.NewFakeRESTMapper(schema.GroupVersionKind{
+					Group:   "apps",
+					Version: "v1",
+					Kind:    "Deployment",
+				}),
+				ServerSideOptions: common.ServerSideOptions{
+					ServerSideApply: true,
+					FieldManager:    "cli-utils",
+				},
+				MigrateClientSideApply: true,
+			}
+
+			var events []event.Event
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for msg := range eventChannel {
+					events = append(events, msg)
+				}
+			}()
+
+			applyTask.Start(taskContext)
+			<-taskContext.TaskChannel()
+			close(eventChannel)
+			wg.Wait()
+
+			if tc.expectedMigrated {
+				assert.Equal(t, 1, patches)
+			} else {
+				assert.Equal(t, 0, patches)
+			}
+			if assert.Len(t, events, 1) {
+				assert.Equal(t, event.ApplySuccessful, events[0].ApplyEvent.Status)
+				assert.Equal(t, tc.expectedMigrated, events[0].ApplyEvent.Migrated)
+			}
+			id := object.UnstructuredToObjMetadata(objs[0])
+			assert.True(t, taskContext.InventoryManager().IsSuccessfulApply(id))
+
+			migrated, err := dynamicClient.Resource(deploymentsGVR).Namespace("default").
+				Get(context.TODO(), "foo", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedManagedFields, managedFields(t, migrated))
+		})
+	}
+}
+
+// managedFields returns the managed fields of the object, without the time
+// of the last change.
+func managedFields(t *testing.T, obj *unstructured.Unstructured) []metav1.ManagedFieldsEntry {
+	entries := obj.GetManagedFields()
+	for i := range entries {
+		entries[i].Time = nil
+	}
+	require.NotNil(t, entries)
+	return entries
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/fluxcd/cli-utils/pkg/apply/event"
 	"github.com/fluxcd/cli-utils/pkg/apply/filter"
 	"github.com/fluxcd/cli-utils/pkg/apply/prune"
+	"github.com/fluxcd/cli-utils/pkg/apply/retry"
 	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
 	"github.com/fluxcd/cli-utils/pkg/common"
 	"github.com/fluxcd/cli-utils/pkg/object"
@@ -28,6 +29,11 @@ type PruneTask struct {
 	// True if we are destroying, which deletes the inventory object
 	// as well (possibly) the inventory namespace.
 	Destroy bool
+	// Concurrency is the maximum number of objects to delete in parallel.
+	Concurrency int
+	// RetryPolicy defines when to retry the deletion of an object, if it
+	// failed with a transient error.
+	RetryPolicy retry.Policy
 }
 
 func (p *PruneTask) Name() string {
@@ -69,6 +75,8 @@ func (p *PruneTask) Start(taskContext *taskrunner.TaskContext) {
 				DryRunStrategy:    p.DryRunStrategy,
 				PropagationPolicy: p.PropagationPolicy,
 				Destroy:           p.Destroy,
+				Concurrency:       p.Concurrency,
+				RetryPolicy:       p.RetryPolicy,
 			},
 		)
 		klog.V(2).Infof("prune task completing (name: %q)", p.Name())
@@ -0,0 +1,229 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package task
+
+import (
+	"context"
+	"errors"
+
+	applyerror "github.com/fluxcd/cli-utils/pkg/apply/error"
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+// RollbackTask rolls back the applied objects, if any of them failed to
+// apply or reconcile. Objects that existed before they were applied are
+// restored from the snapshot recorded by the ApplyTask. Objects that did not
+// exist are deleted. Objects are rolled back in reverse apply order.
+//
+// Restored objects that were not in the previous inventory, i.e. adopted
+// by the apply, are removed from the inventory, because the snapshot does
+// not have the owning-inventory annotation of this inventory.
+//
+// After a rollback, the TaskContext is stopped, so that the remaining tasks
+// (e.g. prune) skip actuation.
+type RollbackTask struct {
+	TaskName string
+
+	DynamicClient dynamic.Interface
+	Mapper        meta.RESTMapper
+	// Ids are the applied objects, in apply order.
+	Ids object.ObjMetadataSet
+	// PrevInventory is the set of objects in the inventory before the apply.
+	PrevInventory object.ObjMetadataSet
+}
+
+func (r *RollbackTask) Name() string {
+	return r.TaskName
+}
+
+func (r *RollbackTask) Action() event.ResourceAction {
+	return event.RollbackAction
+}
+
+func (r *RollbackTask) Identifiers() object.ObjMetadataSet {
+	return r.Ids
+}
+
+// Start creates a new goroutine that rolls back the applied objects, if any
+// failed. It will push a TaskResult on the taskChannel to signal to the
+// taskrunner that the task has completed.
+func (r *RollbackTask) Start(taskContext *taskrunner.TaskContext) {
+	go func() {
+		klog.V(2).Infof("rollback task starting (name: %q, objects: %d)",
+			r.Name(), len(r.Ids))
+		failures := r.countFailures(taskContext)
+		if failures > 0 {
+			r.rollback(taskContext, failures)
+		}
+		klog.V(2).Infof("rollback task completing (name: %q)", r.Name())
+		taskContext.TaskChannel() <- taskrunner.TaskResult{}
+	}()
+}
+
+// countFailures returns the number of objects that failed to apply, failed
+// to reconcile, or timed out reconciling.
+func (r *RollbackTask) countFailures(taskContext *taskrunner.TaskContext) int {
+	im := taskContext.InventoryManager()
+	failures := 0
+	for _, id := range r.Ids {
+		if im.IsFailedApply(id) || im.IsFailedReconcile(id) || im.IsTimeoutReconcile(id) {
+			failures++
+		}
+	}
+	return failures
+}
+
+// rollback restores or deletes the successfully applied objects, in reverse
+// apply order, and stops the TaskContext.
+func (r *RollbackTask) rollback(taskContext *taskrunner.TaskContext, failures int) {
+	// Stopping because of the failure policy or a failed batch must not
+	// prevent the rollback, but cancellation by the caller does. Requests
+	// use the context of the caller, because the TaskContext is done once
+	// stopped.
+	ctx := taskContext.Context()
+	callerCtx := taskContext.CallerContext()
+	var cancelErr error
+	var policyErr *taskrunner.FailurePolicyError
+	var haltErr *taskrunner.BatchHaltedError
+	if ctx.Err() != nil && !errors.As(context.Cause(ctx), &policyErr) &&
+		!errors.As(context.Cause(ctx), &haltErr) {
+		cancelErr = context.Cause(ctx)
+	}
+
+	im := taskContext.InventoryManager()
+	for i := len(r.Ids) - 1; i >= 0; i-- {
+		id := r.Ids[i]
+		snapshot, found := taskContext.Snapshot(id)
+		if !found || !im.IsSuccessfulApply(id) {
+			// not changed by the apply
+			continue
+		}
+		op := event.RollbackRestore
+		if snapshot == nil {
+			op = event.RollbackDelete
+		}
+		if cancelErr == nil && callerCtx.Err() != nil {
+			cancelErr = context.Cause(callerCtx)
+		}
+		if cancelErr != nil {
+			klog.V(4).Infof("rollback cancelled (object: %s): %v", id, cancelErr)
+			taskContext.SendEvent(r.createRollbackEvent(id, op, event.RollbackSkipped, snapshot, cancelErr))
+			continue
+		}
+		var err error
+		if op == event.RollbackDelete {
+			err = r.delete(callerCtx, taskContext, id)
+		} else {
+			err = r.restore(callerCtx, snapshot)
+		}
+		if err != nil {
+			if klog.V(4).Enabled() {
+				// only log event emitted errors if the verbosity > 4
+				klog.Errorf("rollback errored (object: %s): %v", id, err)
+			}
+			taskContext.SendEvent(r.createRollbackEvent(id, op, event.RollbackFailed, snapshot, err))
+			continue
+		}
+		if op == event.RollbackRestore && !r.PrevInventory.Contains(id) {
+			// The restored object is no longer owned by the inventory.
+			klog.V(4).Infof("rollback removed adopted object from inventory (object: %s)", id)
+			taskContext.AddAbandonedObject(id)
+		}
+		taskContext.SendEvent(r.createRollbackEvent(id, op, event.RollbackSuccessful, snapshot, nil))
+	}
+
+	if cancelErr == nil {
+		taskContext.Stop(&applyerror.RolledBackError{Failures: failures})
+	}
+}
+
+// restore re-applies the snapshot of an object, replacing the object in the
+// cluster, or re-creating it if it was deleted since.
+func (r *RollbackTask) restore(ctx context.Context, snapshot *unstructured.Unstructured) error {
+	id := object.UnstructuredToObjMetadata(snapshot)
+	client, err := r.resourceClient(id)
+	if err != nil {
+		return err
+	}
+	obj := snapshot.DeepCopy()
+	obj.SetUID("")
+	live, err := client.Get(ctx, id.Name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		obj.SetResourceVersion("")
+		_, err = client.Create(ctx, obj, metav1.CreateOptions{})
+		return err
+	}
+	obj.SetResourceVersion(live.GetResourceVersion())
+	_, err = client.Update(ctx, obj, metav1.UpdateOptions{})
+	return err
+}
+
+// delete deletes an object that was created by the apply, and records it as
+// deleted, so it is removed from the inventory.
+func (r *RollbackTask) delete(ctx context.Context, taskContext *taskrunner.TaskContext, id object.ObjMetadata) error {
+	client, err := r.resourceClient(id)
+	if err != nil {
+		return err
+	}
+	propagationPolicy := metav1.DeletePropagationBackground
+	opts := metav1.DeleteOptions{
+		PropagationPolicy: &propagationPolicy,
+	}
+	// Only delete the object that was created by the apply.
+	uid, found := taskContext.InventoryManager().AppliedResourceUID(id)
+	if found && uid != "" {
+		opts.Preconditions = &metav1.Preconditions{UID: &uid}
+	}
+	err = client.Delete(ctx, id.Name, opts)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	taskContext.InventoryManager().AddSuccessfulDelete(id, uid)
+	return nil
+}
+
+func (r *RollbackTask) resourceClient(id object.ObjMetadata) (dynamic.ResourceInterface, error) {
+	mapping, err := r.Mapper.RESTMapping(id.GroupKind)
+	if err != nil {
+		return nil, err
+	}
+	return r.DynamicClient.Resource(mapping.Resource).Namespace(id.Namespace), nil
+}
+
+func (r *RollbackTask) createRollbackEvent(
+	id object.ObjMetadata,
+	op event.RollbackOperation,
+	status event.RollbackEventStatus,
+	obj *unstructured.Unstructured,
+	err error,
+) event.Event {
+	return event.Event{
+		Type: event.RollbackType,
+		RollbackEvent: event.RollbackEvent{
+			GroupName:  r.Name(),
+			Identifier: id,
+			Operation:  op,
+			Status:     status,
+			Object:     obj,
+			Error:      err,
+		},
+	}
+}
+
+// Cancel is not supported by the RollbackTask.
+func (r *RollbackTask) Cancel(_ *taskrunner.TaskContext) {}
+
+// StatusUpdate is not supported by the RollbackTask.
+func (r *RollbackTask) StatusUpdate(_ *taskrunner.TaskContext, _ object.ObjMetadata) {}
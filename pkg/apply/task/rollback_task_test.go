@@ -0,0 +1,240 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package task
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/fluxcd/cli-utils/pkg/apply/cache"
+	applyerror "github.com/fluxcd/cli-utils/pkg/apply/error"
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/apply/taskrunner"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/cli-utils/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestRollbackTask(t *testing.T) {
+	// foo existed before the apply, bar was created and baz failed.
+	rss := []resourceInfo{
+		{
+			group:      "apps",
+			apiVersion: "apps/v1",
+			kind:       "Deployment",
+			name:       "foo",
+			namespace:  "default",
+			uid:        types.UID("uid-1"),
+			generation: int64(2),
+		},
+		{
+			group:      "apps",
+			apiVersion: "apps/v1",
+			kind:       "Deployment",
+			name:       "bar",
+			namespace:  "default",
+			uid:        types.UID("uid-2"),
+			generation: int64(1),
+		},
+		{
+			group:      "apps",
+			apiVersion: "apps/v1",
+			kind:       "Deployment",
+			name:       "baz",
+			namespace:  "default",
+			uid:        types.UID("uid-3"),
+			generation: int64(1),
+		},
+	}
+	ids := object.UnstructuredSetToObjMetadataSet(toUnstructureds(rss))
+	fooID, barID, bazID := ids[0], ids[1], ids[2]
+
+	testCases := map[string]struct {
+		failed            bool
+		cancelled         bool
+		adopted           bool
+		expectedEvents    []event.RollbackEvent
+		expectedRestored  bool
+		expectedDeleted   bool
+		expectedAbandoned bool
+		expectedStopCause error
+	}{
+		"no failures": {
+			failed:         false,
+			expectedEvents: nil,
+		},
+		"failure rolls back in reverse order": {
+			failed: true,
+			expectedEvents: []event.RollbackEvent{
+				{
+					GroupName:  "rollback-0",
+					Identifier: barID,
+					Operation:  event.RollbackDelete,
+					Status:     event.RollbackSuccessful,
+				},
+				{
+					GroupName:  "rollback-0",
+					Identifier: fooID,
+					Operation:  event.RollbackRestore,
+					Status:     event.RollbackSuccessful,
+				},
+			},
+			expectedRestored:  true,
+			expectedDeleted:   true,
+			expectedStopCause: &applyerror.RolledBackError{Failures: 1},
+		},
+		"failure removes restored adopted object from inventory": {
+			failed:  true,
+			adopted: true,
+			expectedEvents: []event.RollbackEvent{
+				{
+					GroupName:  "rollback-0",
+					Identifier: barID,
+					Operation:  event.RollbackDelete,
+					Status:     event.RollbackSuccessful,
+				},
+				{
+					GroupName:  "rollback-0",
+					Identifier: fooID,
+					Operation:  event.RollbackRestore,
+					Status:     event.RollbackSuccessful,
+				},
+			},
+			expectedRestored:  true,
+			expectedDeleted:   true,
+			expectedAbandoned: true,
+			expectedStopCause: &applyerror.RolledBackError{Failures: 1},
+		},
+		"cancelled by caller": {
+			failed:    true,
+			cancelled: true,
+			expectedEvents: []event.RollbackEvent{
+				{
+					GroupName:  "rollback-0",
+					Identifier: barID,
+					Operation:  event.RollbackDelete,
+					Status:     event.RollbackSkipped,
+					Error:      context.Canceled,
+				},
+				{
+					GroupName:  "rollback-0",
+					Identifier: fooID,
+					Operation:  event.RollbackRestore,
+					Status:     event.RollbackSkipped,
+					Error:      context.Canceled,
+				},
+			},
+			expectedStopCause: context.Canceled,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tc.cancelled {
+				cancel()
+			}
+
+			eventChannel := make(chan event.Event)
+			resourceCache := cache.NewResourceCacheMap()
+			taskContext := taskrunner.NewTaskContext(ctx, eventChannel, resourceCache)
+
+			// The cluster has the applied objects.
+			objs := toUnstructureds(rss)
+			applied := objs[0].DeepCopy()
+			applied.SetLabels(map[string]string{"version": "v2"})
+			snapshot := objs[0].DeepCopy()
+			snapshot.SetLabels(map[string]string{"version": "v1"})
+
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+				applied, objs[1].DeepCopy())
+
+			im := taskContext.InventoryManager()
+			im.AddSuccessfulApply(fooID, rss[0].uid, rss[0].generation)
+			taskContext.AddSnapshot(fooID, snapshot)
+			im.AddSuccessfulApply(barID, rss[1].uid, rss[1].generation)
+			taskContext.AddSnapshot(barID, nil)
+			if tc.failed {
+				im.AddFailedApply(bazID)
+				taskContext.AddSnapshot(bazID, nil)
+			}
+
+			rollbackTask := &RollbackTask{
+				TaskName:      "rollback-0",
+				DynamicClient: dynamicClient,
+				Mapper: testutil.NewFakeRESTMapper(schema.GroupVersionKind{
+					Group:   "apps",
+					Version: "v1",
+					Kind:    "Deployment",
+				}),
+				Ids:           ids,
+				PrevInventory: object.ObjMetadataSet{fooID},
+			}
+			if tc.adopted {
+				// foo existed, but was not in the inventory before the apply.
+				rollbackTask.PrevInventory = object.ObjMetadataSet{}
+			}
+
+			var events []event.RollbackEvent
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for msg := range eventChannel {
+					if assert.Equal(t, event.RollbackType, msg.Type) {
+						msg.RollbackEvent.Object = nil
+						events = append(events, msg.RollbackEvent)
+					}
+				}
+			}()
+
+			rollbackTask.Start(taskContext)
+			<-taskContext.TaskChannel()
+			close(eventChannel)
+			wg.Wait()
+
+			assert.Equal(t, tc.expectedEvents, events)
+
+			deployments := dynamicClient.Resource(schema.GroupVersionResource{
+				Group:    "apps",
+				Version:  "v1",
+				Resource: "deployments",
+			}).Namespace("default")
+
+			foo, err := deployments.Get(context.TODO(), "foo", metav1.GetOptions{})
+			if assert.NoError(t, err) {
+				if tc.expectedRestored {
+					assert.Equal(t, "v1", foo.GetLabels()["version"])
+				} else {
+					assert.Equal(t, "v2", foo.GetLabels()["version"])
+				}
+			}
+
+			_, err = deployments.Get(context.TODO(), "bar", metav1.GetOptions{})
+			if tc.expectedDeleted {
+				assert.True(t, apierrors.IsNotFound(err))
+				assert.True(t, im.IsSuccessfulDelete(barID))
+			} else {
+				assert.NoError(t, err)
+				assert.True(t, im.IsSuccessfulApply(barID))
+			}
+
+			assert.Equal(t, tc.expectedAbandoned, taskContext.IsAbandonedObject(fooID))
+
+			if tc.expectedStopCause != nil {
+				assert.Equal(t, tc.expectedStopCause, context.Cause(taskContext.Context()))
+			} else {
+				assert.NoError(t, taskContext.Context().Err())
+			}
+		})
+	}
+}
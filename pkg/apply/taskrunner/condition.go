@@ -6,6 +6,7 @@ package taskrunner
 import (
 	"github.com/fluxcd/cli-utils/pkg/kstatus/status"
 	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/cli-utils/pkg/object/waitfor"
 )
 
 // Condition is a type that defines the types of conditions
@@ -93,3 +94,26 @@ func noneMatchStatus(taskContext *TaskContext, ids object.ObjMetadataSet, s stat
 	}
 	return true
 }
+
+// objectConditionMet tests whether the provided custom condition holds true
+// for the resource, according to the ResourceCache.
+// Resources in the cache older that the applied generation are non-matches,
+// unless the condition is None.
+func objectConditionMet(taskContext *TaskContext, id object.ObjMetadata, c waitfor.Condition) bool {
+	if _, ok := c.(waitfor.None); ok {
+		// not waiting - don't need the cache
+		return true
+	}
+	cached := taskContext.ResourceCache().Get(id)
+
+	applyGen, _ := taskContext.InventoryManager().AppliedGeneration(id) // generation at apply time
+	cachedGen := int64(0)
+	if cached.Resource != nil {
+		cachedGen = cached.Resource.GetGeneration()
+	}
+	if cachedGen < applyGen {
+		// cache too old
+		return false
+	}
+	return c.Met(cached.Resource, cached.Status)
+}
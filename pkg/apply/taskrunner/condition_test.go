@@ -4,6 +4,7 @@
 package taskrunner
 
 import (
+	"context"
 	"testing"
 
 	"github.com/fluxcd/cli-utils/pkg/apply/cache"
@@ -153,7 +154,7 @@ func TestCollector_ConditionMet(t *testing.T) {
 				resourceCache.Load(tc.cacheContents...)
 			}
 
-			taskContext := NewTaskContext(nil, resourceCache)
+			taskContext := NewTaskContext(context.TODO(), nil, resourceCache)
 
 			if tc.appliedGen != nil {
 				for id, gen := range tc.appliedGen {
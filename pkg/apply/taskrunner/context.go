@@ -4,23 +4,34 @@
 package taskrunner
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	"github.com/fluxcd/cli-utils/pkg/apply/cache"
 	"github.com/fluxcd/cli-utils/pkg/apply/event"
 	"github.com/fluxcd/cli-utils/pkg/inventory"
 	"github.com/fluxcd/cli-utils/pkg/object"
 	"github.com/fluxcd/cli-utils/pkg/object/graph"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/klog/v2"
 )
 
 // NewTaskContext returns a new TaskContext
-func NewTaskContext(eventChannel chan event.Event, resourceCache cache.ResourceCache) *TaskContext {
+func NewTaskContext(ctx context.Context, eventChannel chan event.Event, resourceCache cache.ResourceCache) *TaskContext {
+	stopCtx, stop := context.WithCancelCause(ctx)
 	return &TaskContext{
+		callerCtx:        ctx,
+		ctx:              stopCtx,
+		stop:             stop,
 		taskChannel:      make(chan TaskResult),
 		eventChannel:     eventChannel,
 		resourceCache:    resourceCache,
 		inventoryManager: inventory.NewManager(),
 		abandonedObjects: make(map[object.ObjMetadata]struct{}),
 		invalidObjects:   make(map[object.ObjMetadata]struct{}),
+		snapshots:        make(map[object.ObjMetadata]*unstructured.Unstructured),
+		actuationTimes:   make(map[object.ObjMetadata]time.Time),
 		graph:            graph.New(),
 	}
 }
@@ -28,15 +39,45 @@ func NewTaskContext(eventChannel chan event.Event, resourceCache cache.ResourceC
 // TaskContext defines a context that is passed between all
 // the tasks that is in a taskqueue.
 type TaskContext struct {
+	callerCtx        context.Context
+	ctx              context.Context
+	stop             context.CancelCauseFunc
 	taskChannel      chan TaskResult
 	eventChannel     chan event.Event
 	resourceCache    cache.ResourceCache
 	inventoryManager *inventory.Manager
+	// mu protects abandonedObjects, invalidObjects, snapshots,
+	// actuationTimes and inventoryBase, which may be updated concurrently by
+	// tasks that actuate objects in parallel.
+	mu               sync.RWMutex
 	abandonedObjects map[object.ObjMetadata]struct{}
 	invalidObjects   map[object.ObjMetadata]struct{}
+	snapshots        map[object.ObjMetadata]*unstructured.Unstructured
+	actuationTimes   map[object.ObjMetadata]time.Time
+	inventoryBase    *inventory.Base
 	graph            *graph.Graph
 }
 
+// Context returns the context of the caller. Tasks should stop actuating
+// objects once it is done. The reason can be retrieved with context.Cause.
+func (tc *TaskContext) Context() context.Context {
+	return tc.ctx
+}
+
+// CallerContext returns the context of the caller, which, unlike Context,
+// is not done when the tasks are stopped. Tasks that still run after a stop,
+// e.g. to roll back, use it for requests to the cluster.
+func (tc *TaskContext) CallerContext() context.Context {
+	return tc.callerCtx
+}
+
+// Stop signals the tasks to stop actuating objects, without cancelling the
+// context of the caller. The cause is reported as the reason objects were
+// skipped.
+func (tc *TaskContext) Stop(cause error) {
+	tc.stop(cause)
+}
+
 func (tc *TaskContext) TaskChannel() chan TaskResult {
 	return tc.taskChannel
 }
@@ -69,32 +110,96 @@ func (tc *TaskContext) SendEvent(e event.Event) {
 
 // IsAbandonedObject returns true if the object is abandoned
 func (tc *TaskContext) IsAbandonedObject(id object.ObjMetadata) bool {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
 	_, found := tc.abandonedObjects[id]
 	return found
 }
 
 // AddAbandonedObject registers that the object is abandoned
 func (tc *TaskContext) AddAbandonedObject(id object.ObjMetadata) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
 	tc.abandonedObjects[id] = struct{}{}
 }
 
 // AbandonedObjects returns all the abandoned objects
 func (tc *TaskContext) AbandonedObjects() object.ObjMetadataSet {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
 	return object.ObjMetadataSetFromMap(tc.abandonedObjects)
 }
 
 // IsInvalidObject returns true if the object is abandoned
 func (tc *TaskContext) IsInvalidObject(id object.ObjMetadata) bool {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
 	_, found := tc.invalidObjects[id]
 	return found
 }
 
 // AddInvalidObject registers that the object is abandoned
 func (tc *TaskContext) AddInvalidObject(id object.ObjMetadata) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
 	tc.invalidObjects[id] = struct{}{}
 }
 
 // InvalidObjects returns all the abandoned objects
 func (tc *TaskContext) InvalidObjects() object.ObjMetadataSet {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
 	return object.ObjMetadataSetFromMap(tc.invalidObjects)
 }
+
+// AddSnapshot records the state of the object in the cluster before it was
+// applied, so it can be rolled back. A nil object records that the object
+// did not exist.
+func (tc *TaskContext) AddSnapshot(id object.ObjMetadata, obj *unstructured.Unstructured) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.snapshots[id] = obj
+}
+
+// Snapshot returns the state of the object in the cluster before it was
+// applied, and true if a snapshot was recorded. The object is nil if it did
+// not exist.
+func (tc *TaskContext) Snapshot(id object.ObjMetadata) (*unstructured.Unstructured, bool) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	obj, found := tc.snapshots[id]
+	return obj, found
+}
+
+// AddActuationTime records when the object was successfully applied or
+// deleted, to measure the time until it is reconciled.
+func (tc *TaskContext) AddActuationTime(id object.ObjMetadata, t time.Time) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.actuationTimes[id] = t
+}
+
+// ActuationTime returns when the object was successfully applied or deleted,
+// and true if the time was recorded.
+func (tc *TaskContext) ActuationTime(id object.ObjMetadata) (time.Time, bool) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	t, found := tc.actuationTimes[id]
+	return t, found
+}
+
+// SetInventoryBase records the base of the inventory last stored by a task,
+// which the next update of the inventory rebases onto.
+func (tc *TaskContext) SetInventoryBase(base *inventory.Base) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.inventoryBase = base
+}
+
+// InventoryBase returns the base of the inventory last stored by a task, or
+// nil if no task stored the inventory yet.
+func (tc *TaskContext) InventoryBase() *inventory.Base {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	return tc.inventoryBase
+}
@@ -0,0 +1,248 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package taskrunner
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// finalizerCheckInterval is how often the finalizers of the objects that
+// are waited on to be deleted are checked.
+var finalizerCheckInterval = time.Second
+
+// terminatingState tracks the finalizers of an object that is terminating.
+type terminatingState struct {
+	// finalizers are the last observed finalizers of the object.
+	finalizers []string
+	// since is when the finalizers last changed.
+	since time.Time
+	// reported is true if the object has been reported as stuck.
+	reported bool
+	// removed is true if the removal of finalizers has been attempted.
+	removed bool
+}
+
+// finalizerRemoval is the removal of finalizers from a stuck object.
+type finalizerRemoval struct {
+	id       object.ObjMetadata
+	obj      *unstructured.Unstructured
+	remove   []string
+	stuckFor time.Duration
+}
+
+// watchFinalizers periodically checks the finalizers of the pending
+// objects, until the context is done.
+func (w *WaitTask) watchFinalizers(ctx context.Context, taskContext *TaskContext) {
+	ticker := time.NewTicker(finalizerCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, r := range w.checkFinalizers(taskContext) {
+				w.removeFinalizers(ctx, taskContext, r)
+			}
+		}
+	}
+}
+
+// checkFinalizers reports the pending objects whose finalizers have not
+// changed for longer than the stuck threshold, and returns the finalizers
+// to remove from the objects stuck for longer than the grace period.
+// The pending set is write locked during execution of checkFinalizers.
+func (w *WaitTask) checkFinalizers(taskContext *TaskContext) []finalizerRemoval {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	var removals []finalizerRemoval
+	for _, id := range w.pending {
+		obj := taskContext.ResourceCache().Get(id).Resource
+		state := w.observeFinalizers(id, obj, now)
+		if state == nil {
+			continue
+		}
+		stuckFor := now.Sub(state.since)
+		opts := w.Finalizers
+		if opts.StuckThreshold > 0 && stuckFor >= opts.StuckThreshold && !state.reported {
+			klog.V(4).Infof("object stuck terminating (object: %q, finalizers: %v, stuck: %v)",
+				id, state.finalizers, stuckFor)
+			state.reported = true
+			e := w.newEvent(taskContext, id, event.ReconcilePending)
+			e.Finalizers = state.finalizers
+			e.StuckFor = stuckFor
+			w.send(taskContext, e)
+		}
+		if len(opts.Remove) > 0 && stuckFor >= opts.GracePeriod() && !state.removed {
+			state.removed = true
+			remove := intersectStrings(state.finalizers, opts.Remove)
+			if len(remove) > 0 {
+				removals = append(removals, finalizerRemoval{
+					id:       id,
+					obj:      obj,
+					remove:   remove,
+					stuckFor: stuckFor,
+				})
+			}
+		}
+	}
+	return removals
+}
+
+// observeFinalizers updates and returns the terminating state of the object,
+// or returns nil if the object is not terminating or has no finalizers.
+// Must be called with the pending set write locked.
+func (w *WaitTask) observeFinalizers(id object.ObjMetadata, obj *unstructured.Unstructured, now time.Time) *terminatingState {
+	if obj == nil || obj.GetDeletionTimestamp() == nil || len(obj.GetFinalizers()) == 0 {
+		delete(w.terminating, id)
+		return nil
+	}
+	finalizers := obj.GetFinalizers()
+	state, found := w.terminating[id]
+	switch {
+	case !found:
+		// The finalizers may have been unchanged since the deletion.
+		state = &terminatingState{
+			finalizers: finalizers,
+			since:      obj.GetDeletionTimestamp().Time,
+		}
+		if w.terminating == nil {
+			w.terminating = make(map[object.ObjMetadata]*terminatingState)
+		}
+		w.terminating[id] = state
+	case !equalStrings(state.finalizers, finalizers):
+		*state = terminatingState{
+			finalizers: finalizers,
+			since:      now,
+		}
+	}
+	return state
+}
+
+// removeFinalizers removes finalizers from a stuck object and reports the
+// removal. The patch is conditional on the resourceVersion of the object. If
+// the cached object is stale, the patch is retried with the live object.
+func (w *WaitTask) removeFinalizers(ctx context.Context, taskContext *TaskContext, r finalizerRemoval) {
+	obj := r.obj
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		err := w.patchFinalizers(ctx, r.id, keepFinalizers(obj, r.remove), obj.GetResourceVersion())
+		if !apierrors.IsConflict(err) {
+			return err
+		}
+		live, getErr := w.getObject(ctx, r.id)
+		if getErr != nil {
+			return getErr
+		}
+		obj = live
+		return err
+	})
+	if apierrors.IsNotFound(err) {
+		klog.V(4).Infof("object deleted before finalizers were removed (object: %q)", r.id)
+		return
+	}
+	if err != nil {
+		klog.Errorf("Failed to remove finalizers (object: %q, finalizers: %v): %v", r.id, r.remove, err)
+		return
+	}
+	klog.V(4).Infof("removed finalizers (object: %q, finalizers: %v)", r.id, r.remove)
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if !w.pending.Contains(r.id) {
+		// deleted in the meantime
+		return
+	}
+	e := w.newEvent(taskContext, r.id, event.ReconcilePending)
+	e.StuckFor = r.stuckFor
+	e.RemovedFinalizers = r.remove
+	w.send(taskContext, e)
+}
+
+// keepFinalizers returns the finalizers of the object, which are not removed.
+func keepFinalizers(obj *unstructured.Unstructured, remove []string) []string {
+	removeSet := sets.New(remove...)
+	var keep []string
+	for _, finalizer := range obj.GetFinalizers() {
+		if !removeSet.Has(finalizer) {
+			keep = append(keep, finalizer)
+		}
+	}
+	return keep
+}
+
+func (w *WaitTask) getObject(ctx context.Context, id object.ObjMetadata) (*unstructured.Unstructured, error) {
+	mapping, err := w.Mapper.RESTMapping(id.GroupKind)
+	if err != nil {
+		return nil, err
+	}
+	return w.DynamicClient.Resource(mapping.Resource).Namespace(id.Namespace).
+		Get(ctx, id.Name, metav1.GetOptions{})
+}
+
+func (w *WaitTask) patchFinalizers(ctx context.Context, id object.ObjMetadata, finalizers []string, resourceVersion string) error {
+	mapping, err := w.Mapper.RESTMapping(id.GroupKind)
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"finalizers":      finalizers,
+			"resourceVersion": resourceVersion,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.DynamicClient.Resource(mapping.Resource).Namespace(id.Namespace).
+		Patch(ctx, id.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// stuckFinalizers adds the finalizers of the object to the event, if the
+// object has been reported as stuck terminating.
+// Must be called with the pending set locked.
+func (w *WaitTask) stuckFinalizers(e *event.WaitEvent) {
+	state, found := w.terminating[e.Identifier]
+	if !found || !state.reported {
+		return
+	}
+	e.Finalizers = state.finalizers
+	e.StuckFor = time.Since(state.since)
+}
+
+func intersectStrings(a, b []string) []string {
+	bSet := sets.New(b...)
+	var result []string
+	for _, s := range a {
+		if bSet.Has(s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
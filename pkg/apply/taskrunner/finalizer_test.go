@@ -0,0 +1,164 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package taskrunner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/cli-utils/pkg/apply/cache"
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/common"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/status"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/cli-utils/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestWaitTask_StuckFinalizers(t *testing.T) {
+	deploymentsGVR := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	deleted := metav1.NewTime(time.Now().Add(-time.Minute))
+
+	testCases := map[string]struct {
+		finalizers                common.FinalizerOptions
+		staleCache                bool
+		expectedStuck             []string
+		expectedRemoved           []string
+		expectedFinalizers        []string
+		expectedTimeoutFinalizers []string
+	}{
+		"disabled": {
+			expectedFinalizers: []string{"example.com/a", "example.com/b"},
+		},
+		"stuck below threshold": {
+			finalizers: common.FinalizerOptions{
+				StuckThreshold: time.Hour,
+			},
+			expectedFinalizers: []string{"example.com/a", "example.com/b"},
+		},
+		"stuck reported": {
+			finalizers: common.FinalizerOptions{
+				StuckThreshold: 30 * time.Second,
+			},
+			expectedStuck:             []string{"example.com/a", "example.com/b"},
+			expectedFinalizers:        []string{"example.com/a", "example.com/b"},
+			expectedTimeoutFinalizers: []string{"example.com/a", "example.com/b"},
+		},
+		"stuck finalizers removed": {
+			finalizers: common.FinalizerOptions{
+				StuckThreshold:    30 * time.Second,
+				Remove:            []string{"example.com/a", "example.com/c"},
+				RemoveGracePeriod: 30 * time.Second,
+			},
+			expectedStuck:             []string{"example.com/a", "example.com/b"},
+			expectedRemoved:           []string{"example.com/a"},
+			expectedFinalizers:        []string{"example.com/b"},
+			expectedTimeoutFinalizers: []string{"example.com/a", "example.com/b"},
+		},
+		"stuck finalizers removed from stale cached object": {
+			finalizers: common.FinalizerOptions{
+				Remove:            []string{"example.com/a"},
+				RemoveGracePeriod: 30 * time.Second,
+			},
+			staleCache:         true,
+			expectedRemoved:    []string{"example.com/a"},
+			expectedFinalizers: []string{"example.com/b"},
+		},
+		"removal within grace period": {
+			finalizers: common.FinalizerOptions{
+				Remove:            []string{"example.com/a"},
+				RemoveGracePeriod: time.Hour,
+			},
+			expectedFinalizers: []string{"example.com/a", "example.com/b"},
+		},
+		"removal within default grace period": {
+			finalizers: common.FinalizerOptions{
+				Remove: []string{"example.com/a"},
+			},
+			expectedFinalizers: []string{"example.com/a", "example.com/b"},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			oldInterval := finalizerCheckInterval
+			finalizerCheckInterval = 10 * time.Millisecond
+			defer func() { finalizerCheckInterval = oldInterval }()
+
+			deployment := testutil.Unstructured(t, testDeployment1YAML)
+			deployment.SetResourceVersion("1")
+			deployment.SetDeletionTimestamp(&deleted)
+			deployment.SetFinalizers([]string{"example.com/a", "example.com/b"})
+			id := object.UnstructuredToObjMetadata(deployment)
+
+			live := deployment.DeepCopy()
+			if tc.staleCache {
+				live.SetResourceVersion("2")
+			}
+			liveVersion := live.GetResourceVersion()
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), live)
+			// The fake client does not check the resourceVersion of patches.
+			dynamicClient.PrependReactor("patch", "deployments", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				var patch unstructured.Unstructured
+				require.NoError(t, json.Unmarshal(action.(clienttesting.PatchAction).GetPatch(), &patch.Object))
+				if patch.GetResourceVersion() != liveVersion {
+					return true, nil, apierrors.NewConflict(deploymentsGVR.GroupResource(), "a", errors.New("object has been modified"))
+				}
+				return false, nil, nil
+			})
+
+			task := NewWaitTask("wait-0", object.ObjMetadataSet{id}, AllNotFound, 500*time.Millisecond,
+				testutil.NewFakeRESTMapper(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}))
+			task.Finalizers = tc.finalizers
+			task.DynamicClient = dynamicClient
+
+			eventChannel := make(chan event.Event, 10)
+			resourceCache := cache.NewResourceCacheMap()
+			taskContext := NewTaskContext(context.TODO(), eventChannel, resourceCache)
+			defer close(eventChannel)
+
+			taskContext.InventoryManager().AddSuccessfulDelete(id, deployment.GetUID())
+			resourceCache.Put(id, cache.ResourceStatus{
+				Resource: deployment,
+				Status:   status.TerminatingStatus,
+			})
+
+			task.Start(taskContext)
+			<-taskContext.TaskChannel()
+
+			var stuck, removed, timeoutFinalizers []string
+			for len(eventChannel) > 0 {
+				e := <-eventChannel
+				switch {
+				case e.WaitEvent.Status == event.ReconcileTimeout:
+					timeoutFinalizers = e.WaitEvent.Finalizers
+				case len(e.WaitEvent.RemovedFinalizers) > 0:
+					removed = e.WaitEvent.RemovedFinalizers
+				case len(e.WaitEvent.Finalizers) > 0:
+					stuck = e.WaitEvent.Finalizers
+					assert.GreaterOrEqual(t, e.WaitEvent.StuckFor, time.Minute)
+				}
+			}
+			assert.Equal(t, tc.expectedStuck, stuck)
+			assert.Equal(t, tc.expectedRemoved, removed)
+			assert.Equal(t, tc.expectedTimeoutFinalizers, timeoutFinalizers)
+
+			live, err := dynamicClient.Resource(deploymentsGVR).Namespace("default").
+				Get(context.TODO(), "a", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedFinalizers, live.GetFinalizers())
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package taskrunner
+
+import "fmt"
+
+// FailurePolicy defines how the TaskStatusRunner responds to objects that
+// failed to be applied or deleted, failed to reconcile, or timed out
+// reconciling.
+//
+//go:generate stringer -type=FailurePolicy
+type FailurePolicy int
+
+const (
+	// ContinueOnError policy runs all the tasks, regardless of failures.
+	// Only the dependents of failed objects are skipped.
+	ContinueOnError FailurePolicy = iota
+
+	// StopOnFirstFailure policy stops actuating after the task in which an
+	// object failed. The remaining tasks are skipped, except the inventory
+	// tasks.
+	StopOnFirstFailure
+
+	// StopAfterPhase policy stops actuating after the phase in which an
+	// object failed, once its wait task completes. The remaining tasks are
+	// skipped, except the inventory tasks.
+	StopAfterPhase
+)
+
+// FailurePolicyError is returned by the TaskStatusRunner when actuation was
+// stopped because of the FailurePolicy.
+type FailurePolicyError struct {
+	Policy FailurePolicy
+	// Failures is the number of objects that failed to be applied, deleted
+	// or reconciled, at the time actuation was stopped.
+	Failures int
+}
+
+func (fpe *FailurePolicyError) Error() string {
+	return fmt.Sprintf("actuation stopped by failure policy %q: %d object(s) failed",
+		fpe.Policy, fpe.Failures)
+}
+
+func (fpe *FailurePolicyError) Is(err error) bool {
+	if err == nil {
+		return false
+	}
+	tErr, ok := err.(*FailurePolicyError)
+	if !ok {
+		return false
+	}
+	return fpe.Policy == tErr.Policy && fpe.Failures == tErr.Failures
+}
+
+// BatchHaltedError is the cause the TaskContext is stopped with, when more
+// objects of a batch failed to apply or reconcile than the failure threshold
+// of its WaitTask tolerates.
+type BatchHaltedError struct {
+	// Batch is the name of the WaitTask of the batch.
+	Batch string
+	// Failures is the number of objects in the batch that failed to apply,
+	// failed to reconcile, or timed out reconciling.
+	Failures int
+	// Threshold is the number of failed objects tolerated.
+	Threshold int
+}
+
+func (bhe *BatchHaltedError) Error() string {
+	return fmt.Sprintf("actuation halted after batch %q: %d object(s) failed, exceeding the failure threshold of %d",
+		bhe.Batch, bhe.Failures, bhe.Threshold)
+}
+
+func (bhe *BatchHaltedError) Is(err error) bool {
+	if err == nil {
+		return false
+	}
+	tErr, ok := err.(*BatchHaltedError)
+	if !ok {
+		return false
+	}
+	return bhe.Batch == tErr.Batch && bhe.Failures == tErr.Failures &&
+		bhe.Threshold == tErr.Threshold
+}
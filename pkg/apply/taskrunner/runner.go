@@ -38,6 +38,9 @@ type Options struct {
 	// RESTScopeStrategy specifies which strategy to use when listing and
 	// watching resources. By default, the strategy is selected automatically.
 	WatcherRESTScopeStrategy watcher.RESTScopeStrategy
+	// FailurePolicy specifies whether to stop actuating after objects fail
+	// to be applied, deleted or reconciled. By default, all tasks are run.
+	FailurePolicy FailurePolicy
 }
 
 // Run executes the tasks in the taskqueue, with the statusPoller running in the
@@ -90,6 +93,26 @@ func (tsr *TaskStatusRunner) Run(
 	abort := false
 	var abortReason error
 
+	// cancelled is used to signal that the passed in context has been
+	// cancelled. Unlike other aborts, the remaining tasks are still
+	// started, so they can register their objects as skipped and the
+	// inventory can be updated to reflect what was actually actuated.
+	// Tasks are expected to stop actuating once the TaskContext's
+	// context is done. Like after a stop, their action groups are reported
+	// as Skipped.
+	cancelled := false
+
+	// stopped is used to signal that the FailurePolicy has been triggered,
+	// or that a task stopped the TaskContext. Like cancellation, the
+	// remaining tasks are still started, but the TaskContext is stopped, so
+	// they skip actuation. Their action groups are reported as Skipped,
+	// except for the inventory and rollback tasks.
+	stopped := false
+
+	// skipped is true, if the action group of the current task was reported
+	// as Skipped. Skipped is the only status sent for such a group.
+	skipped := false
+
 	// We do this so we can set the doneCh to a nil channel after
 	// it has been closed. This is needed to avoid a busy loop.
 	doneCh := ctx.Done()
@@ -137,7 +160,7 @@ func (tsr *TaskStatusRunner) Run(
 			// Tasks may commence!
 			if statusEvent.Type == pollevent.SyncEvent {
 				// Find and start the first task in the queue.
-				currentTask, done = nextTask(taskQueue, taskContext)
+				currentTask, skipped, done = nextTask(taskQueue, taskContext, false)
 				if done {
 					return complete(nil)
 				}
@@ -183,35 +206,63 @@ func (tsr *TaskStatusRunner) Run(
 		// finish, we exit.
 		// If everything is ok, we fetch and start the next task.
 		case msg := <-taskContext.TaskChannel():
-			taskContext.SendEvent(event.Event{
-				Type: event.ActionGroupType,
-				ActionGroupEvent: event.ActionGroupEvent{
-					GroupName: currentTask.Name(),
-					Action:    currentTask.Action(),
-					Status:    event.Finished,
-				},
-			})
+			if !skipped {
+				taskContext.SendEvent(event.Event{
+					Type: event.ActionGroupType,
+					ActionGroupEvent: event.ActionGroupEvent{
+						GroupName: currentTask.Name(),
+						Action:    currentTask.Action(),
+						Status:    event.Finished,
+					},
+				})
+			}
 			if msg.Err != nil {
 				return complete(
 					fmt.Errorf("task failed (action: %q, name: %q): %w",
 						currentTask.Action(), currentTask.Name(), msg.Err))
 			}
-			if abort {
+			if abort && !cancelled {
 				return complete(abortReason)
 			}
-			currentTask, done = nextTask(taskQueue, taskContext)
+			if !abort && !stopped && shouldStop(opts.FailurePolicy, currentTask) {
+				if failures := countFailures(taskContext); failures > 0 {
+					stopped = true
+					abortReason = &FailurePolicyError{
+						Policy:   opts.FailurePolicy,
+						Failures: failures,
+					}
+					klog.V(4).Infof("Runner stopping: %v", abortReason)
+					taskContext.Stop(abortReason)
+				}
+			}
+			if !abort && !stopped && ctx.Err() == nil && taskContext.Context().Err() != nil {
+				// The task stopped the TaskContext, e.g. to halt after a
+				// failed batch or a rollback.
+				stopped = true
+				abortReason = context.Cause(taskContext.Context())
+				klog.V(4).Infof("Runner stopping: %v", abortReason)
+			}
+			currentTask, skipped, done = nextTask(taskQueue, taskContext, stopped || cancelled)
 			// If there are no more tasks, we are done. So just
 			// return.
 			if done {
-				return complete(nil)
+				return complete(abortReason)
 			}
 		// The doneCh will be closed if the passed in context is cancelled.
-		// If so, we just set the abort flag and wait for the currently running
-		// task to complete before we exit.
+		// If so, we set the abort flag and cancel the currently running
+		// task. The remaining tasks are still started, but they will skip
+		// actuation, so that the final inventory task can record the
+		// outcome before we exit.
 		case <-doneCh:
 			doneCh = nil // Set doneCh to nil so we don't enter a busy loop.
-			abort = true
-			abortReason = ctx.Err() // always non-nil when doneCh is closed
+			if !abort {
+				abort = true
+				cancelled = true
+				// Don't mask a previous stop reason
+				if abortReason == nil {
+					abortReason = ctx.Err() // always non-nil when doneCh is closed
+				}
+			}
 			klog.V(7).Infof("Runner aborting: %v", abortReason)
 			if currentTask != nil {
 				currentTask.Cancel(taskContext)
@@ -223,10 +274,37 @@ func (tsr *TaskStatusRunner) Run(
 	}
 }
 
+// shouldStop returns true if the FailurePolicy requires actuation to stop
+// after the completed task, if any objects have failed.
+func shouldStop(policy FailurePolicy, completedTask Task) bool {
+	switch policy {
+	case StopOnFirstFailure:
+		return true
+	case StopAfterPhase:
+		// A phase ends with the wait task for the actuated objects.
+		return completedTask.Action() == event.WaitAction
+	default:
+		return false
+	}
+}
+
+// countFailures returns the number of objects that failed to be applied or
+// deleted, failed to reconcile, or timed out reconciling.
+func countFailures(taskContext *TaskContext) int {
+	im := taskContext.InventoryManager()
+	return len(im.FailedApplies().
+		Union(im.FailedDeletes()).
+		Union(im.FailedReconciles()).
+		Union(im.TimeoutReconciles()))
+}
+
 // nextTask fetches the latest task from the taskQueue and
-// starts it. If the taskQueue is empty, it the second
-// return value will be true.
-func nextTask(taskQueue chan Task, taskContext *TaskContext) (Task, bool) {
+// starts it. If the taskQueue is empty, it the last
+// return value will be true. If stopped is true, the action group of the
+// task is reported as Skipped instead of Started, unless it is an inventory
+// or rollback task, which still run after a failure. The second return
+// value is true, if the action group was reported as Skipped.
+func nextTask(taskQueue chan Task, taskContext *TaskContext, stopped bool) (Task, bool, bool) {
 	var tsk Task
 	select {
 	// If there is any tasks left in the queue, this
@@ -235,21 +313,26 @@ func nextTask(taskQueue chan Task, taskContext *TaskContext) (Task, bool) {
 		tsk = t
 	default:
 		// Only happens when the channel is empty.
-		return nil, true
+		return nil, false, true
 	}
 
+	skipped := stopped && tsk.Action() != event.InventoryAction && tsk.Action() != event.RollbackAction
+	status := event.Started
+	if skipped {
+		status = event.Skipped
+	}
 	taskContext.SendEvent(event.Event{
 		Type: event.ActionGroupType,
 		ActionGroupEvent: event.ActionGroupEvent{
 			GroupName: tsk.Name(),
 			Action:    tsk.Action(),
-			Status:    event.Started,
+			Status:    status,
 		},
 	})
 
 	tsk.Start(taskContext)
 
-	return tsk, false
+	return tsk, skipped, false
 }
 
 // TaskResult is the type returned from tasks once they have completed
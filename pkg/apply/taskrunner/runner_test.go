@@ -280,7 +280,7 @@ func TestBaseRunner(t *testing.T) {
 			statusWatcher := newFakeWatcher(tc.statusEvents)
 			eventChannel := make(chan event.Event)
 			resourceCache := cache.NewResourceCacheMap()
-			taskContext := NewTaskContext(eventChannel, resourceCache)
+			taskContext := NewTaskContext(context.TODO(), eventChannel, resourceCache)
 			runner := NewTaskStatusRunner(ids, statusWatcher)
 
 			// Use a WaitGroup to make sure changes in the goroutines
@@ -366,6 +366,10 @@ func TestBaseRunnerCancellation(t *testing.T) {
 				event.ActionGroupType,
 				event.ApplyType,
 				event.ActionGroupType,
+				// remaining tasks are still started after cancellation,
+				// but their groups are only reported as skipped
+				event.ActionGroupType,
+				event.PruneType,
 			},
 		},
 		"cancellation while wait task is running": {
@@ -384,7 +388,12 @@ func TestBaseRunnerCancellation(t *testing.T) {
 			expectedEventTypes: []event.Type{
 				event.ActionGroupType,
 				event.WaitType, // pending
+				event.WaitType, // skipped
+				event.ActionGroupType,
+				// remaining tasks are still started after cancellation,
+				// but their groups are only reported as skipped
 				event.ActionGroupType,
+				event.PruneType,
 			},
 		},
 		"error while custom task is running": {
@@ -450,9 +459,12 @@ func TestBaseRunnerCancellation(t *testing.T) {
 
 			ids := object.ObjMetadataSet{} // unused by fake statusWatcher
 			statusWatcher := newFakeWatcher(tc.statusEvents)
+			ctx, cancel := context.WithTimeout(context.Background(), tc.contextTimeout)
+			defer cancel()
+
 			eventChannel := make(chan event.Event)
 			resourceCache := cache.NewResourceCacheMap()
-			taskContext := NewTaskContext(eventChannel, resourceCache)
+			taskContext := NewTaskContext(ctx, eventChannel, resourceCache)
 			runner := NewTaskStatusRunner(ids, statusWatcher)
 
 			// Use a WaitGroup to make sure changes in the goroutines
@@ -478,9 +490,6 @@ func TestBaseRunnerCancellation(t *testing.T) {
 				}
 			}()
 
-			ctx, cancel := context.WithTimeout(context.Background(), tc.contextTimeout)
-			defer cancel()
-
 			opts := Options{EmitStatusEvents: true}
 			err := runner.Run(ctx, taskContext, taskQueue, opts)
 			close(statusChannel)
@@ -507,11 +516,332 @@ func TestBaseRunnerCancellation(t *testing.T) {
 	}
 }
 
+func TestBaseRunnerFailurePolicy(t *testing.T) {
+	newTasks := func(haltOnFailure bool) []Task {
+		waitTask := NewWaitTask("wait-0", object.ObjMetadataSet{depID}, AllCurrent,
+			1*time.Minute, testutil.NewFakeRESTMapper())
+		waitTask.HaltOnFailure = haltOnFailure
+		return []Task{
+			&fakeApplyTask{
+				name:        "apply-0",
+				resultEvent: event.Event{Type: event.ApplyType},
+				failedIDs:   object.ObjMetadataSet{depID},
+			},
+			waitTask,
+			&fakeApplyTask{
+				name:        "apply-1",
+				resultEvent: event.Event{Type: event.ApplyType},
+			},
+			NewWaitTask("wait-1", object.ObjMetadataSet{}, AllCurrent,
+				1*time.Minute, testutil.NewFakeRESTMapper()),
+			&fakeApplyTask{
+				name:        "inventory-set-0",
+				action:      event.InventoryAction,
+				resultEvent: event.Event{Type: event.ApplyType},
+			},
+		}
+	}
+
+	testCases := map[string]struct {
+		policy                FailurePolicy
+		haltOnFailure         bool
+		expectedError         error
+		expectedGroupStatuses []event.ActionGroupEventStatus
+	}{
+		"continue on error runs all tasks": {
+			policy: ContinueOnError,
+			expectedGroupStatuses: []event.ActionGroupEventStatus{
+				event.Started, event.Finished, // apply-0
+				event.Started, event.Finished, // wait-0
+				event.Started, event.Finished, // apply-1
+				event.Started, event.Finished, // wait-1
+				event.Started, event.Finished, // inventory-set-0
+			},
+		},
+		"stop on first failure skips the remaining tasks": {
+			policy: StopOnFirstFailure,
+			expectedError: &FailurePolicyError{
+				Policy:   StopOnFirstFailure,
+				Failures: 1,
+			},
+			expectedGroupStatuses: []event.ActionGroupEventStatus{
+				event.Started, event.Finished, // apply-0
+				event.Skipped,                 // wait-0
+				event.Skipped,                 // apply-1
+				event.Skipped,                 // wait-1
+				event.Started, event.Finished, // inventory-set-0
+			},
+		},
+		"stop after phase skips the tasks after the wait task": {
+			policy: StopAfterPhase,
+			expectedError: &FailurePolicyError{
+				Policy:   StopAfterPhase,
+				Failures: 1,
+			},
+			expectedGroupStatuses: []event.ActionGroupEventStatus{
+				event.Started, event.Finished, // apply-0
+				event.Started, event.Finished, // wait-0
+				event.Skipped,                 // apply-1
+				event.Skipped,                 // wait-1
+				event.Started, event.Finished, // inventory-set-0
+			},
+		},
+		"batch halted by wait task skips the remaining tasks": {
+			policy:        ContinueOnError,
+			haltOnFailure: true,
+			expectedError: &BatchHaltedError{
+				Batch:     "wait-0",
+				Failures:  1,
+				Threshold: 0,
+			},
+			expectedGroupStatuses: []event.ActionGroupEventStatus{
+				event.Started, event.Finished, // apply-0
+				event.Started, event.Finished, // wait-0
+				event.Skipped,                 // apply-1
+				event.Skipped,                 // wait-1
+				event.Started, event.Finished, // inventory-set-0
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tasks := newTasks(tc.haltOnFailure)
+			taskQueue := make(chan Task, len(tasks))
+			for _, tsk := range tasks {
+				taskQueue <- tsk
+			}
+
+			ids := object.ObjMetadataSet{} // unused by fake statusWatcher
+			statusWatcher := newFakeWatcher(nil)
+			statusWatcher.Start()
+			eventChannel := make(chan event.Event)
+			resourceCache := cache.NewResourceCacheMap()
+			taskContext := NewTaskContext(context.Background(), eventChannel, resourceCache)
+			runner := NewTaskStatusRunner(ids, statusWatcher)
+
+			var groupStatuses []event.ActionGroupEventStatus
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for msg := range eventChannel {
+					if msg.Type == event.ActionGroupType {
+						groupStatuses = append(groupStatuses, msg.ActionGroupEvent.Status)
+					}
+				}
+			}()
+
+			opts := Options{FailurePolicy: tc.policy}
+			err := runner.Run(context.Background(), taskContext, taskQueue, opts)
+			close(eventChannel)
+			wg.Wait()
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.expectedGroupStatuses, groupStatuses)
+		})
+	}
+}
+
+func TestBaseRunnerFailurePolicyReconcile(t *testing.T) {
+	testCases := map[string]struct {
+		waitTask *fakeApplyTask
+	}{
+		"failed reconcile": {
+			waitTask: &fakeApplyTask{
+				name:               "wait-0",
+				action:             event.WaitAction,
+				resultEvent:        event.Event{Type: event.WaitType},
+				failedReconcileIDs: object.ObjMetadataSet{depID},
+			},
+		},
+		"timeout reconcile": {
+			waitTask: &fakeApplyTask{
+				name:                "wait-0",
+				action:              event.WaitAction,
+				resultEvent:         event.Event{Type: event.WaitType},
+				timeoutReconcileIDs: object.ObjMetadataSet{depID},
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tasks := []Task{
+				&fakeApplyTask{
+					name:        "apply-0",
+					resultEvent: event.Event{Type: event.ApplyType},
+				},
+				tc.waitTask,
+				&fakeApplyTask{
+					name:        "apply-1",
+					resultEvent: event.Event{Type: event.ApplyType},
+				},
+				&fakeApplyTask{
+					name:        "inventory-set-0",
+					action:      event.InventoryAction,
+					resultEvent: event.Event{Type: event.ApplyType},
+				},
+			}
+			taskQueue := make(chan Task, len(tasks))
+			for _, tsk := range tasks {
+				taskQueue <- tsk
+			}
+
+			statusWatcher := newFakeWatcher(nil)
+			statusWatcher.Start()
+			eventChannel := make(chan event.Event)
+			resourceCache := cache.NewResourceCacheMap()
+			taskContext := NewTaskContext(context.Background(), eventChannel, resourceCache)
+			runner := NewTaskStatusRunner(object.ObjMetadataSet{}, statusWatcher)
+
+			var groupStatuses []event.ActionGroupEventStatus
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for msg := range eventChannel {
+					if msg.Type == event.ActionGroupType {
+						groupStatuses = append(groupStatuses, msg.ActionGroupEvent.Status)
+					}
+				}
+			}()
+
+			opts := Options{FailurePolicy: StopAfterPhase}
+			err := runner.Run(context.Background(), taskContext, taskQueue, opts)
+			close(eventChannel)
+			wg.Wait()
+
+			assert.ErrorIs(t, err, &FailurePolicyError{
+				Policy:   StopAfterPhase,
+				Failures: 1,
+			})
+			assert.Equal(t, []event.ActionGroupEventStatus{
+				event.Started, event.Finished, // apply-0
+				event.Started, event.Finished, // wait-0
+				event.Skipped,                 // apply-1
+				event.Started, event.Finished, // inventory-set-0
+			}, groupStatuses)
+		})
+	}
+}
+
+func TestBaseRunnerSkippedGroupEvents(t *testing.T) {
+	testCases := map[string]struct {
+		policy         FailurePolicy
+		contextTimeout time.Duration
+		expectedError  error
+	}{
+		"stopped by failure policy": {
+			policy: StopOnFirstFailure,
+			expectedError: &FailurePolicyError{
+				Policy:   StopOnFirstFailure,
+				Failures: 1,
+			},
+		},
+		"cancelled by caller": {
+			policy:         ContinueOnError,
+			contextTimeout: time.Second,
+			expectedError:  context.DeadlineExceeded,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			tasks := []Task{
+				&fakeApplyTask{
+					name:        "apply-0",
+					resultEvent: event.Event{Type: event.ApplyType},
+					duration:    2 * time.Second,
+					failedIDs:   object.ObjMetadataSet{depID},
+				},
+				&fakeApplyTask{
+					name:        "prune-0",
+					action:      event.PruneAction,
+					resultEvent: event.Event{Type: event.PruneType},
+				},
+				&fakeApplyTask{
+					name:        "inventory-set-0",
+					action:      event.InventoryAction,
+					resultEvent: event.Event{Type: event.ApplyType},
+				},
+			}
+			taskQueue := make(chan Task, len(tasks))
+			for _, tsk := range tasks {
+				taskQueue <- tsk
+			}
+
+			ctx := context.Background()
+			if tc.contextTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.contextTimeout)
+				defer cancel()
+			}
+
+			statusWatcher := newFakeWatcher(nil)
+			statusWatcher.Start()
+			eventChannel := make(chan event.Event)
+			resourceCache := cache.NewResourceCacheMap()
+			taskContext := NewTaskContext(ctx, eventChannel, resourceCache)
+			runner := NewTaskStatusRunner(object.ObjMetadataSet{}, statusWatcher)
+
+			var events []event.Event
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for msg := range eventChannel {
+					events = append(events, msg)
+				}
+			}()
+
+			opts := Options{FailurePolicy: tc.policy}
+			err := runner.Run(ctx, taskContext, taskQueue, opts)
+			close(eventChannel)
+			wg.Wait()
+
+			assert.ErrorIs(t, err, tc.expectedError)
+			groupEvent := func(name string, action event.ResourceAction, status event.ActionGroupEventStatus) event.Event {
+				return event.Event{
+					Type: event.ActionGroupType,
+					ActionGroupEvent: event.ActionGroupEvent{
+						GroupName: name,
+						Action:    action,
+						Status:    status,
+					},
+				}
+			}
+			assert.Equal(t, []event.Event{
+				groupEvent("apply-0", event.ApplyAction, event.Started),
+				{Type: event.ApplyType},
+				groupEvent("apply-0", event.ApplyAction, event.Finished),
+				// The skipped group is not started or finished.
+				groupEvent("prune-0", event.PruneAction, event.Skipped),
+				{Type: event.PruneType},
+				groupEvent("inventory-set-0", event.InventoryAction, event.Started),
+				{Type: event.ApplyType},
+				groupEvent("inventory-set-0", event.InventoryAction, event.Finished),
+			}, events)
+		})
+	}
+}
+
 type fakeApplyTask struct {
 	name        string
+	action      event.ResourceAction
 	resultEvent event.Event
 	duration    time.Duration
 	err         error
+	// failedIDs are recorded as failed applies when the task completes.
+	failedIDs object.ObjMetadataSet
+	// failedReconcileIDs and timeoutReconcileIDs are recorded as applied,
+	// and failed or timed out reconciling, when the task completes.
+	failedReconcileIDs  object.ObjMetadataSet
+	timeoutReconcileIDs object.ObjMetadataSet
 }
 
 func (f *fakeApplyTask) Name() string {
@@ -519,7 +849,7 @@ func (f *fakeApplyTask) Name() string {
 }
 
 func (f *fakeApplyTask) Action() event.ResourceAction {
-	return event.ApplyAction
+	return f.action // defaults to ApplyAction
 }
 
 func (f *fakeApplyTask) Identifiers() object.ObjMetadataSet {
@@ -530,6 +860,18 @@ func (f *fakeApplyTask) Start(taskContext *TaskContext) {
 	go func() {
 		<-time.NewTimer(f.duration).C
 		taskContext.SendEvent(f.resultEvent)
+		im := taskContext.InventoryManager()
+		for _, id := range f.failedIDs {
+			im.AddFailedApply(id)
+		}
+		for _, id := range f.failedReconcileIDs {
+			im.AddSuccessfulApply(id, "", 0)
+			_ = im.SetFailedReconcile(id)
+		}
+		for _, id := range f.timeoutReconcileIDs {
+			im.AddSuccessfulApply(id, "", 0)
+			_ = im.SetTimeoutReconcile(id)
+		}
 		taskContext.TaskChannel() <- TaskResult{
 			Err: f.err,
 		}
@@ -10,11 +10,14 @@ import (
 	"time"
 
 	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/common"
 	"github.com/fluxcd/cli-utils/pkg/kstatus/status"
 	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/cli-utils/pkg/object/waitfor"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/klog/v2"
 )
 
@@ -59,11 +62,28 @@ type WaitTask struct {
 	Ids object.ObjMetadataSet
 	// Condition defines the status we want all resources to reach
 	Condition Condition
+	// Conditions overrides Condition for individual resources.
+	Conditions map[object.ObjMetadata]waitfor.Condition
 	// Timeout defines how long we are willing to wait for the condition
 	// to be met.
 	Timeout time.Duration
+	// Timeouts overrides Timeout for individual resources. Resources time
+	// out individually, while the task keeps waiting for the others.
+	Timeouts map[object.ObjMetadata]time.Duration
 	// Mapper is the RESTMapper to update after CRDs have been reconciled
 	Mapper meta.RESTMapper
+	// HaltOnFailure stops the TaskContext after the wait, if more than
+	// FailureThreshold of the objects failed to apply, failed to reconcile,
+	// or timed out reconciling. Used to gate batches of objects.
+	HaltOnFailure bool
+	// FailureThreshold is the number of failed objects tolerated, if
+	// HaltOnFailure is true.
+	FailureThreshold int
+	// Finalizers configures the detection and removal of stuck finalizers,
+	// while waiting for objects to be deleted.
+	Finalizers common.FinalizerOptions
+	// DynamicClient is used to remove stuck finalizers.
+	DynamicClient dynamic.Interface
 	// cancelFunc is a function that will cancel the timeout timer
 	// on the task.
 	cancelFunc context.CancelFunc
@@ -72,8 +92,24 @@ type WaitTask struct {
 	// failed is the set of resources that we are waiting for, but is considered
 	// failed, i.e. unlikely to successfully reconcile.
 	failed object.ObjMetadataSet
+	// terminating tracks the finalizers of the pending objects that are
+	// being deleted.
+	terminating map[object.ObjMetadata]*terminatingState
+	// timedOut is the set of resources that timed out individually.
+	timedOut object.ObjMetadataSet
+	// timers time out resources individually.
+	timers map[object.ObjMetadata]*objectTimer
+	// timersStopped is true, once the task stopped timing out resources
+	// individually.
+	timersStopped bool
 	// mu protects the pending ObjMetadataSet
 	mu sync.RWMutex
+	// ended is the set of resources whose reconcile timing has ended, so
+	// objects that reconcile more than once are only timed once.
+	ended object.ObjMetadataSet
+	// endedMu protects the ended ObjMetadataSet, which is also updated
+	// while the pending set is only read locked.
+	endedMu sync.Mutex
 }
 
 func (w *WaitTask) Name() string {
@@ -94,17 +130,28 @@ func (w *WaitTask) Start(taskContext *TaskContext) {
 	klog.V(2).Infof("wait task starting (name: %q, objects: %d)",
 		w.Name(), len(w.Ids))
 
-	// TODO: inherit context from task runner, passed through the TaskContext
-	ctx := context.Background()
+	ctx := taskContext.Context()
 
 	// use a context wrapper to handle complete/cancel/timeout
-	if w.Timeout > 0 {
-		ctx, w.cancelFunc = context.WithTimeout(ctx, w.Timeout)
+	taskTimeout := w.taskTimeout()
+	if taskTimeout > 0 {
+		ctx, w.cancelFunc = context.WithTimeout(ctx, taskTimeout)
 	} else {
 		ctx, w.cancelFunc = context.WithCancel(ctx)
 	}
 
 	w.startInner(taskContext)
+	w.startObjectTimers(taskContext, taskTimeout)
+
+	// A goroutine to check for stuck finalizers, until the WaitTask ends.
+	var finalizersWG sync.WaitGroup
+	if w.Condition == AllNotFound && w.Finalizers.Enabled() {
+		finalizersWG.Add(1)
+		go func() {
+			defer finalizersWG.Done()
+			w.watchFinalizers(ctx, taskContext)
+		}()
+	}
 
 	// A goroutine to handle ending the WaitTask.
 	go func() {
@@ -112,13 +159,20 @@ func (w *WaitTask) Start(taskContext *TaskContext) {
 		<-ctx.Done()
 		// Err is always non-nil when Done channel is closed.
 		err := ctx.Err()
+		// Wait for the last stuck finalizer events
+		finalizersWG.Wait()
+		w.stopObjectTimers()
 
 		klog.V(2).Infof("wait task completing (name: %q,): %v", w.TaskName, err)
 
-		switch err {
-		case context.Canceled:
+		switch {
+		case taskContext.Context().Err() != nil:
+			// caller cancelled or runner stopped - stop waiting on the
+			// remaining objects
+			w.sendSkippedEvents(taskContext)
+		case err == context.Canceled:
 			// happy path - cancelled or completed (not considered an error)
-		case context.DeadlineExceeded:
+		case err == context.DeadlineExceeded:
 			// timed out
 			w.sendTimeoutEvents(taskContext)
 		}
@@ -126,22 +180,65 @@ func (w *WaitTask) Start(taskContext *TaskContext) {
 		// Update RESTMapper to pick up new custom resource types
 		w.updateRESTMapper(taskContext)
 
+		// Halt if too many objects failed
+		if w.HaltOnFailure && taskContext.Context().Err() == nil {
+			w.gate(taskContext)
+		}
+
 		// Done here. signal completion to the task runner
 		taskContext.TaskChannel() <- TaskResult{}
 	}()
 }
 
 func (w *WaitTask) sendEvent(taskContext *TaskContext, id object.ObjMetadata, status event.WaitEventStatus) {
+	w.send(taskContext, w.newEvent(taskContext, id, status))
+}
+
+func (w *WaitTask) send(taskContext *TaskContext, e event.WaitEvent) {
 	taskContext.SendEvent(event.Event{
-		Type: event.WaitType,
-		WaitEvent: event.WaitEvent{
-			GroupName:  w.Name(),
-			Identifier: id,
-			Status:     status,
-		},
+		Type:      event.WaitType,
+		WaitEvent: e,
 	})
 }
 
+func (w *WaitTask) newEvent(taskContext *TaskContext, id object.ObjMetadata, status event.WaitEventStatus) event.WaitEvent {
+	var condition string
+	if cond, found := w.Conditions[id]; found {
+		condition = cond.String()
+	}
+	var timing event.Timing
+	if actuated, found := taskContext.ActuationTime(id); found {
+		timing.Start = actuated
+		if w.endTiming(id, status) {
+			timing.End = time.Now()
+		}
+	}
+	return event.WaitEvent{
+		GroupName:  w.Name(),
+		Identifier: id,
+		Status:     status,
+		Condition:  condition,
+		Timing:     timing,
+	}
+}
+
+// endTiming returns true, if the status ends the reconcile timing of the
+// object. Only the first successful, failed or timed out status ends it.
+func (w *WaitTask) endTiming(id object.ObjMetadata, status event.WaitEventStatus) bool {
+	switch status {
+	case event.ReconcileSuccessful, event.ReconcileFailed, event.ReconcileTimeout:
+	default:
+		return false
+	}
+	w.endedMu.Lock()
+	defer w.endedMu.Unlock()
+	if w.ended.Contains(id) {
+		return false
+	}
+	w.ended = append(w.ended, id)
+	return true
+}
+
 // startInner sends initial pending, skipped, an reconciled events.
 // If all objects are reconciled or skipped, cancelFunc is called.
 // The pending set is write locked during execution of startInner.
@@ -192,6 +289,27 @@ func (w *WaitTask) startInner(taskContext *TaskContext) {
 	}
 }
 
+// gate stops the TaskContext, if more objects failed to apply, failed to
+// reconcile, or timed out reconciling than the FailureThreshold.
+func (w *WaitTask) gate(taskContext *TaskContext) {
+	im := taskContext.InventoryManager()
+	failures := 0
+	for _, id := range w.Ids {
+		if im.IsFailedApply(id) || im.IsFailedReconcile(id) || im.IsTimeoutReconcile(id) {
+			failures++
+		}
+	}
+	if failures > w.FailureThreshold {
+		err := &BatchHaltedError{
+			Batch:     w.Name(),
+			Failures:  failures,
+			Threshold: w.FailureThreshold,
+		}
+		klog.V(4).Infof("wait task halting (name: %q): %v", w.Name(), err)
+		taskContext.Stop(err)
+	}
+}
+
 // sendTimeoutEvents sends a timeout event for every remaining pending object
 // The pending set is read locked during execution of sendTimeoutEvents.
 func (w *WaitTask) sendTimeoutEvents(taskContext *TaskContext) {
@@ -204,13 +322,34 @@ func (w *WaitTask) sendTimeoutEvents(taskContext *TaskContext) {
 			// Object never applied or deleted!
 			klog.Errorf("Failed to mark object as pending reconcile: %v", err)
 		}
-		w.sendEvent(taskContext, id, event.ReconcileTimeout)
+		e := w.newEvent(taskContext, id, event.ReconcileTimeout)
+		w.stuckFinalizers(&e)
+		w.send(taskContext, e)
+	}
+}
+
+// sendSkippedEvents sends a skipped event for every remaining pending object.
+// The pending set is read locked during execution of sendSkippedEvents.
+func (w *WaitTask) sendSkippedEvents(taskContext *TaskContext) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	for _, id := range w.pending {
+		err := taskContext.InventoryManager().SetSkippedReconcile(id)
+		if err != nil {
+			// Object never applied or deleted!
+			klog.Errorf("Failed to mark object as skipped reconcile: %v", err)
+		}
+		w.sendEvent(taskContext, id, event.ReconcileSkipped)
 	}
 }
 
 // reconciledByID checks whether the condition set in the task is currently met
 // for the specified object given the status of resource in the cache.
 func (w *WaitTask) reconciledByID(taskContext *TaskContext, id object.ObjMetadata) bool {
+	if cond, found := w.Conditions[id]; found {
+		return objectConditionMet(taskContext, id, cond)
+	}
 	return conditionMet(taskContext, object.ObjMetadataSet{id}, w.Condition)
 }
 
@@ -357,6 +496,21 @@ func (w *WaitTask) StatusUpdate(taskContext *TaskContext, id object.ObjMetadata)
 	case w.skipped(taskContext, id):
 		// skipped - ignore
 		return
+	case w.timedOut.Contains(id):
+		// If a timed out resource becomes reconciled before other
+		// resources have completed/timed out, we consider it
+		// reconciled.
+		if w.reconciledByID(taskContext, id) {
+			err := taskContext.InventoryManager().SetSuccessfulReconcile(id)
+			if err != nil {
+				// Object never applied or deleted!
+				klog.Errorf("Failed to mark object as successful reconcile: %v", err)
+			}
+			w.timedOut = w.timedOut.Remove(id)
+			w.sendEvent(taskContext, id, event.ReconcileSuccessful)
+		}
+		// else - still timed out
+		return
 	case w.failed.Contains(id):
 		// If a failed resource becomes current before other
 		// resources have completed/timed out, we consider it
@@ -380,6 +534,7 @@ func (w *WaitTask) StatusUpdate(taskContext *TaskContext, id object.ObjMetadata)
 			}
 			w.failed = w.failed.Remove(id)
 			w.pending = append(w.pending, id)
+			w.startObjectTimer(taskContext, id, w.taskTimeout())
 			w.sendEvent(taskContext, id, event.ReconcilePending)
 		}
 		// else - still failed
@@ -393,6 +548,7 @@ func (w *WaitTask) StatusUpdate(taskContext *TaskContext, id object.ObjMetadata)
 				klog.Errorf("Failed to mark object as pending reconcile: %v", err)
 			}
 			w.pending = append(w.pending, id)
+			w.startObjectTimer(taskContext, id, w.taskTimeout())
 			w.sendEvent(taskContext, id, event.ReconcilePending)
 		}
 		// else - still reconciled
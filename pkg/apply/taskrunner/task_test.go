@@ -4,6 +4,7 @@
 package taskrunner
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -13,8 +14,10 @@ import (
 	"github.com/fluxcd/cli-utils/pkg/inventory"
 	"github.com/fluxcd/cli-utils/pkg/kstatus/status"
 	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/cli-utils/pkg/object/waitfor"
 	"github.com/fluxcd/cli-utils/pkg/testutil"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 var testDeployment1YAML = `
@@ -85,7 +88,7 @@ func TestWaitTask_CompleteEventually(t *testing.T) {
 
 	eventChannel := make(chan event.Event)
 	resourceCache := cache.NewResourceCacheMap()
-	taskContext := NewTaskContext(eventChannel, resourceCache)
+	taskContext := NewTaskContext(context.TODO(), eventChannel, resourceCache)
 	defer close(eventChannel)
 
 	// Update metadata on successfully applied objects
@@ -253,6 +256,123 @@ loop:
 	testutil.AssertEqual(t, &expectedInventory, taskContext.InventoryManager().Inventory())
 }
 
+func TestWaitTask_CustomConditions(t *testing.T) {
+	testDeployment1ID := testutil.ToIdentifier(t, testDeployment1YAML)
+	testDeployment1 := testutil.Unstructured(t, testDeployment1YAML)
+	testDeployment2ID := testutil.ToIdentifier(t, testDeployment2YAML)
+	testDeployment2 := testutil.Unstructured(t, testDeployment2YAML)
+	ids := object.ObjMetadataSet{
+		testDeployment1ID,
+		testDeployment2ID,
+	}
+	waitTimeout := 2 * time.Second
+	taskName := "wait-1"
+	task := NewWaitTask(taskName, ids, AllCurrent,
+		waitTimeout, testutil.NewFakeRESTMapper())
+	task.Conditions = map[object.ObjMetadata]waitfor.Condition{
+		// deployment1 is not waited on
+		testDeployment1ID: waitfor.None{},
+		// deployment2 is waited on until it is available, even if not current
+		testDeployment2ID: waitfor.StatusCondition{Type: "Available", Status: "True"},
+	}
+
+	eventChannel := make(chan event.Event)
+	resourceCache := cache.NewResourceCacheMap()
+	taskContext := NewTaskContext(context.TODO(), eventChannel, resourceCache)
+	defer close(eventChannel)
+
+	// mark deployment 1 & 2 as apply succeeded
+	taskContext.InventoryManager().AddSuccessfulApply(testDeployment1ID,
+		testDeployment1.GetUID(), testDeployment1.GetGeneration())
+	taskContext.InventoryManager().AddSuccessfulApply(testDeployment2ID,
+		testDeployment2.GetUID(), testDeployment2.GetGeneration())
+
+	// run task async, to let the test collect events
+	go func() {
+		// start the task
+		task.Start(taskContext)
+
+		// mark deployment2 as InProgress, without the condition
+		resourceCache.Put(testDeployment2ID, cache.ResourceStatus{
+			Resource: testDeployment2,
+			Status:   status.InProgressStatus,
+		})
+		task.StatusUpdate(taskContext, testDeployment2ID)
+
+		// mark deployment2 as InProgress, with the condition
+		available := testDeployment2.DeepCopy()
+		err := unstructured.SetNestedSlice(available.Object, []interface{}{
+			map[string]interface{}{
+				"type":   "Available",
+				"status": "True",
+			},
+		}, "status", "conditions")
+		assert.NoError(t, err)
+		resourceCache.Put(testDeployment2ID, cache.ResourceStatus{
+			Resource: available,
+			Status:   status.InProgressStatus,
+		})
+		task.StatusUpdate(taskContext, testDeployment2ID)
+	}()
+
+	// wait for task result
+	timer := time.NewTimer(5 * time.Second)
+	receivedEvents := []event.Event{}
+loop:
+	for {
+		select {
+		case e := <-taskContext.EventChannel():
+			receivedEvents = append(receivedEvents, e)
+		case res := <-taskContext.TaskChannel():
+			timer.Stop()
+			assert.NoError(t, res.Err)
+			break loop
+		case <-timer.C:
+			t.Fatalf("timed out waiting for TaskResult")
+		}
+	}
+
+	expectedEvents := []event.Event{
+		// deployment1 reconciled without waiting
+		{
+			Type: event.WaitType,
+			WaitEvent: event.WaitEvent{
+				GroupName:  taskName,
+				Identifier: testDeployment1ID,
+				Status:     event.ReconcileSuccessful,
+				Condition:  "none",
+			},
+		},
+		// deployment2 pending
+		{
+			Type: event.WaitType,
+			WaitEvent: event.WaitEvent{
+				GroupName:  taskName,
+				Identifier: testDeployment2ID,
+				Status:     event.ReconcilePending,
+				Condition:  "condition=Available=True",
+			},
+		},
+		// deployment2 available
+		{
+			Type: event.WaitType,
+			WaitEvent: event.WaitEvent{
+				GroupName:  taskName,
+				Identifier: testDeployment2ID,
+				Status:     event.ReconcileSuccessful,
+				Condition:  "condition=Available=True",
+			},
+		},
+	}
+	testutil.AssertEqual(t, expectedEvents, receivedEvents,
+		"Actual events (%d) do not match expected events (%d)",
+		len(receivedEvents), len(expectedEvents))
+
+	im := taskContext.InventoryManager()
+	assert.True(t, im.IsSuccessfulReconcile(testDeployment1ID))
+	assert.True(t, im.IsSuccessfulReconcile(testDeployment2ID))
+}
+
 func TestWaitTask_Timeout(t *testing.T) {
 	testDeployment1ID := testutil.ToIdentifier(t, testDeployment1YAML)
 	testDeployment1 := testutil.Unstructured(t, testDeployment1YAML)
@@ -273,7 +393,7 @@ func TestWaitTask_Timeout(t *testing.T) {
 
 	eventChannel := make(chan event.Event)
 	resourceCache := cache.NewResourceCacheMap()
-	taskContext := NewTaskContext(eventChannel, resourceCache)
+	taskContext := NewTaskContext(context.TODO(), eventChannel, resourceCache)
 	defer close(eventChannel)
 
 	// Update metadata on successfully applied objects
@@ -438,7 +558,7 @@ func TestWaitTask_StartAndComplete(t *testing.T) {
 
 	eventChannel := make(chan event.Event)
 	resourceCache := cache.NewResourceCacheMap()
-	taskContext := NewTaskContext(eventChannel, resourceCache)
+	taskContext := NewTaskContext(context.TODO(), eventChannel, resourceCache)
 	defer close(eventChannel)
 
 	// Update metadata on successfully applied objects
@@ -523,7 +643,7 @@ func TestWaitTask_Cancel(t *testing.T) {
 
 	eventChannel := make(chan event.Event)
 	resourceCache := cache.NewResourceCacheMap()
-	taskContext := NewTaskContext(eventChannel, resourceCache)
+	taskContext := NewTaskContext(context.TODO(), eventChannel, resourceCache)
 	defer close(eventChannel)
 
 	// Update metadata on successfully applied objects
@@ -611,7 +731,7 @@ func TestWaitTask_SingleTaskResult(t *testing.T) {
 	// buffer events, because they're sent by StatusUpdate
 	eventChannel := make(chan event.Event, 10)
 	resourceCache := cache.NewResourceCacheMap()
-	taskContext := NewTaskContext(eventChannel, resourceCache)
+	taskContext := NewTaskContext(context.TODO(), eventChannel, resourceCache)
 	defer close(eventChannel)
 
 	// Update metadata on successfully applied objects
@@ -1115,7 +1235,7 @@ func TestWaitTask_Failed(t *testing.T) {
 
 			eventChannel := make(chan event.Event)
 			resourceCache := cache.NewResourceCacheMap()
-			taskContext := NewTaskContext(eventChannel, resourceCache)
+			taskContext := NewTaskContext(context.TODO(), eventChannel, resourceCache)
 			defer close(eventChannel)
 
 			tc.configureTaskContextFunc(taskContext)
@@ -1365,7 +1485,7 @@ func TestWaitTask_UIDChanged(t *testing.T) {
 
 			eventChannel := make(chan event.Event)
 			resourceCache := cache.NewResourceCacheMap()
-			taskContext := NewTaskContext(eventChannel, resourceCache)
+			taskContext := NewTaskContext(context.TODO(), eventChannel, resourceCache)
 			defer close(eventChannel)
 
 			tc.configureTaskContextFunc(taskContext)
@@ -1403,3 +1523,153 @@ func TestWaitTask_UIDChanged(t *testing.T) {
 		})
 	}
 }
+
+func TestWaitTask_HaltOnFailure(t *testing.T) {
+	testDeployment1ID := testutil.ToIdentifier(t, testDeployment1YAML)
+	testDeployment1 := testutil.Unstructured(t, testDeployment1YAML)
+	testDeployment2ID := testutil.ToIdentifier(t, testDeployment2YAML)
+	ids := object.ObjMetadataSet{
+		testDeployment1ID,
+		testDeployment2ID,
+	}
+
+	testCases := map[string]struct {
+		haltOnFailure     bool
+		failureThreshold  int
+		expectedStopCause error
+	}{
+		"no gate": {
+			haltOnFailure: false,
+		},
+		"failures within threshold": {
+			haltOnFailure:    true,
+			failureThreshold: 1,
+		},
+		"failures exceed threshold": {
+			haltOnFailure:    true,
+			failureThreshold: 0,
+			expectedStopCause: &BatchHaltedError{
+				Batch:     "wait-0",
+				Failures:  1,
+				Threshold: 0,
+			},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			task := NewWaitTask("wait-0", ids, AllCurrent,
+				2*time.Second, testutil.NewFakeRESTMapper())
+			task.HaltOnFailure = tc.haltOnFailure
+			task.FailureThreshold = tc.failureThreshold
+
+			// The event channel can not block; make sure its bigger than
+			// all the events that can be put on it.
+			eventChannel := make(chan event.Event, len(ids))
+			resourceCache := cache.NewResourceCacheMap()
+			taskContext := NewTaskContext(context.TODO(), eventChannel, resourceCache)
+			defer close(eventChannel)
+
+			// deployment 1 applied and reconciled, deployment 2 failed
+			taskContext.InventoryManager().AddSuccessfulApply(testDeployment1ID,
+				testDeployment1.GetUID(), testDeployment1.GetGeneration())
+			resourceCache.Put(testDeployment1ID, cache.ResourceStatus{
+				Resource: testDeployment1,
+				Status:   status.CurrentStatus,
+			})
+			taskContext.InventoryManager().AddFailedApply(testDeployment2ID)
+
+			task.Start(taskContext)
+			<-taskContext.TaskChannel()
+
+			if tc.expectedStopCause != nil {
+				assert.Equal(t, tc.expectedStopCause, context.Cause(taskContext.Context()))
+			} else {
+				assert.NoError(t, taskContext.Context().Err())
+			}
+		})
+	}
+}
+
+func TestWaitTask_Timing(t *testing.T) {
+	testDeployment1ID := testutil.ToIdentifier(t, testDeployment1YAML)
+	testDeployment1 := testutil.Unstructured(t, testDeployment1YAML)
+	testDeployment2ID := testutil.ToIdentifier(t, testDeployment2YAML)
+	testDeployment2 := testutil.Unstructured(t, testDeployment2YAML)
+	ids := object.ObjMetadataSet{
+		testDeployment1ID,
+		testDeployment2ID,
+	}
+	task := NewWaitTask("wait-0", ids, AllCurrent,
+		2*time.Second, testutil.NewFakeRESTMapper())
+
+	eventChannel := make(chan event.Event, 2*len(ids))
+	resourceCache := cache.NewResourceCacheMap()
+	taskContext := NewTaskContext(context.TODO(), eventChannel, resourceCache)
+	defer close(eventChannel)
+
+	// deployment 1 is reconciled, deployment 2 is still in progress
+	applied := time.Now().Add(-time.Minute)
+	taskContext.InventoryManager().AddSuccessfulApply(testDeployment1ID,
+		testDeployment1.GetUID(), testDeployment1.GetGeneration())
+	taskContext.AddActuationTime(testDeployment1ID, applied)
+	resourceCache.Put(testDeployment1ID, cache.ResourceStatus{
+		Resource: testDeployment1,
+		Status:   status.CurrentStatus,
+	})
+	taskContext.InventoryManager().AddSuccessfulApply(testDeployment2ID,
+		testDeployment2.GetUID(), testDeployment2.GetGeneration())
+	taskContext.AddActuationTime(testDeployment2ID, applied)
+	resourceCache.Put(testDeployment2ID, cache.ResourceStatus{
+		Resource: testDeployment2,
+		Status:   status.InProgressStatus,
+	})
+
+	task.Start(taskContext)
+	<-taskContext.TaskChannel()
+
+	timings := make(map[event.WaitEventStatus]event.Timing)
+	for len(eventChannel) > 0 {
+		e := <-eventChannel
+		timings[e.WaitEvent.Status] = e.WaitEvent.Timing
+	}
+
+	// The reconciled object is timed from its actuation.
+	reconciled := timings[event.ReconcileSuccessful]
+	assert.Equal(t, applied, reconciled.Start)
+	assert.GreaterOrEqual(t, reconciled.Duration(), time.Minute)
+
+	// The pending object has not ended yet.
+	pending := timings[event.ReconcilePending]
+	assert.Equal(t, applied, pending.Start)
+	assert.True(t, pending.End.IsZero())
+
+	// The timed out object is timed until the timeout.
+	timedOut := timings[event.ReconcileTimeout]
+	assert.Equal(t, applied, timedOut.Start)
+	assert.GreaterOrEqual(t, timedOut.Duration(), time.Minute+2*time.Second)
+}
+
+func TestWaitTask_TimingEndsOnce(t *testing.T) {
+	testDeployment1ID := testutil.ToIdentifier(t, testDeployment1YAML)
+	task := NewWaitTask("wait-0", object.ObjMetadataSet{testDeployment1ID}, AllCurrent,
+		time.Minute, testutil.NewFakeRESTMapper())
+	taskContext := NewTaskContext(context.TODO(), make(chan event.Event), cache.NewResourceCacheMap())
+	taskContext.AddActuationTime(testDeployment1ID, time.Now().Add(-time.Minute))
+
+	// An object that becomes Current, Pending and Current again is only
+	// timed until it first reconciled.
+	var ended []bool
+	for _, status := range []event.WaitEventStatus{
+		event.ReconcileSkipped,
+		event.ReconcilePending,
+		event.ReconcileSuccessful,
+		event.ReconcilePending,
+		event.ReconcileSuccessful,
+		event.ReconcileTimeout,
+	} {
+		e := task.newEvent(taskContext, testDeployment1ID, status)
+		ended = append(ended, !e.Timing.End.IsZero())
+	}
+	assert.Equal(t, []bool{false, false, true, false, false, false}, ended)
+}
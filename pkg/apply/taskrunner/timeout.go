@@ -0,0 +1,124 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package taskrunner
+
+import (
+	"time"
+
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"k8s.io/klog/v2"
+)
+
+// ObjectTimeout returns the effective timeout of the object: the timeout of
+// the object, if any, or the timeout of the task. Zero means no timeout.
+func (w *WaitTask) ObjectTimeout(id object.ObjMetadata) time.Duration {
+	if timeout, found := w.Timeouts[id]; found {
+		return timeout
+	}
+	return w.Timeout
+}
+
+// taskTimeout returns how long the task waits for all of its objects: the
+// longest effective timeout of the objects. Zero means no timeout.
+func (w *WaitTask) taskTimeout() time.Duration {
+	if len(w.Timeouts) == 0 {
+		return w.Timeout
+	}
+	var longest time.Duration
+	for _, id := range w.Ids {
+		timeout := w.ObjectTimeout(id)
+		if timeout == 0 {
+			return 0
+		}
+		if timeout > longest {
+			longest = timeout
+		}
+	}
+	return longest
+}
+
+// objectTimer times out an object individually.
+type objectTimer struct {
+	timer *time.Timer
+}
+
+// startObjectTimers starts a timer for every object that times out before
+// the task. The pending set is write locked during execution of
+// startObjectTimers.
+func (w *WaitTask) startObjectTimers(taskContext *TaskContext, taskTimeout time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, id := range w.pending {
+		w.startObjectTimer(taskContext, id, taskTimeout)
+	}
+}
+
+// startObjectTimer starts the timer of the object, if it times out before the
+// task. A running timer of the object is replaced, so that an object that
+// becomes pending again is waited for its whole timeout again.
+// Must be called with the pending set write locked.
+func (w *WaitTask) startObjectTimer(taskContext *TaskContext, id object.ObjMetadata, taskTimeout time.Duration) {
+	if w.timersStopped {
+		return
+	}
+	timeout := w.ObjectTimeout(id)
+	if timeout == 0 || (taskTimeout > 0 && timeout >= taskTimeout) {
+		return
+	}
+	if t, found := w.timers[id]; found {
+		t.timer.Stop()
+	}
+	if w.timers == nil {
+		w.timers = make(map[object.ObjMetadata]*objectTimer)
+	}
+	t := &objectTimer{}
+	t.timer = time.AfterFunc(timeout, func() {
+		w.timeoutObject(taskContext, id, t)
+	})
+	w.timers[id] = t
+}
+
+// stopObjectTimers stops the object timers and waits for the running
+// timeouts to finish. The pending set is write locked during execution of
+// stopObjectTimers.
+func (w *WaitTask) stopObjectTimers() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, t := range w.timers {
+		t.timer.Stop()
+	}
+	w.timersStopped = true
+}
+
+// timeoutObject sends a timeout event for the object, if it is still
+// pending and the timer was not replaced, and stops waiting for it. If no
+// objects are pending anymore, cancelFunc is called.
+// The pending set is write locked during execution of timeoutObject.
+func (w *WaitTask) timeoutObject(taskContext *TaskContext, id object.ObjMetadata, t *objectTimer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.timersStopped || w.timers[id] != t || !w.pending.Contains(id) {
+		return
+	}
+	klog.V(3).Infof("object reconcile timeout (object: %q, timeout: %v)", id, w.ObjectTimeout(id))
+	err := taskContext.InventoryManager().SetTimeoutReconcile(id)
+	if err != nil {
+		// Object never applied or deleted!
+		klog.Errorf("Failed to mark object as timeout reconcile: %v", err)
+	}
+	w.pending = w.pending.Remove(id)
+	w.timedOut = append(w.timedOut, id)
+	e := w.newEvent(taskContext, id, event.ReconcileTimeout)
+	w.stuckFinalizers(&e)
+	w.send(taskContext, e)
+
+	if len(w.pending) == 0 {
+		klog.V(3).Infof("all objects reconciled, skipped, or timed out (name: %q)", w.TaskName)
+		w.cancelFunc()
+	}
+}
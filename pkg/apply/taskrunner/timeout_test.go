@@ -0,0 +1,237 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package taskrunner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/cli-utils/pkg/apply/cache"
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/kstatus/status"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/cli-utils/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitTask_TaskTimeout(t *testing.T) {
+	testDeployment1ID := testutil.ToIdentifier(t, testDeployment1YAML)
+	testDeployment2ID := testutil.ToIdentifier(t, testDeployment2YAML)
+	ids := object.ObjMetadataSet{testDeployment1ID, testDeployment2ID}
+
+	testCases := map[string]struct {
+		timeout         time.Duration
+		timeouts        map[object.ObjMetadata]time.Duration
+		expectedTimeout time.Duration
+	}{
+		"task timeout": {
+			timeout:         time.Minute,
+			expectedTimeout: time.Minute,
+		},
+		"no timeout": {
+			timeout:         0,
+			expectedTimeout: 0,
+		},
+		"longer object timeout": {
+			timeout:         time.Minute,
+			timeouts:        map[object.ObjMetadata]time.Duration{testDeployment1ID: time.Hour},
+			expectedTimeout: time.Hour,
+		},
+		"shorter object timeout": {
+			timeout:         time.Minute,
+			timeouts:        map[object.ObjMetadata]time.Duration{testDeployment1ID: time.Second},
+			expectedTimeout: time.Minute,
+		},
+		"object timeouts without task timeout": {
+			timeout:         0,
+			timeouts:        map[object.ObjMetadata]time.Duration{testDeployment1ID: time.Second},
+			expectedTimeout: 0,
+		},
+		"all objects with timeouts": {
+			timeout: 0,
+			timeouts: map[object.ObjMetadata]time.Duration{
+				testDeployment1ID: time.Second,
+				testDeployment2ID: time.Minute,
+			},
+			expectedTimeout: time.Minute,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			task := NewWaitTask("wait-0", ids, AllCurrent, tc.timeout, testutil.NewFakeRESTMapper())
+			task.Timeouts = tc.timeouts
+			assert.Equal(t, tc.expectedTimeout, task.taskTimeout())
+		})
+	}
+}
+
+func TestWaitTask_ObjectTimeout(t *testing.T) {
+	testDeployment1ID := testutil.ToIdentifier(t, testDeployment1YAML)
+	testDeployment1 := testutil.Unstructured(t, testDeployment1YAML)
+	testDeployment2ID := testutil.ToIdentifier(t, testDeployment2YAML)
+	testDeployment2 := testutil.Unstructured(t, testDeployment2YAML)
+	ids := object.ObjMetadataSet{testDeployment1ID, testDeployment2ID}
+
+	// deployment 1 times out individually, deployment 2 with the task
+	task := NewWaitTask("wait-0", ids, AllCurrent, 2*time.Second, testutil.NewFakeRESTMapper())
+	task.Timeouts = map[object.ObjMetadata]time.Duration{
+		testDeployment1ID: 100 * time.Millisecond,
+	}
+
+	eventChannel := make(chan event.Event)
+	resourceCache := cache.NewResourceCacheMap()
+	taskContext := NewTaskContext(context.TODO(), eventChannel, resourceCache)
+	defer close(eventChannel)
+
+	im := taskContext.InventoryManager()
+	im.AddSuccessfulApply(testDeployment1ID, testDeployment1.GetUID(), testDeployment1.GetGeneration())
+	im.AddSuccessfulApply(testDeployment2ID, testDeployment2.GetUID(), testDeployment2.GetGeneration())
+	resourceCache.Put(testDeployment1ID, cache.ResourceStatus{
+		Resource: testDeployment1,
+		Status:   status.InProgressStatus,
+	})
+	resourceCache.Put(testDeployment2ID, cache.ResourceStatus{
+		Resource: testDeployment2,
+		Status:   status.InProgressStatus,
+	})
+
+	var events []event.WaitEvent
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range eventChannel {
+			events = append(events, e.WaitEvent)
+			// deployment 2 reconciles after deployment 1 timed out
+			if e.WaitEvent.Status == event.ReconcileTimeout {
+				resourceCache.Put(testDeployment2ID, cache.ResourceStatus{
+					Resource: testDeployment2,
+					Status:   status.CurrentStatus,
+				})
+				go task.StatusUpdate(taskContext, testDeployment2ID)
+			}
+			if e.WaitEvent.Status == event.ReconcileSuccessful {
+				return
+			}
+		}
+	}()
+
+	start := time.Now()
+	task.Start(taskContext)
+	<-done
+	<-taskContext.TaskChannel()
+	elapsed := time.Since(start)
+
+	expected := []event.WaitEvent{
+		{GroupName: "wait-0", Identifier: testDeployment1ID, Status: event.ReconcilePending},
+		{GroupName: "wait-0", Identifier: testDeployment2ID, Status: event.ReconcilePending},
+		{GroupName: "wait-0", Identifier: testDeployment1ID, Status: event.ReconcileTimeout},
+		{GroupName: "wait-0", Identifier: testDeployment2ID, Status: event.ReconcileSuccessful},
+	}
+	for i := range events {
+		// ignore timing
+		events[i].Timing = event.Timing{}
+	}
+	testutil.AssertEqual(t, expected, events)
+	assert.Less(t, elapsed, 2*time.Second)
+
+	assert.True(t, im.IsTimeoutReconcile(testDeployment1ID))
+	assert.True(t, im.IsSuccessfulReconcile(testDeployment2ID))
+}
+
+func TestWaitTask_ObjectTimeoutRestart(t *testing.T) {
+	testDeployment1ID := testutil.ToIdentifier(t, testDeployment1YAML)
+	testDeployment1 := testutil.Unstructured(t, testDeployment1YAML)
+	testDeployment2ID := testutil.ToIdentifier(t, testDeployment2YAML)
+	testDeployment2 := testutil.Unstructured(t, testDeployment2YAML)
+	ids := object.ObjMetadataSet{testDeployment1ID, testDeployment2ID}
+	objectTimeout := 300 * time.Millisecond
+
+	// deployment 1 times out individually, deployment 2 with the task
+	task := NewWaitTask("wait-0", ids, AllCurrent, 5*time.Second, testutil.NewFakeRESTMapper())
+	task.Timeouts = map[object.ObjMetadata]time.Duration{
+		testDeployment1ID: objectTimeout,
+	}
+
+	eventChannel := make(chan event.Event)
+	resourceCache := cache.NewResourceCacheMap()
+	taskContext := NewTaskContext(context.TODO(), eventChannel, resourceCache)
+	defer close(eventChannel)
+
+	im := taskContext.InventoryManager()
+	im.AddSuccessfulApply(testDeployment1ID, testDeployment1.GetUID(), testDeployment1.GetGeneration())
+	im.AddSuccessfulApply(testDeployment2ID, testDeployment2.GetUID(), testDeployment2.GetGeneration())
+	resourceCache.Put(testDeployment1ID, cache.ResourceStatus{
+		Resource: testDeployment1,
+		Status:   status.InProgressStatus,
+	})
+	resourceCache.Put(testDeployment2ID, cache.ResourceStatus{
+		Resource: testDeployment2,
+		Status:   status.InProgressStatus,
+	})
+
+	var events []event.WaitEvent
+	var timedOut time.Time
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for e := range eventChannel {
+			events = append(events, e.WaitEvent)
+			// deployment 2 reconciles after deployment 1 timed out
+			if e.WaitEvent.Status == event.ReconcileTimeout {
+				timedOut = time.Now()
+				resourceCache.Put(testDeployment2ID, cache.ResourceStatus{
+					Resource: testDeployment2,
+					Status:   status.CurrentStatus,
+				})
+				go task.StatusUpdate(taskContext, testDeployment2ID)
+			}
+			if e.WaitEvent.Identifier == testDeployment2ID && e.WaitEvent.Status == event.ReconcileSuccessful {
+				return
+			}
+		}
+	}()
+
+	task.Start(taskContext)
+
+	// deployment 1 reconciles, and becomes pending again before its first
+	// timer expires
+	time.Sleep(objectTimeout / 3)
+	resourceCache.Put(testDeployment1ID, cache.ResourceStatus{
+		Resource: testDeployment1,
+		Status:   status.CurrentStatus,
+	})
+	task.StatusUpdate(taskContext, testDeployment1ID)
+	time.Sleep(objectTimeout / 3)
+	resourceCache.Put(testDeployment1ID, cache.ResourceStatus{
+		Resource: testDeployment1,
+		Status:   status.InProgressStatus,
+	})
+	pendingAgain := time.Now()
+	task.StatusUpdate(taskContext, testDeployment1ID)
+
+	<-done
+	<-taskContext.TaskChannel()
+
+	expected := []event.WaitEvent{
+		{GroupName: "wait-0", Identifier: testDeployment1ID, Status: event.ReconcilePending},
+		{GroupName: "wait-0", Identifier: testDeployment2ID, Status: event.ReconcilePending},
+		{GroupName: "wait-0", Identifier: testDeployment1ID, Status: event.ReconcileSuccessful},
+		{GroupName: "wait-0", Identifier: testDeployment1ID, Status: event.ReconcilePending},
+		{GroupName: "wait-0", Identifier: testDeployment1ID, Status: event.ReconcileTimeout},
+		{GroupName: "wait-0", Identifier: testDeployment2ID, Status: event.ReconcileSuccessful},
+	}
+	for i := range events {
+		// ignore timing
+		events[i].Timing = event.Timing{}
+	}
+	testutil.AssertEqual(t, expected, events)
+	// The timer restarted, when deployment 1 became pending again.
+	assert.GreaterOrEqual(t, timedOut.Sub(pendingAgain), objectTimeout)
+	assert.Less(t, timedOut.Sub(pendingAgain), 5*time.Second)
+
+	assert.True(t, im.IsTimeoutReconcile(testDeployment1ID))
+	assert.True(t, im.IsSuccessfulReconcile(testDeployment2ID))
+}
@@ -0,0 +1,307 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/common"
+	"github.com/fluxcd/cli-utils/pkg/inventory"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+const (
+	transferGroupName  = "transfer-0"
+	inventoryGroupName = "inventory-set-0"
+)
+
+// Transferer transfers the ownership of objects from one inventory to
+// another, without applying or deleting them.
+type Transferer struct {
+	invClient inventory.Client
+	client    dynamic.Interface
+	mapper    meta.RESTMapper
+}
+
+type TransfererOptions struct {
+	// InventoryPolicy defines which objects the source inventory may
+	// transfer. By default, only objects owned by the source inventory are
+	// transferred.
+	InventoryPolicy inventory.Policy
+
+	// DryRunStrategy defines whether changes should actually be performed,
+	// or if it is just talk and no action.
+	DryRunStrategy common.DryRunStrategy
+}
+
+// Run transfers the passed objects from the source inventory to the target
+// inventory. The transferred objects are first added to the target inventory,
+// which is created if it does not exist, with the object status stored in the
+// source inventory. Then the owning-inventory annotation of each object is set
+// to the target inventory, and finally the transferred objects are removed
+// from the source inventory. If any step fails, each object is either still
+// in the source inventory or owned by the target inventory, so the objects
+// are never pruned by mistake, and the transfer can be run again.
+//
+// Objects that are not in the source inventory, not found in the cluster, or
+// not owned by the source inventory are skipped. If the annotation of an
+// object can not be updated, the object is not transferred, and is removed
+// from the target inventory again. Progress and errors are reported back on
+// the event channel.
+func (t *Transferer) Run(ctx context.Context, source, target inventory.Info, ids object.ObjMetadataSet,
+	options TransfererOptions) <-chan event.Event {
+	eventChannel := make(chan event.Event)
+	go func() {
+		defer close(eventChannel)
+		sourceIds, err := t.invClient.GetClusterObjs(source)
+		if err != nil {
+			handleError(eventChannel, err)
+			return
+		}
+
+		eventChannel <- event.Event{
+			Type: event.InitType,
+			InitEvent: event.InitEvent{
+				ActionGroups: event.ActionGroupList{
+					{
+						Name:        transferGroupName,
+						Action:      event.TransferAction,
+						Identifiers: ids,
+					},
+					{
+						Name:   inventoryGroupName,
+						Action: event.InventoryAction,
+					},
+				},
+			},
+		}
+
+		sendActionGroupEvent(eventChannel, transferGroupName, event.TransferAction, event.Started)
+		// Read the objects that can be transferred.
+		var objs object.UnstructuredSet
+		for _, id := range ids {
+			obj, e := t.getObject(ctx, id, sourceIds, source, options)
+			if obj == nil {
+				eventChannel <- event.Event{
+					Type:          event.TransferType,
+					TransferEvent: e,
+				}
+				continue
+			}
+			objs = append(objs, obj)
+		}
+		if len(objs) == 0 {
+			sendActionGroupEvent(eventChannel, transferGroupName, event.TransferAction, event.Finished)
+			sendActionGroupEvent(eventChannel, inventoryGroupName, event.InventoryAction, event.Started)
+			sendActionGroupEvent(eventChannel, inventoryGroupName, event.InventoryAction, event.Finished)
+			return
+		}
+
+		// Add the objects to the target inventory, before the target owns them.
+		targetIds, err := t.invClient.GetClusterObjs(target)
+		if err != nil {
+			handleError(eventChannel, err)
+			return
+		}
+		sourceStatus, err := inventory.GetClusterObjStatus(t.invClient, source)
+		if err != nil {
+			handleError(eventChannel, err)
+			return
+		}
+		objIds := object.UnstructuredSetToObjMetadataSet(objs)
+		targetBase, err := t.addToTarget(target, targetIds, objIds, sourceStatus, options.DryRunStrategy)
+		if err != nil {
+			handleError(eventChannel, err)
+			return
+		}
+
+		// Hand over the objects to the target inventory.
+		var transferred object.ObjMetadataSet
+		for _, obj := range objs {
+			e := t.transferObject(ctx, obj, target, options)
+			eventChannel <- event.Event{
+				Type:          event.TransferType,
+				TransferEvent: e,
+			}
+			if e.Status == event.TransferSuccessful {
+				transferred = append(transferred, e.Identifier)
+			}
+		}
+		sendActionGroupEvent(eventChannel, transferGroupName, event.TransferAction, event.Finished)
+
+		sendActionGroupEvent(eventChannel, inventoryGroupName, event.InventoryAction, event.Started)
+		if err := t.transferInventory(source, target, targetBase, sourceIds, targetIds, objIds, transferred, sourceStatus,
+			options.DryRunStrategy); err != nil {
+			handleError(eventChannel, err)
+			return
+		}
+		sendActionGroupEvent(eventChannel, inventoryGroupName, event.InventoryAction, event.Finished)
+	}()
+	return eventChannel
+}
+
+// getObject returns the object to transfer, or nil and the event of the
+// skipped or failed object.
+func (t *Transferer) getObject(ctx context.Context, id object.ObjMetadata, sourceIds object.ObjMetadataSet,
+	source inventory.Info, options TransfererOptions) (*unstructured.Unstructured, event.TransferEvent) {
+	e := event.TransferEvent{
+		GroupName:  transferGroupName,
+		Identifier: id,
+	}
+	if ctx.Err() != nil {
+		e.Status = event.TransferSkipped
+		e.Error = context.Cause(ctx)
+		return nil, e
+	}
+	if !sourceIds.Contains(id) {
+		e.Status = event.TransferSkipped
+		e.Error = fmt.Errorf("object not in source inventory %q", source.ID())
+		return nil, e
+	}
+	client, err := t.namespacedClient(id)
+	if err != nil {
+		e.Status = event.TransferFailed
+		e.Error = err
+		return nil, e
+	}
+	obj, err := client.Get(ctx, id.Name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			e.Status = event.TransferSkipped
+		} else {
+			e.Status = event.TransferFailed
+		}
+		e.Error = err
+		return nil, e
+	}
+	if _, err := inventory.CanPrune(source, obj, options.InventoryPolicy); err != nil {
+		e.Status = event.TransferSkipped
+		e.Error = err
+		return nil, e
+	}
+	return obj, e
+}
+
+// transferObject sets the owning-inventory annotation of the object to the
+// target inventory and returns the resulting event.
+func (t *Transferer) transferObject(ctx context.Context, obj *unstructured.Unstructured, target inventory.Info,
+	options TransfererOptions) event.TransferEvent {
+	id := object.UnstructuredToObjMetadata(obj)
+	e := event.TransferEvent{
+		GroupName:  transferGroupName,
+		Identifier: id,
+	}
+	if ctx.Err() != nil {
+		e.Status = event.TransferSkipped
+		e.Error = context.Cause(ctx)
+		return e
+	}
+	client, err := t.namespacedClient(id)
+	if err != nil {
+		e.Status = event.TransferFailed
+		e.Error = err
+		return e
+	}
+	inventory.AddInventoryIDAnnotation(obj, target)
+	e.Object = obj
+	if !options.DryRunStrategy.ClientDryRun() {
+		updateOptions := metav1.UpdateOptions{}
+		if options.DryRunStrategy.ServerDryRun() {
+			updateOptions.DryRun = []string{metav1.DryRunAll}
+		}
+		klog.V(4).Infof("transferring object (object: %q, inventory: %q)", id, target.ID())
+		obj, err = client.Update(ctx, obj, updateOptions)
+		if err != nil {
+			e.Status = event.TransferFailed
+			e.Error = err
+			return e
+		}
+		e.Object = obj
+	}
+	e.Status = event.TransferSuccessful
+	return e
+}
+
+// addToTarget adds the objects to the target inventory, with the status
+// stored in the source inventory. Returns the base of the target inventory.
+func (t *Transferer) addToTarget(target inventory.Info, targetIds, ids object.ObjMetadataSet,
+	sourceStatus []actuation.ObjectStatus, dryRun common.DryRunStrategy) (*inventory.Base, error) {
+	targetStatus, err := inventory.GetClusterObjStatus(t.invClient, target)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range sourceStatus {
+		if ids.Contains(inventory.ObjMetadataFromObjectReference(status.ObjectReference)) {
+			targetStatus = append(targetStatus, status)
+		}
+	}
+	return inventory.ReplaceWithBase(t.invClient, target, targetIds.Union(ids), targetStatus, nil,
+		inventory.ReplaceOptions{DryRun: dryRun})
+}
+
+// transferInventory removes the objects that were added to the target
+// inventory, but not transferred, from the target inventory, and then removes
+// the transferred objects from the source inventory. The target inventory is
+// rebased onto targetBase, which was stored by addToTarget.
+func (t *Transferer) transferInventory(source, target inventory.Info, targetBase *inventory.Base,
+	sourceIds, targetIds, added, transferred object.ObjMetadataSet,
+	sourceStatus []actuation.ObjectStatus, dryRun common.DryRunStrategy) error {
+	if failed := added.Diff(transferred).Diff(targetIds); len(failed) > 0 {
+		targetStatus, err := inventory.GetClusterObjStatus(t.invClient, target)
+		if err != nil {
+			return err
+		}
+		_, err = inventory.ReplaceWithBase(t.invClient, target, targetIds.Union(transferred),
+			withoutStatus(targetStatus, failed), targetBase, inventory.ReplaceOptions{DryRun: dryRun})
+		if err != nil {
+			return err
+		}
+	}
+	if len(transferred) == 0 {
+		return nil
+	}
+	_, err := inventory.ReplaceWithBase(t.invClient, source, sourceIds.Diff(transferred),
+		withoutStatus(sourceStatus, transferred), nil, inventory.ReplaceOptions{DryRun: dryRun})
+	return err
+}
+
+// withoutStatus returns the status of the objects that are not in ids.
+func withoutStatus(status []actuation.ObjectStatus, ids object.ObjMetadataSet) []actuation.ObjectStatus {
+	var kept []actuation.ObjectStatus
+	for _, s := range status {
+		if !ids.Contains(inventory.ObjMetadataFromObjectReference(s.ObjectReference)) {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+func (t *Transferer) namespacedClient(id object.ObjMetadata) (dynamic.ResourceInterface, error) {
+	mapping, err := t.mapper.RESTMapping(id.GroupKind)
+	if err != nil {
+		return nil, err
+	}
+	return t.client.Resource(mapping.Resource).Namespace(id.Namespace), nil
+}
+
+func sendActionGroupEvent(eventChannel chan<- event.Event, groupName string, action event.ResourceAction,
+	status event.ActionGroupEventStatus) {
+	eventChannel <- event.Event{
+		Type: event.ActionGroupType,
+		ActionGroupEvent: event.ActionGroupEvent{
+			GroupName: groupName,
+			Action:    action,
+			Status:    status,
+		},
+	}
+}
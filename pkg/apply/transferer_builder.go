@@ -0,0 +1,66 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"github.com/fluxcd/cli-utils/pkg/inventory"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/kubectl/pkg/cmd/util"
+)
+
+type TransfererBuilder struct {
+	commonBuilder
+}
+
+// NewTransfererBuilder returns a new TransfererBuilder.
+func NewTransfererBuilder() *TransfererBuilder {
+	return &TransfererBuilder{
+		// Defaults, if any, go here.
+	}
+}
+
+func (b *TransfererBuilder) Build() (*Transferer, error) {
+	bx, err := b.finalize()
+	if err != nil {
+		return nil, err
+	}
+	return &Transferer{
+		invClient: bx.invClient,
+		client:    bx.client,
+		mapper:    bx.mapper,
+	}, nil
+}
+
+func (b *TransfererBuilder) WithFactory(factory util.Factory) *TransfererBuilder {
+	b.factory = factory
+	return b
+}
+
+func (b *TransfererBuilder) WithInventoryClient(invClient inventory.Client) *TransfererBuilder {
+	b.invClient = invClient
+	return b
+}
+
+func (b *TransfererBuilder) WithDynamicClient(client dynamic.Interface) *TransfererBuilder {
+	b.client = client
+	return b
+}
+
+func (b *TransfererBuilder) WithDiscoveryClient(discoClient discovery.CachedDiscoveryInterface) *TransfererBuilder {
+	b.discoClient = discoClient
+	return b
+}
+
+func (b *TransfererBuilder) WithRestMapper(mapper meta.RESTMapper) *TransfererBuilder {
+	b.mapper = mapper
+	return b
+}
+
+func (b *TransfererBuilder) WithRestConfig(restConfig *rest.Config) *TransfererBuilder {
+	b.restConfig = restConfig
+	return b
+}
@@ -0,0 +1,219 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package apply
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/common"
+	"github.com/fluxcd/cli-utils/pkg/inventory"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/cli-utils/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+// multiInventoryClient stores the objects and status of multiple
+// inventories, keyed by inventory ID.
+type multiInventoryClient struct {
+	*inventory.FakeClient
+	objs   map[string]object.ObjMetadataSet
+	status map[string][]actuation.ObjectStatus
+	// failReplace is the ID of the inventory that can not be replaced.
+	failReplace string
+}
+
+func (c *multiInventoryClient) GetClusterObjs(inv inventory.Info) (object.ObjMetadataSet, error) {
+	return c.objs[inv.ID()], nil
+}
+
+func (c *multiInventoryClient) GetClusterObjStatus(inv inventory.Info) ([]actuation.ObjectStatus, error) {
+	return c.status[inv.ID()], nil
+}
+
+func (c *multiInventoryClient) ReplaceWithBase(inv inventory.Info, objs object.ObjMetadataSet, status []actuation.ObjectStatus,
+	base *inventory.Base, opts inventory.ReplaceOptions) (*inventory.Base, error) {
+	if inv.ID() == c.failReplace {
+		return nil, fmt.Errorf("failed to replace inventory %q", inv.ID())
+	}
+	if opts.DryRun.ClientOrServerDryRun() {
+		return base, nil
+	}
+	c.objs[inv.ID()] = objs
+	c.status[inv.ID()] = status
+	return &inventory.Base{Objects: objs}, nil
+}
+
+func TestTransferer(t *testing.T) {
+	source := inventoryInfo{name: "source", namespace: "test", id: "source"}
+	target := inventoryInfo{name: "target", namespace: "test", id: "target"}
+	deploymentID := testutil.ToIdentifier(t, resources["deployment"])
+	secretID := testutil.ToIdentifier(t, resources["secret"])
+	// not in the cluster
+	clusterScopedID := testutil.ToIdentifier(t, resources["clusterScopedObj"])
+	deploymentStatus := actuation.ObjectStatus{
+		ObjectReference: inventory.ObjectReferenceFromObjMetadata(deploymentID),
+		Strategy:        actuation.ActuationStrategyApply,
+		Actuation:       actuation.ActuationSucceeded,
+		Reconcile:       actuation.ReconcileSucceeded,
+	}
+	secretStatus := actuation.ObjectStatus{
+		ObjectReference: inventory.ObjectReferenceFromObjMetadata(secretID),
+		Strategy:        actuation.ActuationStrategyApply,
+		Actuation:       actuation.ActuationSucceeded,
+		Reconcile:       actuation.ReconcileSucceeded,
+	}
+
+	testCases := map[string]struct {
+		ids                     object.ObjMetadataSet
+		dryRun                  common.DryRunStrategy
+		failReplace             string
+		failUpdate              bool
+		expectedError           bool
+		expectedStatus          map[object.ObjMetadata]event.TransferEventStatus
+		expectedSourceObjs      object.ObjMetadataSet
+		expectedTargetObjs      object.ObjMetadataSet
+		expectedSourceStatus    []actuation.ObjectStatus
+		expectedTargetStatus    []actuation.ObjectStatus
+		expectedDeploymentOwner string
+	}{
+		"owned object transferred": {
+			ids: object.ObjMetadataSet{deploymentID},
+			expectedStatus: map[object.ObjMetadata]event.TransferEventStatus{
+				deploymentID: event.TransferSuccessful,
+			},
+			expectedSourceObjs:      object.ObjMetadataSet{secretID},
+			expectedTargetObjs:      object.ObjMetadataSet{deploymentID},
+			expectedSourceStatus:    []actuation.ObjectStatus{secretStatus},
+			expectedTargetStatus:    []actuation.ObjectStatus{deploymentStatus},
+			expectedDeploymentOwner: "target",
+		},
+		"object owned by other inventory or missing skipped": {
+			ids: object.ObjMetadataSet{deploymentID, secretID, clusterScopedID},
+			expectedStatus: map[object.ObjMetadata]event.TransferEventStatus{
+				deploymentID:    event.TransferSuccessful,
+				secretID:        event.TransferSkipped,
+				clusterScopedID: event.TransferSkipped,
+			},
+			expectedSourceObjs:      object.ObjMetadataSet{secretID},
+			expectedTargetObjs:      object.ObjMetadataSet{deploymentID},
+			expectedSourceStatus:    []actuation.ObjectStatus{secretStatus},
+			expectedTargetStatus:    []actuation.ObjectStatus{deploymentStatus},
+			expectedDeploymentOwner: "target",
+		},
+		"dry-run changes nothing": {
+			ids:    object.ObjMetadataSet{deploymentID},
+			dryRun: common.DryRunClient,
+			expectedStatus: map[object.ObjMetadata]event.TransferEventStatus{
+				deploymentID: event.TransferSuccessful,
+			},
+			expectedSourceObjs:      object.ObjMetadataSet{deploymentID, secretID},
+			expectedSourceStatus:    []actuation.ObjectStatus{deploymentStatus, secretStatus},
+			expectedDeploymentOwner: "source",
+		},
+		"adding to target inventory fails": {
+			ids:                     object.ObjMetadataSet{deploymentID},
+			failReplace:             "target",
+			expectedStatus:          map[object.ObjMetadata]event.TransferEventStatus{},
+			expectedError:           true,
+			expectedSourceObjs:      object.ObjMetadataSet{deploymentID, secretID},
+			expectedSourceStatus:    []actuation.ObjectStatus{deploymentStatus, secretStatus},
+			expectedDeploymentOwner: "source",
+		},
+		"updating annotation fails": {
+			ids:        object.ObjMetadataSet{deploymentID},
+			failUpdate: true,
+			expectedStatus: map[object.ObjMetadata]event.TransferEventStatus{
+				deploymentID: event.TransferFailed,
+			},
+			expectedSourceObjs:      object.ObjMetadataSet{deploymentID, secretID},
+			expectedTargetObjs:      object.ObjMetadataSet{},
+			expectedSourceStatus:    []actuation.ObjectStatus{deploymentStatus, secretStatus},
+			expectedDeploymentOwner: "source",
+		},
+		"removing from source inventory fails": {
+			ids:         object.ObjMetadataSet{deploymentID},
+			failReplace: "source",
+			expectedStatus: map[object.ObjMetadata]event.TransferEventStatus{
+				deploymentID: event.TransferSuccessful,
+			},
+			expectedError:           true,
+			expectedSourceObjs:      object.ObjMetadataSet{deploymentID, secretID},
+			expectedTargetObjs:      object.ObjMetadataSet{deploymentID},
+			expectedSourceStatus:    []actuation.ObjectStatus{deploymentStatus, secretStatus},
+			expectedTargetStatus:    []actuation.ObjectStatus{deploymentStatus},
+			expectedDeploymentOwner: "target",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			mapper := testutil.NewFakeRESTMapper(
+				schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+				schema.GroupVersionKind{Version: "v1", Kind: "Secret"},
+				schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+			)
+			client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+				testutil.Unstructured(t, resources["deployment"], testutil.AddOwningInv(t, "source")),
+				testutil.Unstructured(t, resources["secret"], testutil.AddOwningInv(t, "other")))
+			if tc.failUpdate {
+				client.PrependReactor("update", "*", func(clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, fmt.Errorf("update failed")
+				})
+			}
+			invClient := &multiInventoryClient{
+				FakeClient:  inventory.NewFakeClient(nil),
+				failReplace: tc.failReplace,
+				objs: map[string]object.ObjMetadataSet{
+					"source": {deploymentID, secretID},
+				},
+				status: map[string][]actuation.ObjectStatus{
+					"source": {deploymentStatus, secretStatus},
+				},
+			}
+			transferer := &Transferer{
+				invClient: invClient,
+				client:    client,
+				mapper:    mapper,
+			}
+
+			eventChannel := transferer.Run(context.TODO(), source.toWrapped(), target.toWrapped(), tc.ids,
+				TransfererOptions{DryRunStrategy: tc.dryRun})
+			statuses := make(map[object.ObjMetadata]event.TransferEventStatus)
+			var errored bool
+			for e := range eventChannel {
+				switch e.Type {
+				case event.ErrorType:
+					require.True(t, tc.expectedError, "unexpected error: %v", e.ErrorEvent.Err)
+					errored = true
+				case event.TransferType:
+					statuses[e.TransferEvent.Identifier] = e.TransferEvent.Status
+				}
+			}
+			assert.Equal(t, tc.expectedError, errored)
+			assert.Equal(t, tc.expectedStatus, statuses)
+
+			testutil.AssertEqual(t, tc.expectedSourceObjs, invClient.objs["source"])
+			testutil.AssertEqual(t, tc.expectedTargetObjs, invClient.objs["target"])
+			assert.Equal(t, tc.expectedSourceStatus, invClient.status["source"])
+			assert.Equal(t, tc.expectedTargetStatus, invClient.status["target"])
+
+			mapping, err := mapper.RESTMapping(deploymentID.GroupKind)
+			require.NoError(t, err)
+			deployment, err := client.Resource(mapping.Resource).Namespace(deploymentID.Namespace).
+				Get(context.TODO(), deploymentID.Name, metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedDeploymentOwner, deployment.GetAnnotations()[inventory.OwningInventoryKey])
+		})
+	}
+}
@@ -5,6 +5,7 @@ package common
 
 import (
 	"fmt"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/rand"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -128,4 +129,87 @@ type ServerSideOptions struct {
 
 	// FieldManager identifies the client "owner" of the applied fields (e.g. kubectl)
 	FieldManager string
+
+	// ForceConflictManagers are the field managers to take ownership from,
+	// if ForceConflicts is false. If all the fields in conflict are owned by
+	// these managers, the object is applied again with ForceConflicts.
+	// Otherwise the conflicts are reported. Useful to migrate the fields
+	// owned by an old manager (e.g. kubectl) to server-side apply.
+	ForceConflictManagers []string
+}
+
+// BatchOptions encapsulates the fields to apply the objects of a phase in
+// batches, waiting for each batch to reconcile before applying the next.
+type BatchOptions struct {
+	// Size is the maximum number of objects in a batch.
+	// Zero disables batching, unless Percent is set.
+	Size int
+
+	// Percent is the maximum number of objects in a batch, as a percentage
+	// of the objects in the phase, rounded up. Ignored if Size is set.
+	Percent int
+
+	// FailureThreshold is the number of objects in a batch that may fail to
+	// apply or reconcile. If exceeded, actuation halts after the batch.
+	FailureThreshold int
+}
+
+// Enabled returns true if the objects should be applied in batches.
+func (b BatchOptions) Enabled() bool {
+	return b.Size > 0 || b.Percent > 0
+}
+
+// BatchSize returns the maximum number of objects in a batch, for a phase
+// of the specified number of objects.
+func (b BatchOptions) BatchSize(objects int) int {
+	size := objects
+	switch {
+	case b.Size > 0:
+		size = b.Size
+	case b.Percent > 0:
+		size = (objects*b.Percent + 99) / 100
+	}
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// FinalizerOptions encapsulates the fields to detect deleted objects that
+// are stuck terminating, because their finalizers are not removed.
+type FinalizerOptions struct {
+	// StuckThreshold is how long the finalizers of a terminating object may
+	// stay unchanged, before the object is reported as stuck.
+	// Zero disables the detection.
+	StuckThreshold time.Duration
+
+	// Remove are the finalizers removed from terminating objects, whose
+	// finalizers stayed unchanged for longer than the RemoveGracePeriod.
+	// Only intended to clean up after controllers that no longer run, e.g.
+	// in test clusters. By default, no finalizers are removed.
+	Remove []string
+
+	// RemoveGracePeriod is how long the finalizers of a terminating object
+	// may stay unchanged, before the finalizers in Remove are removed.
+	// Zero uses the DefaultFinalizerRemoveGracePeriod.
+	RemoveGracePeriod time.Duration
+}
+
+// DefaultFinalizerRemoveGracePeriod is how long the finalizers of a
+// terminating object may stay unchanged by default, before they are removed.
+const DefaultFinalizerRemoveGracePeriod = 5 * time.Minute
+
+// GracePeriod returns the RemoveGracePeriod, or the default grace period if
+// it is not set.
+func (f FinalizerOptions) GracePeriod() time.Duration {
+	if f.RemoveGracePeriod <= 0 {
+		return DefaultFinalizerRemoveGracePeriod
+	}
+	return f.RemoveGracePeriod
+}
+
+// Enabled returns true if terminating objects should be checked for stuck
+// finalizers.
+func (f FinalizerOptions) Enabled() bool {
+	return f.StuckThreshold > 0 || len(f.Remove) > 0
 }
@@ -22,6 +22,8 @@ type FakeClient struct {
 
 var (
 	_ Client        = &FakeClient{}
+	_ StatusClient  = &FakeClient{}
+	_ RebaseClient  = &FakeClient{}
 	_ ClientFactory = FakeClientFactory{}
 )
 
@@ -47,28 +49,51 @@ func (fic *FakeClient) GetClusterObjs(Info) (object.ObjMetadataSet, error) {
 	return fic.Objs, nil
 }
 
+// GetClusterObjStatus returns currently stored object status.
+func (fic *FakeClient) GetClusterObjStatus(Info) ([]actuation.ObjectStatus, error) {
+	if fic.Err != nil {
+		return nil, fic.Err
+	}
+	return fic.Status, nil
+}
+
 // Merge stores the passed objects with the current stored cluster inventory
 // objects. Returns the set difference of the current set of objects minus
 // the passed set of objects, or an error if one is set up.
-func (fic *FakeClient) Merge(_ Info, objs object.ObjMetadataSet, _ common.DryRunStrategy) (object.ObjMetadataSet, error) {
+func (fic *FakeClient) Merge(inv Info, objs object.ObjMetadataSet, dryRun common.DryRunStrategy) (object.ObjMetadataSet, error) {
+	diffObjs, _, err := fic.MergeWithBase(inv, objs, MergeOptions{DryRun: dryRun})
+	return diffObjs, err
+}
+
+// MergeWithBase is like Merge, but also returns the base of the stored
+// objects.
+func (fic *FakeClient) MergeWithBase(_ Info, objs object.ObjMetadataSet, _ MergeOptions) (object.ObjMetadataSet, *Base, error) {
 	if fic.Err != nil {
-		return object.ObjMetadataSet{}, fic.Err
+		return object.ObjMetadataSet{}, nil, fic.Err
 	}
 	diffObjs := fic.Objs.Diff(objs)
 	fic.Objs = fic.Objs.Union(objs)
-	return diffObjs, nil
+	return diffObjs, &Base{Objects: fic.Objs}, nil
+}
+
+// Replace the stored cluster inventory objs with the passed obj, or returns
+// an error if one is set up.
+func (fic *FakeClient) Replace(inv Info, objs object.ObjMetadataSet, status []actuation.ObjectStatus,
+	dryRun common.DryRunStrategy) error {
+	_, err := fic.ReplaceWithBase(inv, objs, status, nil, ReplaceOptions{DryRun: dryRun})
+	return err
 }
 
-// Replace the stored cluster inventory objs with the passed obj, or an
-// error if one is set up.
-func (fic *FakeClient) Replace(_ Info, objs object.ObjMetadataSet, status []actuation.ObjectStatus,
-	_ common.DryRunStrategy) error {
+// ReplaceWithBase is like Replace, but also returns the base of the stored
+// objects.
+func (fic *FakeClient) ReplaceWithBase(_ Info, objs object.ObjMetadataSet, status []actuation.ObjectStatus,
+	_ *Base, _ ReplaceOptions) (*Base, error) {
 	if fic.Err != nil {
-		return fic.Err
+		return nil, fic.Err
 	}
 	fic.Objs = objs
 	fic.Status = status
-	return nil
+	return &Base{Objects: objs}, nil
 }
 
 // DeleteInventoryObj returns an error if one is forced; does nothing otherwise.
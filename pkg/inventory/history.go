@@ -0,0 +1,346 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Introduces the inventory history, which records the changes of the set of
+// objects stored in an inventory object as revisions. Each revision is stored
+// in a ConfigMap in the namespace of the inventory object.
+
+package inventory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fluxcd/cli-utils/pkg/common"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// RevisionLabel is the label on the revisions of an inventory object.
+	// The value of the label is the inventory id.
+	RevisionLabel = "cli-utils.sigs.k8s.io/inventory-revision-of"
+	// RevisionAnnotation is the annotation on a revision, which specifies
+	// the number of the revision.
+	RevisionAnnotation = "cli-utils.sigs.k8s.io/inventory-revision"
+
+	revisionTimestampKey    = "timestamp"
+	revisionFieldManagerKey = "fieldManager"
+	revisionObjectsKey      = "objects"
+	revisionAddedKey        = "added"
+	revisionRemovedKey      = "removed"
+)
+
+// HistoryOptions configures the inventory history.
+type HistoryOptions struct {
+	// Limit is the number of revisions retained. The history is disabled
+	// if zero.
+	Limit int
+	// FieldManager is recorded in the revisions as the author of the change,
+	// unless the apply passes its own field manager to ReplaceWithBase.
+	// Defaults to common.DefaultFieldManager.
+	FieldManager string
+}
+
+// Enabled returns true if the inventory history is recorded.
+func (ho HistoryOptions) Enabled() bool {
+	return ho.Limit > 0
+}
+
+// Revision is a recorded change of the set of objects in an inventory.
+type Revision struct {
+	// Number is the sequence number of the revision, starting at 1.
+	Number int
+	// Timestamp is the time the revision was recorded.
+	Timestamp time.Time
+	// FieldManager is the field manager of the apply, which stored the
+	// revision.
+	FieldManager string
+	// Objects is the set of objects in the inventory.
+	Objects object.ObjMetadataSet
+	// Added is the set of objects added since the previous revision.
+	Added object.ObjMetadataSet
+	// Removed is the set of objects removed since the previous revision.
+	Removed object.ObjMetadataSet
+}
+
+// HistoryClient reads the history of an inventory.
+type HistoryClient interface {
+	// ListRevisions returns the retained revisions of the inventory, ordered
+	// by number, or an error if one occurred.
+	ListRevisions(inv Info) ([]Revision, error)
+	// GetRevision returns the revision of the inventory with the passed
+	// number, or an error if one occurred or the revision is not retained.
+	GetRevision(inv Info, number int) (*Revision, error)
+}
+
+var _ HistoryClient = &ClusterClient{}
+
+// ListRevisions returns the retained revisions of the inventory, ordered by
+// number, or an error if one occurred.
+func (cic *ClusterClient) ListRevisions(inv Info) ([]Revision, error) {
+	if inv == nil {
+		return nil, fmt.Errorf("inventoryInfo must be specified")
+	}
+	items, err := cic.listRevisionObjs(inv.Namespace(), inv.ID())
+	if err != nil {
+		return nil, err
+	}
+	revisions := make([]Revision, 0, len(items))
+	for _, item := range items {
+		rev, err := revisionFrom(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid inventory revision %q: %w", item.GetName(), err)
+		}
+		revisions = append(revisions, *rev)
+	}
+	return revisions, nil
+}
+
+// GetRevision returns the revision of the inventory with the passed number,
+// or an error if one occurred or the revision is not retained.
+func (cic *ClusterClient) GetRevision(inv Info, number int) (*Revision, error) {
+	revisions, err := cic.ListRevisions(inv)
+	if err != nil {
+		return nil, err
+	}
+	for i := range revisions {
+		if revisions[i].Number == number {
+			return &revisions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("inventory revision %d not found", number)
+}
+
+// recordRevision records the passed set of objects as a new revision of the
+// cluster inventory object, if it differs from the latest revision, and
+// deletes the revisions beyond the history limit. The revision is recorded
+// with the next number again, if a concurrent writer recorded a revision with
+// the same number. The revision records the passed field manager, if not
+// empty.
+func (cic *ClusterClient) recordRevision(clusterInv *unstructured.Unstructured, objs object.ObjMetadataSet, fieldManager string) error {
+	id := clusterInv.GetLabels()[common.InventoryLabel]
+	if id == "" {
+		return fmt.Errorf("inventory object %s/%s has no inventory id", clusterInv.GetNamespace(), clusterInv.GetName())
+	}
+	client, err := cic.revisionClient(clusterInv.GetNamespace())
+	if err != nil {
+		return err
+	}
+
+	var items []*unstructured.Unstructured
+	err = retry.OnError(retry.DefaultRetry, apierrors.IsAlreadyExists, func() error {
+		var err error
+		items, err = cic.listRevisionObjs(clusterInv.GetNamespace(), id)
+		if err != nil {
+			return err
+		}
+		rev, err := cic.nextRevision(items, objs, fieldManager)
+		if err != nil || rev == nil {
+			return err
+		}
+		obj, err := revisionObject(clusterInv, id, *rev)
+		if err != nil {
+			return err
+		}
+		klog.V(4).Infof("creating inventory revision: %s/%s", obj.GetNamespace(), obj.GetName())
+		if _, err := client.Create(context.TODO(), obj, metav1.CreateOptions{}); err != nil {
+			return err
+		}
+		items = append(items, obj)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < len(items)-cic.History.Limit; i++ {
+		name := items[i].GetName()
+		klog.V(4).Infof("deleting inventory revision: %s/%s", clusterInv.GetNamespace(), name)
+		err := client.Delete(context.TODO(), name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextRevision returns the revision following the passed revision objects,
+// or nil if the passed set of objects equals the latest revision.
+func (cic *ClusterClient) nextRevision(items []*unstructured.Unstructured, objs object.ObjMetadataSet,
+	fieldManager string) (*Revision, error) {
+	number := 1
+	var prevObjs object.ObjMetadataSet
+	if len(items) > 0 {
+		prev, err := revisionFrom(items[len(items)-1])
+		if err != nil {
+			return nil, err
+		}
+		number = prev.Number + 1
+		prevObjs = prev.Objects
+	}
+	rev := &Revision{
+		Number:       number,
+		Timestamp:    time.Now().UTC(),
+		FieldManager: fieldManager,
+		Objects:      objs,
+		Added:        objs.Diff(prevObjs),
+		Removed:      prevObjs.Diff(objs),
+	}
+	if rev.FieldManager == "" {
+		rev.FieldManager = cic.History.FieldManager
+	}
+	if rev.FieldManager == "" {
+		rev.FieldManager = common.DefaultFieldManager
+	}
+	if len(items) > 0 && len(rev.Added) == 0 && len(rev.Removed) == 0 {
+		return nil, nil
+	}
+	return rev, nil
+}
+
+// listRevisionObjs returns the revision objects of the inventory, ordered by
+// number.
+func (cic *ClusterClient) listRevisionObjs(namespace, id string) ([]*unstructured.Unstructured, error) {
+	client, err := cic.revisionClient(namespace)
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.List(context.TODO(), metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", RevisionLabel, id),
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*unstructured.Unstructured, len(list.Items))
+	numbers := make(map[*unstructured.Unstructured]int, len(list.Items))
+	for i := range list.Items {
+		items[i] = &list.Items[i]
+		numbers[items[i]], _ = strconv.Atoi(items[i].GetAnnotations()[RevisionAnnotation])
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return numbers[items[i]] < numbers[items[j]]
+	})
+	return items, nil
+}
+
+func (cic *ClusterClient) revisionClient(namespace string) (dynamic.ResourceInterface, error) {
+	mapping, err := cic.mapper.RESTMapping(ConfigMapGVK.GroupKind(), ConfigMapGVK.Version)
+	if err != nil {
+		return nil, err
+	}
+	return cic.dc.Resource(mapping.Resource).Namespace(namespace), nil
+}
+
+// revisionObject returns the ConfigMap storing the revision, owned by the
+// inventory object, so that it is garbage collected with it. The revision is
+// stored compressed, in the FormatGzip format of the inventory ConfigMap.
+func revisionObject(clusterInv *unstructured.Unstructured, id string, rev Revision) (*unstructured.Unstructured, error) {
+	suffix := fmt.Sprintf("-rev-%d", rev.Number)
+	prefix := clusterInv.GetName()
+	if l := maxNameLength - len(suffix); len(prefix) > l {
+		prefix = prefix[:l]
+	}
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(ConfigMapGVK)
+	obj.SetNamespace(clusterInv.GetNamespace())
+	obj.SetName(prefix + suffix)
+	obj.SetLabels(map[string]string{RevisionLabel: id})
+	obj.SetAnnotations(map[string]string{RevisionAnnotation: strconv.Itoa(rev.Number)})
+	if clusterInv.GetUID() != "" {
+		obj.SetOwnerReferences([]metav1.OwnerReference{
+			{
+				APIVersion: clusterInv.GetAPIVersion(),
+				Kind:       clusterInv.GetKind(),
+				Name:       clusterInv.GetName(),
+				UID:        clusterInv.GetUID(),
+			},
+		})
+	}
+	err := setCompressedData(obj, map[string]string{
+		revisionTimestampKey:    rev.Timestamp.Format(time.RFC3339),
+		revisionFieldManagerKey: rev.FieldManager,
+		revisionObjectsKey:      joinObjMetas(rev.Objects),
+		revisionAddedKey:        joinObjMetas(rev.Added),
+		revisionRemovedKey:      joinObjMetas(rev.Removed),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// revisionFrom parses the revision stored by revisionObject.
+func revisionFrom(obj *unstructured.Unstructured) (*Revision, error) {
+	numberStr := obj.GetAnnotations()[RevisionAnnotation]
+	number, err := strconv.Atoi(numberStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid revision number %q: %w", numberStr, err)
+	}
+	data, err := configMapData(obj)
+	if err != nil {
+		return nil, err
+	}
+	rev := &Revision{
+		Number:       number,
+		FieldManager: data[revisionFieldManagerKey],
+	}
+	if rev.Timestamp, err = time.Parse(time.RFC3339, data[revisionTimestampKey]); err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q: %w", data[revisionTimestampKey], err)
+	}
+	if rev.Objects, err = splitObjMetas(data[revisionObjectsKey]); err != nil {
+		return nil, err
+	}
+	if rev.Added, err = splitObjMetas(data[revisionAddedKey]); err != nil {
+		return nil, err
+	}
+	if rev.Removed, err = splitObjMetas(data[revisionRemovedKey]); err != nil {
+		return nil, err
+	}
+	return rev, nil
+}
+
+// IsInventoryRevision returns true if the passed object is the revision of an
+// inventory object.
+func IsInventoryRevision(obj *unstructured.Unstructured) bool {
+	if obj == nil {
+		return false
+	}
+	_, found := obj.GetLabels()[RevisionLabel]
+	return found
+}
+
+// joinObjMetas returns the sorted object metadata, one per line.
+func joinObjMetas(objs object.ObjMetadataSet) string {
+	strs := make([]string, len(objs))
+	for i, obj := range objs {
+		strs[i] = obj.String()
+	}
+	sort.Strings(strs)
+	return strings.Join(strs, "\n")
+}
+
+// splitObjMetas parses the object metadata joined by joinObjMetas.
+func splitObjMetas(s string) (object.ObjMetadataSet, error) {
+	objs := object.ObjMetadataSet{}
+	for _, line := range strings.Split(s, "\n") {
+		if line == "" {
+			continue
+		}
+		obj, err := object.ParseObjMetadata(line)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
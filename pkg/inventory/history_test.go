@@ -0,0 +1,192 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/cli-utils/pkg/common"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/cli-utils/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func newHistoryClient(inv *unstructured.Unstructured) (*dynamicfake.FakeDynamicClient, *ClusterClient) {
+	dc := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			configMapGVR: "ConfigMapList",
+		}, inv.DeepCopy())
+	return dc, &ClusterClient{
+		dc:                    dc,
+		mapper:                testutil.NewFakeRESTMapper(ConfigMapGVK),
+		InventoryFactoryFunc:  WrapInventoryObj,
+		invToUnstructuredFunc: InvInfoToConfigMap,
+		statusPolicy:          StatusPolicyNone,
+		gvk:                   ConfigMapGVK,
+		History: HistoryOptions{
+			Limit:        2,
+			FieldManager: "test-manager",
+		},
+	}
+}
+
+func TestInventoryHistory(t *testing.T) {
+	objA := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "a"}
+	objB := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "b"}
+	objC := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "c"}
+
+	inv := newShardedInv("test-id")
+	inv.SetUID("inv-uid")
+	dc, invClient := newHistoryClient(inv)
+	localInv := WrapInventoryInfoObj(inv)
+
+	for _, objs := range []object.ObjMetadataSet{
+		{objA, objB},
+		// unchanged, not recorded
+		{objA, objB},
+		{objB, objC},
+		{objC},
+	} {
+		err := invClient.Replace(localInv, objs, nil, common.DryRunNone)
+		require.NoError(t, err)
+	}
+
+	revisions, err := invClient.ListRevisions(localInv)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+
+	assert.Equal(t, 2, revisions[0].Number)
+	assert.Equal(t, "test-manager", revisions[0].FieldManager)
+	assert.False(t, revisions[0].Timestamp.IsZero())
+	assert.ElementsMatch(t, object.ObjMetadataSet{objB, objC}, revisions[0].Objects)
+	assert.Equal(t, object.ObjMetadataSet{objC}, revisions[0].Added)
+	assert.Equal(t, object.ObjMetadataSet{objA}, revisions[0].Removed)
+
+	assert.Equal(t, 3, revisions[1].Number)
+	assert.Equal(t, object.ObjMetadataSet{objC}, revisions[1].Objects)
+	assert.Equal(t, object.ObjMetadataSet{}, revisions[1].Added)
+	assert.Equal(t, object.ObjMetadataSet{objB}, revisions[1].Removed)
+
+	rev, err := invClient.GetRevision(localInv, 3)
+	require.NoError(t, err)
+	assert.Equal(t, revisions[1], *rev)
+	_, err = invClient.GetRevision(localInv, 1)
+	assert.EqualError(t, err, "inventory revision 1 not found")
+
+	// Revisions are owned by the inventory object.
+	revObj, err := dc.Resource(configMapGVR).Namespace("test-ns").
+		Get(context.TODO(), "inventory-rev-3", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, revObj.GetOwnerReferences(), 1)
+	assert.Equal(t, inv.GetUID(), revObj.GetOwnerReferences()[0].UID)
+	// Revisions are stored compressed.
+	assert.Equal(t, FormatGzip, revObj.GetAnnotations()[FormatAnnotation])
+	_, found := revObj.Object["data"]
+	assert.False(t, found)
+
+	// Revisions are not listed as inventory objects.
+	invs, err := invClient.ListClusterInventoryObjs(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]object.ObjMetadataSet{"inventory": {objC}}, invs)
+}
+
+func TestInventoryHistoryConcurrentRevision(t *testing.T) {
+	objA := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "a"}
+	objB := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "b"}
+
+	inv := newShardedInv("test-id")
+	dc, invClient := newHistoryClient(inv)
+
+	// A concurrent writer records the first revision, just before this one.
+	concurrentRev, err := revisionObject(inv, "test-id", Revision{
+		Number:       1,
+		Timestamp:    time.Now().UTC(),
+		FieldManager: "other-manager",
+		Objects:      object.ObjMetadataSet{objA},
+		Added:        object.ObjMetadataSet{objA},
+	})
+	require.NoError(t, err)
+	dc.PrependReactor("create", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		obj := action.(clienttesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		if concurrentRev == nil || obj.GetName() != concurrentRev.GetName() {
+			return false, nil, nil
+		}
+		err := dc.Tracker().Create(configMapGVR, concurrentRev, "test-ns")
+		concurrentRev = nil
+		if err != nil {
+			return true, nil, err
+		}
+		return true, nil, apierrors.NewAlreadyExists(configMapGVR.GroupResource(), obj.GetName())
+	})
+
+	err = invClient.Replace(WrapInventoryInfoObj(inv), object.ObjMetadataSet{objA, objB}, nil, common.DryRunNone)
+	require.NoError(t, err)
+
+	revisions, err := invClient.ListRevisions(WrapInventoryInfoObj(inv))
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "other-manager", revisions[0].FieldManager)
+	assert.Equal(t, 2, revisions[1].Number)
+	assert.Equal(t, "test-manager", revisions[1].FieldManager)
+	assert.Equal(t, object.ObjMetadataSet{objB}, revisions[1].Added)
+	assert.Equal(t, object.ObjMetadataSet{}, revisions[1].Removed)
+}
+
+func TestInventoryHistoryFailure(t *testing.T) {
+	objA := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "a"}
+
+	inv := newShardedInv("test-id")
+	dc, invClient := newHistoryClient(inv)
+	dc.PrependReactor("create", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		obj := action.(clienttesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		if !IsInventoryRevision(obj) {
+			return false, nil, nil
+		}
+		return true, nil, apierrors.NewRequestEntityTooLargeError("limit is 1048576")
+	})
+
+	// A failure to record the revision does not fail the replace.
+	err := invClient.Replace(WrapInventoryInfoObj(inv), object.ObjMetadataSet{objA}, nil, common.DryRunNone)
+	require.NoError(t, err)
+
+	objs, err := invClient.GetClusterObjs(WrapInventoryInfoObj(inv))
+	require.NoError(t, err)
+	assert.Equal(t, object.ObjMetadataSet{objA}, objs)
+	revisions, err := invClient.ListRevisions(WrapInventoryInfoObj(inv))
+	require.NoError(t, err)
+	assert.Empty(t, revisions)
+}
+
+func TestInventoryHistoryFieldManager(t *testing.T) {
+	objA := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "a"}
+	objB := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "b"}
+
+	inv := newShardedInv("test-id")
+	_, invClient := newHistoryClient(inv)
+	localInv := WrapInventoryInfoObj(inv)
+
+	// The field manager of the apply is recorded.
+	_, err := invClient.ReplaceWithBase(localInv, object.ObjMetadataSet{objA}, nil, nil,
+		ReplaceOptions{FieldManager: "my-manager"})
+	require.NoError(t, err)
+	// Without it, the field manager of the history options is recorded.
+	err = invClient.Replace(localInv, object.ObjMetadataSet{objA, objB}, nil, common.DryRunNone)
+	require.NoError(t, err)
+
+	revisions, err := invClient.ListRevisions(localInv)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "my-manager", revisions[0].FieldManager)
+	assert.Equal(t, "test-manager", revisions[1].FieldManager)
+}
@@ -4,6 +4,8 @@
 package inventory
 
 import (
+	"fmt"
+
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 )
 
@@ -19,8 +21,47 @@ type ClientFactory interface {
 // ClusterClientFactory is a factory that creates instances of ClusterClient inventory client.
 type ClusterClientFactory struct {
 	StatusPolicy StatusPolicy
+	// Sharding splits the inventory across multiple objects, if enabled, so
+	// that it may exceed the size limit of a single ConfigMap.
+	Sharding ShardOptions
+	// Compress stores the inventory compressed in the binaryData of the
+	// ConfigMap. Not supported together with Sharding.
+	Compress bool
+	// History retains the last revisions of the inventory, if enabled.
+	History HistoryOptions
 }
 
 func (ccf ClusterClientFactory) NewClient(factory cmdutil.Factory) (Client, error) {
-	return NewClient(factory, WrapInventoryObj, InvInfoToConfigMap, ccf.StatusPolicy, ConfigMapGVK)
+	invFunc, err := ccf.storageFactory(factory)
+	if err != nil {
+		return nil, err
+	}
+	client, err := NewClient(factory, invFunc, InvInfoToConfigMap, ccf.StatusPolicy, ConfigMapGVK)
+	if err != nil {
+		return nil, err
+	}
+	client.History = ccf.History
+	return client, nil
+}
+
+// storageFactory returns the StorageFactoryFunc for the configured format.
+func (ccf ClusterClientFactory) storageFactory(factory cmdutil.Factory) (StorageFactoryFunc, error) {
+	if !ccf.Sharding.Enabled() {
+		if ccf.Compress {
+			return WrapCompressedInventoryObj, nil
+		}
+		return WrapInventoryObj, nil
+	}
+	if ccf.Compress {
+		return nil, fmt.Errorf("compressed inventory is not supported with sharding")
+	}
+	dc, err := factory.DynamicClient()
+	if err != nil {
+		return nil, err
+	}
+	mapper, err := factory.ToRESTMapper()
+	if err != nil {
+		return nil, err
+	}
+	return ShardedStorageFactory(dc, mapper, ccf.Sharding), nil
 }
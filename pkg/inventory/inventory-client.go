@@ -17,6 +17,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util"
@@ -49,6 +50,93 @@ type Client interface {
 	ListClusterInventoryObjs(ctx context.Context) (map[string]object.ObjMetadataSet, error)
 }
 
+// StatusClient is implemented by clients, which read the object status stored
+// in the cluster inventory object. Use GetClusterObjStatus to call it, if the
+// client implements it.
+type StatusClient interface {
+	// GetClusterObjStatus returns the object status stored in the inventory
+	// object living in the cluster by the previous apply or destroy, or an
+	// error if one occurred. The status is only stored with StatusPolicyAll.
+	GetClusterObjStatus(inv Info) ([]actuation.ObjectStatus, error)
+}
+
+// RebaseClient is implemented by clients, which keep the changes of
+// concurrent writers of the inventory object. Use MergeWithBase and
+// ReplaceWithBase to call it, if the client implements it.
+type RebaseClient interface {
+	// MergeWithBase is like Merge, but also returns the base of the stored
+	// inventory, which is nil for dry-runs.
+	MergeWithBase(inv Info, objs object.ObjMetadataSet, opts MergeOptions) (object.ObjMetadataSet, *Base, error)
+	// ReplaceWithBase is like Replace, but creates the inventory object if
+	// it does not exist, and rebases the objects onto the inventory object,
+	// if it was changed since the passed base was stored. The cluster
+	// inventory object is the base, if it is nil. Returns the base of the
+	// stored inventory.
+	ReplaceWithBase(inv Info, objs object.ObjMetadataSet, status []actuation.ObjectStatus, base *Base,
+		opts ReplaceOptions) (*Base, error)
+}
+
+// MergeOptions configures MergeWithBase.
+type MergeOptions struct {
+	DryRun common.DryRunStrategy
+	// KeepStatus keeps the status of the objects already stored in the
+	// inventory object, instead of resetting it to pending, so that an
+	// interrupted run can be resumed from it. Requires StatusPolicyAll.
+	KeepStatus bool
+}
+
+// ReplaceOptions configures ReplaceWithBase.
+type ReplaceOptions struct {
+	DryRun common.DryRunStrategy
+	// FieldManager is the field manager of the apply storing the objects,
+	// which is recorded in the inventory revision. Defaults to the
+	// FieldManager of the HistoryOptions.
+	FieldManager string
+}
+
+// GetClusterObjStatus returns the object status stored in the cluster
+// inventory object with the client, or nil if the client is not a
+// StatusClient.
+func GetClusterObjStatus(c Client, inv Info) ([]actuation.ObjectStatus, error) {
+	if sc, ok := c.(StatusClient); ok {
+		return sc.GetClusterObjStatus(inv)
+	}
+	return nil, nil
+}
+
+// Base is the content of the cluster inventory object stored by Merge or
+// Replace, which a later Replace rebases the replaced objects onto, if the
+// inventory object was changed concurrently in between.
+type Base struct {
+	// Objects are the objects stored in the inventory object.
+	Objects object.ObjMetadataSet
+	// ResourceVersion is the resourceVersion of the stored inventory object.
+	ResourceVersion string
+}
+
+// MergeWithBase merges the objects into the inventory object with the client,
+// and returns the objects to prune and the base of the stored inventory. The
+// base is nil, if the client is not a RebaseClient.
+func MergeWithBase(c Client, inv Info, objs object.ObjMetadataSet, opts MergeOptions) (object.ObjMetadataSet, *Base, error) {
+	if rc, ok := c.(RebaseClient); ok {
+		return rc.MergeWithBase(inv, objs, opts)
+	}
+	pruneIds, err := c.Merge(inv, objs, opts.DryRun)
+	return pruneIds, nil, err
+}
+
+// ReplaceWithBase replaces the objects stored in the inventory object with the
+// client, and returns the base of the stored inventory. The objects are only
+// rebased onto the changes of concurrent writers, and the base is nil, if the
+// client is not a RebaseClient.
+func ReplaceWithBase(c Client, inv Info, objs object.ObjMetadataSet, status []actuation.ObjectStatus,
+	base *Base, opts ReplaceOptions) (*Base, error) {
+	if rc, ok := c.(RebaseClient); ok {
+		return rc.ReplaceWithBase(inv, objs, status, base, opts)
+	}
+	return nil, c.Replace(inv, objs, status, opts.DryRun)
+}
+
 // ClusterClient is a concrete implementation of the
 // Client interface.
 type ClusterClient struct {
@@ -59,9 +147,15 @@ type ClusterClient struct {
 	invToUnstructuredFunc ToUnstructuredFunc
 	statusPolicy          StatusPolicy
 	gvk                   schema.GroupVersionKind
+	// History records the changes of the inventory by Replace, if enabled.
+	History HistoryOptions
 }
 
-var _ Client = &ClusterClient{}
+var (
+	_ Client       = &ClusterClient{}
+	_ StatusClient = &ClusterClient{}
+	_ RebaseClient = &ClusterClient{}
+)
 
 // NewClient returns a concrete implementation of the
 // Client interface or an error.
@@ -102,12 +196,36 @@ func NewClient(factory cmdutil.Factory,
 // to prune. Creates the initial cluster inventory object storing the passed
 // objects if an inventory object does not exist. Returns an error if one
 // occurred.
+//
+// If the cluster inventory object is changed or created concurrently, it is
+// read again and the union is stored again.
 func (cic *ClusterClient) Merge(localInv Info, objs object.ObjMetadataSet, dryRun common.DryRunStrategy) (object.ObjMetadataSet, error) {
+	pruneIds, _, err := cic.MergeWithBase(localInv, objs, MergeOptions{DryRun: dryRun})
+	return pruneIds, err
+}
+
+// MergeWithBase is like Merge, but also returns the base of the stored
+// inventory. The base is passed to ReplaceWithBase, so that it keeps the
+// changes of writers after Merge.
+func (cic *ClusterClient) MergeWithBase(localInv Info, objs object.ObjMetadataSet, opts MergeOptions) (object.ObjMetadataSet, *Base, error) {
+	var pruneIds object.ObjMetadataSet
+	var base *Base
+	err := retryOnConflict(func() error {
+		var err error
+		pruneIds, base, err = cic.merge(localInv, objs, opts)
+		return err
+	})
+	return pruneIds, base, err
+}
+
+// merge is a single attempt of Merge.
+func (cic *ClusterClient) merge(localInv Info, objs object.ObjMetadataSet, opts MergeOptions) (object.ObjMetadataSet, *Base, error) {
+	dryRun := opts.DryRun
 	pruneIds := object.ObjMetadataSet{}
 	invObj := cic.invToUnstructuredFunc(localInv)
 	clusterInv, err := cic.GetClusterInventoryInfo(localInv)
 	if err != nil {
-		return pruneIds, err
+		return pruneIds, nil, err
 	}
 
 	// Inventory does not exist on the cluster.
@@ -119,91 +237,244 @@ func (cic *ClusterClient) Merge(localInv Info, objs object.ObjMetadataSet, dryRu
 		}
 		inv := cic.InventoryFactoryFunc(invObj)
 		if err := inv.Store(objs, status); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		klog.V(4).Infof("creating initial inventory object with %d objects", len(objs))
 
 		if dryRun.ClientOrServerDryRun() {
 			klog.V(4).Infof("dry-run create inventory object: not created")
-			return nil, nil
+			return nil, nil, nil
 		}
 
-		err = inv.Apply(cic.dc, cic.mapper, cic.statusPolicy)
-		return nil, err
+		if err := inv.Apply(cic.dc, cic.mapper, cic.statusPolicy); err != nil {
+			return nil, nil, err
+		}
+		base, err := storedBase(inv, objs)
+		return nil, base, err
 	}
 
 	// Update existing cluster inventory with merged union of objects
-	clusterObjs, err := cic.GetClusterObjs(localInv)
+	wrappedInv := cic.InventoryFactoryFunc(clusterInv)
+	clusterObjs, err := wrappedInv.Load()
 	if err != nil {
-		return pruneIds, err
+		return pruneIds, nil, err
 	}
 	pruneIds = clusterObjs.Diff(objs)
 	unionObjs := clusterObjs.Union(objs)
 	var status []actuation.ObjectStatus
 	if cic.statusPolicy == StatusPolicyAll {
 		status = getObjStatus(pruneIds, unionObjs)
+		if opts.KeepStatus {
+			clusterStatus, err := loadStatus(wrappedInv)
+			if err != nil {
+				return pruneIds, nil, err
+			}
+			status = keepObjStatus(status, clusterStatus)
+		}
 	}
 	klog.V(4).Infof("num objects to prune: %d", len(pruneIds))
 	klog.V(4).Infof("num merged objects to store in inventory: %d", len(unionObjs))
-	wrappedInv := cic.InventoryFactoryFunc(clusterInv)
 	if err = wrappedInv.Store(unionObjs, status); err != nil {
-		return pruneIds, err
+		return pruneIds, nil, err
 	}
 
 	// Update not required when all objects in inventory are the same and
 	// status does not need to be updated. If status is stored, always update the
 	// inventory to store the latest status.
 	if objs.Equal(clusterObjs) && cic.statusPolicy == StatusPolicyNone {
-		return pruneIds, nil
+		return pruneIds, &Base{Objects: clusterObjs, ResourceVersion: clusterInv.GetResourceVersion()}, nil
 	}
 
 	if dryRun.ClientOrServerDryRun() {
 		klog.V(4).Infof("dry-run create inventory object: not created")
-		return pruneIds, nil
+		return pruneIds, nil, nil
 	}
-	err = wrappedInv.Apply(cic.dc, cic.mapper, cic.statusPolicy)
-	return pruneIds, err
+	if err := wrappedInv.Apply(cic.dc, cic.mapper, cic.statusPolicy); err != nil {
+		return pruneIds, nil, err
+	}
+	base, err := storedBase(wrappedInv, unionObjs)
+	return pruneIds, base, err
+}
+
+// storedBase returns the base of the objects stored by the applied inventory
+// object.
+func storedBase(wrappedInv Storage, objs object.ObjMetadataSet) (*Base, error) {
+	invObj, err := wrappedInv.GetObject()
+	if err != nil {
+		return nil, err
+	}
+	return &Base{Objects: objs, ResourceVersion: invObj.GetResourceVersion()}, nil
 }
 
 // Replace stores the passed objects in the cluster inventory object, or
-// an error if one occurred.
+// an error if one occurred. Creates the inventory object, if it does not
+// exist. If the cluster inventory object is changed concurrently, the
+// objects are rebased onto it, like ReplaceWithBase does with a nil base.
 func (cic *ClusterClient) Replace(localInv Info, objs object.ObjMetadataSet, status []actuation.ObjectStatus,
 	dryRun common.DryRunStrategy) error {
+	_, err := cic.ReplaceWithBase(localInv, objs, status, nil, ReplaceOptions{DryRun: dryRun})
+	return err
+}
+
+// ReplaceWithBase stores the passed objects in the cluster inventory object,
+// or an error if one occurred. Creates the inventory object, if it does not
+// exist. Returns the base of the stored inventory.
+//
+// If the cluster inventory object was changed since the passed base was
+// stored, or is changed concurrently, it is read again, and the objects added
+// and removed by Replace are added to and removed from the objects stored by
+// the concurrent writer. If the base is nil, the cluster inventory object read
+// first is the base. Returns an InventoryConflictError, if the concurrent
+// writer removed objects that are still stored, because it is ambiguous
+// whether they still exist.
+func (cic *ClusterClient) ReplaceWithBase(localInv Info, objs object.ObjMetadataSet, status []actuation.ObjectStatus,
+	base *Base, opts ReplaceOptions) (*Base, error) {
 	// Skip entire function for dry-run.
-	if dryRun.ClientOrServerDryRun() {
+	if opts.DryRun.ClientOrServerDryRun() {
 		klog.V(4).Infoln("dry-run replace inventory object: not applied")
-		return nil
+		return base, nil
 	}
-	clusterInv, err := cic.GetClusterInventoryInfo(localInv)
+
+	var clusterInv *unstructured.Unstructured
+	var stored *Base
+	replacedObjs := objs
+	err := retryOnConflict(func() error {
+		var err error
+		clusterInv, err = cic.GetClusterInventoryInfo(localInv)
+		if err != nil {
+			return fmt.Errorf("failed to read inventory from cluster: %w", err)
+		}
+		var wrapped Storage
+		var clusterObjs object.ObjMetadataSet
+		var clusterVersion string
+		if clusterInv != nil {
+			wrapped = cic.InventoryFactoryFunc(clusterInv)
+			if clusterObjs, err = wrapped.Load(); err != nil {
+				return fmt.Errorf("failed to read inventory objects from cluster: %w", err)
+			}
+			clusterVersion = clusterInv.GetResourceVersion()
+		}
+		if base == nil {
+			base = &Base{Objects: clusterObjs, ResourceVersion: clusterVersion}
+		}
+
+		replacedObjs = objs
+		replacedStatus := status
+		if clusterVersion != base.ResourceVersion {
+			klog.V(4).Infof("inventory changed concurrently, rebasing %d objects", len(objs))
+			var clusterStatus []actuation.ObjectStatus
+			if wrapped != nil {
+				if clusterStatus, err = loadStatus(wrapped); err != nil {
+					return fmt.Errorf("failed to read inventory objects from cluster: %w", err)
+				}
+			}
+			replacedObjs, err = rebaseObjs(base.Objects, objs, clusterObjs)
+			if err != nil {
+				return err
+			}
+			replacedStatus = rebaseStatus(replacedObjs, objs, status, clusterStatus)
+		}
+		stored, err = cic.replace(localInv, clusterInv, clusterObjs, replacedObjs, replacedStatus)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to read inventory from cluster: %w", err)
+		return nil, err
 	}
 
-	clusterObjs, err := cic.GetClusterObjs(localInv)
-	if err != nil {
-		return fmt.Errorf("failed to read inventory objects from cluster: %w", err)
+	// The objects may have been added to the inventory by Merge, so the
+	// revision is recorded even if the inventory was not updated.
+	if cic.History.Enabled() {
+		if clusterInv == nil {
+			clusterInv = cic.invToUnstructuredFunc(localInv)
+		}
+		// The inventory is already stored, so a failure to record the
+		// revision does not fail the replace.
+		if err := cic.recordRevision(clusterInv, replacedObjs, opts.FieldManager); err != nil {
+			klog.Warningf("failed to record inventory revision: %v", err)
+		}
+	}
+	return stored, nil
+}
+
+// replace is a single attempt of Replace, which stores the objects in the
+// cluster inventory object, which stores clusterObjs. Creates the inventory
+// object, if clusterInv is nil. The update is conditional on the
+// resourceVersion of clusterInv.
+func (cic *ClusterClient) replace(localInv Info, clusterInv *unstructured.Unstructured, clusterObjs, objs object.ObjMetadataSet,
+	status []actuation.ObjectStatus) (*Base, error) {
+	if clusterInv == nil {
+		_, wrappedInv, err := cic.replaceInventory(cic.invToUnstructuredFunc(localInv), objs, status)
+		if err != nil {
+			return nil, err
+		}
+		klog.V(4).Infof("creating inventory object with %d objects", len(objs))
+		if err := wrappedInv.Apply(cic.dc, cic.mapper, cic.statusPolicy); err != nil {
+			return nil, fmt.Errorf("failed to create inventory in cluster: %w", err)
+		}
+		return storedBase(wrappedInv, objs)
 	}
 
+	clusterVersion := clusterInv.GetResourceVersion()
 	clusterInv, wrappedInv, err := cic.replaceInventory(clusterInv, objs, status)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Update not required when all objects in inventory are the same and
 	// status does not need to be updated. If status is stored, always update the
 	// inventory to store the latest status.
 	if objs.Equal(clusterObjs) && cic.statusPolicy == StatusPolicyNone {
-		return nil
+		return &Base{Objects: clusterObjs, ResourceVersion: clusterVersion}, nil
 	}
 
 	klog.V(4).Infof("replace cluster inventory: %s/%s", clusterInv.GetNamespace(), clusterInv.GetName())
 	klog.V(4).Infof("replace cluster inventory %d objects", len(objs))
 
 	if err := wrappedInv.ApplyWithPrune(cic.dc, cic.mapper, cic.statusPolicy, objs); err != nil {
-		return fmt.Errorf("failed to write updated inventory to cluster: %w", err)
+		return nil, fmt.Errorf("failed to write updated inventory to cluster: %w", err)
 	}
+	return storedBase(wrappedInv, objs)
+}
 
-	return nil
+// rebaseObjs returns the objects stored by a concurrent writer (theirs), with
+// the objects added to and removed from base by ours added and removed.
+// Returns an InventoryConflictError, if the concurrent writer removed objects
+// from base that ours still stores.
+func rebaseObjs(base, ours, theirs object.ObjMetadataSet) (object.ObjMetadataSet, error) {
+	if removed := base.Intersection(ours).Diff(theirs); len(removed) > 0 {
+		return nil, &InventoryConflictError{Objects: removed}
+	}
+	return theirs.Union(ours.Diff(base)).Diff(base.Diff(ours)), nil
+}
+
+// rebaseStatus returns the status of the rebased objects: the status of ours
+// for the objects of ours, and the status of theirs for the others.
+func rebaseStatus(objs, ours object.ObjMetadataSet, ourStatus, theirStatus []actuation.ObjectStatus) []actuation.ObjectStatus {
+	statusMap := map[object.ObjMetadata]actuation.ObjectStatus{}
+	for _, s := range theirStatus {
+		id := ObjMetadataFromObjectReference(s.ObjectReference)
+		if !ours.Contains(id) {
+			statusMap[id] = s
+		}
+	}
+	for _, s := range ourStatus {
+		statusMap[ObjMetadataFromObjectReference(s.ObjectReference)] = s
+	}
+	var status []actuation.ObjectStatus
+	for _, id := range objs {
+		if s, found := statusMap[id]; found {
+			status = append(status, s)
+		}
+	}
+	return status
+}
+
+// retryOnConflict retries fn, if the inventory object was changed or created
+// concurrently.
+func retryOnConflict(fn func() error) error {
+	return retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)
+	}, fn)
 }
 
 // replaceInventory stores the passed objects into the passed inventory object.
@@ -268,6 +539,20 @@ func (cic *ClusterClient) GetClusterObjs(localInv Info) (object.ObjMetadataSet,
 	return wrapped.Load()
 }
 
+// GetClusterObjStatus returns the object status stored in the cluster
+// inventory object, or an error if one occurred.
+func (cic *ClusterClient) GetClusterObjStatus(localInv Info) ([]actuation.ObjectStatus, error) {
+	clusterInv, err := cic.GetClusterInventoryInfo(localInv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inventory from cluster: %w", err)
+	}
+	// First time; no inventory obj yet.
+	if clusterInv == nil {
+		return nil, nil
+	}
+	return loadStatus(cic.InventoryFactoryFunc(clusterInv))
+}
+
 // getClusterInventoryObj returns a pointer to the cluster inventory object, or
 // an error if one occurred. Returns the cached cluster inventory object if it
 // has been previously retrieved. Uses the ResourceBuilder to retrieve the
@@ -384,6 +669,11 @@ func (cic *ClusterClient) ListClusterInventoryObjs(ctx context.Context) (map[str
 	identifiers := make(map[string]object.ObjMetadataSet)
 
 	for i, inv := range clusterInvs.Items {
+		if IsInventoryShard(&clusterInvs.Items[i]) || IsInventoryRevision(&clusterInvs.Items[i]) {
+			// Shards are loaded with their inventory object, and revisions
+			// are not inventory objects.
+			continue
+		}
 		invName := inv.GetName()
 		identifiers[invName] = object.ObjMetadataSet{}
 		wrappedInvObjSlice, err := cic.InventoryFactoryFunc(&clusterInvs.Items[i]).Load()
@@ -475,6 +765,27 @@ func (cic *ClusterClient) getMapping(obj *unstructured.Unstructured) (*meta.REST
 	return cic.mapper.RESTMapping(obj.GroupVersionKind().GroupKind(), obj.GroupVersionKind().Version)
 }
 
+// keepObjStatus returns the passed pending status, with the apply status of
+// each object replaced by its status in the passed cluster status, if the
+// object was applied before.
+func keepObjStatus(status, clusterStatus []actuation.ObjectStatus) []actuation.ObjectStatus {
+	applied := make(map[object.ObjMetadata]actuation.ObjectStatus, len(clusterStatus))
+	for _, s := range clusterStatus {
+		if s.Strategy == actuation.ActuationStrategyApply {
+			applied[ObjMetadataFromObjectReference(s.ObjectReference)] = s
+		}
+	}
+	for i, s := range status {
+		if s.Strategy != actuation.ActuationStrategyApply {
+			continue
+		}
+		if prev, found := applied[ObjMetadataFromObjectReference(s.ObjectReference)]; found {
+			status[i] = prev
+		}
+	}
+	return status
+}
+
 // getObjStatus returns the list of object status
 // at the beginning of an apply process.
 func getObjStatus(pruneIds, unionIds []object.ObjMetadata) []actuation.ObjectStatus {
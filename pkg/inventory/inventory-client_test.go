@@ -10,6 +10,7 @@ import (
 	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
 	"github.com/fluxcd/cli-utils/pkg/common"
 	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/cli-utils/pkg/testutil"
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -18,6 +19,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/resource"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	clienttesting "k8s.io/client-go/testing"
 	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
 )
@@ -223,6 +225,74 @@ func TestMerge(t *testing.T) {
 	}
 }
 
+func TestMergeKeepStatus(t *testing.T) {
+	objA := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "a"}
+	objB := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "b"}
+	appliedStatus := actuation.ObjectStatus{
+		ObjectReference: ObjectReferenceFromObjMetadata(objA),
+		Strategy:        actuation.ActuationStrategyApply,
+		Actuation:       actuation.ActuationSucceeded,
+		Reconcile:       actuation.ReconcileSucceeded,
+		UID:             "uid-a",
+		Generation:      2,
+		Hash:            "hash-a",
+	}
+	pendingStatus := func(id object.ObjMetadata) actuation.ObjectStatus {
+		return actuation.ObjectStatus{
+			ObjectReference: ObjectReferenceFromObjMetadata(id),
+			Strategy:        actuation.ActuationStrategyApply,
+			Actuation:       actuation.ActuationPending,
+			Reconcile:       actuation.ReconcilePending,
+		}
+	}
+
+	testCases := map[string]struct {
+		keepStatus     bool
+		expectedStatus []actuation.ObjectStatus
+	}{
+		"status reset to pending": {
+			expectedStatus: []actuation.ObjectStatus{pendingStatus(objA), pendingStatus(objB)},
+		},
+		"status of stored objects kept": {
+			keepStatus:     true,
+			expectedStatus: []actuation.ObjectStatus{appliedStatus, pendingStatus(objB)},
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			inv := newShardedInv("test-id")
+			wrapped := WrapInventoryObj(inv)
+			require.NoError(t, wrapped.Store(object.ObjMetadataSet{objA}, []actuation.ObjectStatus{appliedStatus}))
+			inv, err := wrapped.GetObject()
+			require.NoError(t, err)
+			dc := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{
+					configMapGVR: "ConfigMapList",
+				}, inv)
+			invClient := &ClusterClient{
+				dc:                    dc,
+				mapper:                testutil.NewFakeRESTMapper(ConfigMapGVK),
+				InventoryFactoryFunc:  WrapInventoryObj,
+				invToUnstructuredFunc: InvInfoToConfigMap,
+				statusPolicy:          StatusPolicyAll,
+				gvk:                   ConfigMapGVK,
+			}
+			localInv := WrapInventoryInfoObj(inv)
+
+			_, _, err = invClient.MergeWithBase(localInv, object.ObjMetadataSet{objA, objB}, MergeOptions{
+				DryRun:     common.DryRunNone,
+				KeepStatus: tc.keepStatus,
+			})
+			require.NoError(t, err)
+
+			status, err := invClient.GetClusterObjStatus(localInv)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tc.expectedStatus, status)
+		})
+	}
+}
+
 func TestCreateInventory(t *testing.T) {
 	tests := map[string]struct {
 		statusPolicy StatusPolicy
@@ -595,3 +665,264 @@ func storeObjsInInventory(info Info, objs object.ObjMetadataSet, status []actuat
 	inv, _ := wrapped.GetObject()
 	return inv
 }
+
+func TestRebaseObjs(t *testing.T) {
+	objA := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "a"}
+	objB := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "b"}
+	objC := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "c"}
+	objD := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "d"}
+
+	tests := map[string]struct {
+		base          object.ObjMetadataSet
+		ours          object.ObjMetadataSet
+		theirs        object.ObjMetadataSet
+		expected      object.ObjMetadataSet
+		expectedError error
+	}{
+		"theirs unchanged": {
+			base:     object.ObjMetadataSet{objA, objB},
+			ours:     object.ObjMetadataSet{objB, objC},
+			theirs:   object.ObjMetadataSet{objA, objB},
+			expected: object.ObjMetadataSet{objB, objC},
+		},
+		"both added objects": {
+			base:     object.ObjMetadataSet{objA},
+			ours:     object.ObjMetadataSet{objA, objB},
+			theirs:   object.ObjMetadataSet{objA, objC},
+			expected: object.ObjMetadataSet{objA, objC, objB},
+		},
+		"both removed the same object": {
+			base:     object.ObjMetadataSet{objA, objB},
+			ours:     object.ObjMetadataSet{objA},
+			theirs:   object.ObjMetadataSet{objA},
+			expected: object.ObjMetadataSet{objA},
+		},
+		"ours removed, theirs added": {
+			base:     object.ObjMetadataSet{objA, objB},
+			ours:     object.ObjMetadataSet{objA},
+			theirs:   object.ObjMetadataSet{objA, objB, objD},
+			expected: object.ObjMetadataSet{objA, objD},
+		},
+		"theirs removed an object ours kept": {
+			base:          object.ObjMetadataSet{objA, objB},
+			ours:          object.ObjMetadataSet{objA, objB, objC},
+			theirs:        object.ObjMetadataSet{objA},
+			expectedError: &InventoryConflictError{Objects: object.ObjMetadataSet{objB}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			actual, err := rebaseObjs(tc.base, tc.ours, tc.theirs)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestUpdateConflict(t *testing.T) {
+	objA := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "a"}
+	objB := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "b"}
+	objC := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "c"}
+
+	tests := map[string]struct {
+		merge         bool
+		clusterObjs   object.ObjMetadataSet
+		localObjs     object.ObjMetadataSet
+		theirObjs     object.ObjMetadataSet
+		expected      object.ObjMetadataSet
+		expectedError error
+	}{
+		"merge with concurrently added object": {
+			merge:       true,
+			clusterObjs: object.ObjMetadataSet{objA},
+			localObjs:   object.ObjMetadataSet{objB},
+			theirObjs:   object.ObjMetadataSet{objA, objC},
+			expected:    object.ObjMetadataSet{objA, objC, objB},
+		},
+		"replace with concurrently added object": {
+			clusterObjs: object.ObjMetadataSet{objA, objB},
+			localObjs:   object.ObjMetadataSet{objB},
+			theirObjs:   object.ObjMetadataSet{objA, objB, objC},
+			expected:    object.ObjMetadataSet{objB, objC},
+		},
+		"replace with concurrently removed object": {
+			clusterObjs:   object.ObjMetadataSet{objA, objB},
+			localObjs:     object.ObjMetadataSet{objB},
+			theirObjs:     object.ObjMetadataSet{objA},
+			expectedError: &InventoryConflictError{Objects: object.ObjMetadataSet{objB}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			inv := newShardedInv("test-id")
+			wrapped := WrapInventoryObj(inv)
+			require.NoError(t, wrapped.Store(tc.clusterObjs, nil))
+			inv, err := wrapped.GetObject()
+			require.NoError(t, err)
+			inv.SetResourceVersion("1")
+
+			dc := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{
+					configMapGVR: "ConfigMapList",
+				}, inv)
+			// The first update conflicts with a concurrent writer, which
+			// stores their objects.
+			conflicts := 0
+			dc.PrependReactor("update", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				if conflicts > 0 {
+					return false, nil, nil
+				}
+				conflicts++
+				// The update is conditional on the resourceVersion read.
+				updated := action.(clienttesting.UpdateAction).GetObject().(*unstructured.Unstructured)
+				assert.Equal(t, "1", updated.GetResourceVersion())
+				theirs := WrapInventoryObj(inv.DeepCopy())
+				require.NoError(t, theirs.Store(tc.theirObjs, nil))
+				theirInv, err := theirs.GetObject()
+				require.NoError(t, err)
+				theirInv.SetResourceVersion("2")
+				require.NoError(t, dc.Tracker().Update(configMapGVR, theirInv, theirInv.GetNamespace()))
+				return true, nil, errors.NewConflict(schema.GroupResource{Resource: "configmaps"},
+					inv.GetName(), fmt.Errorf("the object has been modified"))
+			})
+
+			invClient := &ClusterClient{
+				dc:                    dc,
+				mapper:                testutil.NewFakeRESTMapper(ConfigMapGVK),
+				InventoryFactoryFunc:  WrapInventoryObj,
+				invToUnstructuredFunc: InvInfoToConfigMap,
+				statusPolicy:          StatusPolicyNone,
+				gvk:                   ConfigMapGVK,
+			}
+			localInv := WrapInventoryInfoObj(inv)
+			if tc.merge {
+				_, err = invClient.Merge(localInv, tc.localObjs, common.DryRunNone)
+			} else {
+				err = invClient.Replace(localInv, tc.localObjs, nil, common.DryRunNone)
+			}
+			assert.Equal(t, 1, conflicts)
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+
+			actual, err := invClient.GetClusterObjs(localInv)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestReplaceRebasesOntoBase(t *testing.T) {
+	objA := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "a"}
+	objB := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "b"}
+	objC := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "c"}
+	base := &Base{Objects: object.ObjMetadataSet{objA, objB}, ResourceVersion: "1"}
+
+	tests := map[string]struct {
+		// theirObjs are stored by another writer after the base, if set.
+		theirObjs       object.ObjMetadataSet
+		localObjs       object.ObjMetadataSet
+		expected        object.ObjMetadataSet
+		expectedVersion string
+		expectedError   error
+	}{
+		"unchanged since base": {
+			localObjs:       object.ObjMetadataSet{objB},
+			expected:        object.ObjMetadataSet{objB},
+			expectedVersion: "1",
+		},
+		"object added since base": {
+			theirObjs:       object.ObjMetadataSet{objA, objB, objC},
+			localObjs:       object.ObjMetadataSet{objB},
+			expected:        object.ObjMetadataSet{objB, objC},
+			expectedVersion: "2",
+		},
+		"object removed since base": {
+			theirObjs:     object.ObjMetadataSet{objA},
+			localObjs:     object.ObjMetadataSet{objB},
+			expectedError: &InventoryConflictError{Objects: object.ObjMetadataSet{objB}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			storedObjs := base.Objects
+			version := base.ResourceVersion
+			if tc.theirObjs != nil {
+				storedObjs = tc.theirObjs
+				version = "2"
+			}
+			inv := newShardedInv("test-id")
+			wrapped := WrapInventoryObj(inv)
+			require.NoError(t, wrapped.Store(storedObjs, nil))
+			inv, err := wrapped.GetObject()
+			require.NoError(t, err)
+			inv.SetResourceVersion(version)
+
+			dc := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{
+					configMapGVR: "ConfigMapList",
+				}, inv)
+			dc.PrependReactor("update", "configmaps", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				// The update is conditional on the resourceVersion read.
+				updated := action.(clienttesting.UpdateAction).GetObject().(*unstructured.Unstructured)
+				assert.Equal(t, version, updated.GetResourceVersion())
+				return false, nil, nil
+			})
+
+			invClient := &ClusterClient{
+				dc:                    dc,
+				mapper:                testutil.NewFakeRESTMapper(ConfigMapGVK),
+				InventoryFactoryFunc:  WrapInventoryObj,
+				invToUnstructuredFunc: InvInfoToConfigMap,
+				statusPolicy:          StatusPolicyNone,
+				gvk:                   ConfigMapGVK,
+			}
+			localInv := WrapInventoryInfoObj(inv)
+			stored, err := invClient.ReplaceWithBase(localInv, tc.localObjs, nil, base, ReplaceOptions{})
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tc.expected, stored.Objects)
+			assert.Equal(t, tc.expectedVersion, stored.ResourceVersion)
+
+			actual, err := invClient.GetClusterObjs(localInv)
+			require.NoError(t, err)
+			assert.ElementsMatch(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestReplaceCreatesInventory(t *testing.T) {
+	objA := object.ObjMetadata{GroupKind: schema.GroupKind{Kind: "Pod"}, Namespace: "test-ns", Name: "a"}
+
+	dc := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			configMapGVR: "ConfigMapList",
+		})
+	invClient := &ClusterClient{
+		dc:                    dc,
+		mapper:                testutil.NewFakeRESTMapper(ConfigMapGVK),
+		InventoryFactoryFunc:  WrapInventoryObj,
+		invToUnstructuredFunc: InvInfoToConfigMap,
+		statusPolicy:          StatusPolicyNone,
+		gvk:                   ConfigMapGVK,
+	}
+	localInv := WrapInventoryInfoObj(newShardedInv("test-id"))
+	err := invClient.Replace(localInv, object.ObjMetadataSet{objA}, nil, common.DryRunNone)
+	require.NoError(t, err)
+
+	actual, err := invClient.GetClusterObjs(localInv)
+	require.NoError(t, err)
+	assert.Equal(t, object.ObjMetadataSet{objA}, actual)
+}
@@ -87,3 +87,29 @@ func (e *PolicyPreventedActuationError) Is(err error) bool {
 		e.Policy == tErr.Policy &&
 		e.Status == tErr.Status
 }
+
+// InventoryConflictError is returned when the inventory object was changed
+// concurrently, and the changes can not be merged unambiguously.
+type InventoryConflictError struct {
+	// Objects are the objects removed from the inventory by the concurrent
+	// writer, that are still stored.
+	Objects object.ObjMetadataSet
+}
+
+func (e *InventoryConflictError) Error() string {
+	return fmt.Sprintf("inventory changed concurrently: %d object(s) removed by another writer are still applied: %v",
+		len(e.Objects), e.Objects)
+}
+
+// Is returns true if the specified error is equal to this error.
+// Use errors.Is(error) to recursively check if an error wraps this error.
+func (e *InventoryConflictError) Is(err error) bool {
+	if err == nil {
+		return false
+	}
+	tErr, ok := err.(*InventoryConflictError)
+	if !ok {
+		return false
+	}
+	return e.Objects.Equal(tErr.Objects)
+}
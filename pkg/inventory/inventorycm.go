@@ -9,9 +9,15 @@
 package inventory
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
+	"strconv"
+	"time"
 
 	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
 	"github.com/fluxcd/cli-utils/pkg/common"
@@ -21,6 +27,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/klog/v2"
 )
@@ -31,6 +38,19 @@ var ConfigMapGVK = schema.GroupVersionKind{
 	Version: "v1",
 }
 
+const (
+	// FormatAnnotation is the annotation on the inventory ConfigMap, which
+	// specifies the format the inventory is stored in. ConfigMaps without
+	// the annotation store the inventory in their data.
+	FormatAnnotation = "cli-utils.sigs.k8s.io/inventory-format"
+	// FormatGzip stores the inventory as gzip compressed JSON in the
+	// binaryData of the ConfigMap, under the CompressedDataKey.
+	FormatGzip = "gzip-v1"
+	// CompressedDataKey is the key of the compressed inventory in the
+	// binaryData of the ConfigMap.
+	CompressedDataKey = "inventory.json.gz"
+)
+
 // WrapInventoryObj takes a passed ConfigMap (as a resource.Info),
 // wraps it with the ConfigMap and upcasts the wrapper as
 // an the Inventory interface.
@@ -38,6 +58,13 @@ func WrapInventoryObj(inv *unstructured.Unstructured) Storage {
 	return &ConfigMap{inv: inv}
 }
 
+// WrapCompressedInventoryObj takes a passed ConfigMap, wraps it with a
+// ConfigMap which stores the inventory compressed, and upcasts the wrapper as
+// the Storage interface.
+func WrapCompressedInventoryObj(inv *unstructured.Unstructured) Storage {
+	return &ConfigMap{inv: inv, compress: true}
+}
+
 // WrapInventoryInfoObj takes a passed ConfigMap (as a resource.Info),
 // wraps it with the ConfigMap and upcasts the wrapper as
 // an the Info interface.
@@ -56,10 +83,15 @@ func InvInfoToConfigMap(inv Info) *unstructured.Unstructured {
 // ConfigMap wraps a ConfigMap resource and implements
 // the Inventory interface. This wrapper loads and stores the
 // object metadata (inventory) to and from the wrapped ConfigMap.
+//
+// The inventory is loaded from either format, and stored in the format
+// of the wrapper, which migrates existing inventories on the next store.
 type ConfigMap struct {
 	inv       *unstructured.Unstructured
 	objMetas  object.ObjMetadataSet
 	objStatus []actuation.ObjectStatus
+	// compress stores the inventory in the FormatGzip format.
+	compress bool
 }
 
 var _ Info = &ConfigMap{}
@@ -89,22 +121,21 @@ func (icm *ConfigMap) UnstructuredInventory() *unstructured.Unstructured {
 // Load is an Inventory interface function returning the set of
 // object metadata from the wrapped ConfigMap, or an error.
 func (icm *ConfigMap) Load() (object.ObjMetadataSet, error) {
-	objs := object.ObjMetadataSet{}
-	objMap, exists, err := unstructured.NestedStringMap(icm.inv.Object, "data")
+	objMap, err := configMapData(icm.inv)
 	if err != nil {
-		err := fmt.Errorf("error retrieving object metadata from inventory object")
-		return objs, err
-	}
-	if exists {
-		for objStr := range objMap {
-			obj, err := object.ParseObjMetadata(objStr)
-			if err != nil {
-				return objs, err
-			}
-			objs = append(objs, obj)
-		}
+		return object.ObjMetadataSet{}, fmt.Errorf("error retrieving object metadata from inventory object: %w", err)
 	}
-	return objs, nil
+	return objMetasFrom(objMap)
+}
+
+// LoadStatus is an Inventory interface function returning the object
+// status stored in the wrapped ConfigMap, or an error.
+func (icm *ConfigMap) LoadStatus() ([]actuation.ObjectStatus, error) {
+	objMap, err := configMapData(icm.inv)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving object status from inventory object: %w", err)
+	}
+	return objStatusFrom(objMap)
 }
 
 // Store is an Inventory interface function implemented to store
@@ -123,6 +154,13 @@ func (icm *ConfigMap) GetObject() (*unstructured.Unstructured, error) {
 	objMap := buildObjMap(icm.objMetas, icm.objStatus)
 	// Create the inventory object by copying the template.
 	invCopy := icm.inv.DeepCopy()
+	removeCompressedData(invCopy)
+	if icm.compress {
+		if err := setCompressedData(invCopy, objMap); err != nil {
+			return nil, err
+		}
+		return invCopy, nil
+	}
 	// Adds the inventory map to the ConfigMap "data" section.
 	err := unstructured.SetNestedStringMap(invCopy.UnstructuredContent(),
 		objMap, "data")
@@ -134,6 +172,12 @@ func (icm *ConfigMap) GetObject() (*unstructured.Unstructured, error) {
 
 // Apply is an Storage interface function implemented to apply the inventory
 // object. StatusPolicy is not needed since ConfigMaps do not have a status subresource.
+//
+// The update is conditional on the resourceVersion of the wrapped object, so
+// that it fails with a conflict, if the cluster object was changed since it
+// was read. Wrapped objects not read from the cluster are updated
+// conditionally on the resourceVersion of the cluster object just read. The
+// wrapped object gets the resourceVersion of the written object.
 func (icm *ConfigMap) Apply(dc dynamic.Interface, mapper meta.RESTMapper, _ StatusPolicy) error {
 	invInfo, namespacedClient, err := icm.getNamespacedClient(dc, mapper)
 	if err != nil {
@@ -149,28 +193,54 @@ func (icm *ConfigMap) Apply(dc dynamic.Interface, mapper meta.RESTMapper, _ Stat
 	// Create cluster inventory object, if it does not exist on cluster.
 	if clusterObj == nil {
 		klog.V(4).Infof("creating inventory object: %s/%s", invInfo.GetNamespace(), invInfo.GetName())
-		_, err = namespacedClient.Create(context.TODO(), invInfo, metav1.CreateOptions{})
-		return err
+		invInfo.SetResourceVersion("")
+		created, err := namespacedClient.Create(context.TODO(), invInfo, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		icm.inv.SetResourceVersion(created.GetResourceVersion())
+		return nil
 	}
 
 	// Update the cluster inventory object instead.
 	klog.V(4).Infof("updating inventory object: %s/%s", invInfo.GetNamespace(), invInfo.GetName())
-	_, err = namespacedClient.Update(context.TODO(), invInfo, metav1.UpdateOptions{})
-	return err
+	if invInfo.GetResourceVersion() == "" {
+		invInfo.SetResourceVersion(clusterObj.GetResourceVersion())
+	}
+	return icm.update(namespacedClient, invInfo)
 }
 
 // ApplyWithPrune is a Storage interface function implemented to apply the inventory object with a list of objects
 // to be pruned. StatusPolicy is not needed since ConfigMaps do not have a status subresource.
+// Like Apply, the update is conditional on the resourceVersion of the wrapped object.
 func (icm *ConfigMap) ApplyWithPrune(dc dynamic.Interface, mapper meta.RESTMapper, _ StatusPolicy, _ object.ObjMetadataSet) error {
 	invInfo, namespacedClient, err := icm.getNamespacedClient(dc, mapper)
 	if err != nil {
 		return err
 	}
 
+	if invInfo.GetResourceVersion() == "" {
+		clusterObj, err := namespacedClient.Get(context.TODO(), invInfo.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		invInfo.SetResourceVersion(clusterObj.GetResourceVersion())
+	}
+
 	// Update the cluster inventory object.
 	klog.V(4).Infof("updating inventory object: %s/%s", invInfo.GetNamespace(), invInfo.GetName())
-	_, err = namespacedClient.Update(context.TODO(), invInfo, metav1.UpdateOptions{})
-	return err
+	return icm.update(namespacedClient, invInfo)
+}
+
+// update updates the cluster inventory object, and sets the resourceVersion
+// of the updated object on the wrapped object.
+func (icm *ConfigMap) update(namespacedClient dynamic.ResourceInterface, invInfo *unstructured.Unstructured) error {
+	updated, err := namespacedClient.Update(context.TODO(), invInfo, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	icm.inv.SetResourceVersion(updated.GetResourceVersion())
+	return nil
 }
 
 // getNamespacedClient is a helper function for Apply and ApplyWithPrune that creates a namespaced client for interacting with the live
@@ -212,15 +282,180 @@ func buildObjMap(objMetas object.ObjMetadataSet, objStatus []actuation.ObjectSta
 	return objMap
 }
 
+// configMapData returns the objMap stored in the inventory ConfigMap, in
+// either format. Sharded inventory ConfigMaps store no data, so reading them
+// as an empty inventory would prune or delete nothing, and is an error.
+func configMapData(inv *unstructured.Unstructured) (map[string]string, error) {
+	if _, found := inv.GetAnnotations()[ShardsAnnotation]; found {
+		return nil, fmt.Errorf("inventory is sharded: inventory sharding must be enabled to read it")
+	}
+	format, found := inv.GetAnnotations()[FormatAnnotation]
+	if !found {
+		objMap, _, err := unstructured.NestedStringMap(inv.Object, "data")
+		return objMap, err
+	}
+	if format != FormatGzip {
+		return nil, fmt.Errorf("unsupported inventory format %q", format)
+	}
+	encoded, found, err := unstructured.NestedString(inv.Object, "binaryData", CompressedDataKey)
+	if err != nil || !found {
+		return nil, fmt.Errorf("missing compressed inventory %q", CompressedDataKey)
+	}
+	compressed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	objMap := map[string]string{}
+	if err := json.Unmarshal(data, &objMap); err != nil {
+		return nil, err
+	}
+	return objMap, nil
+}
+
+// setCompressedData stores the objMap in the inventory ConfigMap in the
+// FormatGzip format.
+func setCompressedData(inv *unstructured.Unstructured, objMap map[string]string) error {
+	data, err := json.Marshal(objMap)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	unstructured.RemoveNestedField(inv.Object, "data")
+	err = unstructured.SetNestedField(inv.Object,
+		base64.StdEncoding.EncodeToString(buf.Bytes()), "binaryData", CompressedDataKey)
+	if err != nil {
+		return err
+	}
+	annotations := inv.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[FormatAnnotation] = FormatGzip
+	inv.SetAnnotations(annotations)
+	return nil
+}
+
+// removeCompressedData removes the compressed inventory and the format
+// annotation from the inventory ConfigMap.
+func removeCompressedData(inv *unstructured.Unstructured) {
+	unstructured.RemoveNestedField(inv.Object, "binaryData", CompressedDataKey)
+	if binaryData, found, _ := unstructured.NestedMap(inv.Object, "binaryData"); found && len(binaryData) == 0 {
+		unstructured.RemoveNestedField(inv.Object, "binaryData")
+	}
+	annotations := inv.GetAnnotations()
+	if _, found := annotations[FormatAnnotation]; found {
+		delete(annotations, FormatAnnotation)
+		inv.SetAnnotations(annotations)
+	}
+}
+
+// objMetasFrom parses the object metadata of the entries of an objMap.
+func objMetasFrom(objMap map[string]string) (object.ObjMetadataSet, error) {
+	objs := object.ObjMetadataSet{}
+	for objStr := range objMap {
+		obj, err := object.ParseObjMetadata(objStr)
+		if err != nil {
+			return objs, err
+		}
+		objs = append(objs, obj)
+	}
+	return objs, nil
+}
+
+// objStatusFrom parses the object status of the entries of an objMap.
+// Entries without status are skipped.
+func objStatusFrom(objMap map[string]string) ([]actuation.ObjectStatus, error) {
+	var status []actuation.ObjectStatus
+	for objStr, statusStr := range objMap {
+		if statusStr == "" {
+			continue
+		}
+		obj, err := object.ParseObjMetadata(objStr)
+		if err != nil {
+			return status, err
+		}
+		objStatus, err := statusFrom(obj, statusStr)
+		if err != nil {
+			return status, fmt.Errorf("error parsing status of %s: %w", obj, err)
+		}
+		status = append(status, objStatus)
+	}
+	return status, nil
+}
+
 func stringFrom(status actuation.ObjectStatus) string {
 	tmp := map[string]string{
 		"strategy":  status.Strategy.String(),
 		"actuation": status.Actuation.String(),
 		"reconcile": status.Reconcile.String(),
 	}
+	if status.UID != "" {
+		tmp["uid"] = string(status.UID)
+	}
+	if status.Generation != 0 {
+		tmp["generation"] = strconv.FormatInt(status.Generation, 10)
+	}
+	if status.Hash != "" {
+		tmp["hash"] = status.Hash
+	}
+	if status.AppliedTime != nil {
+		tmp["appliedTime"] = status.AppliedTime.UTC().Format(time.RFC3339)
+	}
 	data, err := json.Marshal(tmp)
 	if err != nil || string(data) == "{}" {
 		return ""
 	}
 	return string(data)
 }
+
+// statusFrom parses the object status stored by stringFrom.
+func statusFrom(id object.ObjMetadata, data string) (actuation.ObjectStatus, error) {
+	status := actuation.ObjectStatus{
+		ObjectReference: ObjectReferenceFromObjMetadata(id),
+	}
+	tmp := map[string]string{}
+	if err := json.Unmarshal([]byte(data), &tmp); err != nil {
+		return status, err
+	}
+	var err error
+	if status.Strategy, err = actuation.ParseActuationStrategy(tmp["strategy"]); err != nil {
+		return status, err
+	}
+	if status.Actuation, err = actuation.ParseActuationStatus(tmp["actuation"]); err != nil {
+		return status, err
+	}
+	if status.Reconcile, err = actuation.ParseReconcileStatus(tmp["reconcile"]); err != nil {
+		return status, err
+	}
+	status.UID = types.UID(tmp["uid"])
+	if gen, found := tmp["generation"]; found {
+		if status.Generation, err = strconv.ParseInt(gen, 10, 64); err != nil {
+			return status, fmt.Errorf("invalid generation %q: %w", gen, err)
+		}
+	}
+	status.Hash = tmp["hash"]
+	if appliedTime, found := tmp["appliedTime"]; found {
+		t, err := time.Parse(time.RFC3339, appliedTime)
+		if err != nil {
+			return status, fmt.Errorf("invalid applied time %q: %w", appliedTime, err)
+		}
+		status.AppliedTime = &metav1.Time{Time: t}
+	}
+	return status, nil
+}
@@ -5,10 +5,16 @@ package inventory
 
 import (
 	"testing"
+	"time"
 
 	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
 	"github.com/fluxcd/cli-utils/pkg/object"
 	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestBuildObjMap(t *testing.T) {
@@ -52,6 +58,38 @@ func TestBuildObjMap(t *testing.T) {
 				"ns_na_group2_Kind": `{"actuation":"Skipped","reconcile":"Succeeded","strategy":"Delete"}`,
 			},
 		},
+		"uid and generation are stored if set": {
+			objSet: object.ObjMetadataSet{ObjMetadataFromObjectReference(obj1)},
+			objStatus: []actuation.ObjectStatus{
+				{
+					ObjectReference: obj1,
+					Strategy:        actuation.ActuationStrategyApply,
+					Actuation:       actuation.ActuationSucceeded,
+					Reconcile:       actuation.ReconcileSucceeded,
+					UID:             "uid-1",
+					Generation:      3,
+				},
+			},
+			expected: map[string]string{
+				"ns_na_group1_Kind": `{"actuation":"Succeeded","generation":"3","reconcile":"Succeeded","strategy":"Apply","uid":"uid-1"}`,
+			},
+		},
+		"hash and applied time are stored if set": {
+			objSet: object.ObjMetadataSet{ObjMetadataFromObjectReference(obj1)},
+			objStatus: []actuation.ObjectStatus{
+				{
+					ObjectReference: obj1,
+					Strategy:        actuation.ActuationStrategyApply,
+					Actuation:       actuation.ActuationSucceeded,
+					Reconcile:       actuation.ReconcileSucceeded,
+					Hash:            "abc",
+					AppliedTime:     &metav1.Time{Time: time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)},
+				},
+			},
+			expected: map[string]string{
+				"ns_na_group1_Kind": `{"actuation":"Succeeded","appliedTime":"2022-01-01T10:00:00Z","hash":"abc","reconcile":"Succeeded","strategy":"Apply"}`,
+			},
+		},
 		"empty object status list": {
 			objSet:   object.ObjMetadataSet{ObjMetadataFromObjectReference(obj1), ObjMetadataFromObjectReference(obj2)},
 			hasError: false,
@@ -70,3 +108,214 @@ func TestBuildObjMap(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadStatus(t *testing.T) {
+	tests := map[string]struct {
+		data     map[string]interface{}
+		expected []actuation.ObjectStatus
+		hasError bool
+	}{
+		"no data": {
+			data: nil,
+		},
+		"objects without status are omitted": {
+			data: map[string]interface{}{
+				"ns_na_group1_Kind": "",
+			},
+		},
+		"status with uid and generation": {
+			data: map[string]interface{}{
+				"ns_na_group1_Kind": `{"actuation":"Succeeded","generation":"3","reconcile":"Succeeded","strategy":"Apply","uid":"uid-1"}`,
+			},
+			expected: []actuation.ObjectStatus{
+				{
+					ObjectReference: actuation.ObjectReference{
+						Group:     "group1",
+						Kind:      "Kind",
+						Namespace: "ns",
+						Name:      "na",
+					},
+					Strategy:   actuation.ActuationStrategyApply,
+					Actuation:  actuation.ActuationSucceeded,
+					Reconcile:  actuation.ReconcileSucceeded,
+					UID:        "uid-1",
+					Generation: 3,
+				},
+			},
+		},
+		"status with hash and applied time": {
+			data: map[string]interface{}{
+				"ns_na_group1_Kind": `{"actuation":"Succeeded","appliedTime":"2022-01-01T10:00:00Z","hash":"abc","reconcile":"Succeeded","strategy":"Apply"}`,
+			},
+			expected: []actuation.ObjectStatus{
+				{
+					ObjectReference: actuation.ObjectReference{
+						Group:     "group1",
+						Kind:      "Kind",
+						Namespace: "ns",
+						Name:      "na",
+					},
+					Strategy:    actuation.ActuationStrategyApply,
+					Actuation:   actuation.ActuationSucceeded,
+					Reconcile:   actuation.ReconcileSucceeded,
+					Hash:        "abc",
+					AppliedTime: &metav1.Time{Time: time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)},
+				},
+			},
+		},
+		"invalid applied time is an error": {
+			data: map[string]interface{}{
+				"ns_na_group1_Kind": `{"actuation":"Succeeded","appliedTime":"yesterday","reconcile":"Succeeded","strategy":"Apply"}`,
+			},
+			hasError: true,
+		},
+		"status without uid and generation": {
+			data: map[string]interface{}{
+				"ns_na_group1_Kind": `{"actuation":"Skipped","reconcile":"Pending","strategy":"Delete"}`,
+			},
+			expected: []actuation.ObjectStatus{
+				{
+					ObjectReference: actuation.ObjectReference{
+						Group:     "group1",
+						Kind:      "Kind",
+						Namespace: "ns",
+						Name:      "na",
+					},
+					Strategy:  actuation.ActuationStrategyDelete,
+					Actuation: actuation.ActuationSkipped,
+					Reconcile: actuation.ReconcilePending,
+				},
+			},
+		},
+		"invalid status is an error": {
+			data: map[string]interface{}{
+				"ns_na_group1_Kind": `{"actuation":"Unknown","reconcile":"Pending","strategy":"Apply"}`,
+			},
+			hasError: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			inv := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			if tc.data != nil {
+				inv.Object["data"] = tc.data
+			}
+			actual, err := loadStatus(WrapInventoryObj(inv))
+			if tc.hasError {
+				if err == nil {
+					t.Fatalf("expected error but received none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf(diff)
+			}
+		})
+	}
+}
+
+func TestCompressedFormat(t *testing.T) {
+	obj1 := object.ObjMetadata{
+		GroupKind: schema.GroupKind{Group: "group1", Kind: "Kind"},
+		Namespace: "ns",
+		Name:      "na",
+	}
+	status1 := actuation.ObjectStatus{
+		ObjectReference: ObjectReferenceFromObjMetadata(obj1),
+		Strategy:        actuation.ActuationStrategyApply,
+		Actuation:       actuation.ActuationSucceeded,
+		Reconcile:       actuation.ReconcileSucceeded,
+		UID:             "uid-1",
+	}
+	obj2 := object.ObjMetadata{
+		GroupKind: schema.GroupKind{Group: "group2", Kind: "Kind"},
+		Namespace: "ns",
+		Name:      "na",
+	}
+
+	tests := map[string]struct {
+		compress bool
+		data     map[string]interface{}
+	}{
+		"legacy inventory is compressed": {
+			compress: true,
+			data: map[string]interface{}{
+				obj2.String(): "",
+			},
+		},
+		"compressed inventory is updated": {
+			compress: true,
+		},
+		"compressed inventory is decompressed": {
+			compress: false,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			inv := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			inv.SetGroupVersionKind(ConfigMapGVK)
+			inv.SetAnnotations(map[string]string{"foo": "bar"})
+			if tc.data != nil {
+				inv.Object["data"] = tc.data
+			} else {
+				// start with a compressed inventory
+				compressed, err := WrapCompressedInventoryObj(inv).GetObject()
+				require.NoError(t, err)
+				inv = compressed
+			}
+			wrap := WrapInventoryObj
+			if tc.compress {
+				wrap = WrapCompressedInventoryObj
+			}
+			storage := wrap(inv)
+			require.NoError(t, storage.Store(object.ObjMetadataSet{obj1},
+				[]actuation.ObjectStatus{status1}))
+			stored, err := storage.GetObject()
+			require.NoError(t, err)
+
+			_, hasData, _ := unstructured.NestedFieldNoCopy(stored.Object, "data")
+			_, hasBinaryData, _ := unstructured.NestedFieldNoCopy(stored.Object, "binaryData")
+			assert.Equal(t, !tc.compress, hasData)
+			assert.Equal(t, tc.compress, hasBinaryData)
+			if tc.compress {
+				assert.Equal(t, FormatGzip, stored.GetAnnotations()[FormatAnnotation])
+			} else {
+				assert.NotContains(t, stored.GetAnnotations(), FormatAnnotation)
+			}
+			assert.Equal(t, "bar", stored.GetAnnotations()["foo"])
+
+			// Both formats are loaded by either wrapper.
+			for _, load := range []StorageFactoryFunc{WrapInventoryObj, WrapCompressedInventoryObj} {
+				objs, err := load(stored).Load()
+				require.NoError(t, err)
+				assert.Equal(t, object.ObjMetadataSet{obj1}, objs)
+				status, err := loadStatus(load(stored))
+				require.NoError(t, err)
+				assert.Equal(t, []actuation.ObjectStatus{status1}, status)
+			}
+		})
+	}
+}
+
+func TestLoadUnsupportedFormat(t *testing.T) {
+	inv := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	inv.SetAnnotations(map[string]string{FormatAnnotation: "zstd-v1"})
+	_, err := configMapData(inv)
+	assert.EqualError(t, err, `unsupported inventory format "zstd-v1"`)
+}
+
+func TestLoadShardedInventory(t *testing.T) {
+	inv := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	inv.SetAnnotations(map[string]string{ShardsAnnotation: "inventory-0123456789"})
+	_, err := configMapData(inv)
+	assert.EqualError(t, err, "inventory is sharded: inventory sharding must be enabled to read it")
+
+	// A sharded inventory is not read as an empty inventory.
+	_, err = WrapInventoryObj(inv).Load()
+	assert.Error(t, err)
+	_, err = loadStatus(WrapInventoryObj(inv))
+	assert.Error(t, err)
+}
@@ -0,0 +1,528 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Introduces the ShardedConfigMap struct which implements the Storage
+// interface. The ShardedConfigMap wraps an inventory ConfigMap, which
+// references the shards storing the set of inventory (object metadata).
+
+package inventory
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
+	"github.com/fluxcd/cli-utils/pkg/common"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// ShardsAnnotation is the annotation on the inventory object, which
+	// lists the names of its shards, separated by commas. Inventory objects
+	// without the annotation store the inventory in their own data.
+	ShardsAnnotation = "cli-utils.sigs.k8s.io/inventory-shards"
+	// ShardKindAnnotation is the annotation on the inventory object, which
+	// specifies the ShardKind of its shards. Shards of inventory objects
+	// without the annotation are ConfigMaps.
+	ShardKindAnnotation = "cli-utils.sigs.k8s.io/inventory-shard-kind"
+	// ShardLabel is the label on the shards of an inventory object. The
+	// value of the label is the inventory id. Shards do not have the
+	// inventory label, so they are not found as inventory objects.
+	ShardLabel = "cli-utils.sigs.k8s.io/inventory-shard-of"
+
+	// DefaultShardMaxSize is the default maximum size of the data of a
+	// shard, well below the size limit of objects stored in etcd.
+	DefaultShardMaxSize = 512 * 1024
+
+	// shardHashLength is the length of the content hash suffix of shard
+	// names.
+	shardHashLength = 10
+	// maxNameLength is the maximum length of a ConfigMap or Secret name.
+	maxNameLength = 253
+)
+
+// ShardKind is the kind of objects storing the shards of an inventory.
+type ShardKind string
+
+const (
+	ShardConfigMap ShardKind = "ConfigMap"
+	ShardSecret    ShardKind = "Secret"
+)
+
+// ShardOptions configures the sharded inventory storage.
+type ShardOptions struct {
+	// Kind is the kind of the shard objects. Sharding is disabled if empty.
+	Kind ShardKind
+	// MaxSize is the maximum size in bytes of the data of a shard. Defaults
+	// to DefaultShardMaxSize. Entries larger than MaxSize are stored in a
+	// shard of their own.
+	MaxSize int
+}
+
+// Enabled returns true if the inventory is sharded.
+func (so ShardOptions) Enabled() bool {
+	return so.Kind != ""
+}
+
+// ShardedStorageFactory returns a StorageFactoryFunc, which wraps inventory
+// ConfigMaps with a ShardedConfigMap. The passed client is used to load the
+// shards.
+func ShardedStorageFactory(dc dynamic.Interface, mapper meta.RESTMapper, opts ShardOptions) StorageFactoryFunc {
+	return func(inv *unstructured.Unstructured) Storage {
+		return &ShardedConfigMap{
+			inv:    inv,
+			dc:     dc,
+			mapper: mapper,
+			opts:   opts,
+		}
+	}
+}
+
+// ShardedConfigMap wraps an inventory ConfigMap and implements the Storage
+// interface. The object metadata (inventory) is split across shards, which
+// are ConfigMaps or Secrets in the namespace of the inventory, owned by the
+// inventory ConfigMap. The inventory ConfigMap lists the shards.
+//
+// Shards are named after the hash of their content and never modified.
+// Storing the inventory creates the changed shards, then updates the list of
+// shards in the inventory ConfigMap, then deletes the shards no longer
+// listed. The update of the inventory ConfigMap is conditional on the
+// resourceVersion it was read with. Because the inventory ConfigMap is
+// updated in a single write, the inventory is either loaded from the previous
+// or the new shards, even if storing fails halfway.
+//
+// Inventory ConfigMaps without shards are loaded like a ConfigMap, so that
+// existing inventories are sharded when they are stored again. Shards are
+// loaded with the kind recorded on the inventory ConfigMap, so that changing
+// the Kind of the ShardOptions migrates the shards on the next store.
+type ShardedConfigMap struct {
+	inv       *unstructured.Unstructured
+	dc        dynamic.Interface
+	mapper    meta.RESTMapper
+	opts      ShardOptions
+	objMetas  object.ObjMetadataSet
+	objStatus []actuation.ObjectStatus
+}
+
+var _ Storage = &ShardedConfigMap{}
+
+// shard is the content of a shard object.
+type shard struct {
+	name string
+	data map[string]string
+}
+
+// Load is a Storage interface function returning the set of object metadata
+// from the shards of the wrapped ConfigMap, or an error.
+func (scm *ShardedConfigMap) Load() (object.ObjMetadataSet, error) {
+	objMap, err := scm.loadData()
+	if err != nil {
+		return object.ObjMetadataSet{}, fmt.Errorf("error retrieving object metadata from inventory object: %w", err)
+	}
+	return objMetasFrom(objMap)
+}
+
+// LoadStatus is a Storage interface function returning the object status
+// stored in the shards of the wrapped ConfigMap, or an error.
+func (scm *ShardedConfigMap) LoadStatus() ([]actuation.ObjectStatus, error) {
+	objMap, err := scm.loadData()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving object status from inventory object: %w", err)
+	}
+	return objStatusFrom(objMap)
+}
+
+// loadData returns the merged data of the shards, or the data of the
+// wrapped ConfigMap, if it has no shards.
+func (scm *ShardedConfigMap) loadData() (map[string]string, error) {
+	shardsStr, found := scm.inv.GetAnnotations()[ShardsAnnotation]
+	if !found {
+		return configMapData(scm.inv)
+	}
+	client, err := shardClient(scm.dc, scm.mapper, scm.inv.GetNamespace(), recordedShardKind(scm.inv))
+	if err != nil {
+		return nil, err
+	}
+	objMap := map[string]string{}
+	for _, name := range splitShardNames(shardsStr) {
+		klog.V(4).Infof("loading inventory shard: %s/%s", scm.inv.GetNamespace(), name)
+		obj, err := client.Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get inventory shard %q: %w", name, err)
+		}
+		data, err := scm.shardData(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read inventory shard %q: %w", name, err)
+		}
+		for k, v := range data {
+			objMap[k] = v
+		}
+	}
+	return objMap, nil
+}
+
+// Store is a Storage interface function implemented to store the object
+// metadata in the shards of the wrapped ConfigMap. Actual storing happens in
+// "Apply" and "ApplyWithPrune".
+func (scm *ShardedConfigMap) Store(objMetas object.ObjMetadataSet, status []actuation.ObjectStatus) error {
+	scm.objMetas = objMetas
+	scm.objStatus = status
+	return nil
+}
+
+// GetObject returns the wrapped ConfigMap, listing the shards of the stored
+// object metadata, or an error if one occurs.
+func (scm *ShardedConfigMap) GetObject() (*unstructured.Unstructured, error) {
+	shards, err := scm.buildShards()
+	if err != nil {
+		return nil, err
+	}
+	return scm.manifest(shards), nil
+}
+
+// manifest returns a copy of the wrapped ConfigMap, listing the passed
+// shards instead of storing any data.
+func (scm *ShardedConfigMap) manifest(shards []shard) *unstructured.Unstructured {
+	invCopy := scm.inv.DeepCopy()
+	unstructured.RemoveNestedField(invCopy.Object, "data")
+	removeCompressedData(invCopy)
+	names := make([]string, len(shards))
+	for i, s := range shards {
+		names[i] = s.name
+	}
+	annotations := invCopy.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[ShardsAnnotation] = strings.Join(names, ",")
+	annotations[ShardKindAnnotation] = string(scm.shardKind())
+	invCopy.SetAnnotations(annotations)
+	return invCopy
+}
+
+// Apply is a Storage interface function implemented to apply the inventory
+// object and its shards. StatusPolicy is not needed since ConfigMaps do not
+// have a status subresource.
+func (scm *ShardedConfigMap) Apply(dc dynamic.Interface, mapper meta.RESTMapper, _ StatusPolicy) error {
+	return scm.apply(dc, mapper)
+}
+
+// ApplyWithPrune is a Storage interface function implemented to apply the
+// inventory object and its shards. StatusPolicy is not needed since
+// ConfigMaps do not have a status subresource.
+func (scm *ShardedConfigMap) ApplyWithPrune(dc dynamic.Interface, mapper meta.RESTMapper, _ StatusPolicy, _ object.ObjMetadataSet) error {
+	return scm.apply(dc, mapper)
+}
+
+// apply creates the inventory ConfigMap if it does not exist, creates the
+// missing shards owned by it, updates the list of shards, and deletes the
+// shards listed before, but no longer. The wrapped ConfigMap gets the
+// resourceVersion of the updated object. If the update fails, the shards
+// created by apply are deleted again.
+func (scm *ShardedConfigMap) apply(dc dynamic.Interface, mapper meta.RESTMapper) error {
+	shards, err := scm.buildShards()
+	if err != nil {
+		return err
+	}
+	manifest := scm.manifest(shards)
+	mapping, err := mapper.RESTMapping(manifest.GroupVersionKind().GroupKind(), manifest.GroupVersionKind().Version)
+	if err != nil {
+		return err
+	}
+	invClient := dc.Resource(mapping.Resource).Namespace(manifest.GetNamespace())
+
+	// The inventory ConfigMap must exist to own the shards.
+	live, err := invClient.Get(context.TODO(), manifest.GetName(), metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		klog.V(4).Infof("creating inventory object: %s/%s", manifest.GetNamespace(), manifest.GetName())
+		initial := scm.manifest(nil)
+		initial.SetResourceVersion("")
+		live, err = invClient.Create(context.TODO(), initial, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+	}
+
+	client, err := shardClient(dc, mapper, manifest.GetNamespace(), scm.shardKind())
+	if err != nil {
+		return err
+	}
+	current := map[string]bool{}
+	var created []string
+	for _, s := range shards {
+		current[s.name] = true
+		obj := scm.shardObject(s, live)
+		klog.V(4).Infof("creating inventory shard: %s/%s", obj.GetNamespace(), obj.GetName())
+		_, err := client.Create(context.TODO(), obj, metav1.CreateOptions{})
+		if err != nil && !apierrors.IsAlreadyExists(err) {
+			scm.deleteCreatedShards(invClient, client, manifest, created)
+			return fmt.Errorf("failed to create inventory shard %q: %w", s.name, err)
+		}
+		if err == nil {
+			created = append(created, s.name)
+		}
+	}
+
+	// Switch to the new shards. The update fails with a conflict, if the
+	// inventory object was changed since it was read, so that the shards
+	// listed by a concurrent writer are neither lost nor deleted.
+	replaced := scm.inv
+	if manifest.GetResourceVersion() == "" {
+		manifest.SetResourceVersion(live.GetResourceVersion())
+		replaced = live
+	}
+	klog.V(4).Infof("updating inventory object: %s/%s (%d shards)", manifest.GetNamespace(), manifest.GetName(), len(shards))
+	updated, err := invClient.Update(context.TODO(), manifest, metav1.UpdateOptions{})
+	if err != nil {
+		scm.deleteCreatedShards(invClient, client, manifest, created)
+		return err
+	}
+	scm.inv.SetResourceVersion(updated.GetResourceVersion())
+
+	// Stale shards no longer affect the inventory, so failing to delete
+	// them is not an error. Shards that are not deleted are garbage
+	// collected with the inventory object. The stale shards may be of
+	// another kind, if the kind of the shards changed.
+	staleClient := client
+	if kind := recordedShardKind(replaced); kind != scm.shardKind() {
+		staleClient, err = shardClient(dc, mapper, manifest.GetNamespace(), kind)
+		if err != nil {
+			klog.Warningf("failed to delete stale inventory shards: %v", err)
+			return nil
+		}
+		current = map[string]bool{}
+	}
+	for _, name := range splitShardNames(replaced.GetAnnotations()[ShardsAnnotation]) {
+		if current[name] {
+			continue
+		}
+		deleteShard(staleClient, manifest.GetNamespace(), name)
+	}
+	return nil
+}
+
+// deleteCreatedShards deletes the passed shards, created by a failed apply,
+// unless the live inventory object lists them, e.g. because a concurrent
+// writer stored the same shard. Shards that are not deleted are garbage
+// collected with the inventory object.
+func (scm *ShardedConfigMap) deleteCreatedShards(invClient, client dynamic.ResourceInterface,
+	manifest *unstructured.Unstructured, created []string) {
+	if len(created) == 0 {
+		return
+	}
+	listed := map[string]bool{}
+	live, err := invClient.Get(context.TODO(), manifest.GetName(), metav1.GetOptions{})
+	if err != nil {
+		klog.Warningf("failed to delete created inventory shards: %v", err)
+		return
+	}
+	if recordedShardKind(live) == scm.shardKind() {
+		for _, name := range splitShardNames(live.GetAnnotations()[ShardsAnnotation]) {
+			listed[name] = true
+		}
+	}
+	for _, name := range created {
+		if !listed[name] {
+			deleteShard(client, manifest.GetNamespace(), name)
+		}
+	}
+}
+
+// deleteShard deletes the shard and logs a warning if that fails.
+func deleteShard(client dynamic.ResourceInterface, namespace, name string) {
+	klog.V(4).Infof("deleting inventory shard: %s/%s", namespace, name)
+	err := client.Delete(context.TODO(), name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.Warningf("failed to delete inventory shard %q: %v", name, err)
+	}
+}
+
+// buildShards splits the stored object metadata into shards, in the order of
+// their keys, so that unchanged shards keep their names.
+func (scm *ShardedConfigMap) buildShards() ([]shard, error) {
+	if scm.id() == "" {
+		return nil, fmt.Errorf("inventory object %s/%s has no inventory id", scm.inv.GetNamespace(), scm.inv.GetName())
+	}
+	objMap := buildObjMap(scm.objMetas, scm.objStatus)
+	keys := make([]string, 0, len(objMap))
+	for k := range objMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	maxSize := scm.opts.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultShardMaxSize
+	}
+	var shards []shard
+	data := map[string]string{}
+	size := 0
+	for _, k := range keys {
+		entrySize := len(k) + scm.valueSize(objMap[k])
+		if len(data) > 0 && size+entrySize > maxSize {
+			shards = append(shards, scm.newShard(data))
+			data = map[string]string{}
+			size = 0
+		}
+		data[k] = objMap[k]
+		size += entrySize
+	}
+	if len(data) > 0 {
+		shards = append(shards, scm.newShard(data))
+	}
+	return shards, nil
+}
+
+// newShard returns a shard with the passed data, named after the inventory
+// and the hash of the data.
+func (scm *ShardedConfigMap) newShard(data map[string]string) shard {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\n", k, data[k])
+	}
+	hash := fmt.Sprintf("%x", h.Sum(nil))[:shardHashLength]
+	prefix := scm.inv.GetName()
+	if l := maxNameLength - shardHashLength - 1; len(prefix) > l {
+		prefix = prefix[:l]
+	}
+	return shard{
+		name: fmt.Sprintf("%s-%s", prefix, hash),
+		data: data,
+	}
+}
+
+// shardObject returns the object storing the shard, owned by the passed
+// inventory object.
+func (scm *ShardedConfigMap) shardObject(s shard, owner *unstructured.Unstructured) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(scm.shardGVK())
+	obj.SetNamespace(scm.inv.GetNamespace())
+	obj.SetName(s.name)
+	obj.SetLabels(map[string]string{ShardLabel: scm.id()})
+	obj.SetOwnerReferences([]metav1.OwnerReference{
+		{
+			APIVersion: owner.GetAPIVersion(),
+			Kind:       owner.GetKind(),
+			Name:       owner.GetName(),
+			UID:        owner.GetUID(),
+		},
+	})
+	data := make(map[string]interface{}, len(s.data))
+	for k, v := range s.data {
+		if scm.shardKind() == ShardSecret {
+			v = base64.StdEncoding.EncodeToString([]byte(v))
+		}
+		data[k] = v
+	}
+	obj.Object["data"] = data
+	if scm.shardKind() == ShardSecret {
+		obj.Object["type"] = "Opaque"
+	}
+	return obj
+}
+
+// shardData returns the data of a shard object.
+func (scm *ShardedConfigMap) shardData(obj *unstructured.Unstructured) (map[string]string, error) {
+	data, _, err := unstructured.NestedStringMap(obj.Object, "data")
+	if err != nil {
+		return nil, err
+	}
+	if obj.GetKind() != string(ShardSecret) {
+		return data, nil
+	}
+	for k, v := range data {
+		decoded, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of %q: %w", k, err)
+		}
+		data[k] = string(decoded)
+	}
+	return data, nil
+}
+
+// valueSize returns the size of a value stored in a shard.
+func (scm *ShardedConfigMap) valueSize(v string) int {
+	if scm.shardKind() == ShardSecret {
+		return base64.StdEncoding.EncodedLen(len(v))
+	}
+	return len(v)
+}
+
+// shardKind returns the kind of the shards stored by the ShardedConfigMap.
+func (scm *ShardedConfigMap) shardKind() ShardKind {
+	if scm.opts.Kind == "" {
+		return ShardConfigMap
+	}
+	return scm.opts.Kind
+}
+
+func (scm *ShardedConfigMap) shardGVK() schema.GroupVersionKind {
+	return shardGVK(scm.shardKind())
+}
+
+// recordedShardKind returns the kind of the shards of the inventory object.
+func recordedShardKind(inv *unstructured.Unstructured) ShardKind {
+	if kind, found := inv.GetAnnotations()[ShardKindAnnotation]; found {
+		return ShardKind(kind)
+	}
+	return ShardConfigMap
+}
+
+func shardGVK(kind ShardKind) schema.GroupVersionKind {
+	return schema.GroupVersionKind{Version: "v1", Kind: string(kind)}
+}
+
+func shardClient(dc dynamic.Interface, mapper meta.RESTMapper, namespace string, kind ShardKind) (dynamic.ResourceInterface, error) {
+	if kind != ShardConfigMap && kind != ShardSecret {
+		return nil, fmt.Errorf("unsupported inventory shard kind %q", kind)
+	}
+	gvk := shardGVK(kind)
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	return dc.Resource(mapping.Resource).Namespace(namespace), nil
+}
+
+func (scm *ShardedConfigMap) id() string {
+	return scm.inv.GetLabels()[common.InventoryLabel]
+}
+
+// IsInventoryShard returns true if the passed object is the shard of an
+// inventory object.
+func IsInventoryShard(obj *unstructured.Unstructured) bool {
+	if obj == nil {
+		return false
+	}
+	_, found := obj.GetLabels()[ShardLabel]
+	return found
+}
+
+func splitShardNames(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
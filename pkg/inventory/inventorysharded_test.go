@@ -0,0 +1,251 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
+	"github.com/fluxcd/cli-utils/pkg/common"
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/fluxcd/cli-utils/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+var (
+	configMapGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	secretGVR    = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+)
+
+func newShardedInv(id string) *unstructured.Unstructured {
+	inv := &unstructured.Unstructured{}
+	inv.SetGroupVersionKind(ConfigMapGVK)
+	inv.SetNamespace("test-ns")
+	inv.SetName("inventory")
+	inv.SetLabels(map[string]string{common.InventoryLabel: id})
+	return inv
+}
+
+func shardedTestObjs(n int) object.ObjMetadataSet {
+	var objs object.ObjMetadataSet
+	for i := 0; i < n; i++ {
+		objs = append(objs, object.ObjMetadata{
+			GroupKind: schema.GroupKind{Kind: "ConfigMap"},
+			Namespace: "test-ns",
+			Name:      fmt.Sprintf("cm-%03d", i),
+		})
+	}
+	return objs
+}
+
+func TestShardedConfigMap(t *testing.T) {
+	testCases := map[string]struct {
+		kind           ShardKind
+		maxSize        int
+		objs           object.ObjMetadataSet
+		updatedObjs    object.ObjMetadataSet
+		expectedShards int
+	}{
+		"single shard": {
+			kind:           ShardConfigMap,
+			objs:           shardedTestObjs(10),
+			expectedShards: 1,
+		},
+		"multiple config map shards": {
+			kind:           ShardConfigMap,
+			maxSize:        500,
+			objs:           shardedTestObjs(20),
+			expectedShards: 4,
+		},
+		"multiple secret shards": {
+			kind:           ShardSecret,
+			maxSize:        500,
+			objs:           shardedTestObjs(20),
+			expectedShards: 5,
+		},
+		"updated objects replace stale shards": {
+			kind:           ShardConfigMap,
+			maxSize:        500,
+			objs:           shardedTestObjs(20),
+			updatedObjs:    shardedTestObjs(5),
+			expectedShards: 1,
+		},
+		"no objects": {
+			kind:           ShardConfigMap,
+			objs:           object.ObjMetadataSet{},
+			expectedShards: 0,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			dc := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+				map[schema.GroupVersionResource]string{
+					configMapGVR: "ConfigMapList",
+					secretGVR:    "SecretList",
+				})
+			mapper := testutil.NewFakeRESTMapper(ConfigMapGVK,
+				schema.GroupVersionKind{Version: "v1", Kind: "Secret"})
+			factory := ShardedStorageFactory(dc, mapper, ShardOptions{
+				Kind:    tc.kind,
+				MaxSize: tc.maxSize,
+			})
+
+			expected := tc.objs
+			store := func(objs object.ObjMetadataSet) {
+				var status []actuation.ObjectStatus
+				for _, id := range objs {
+					status = append(status, actuation.ObjectStatus{
+						ObjectReference: ObjectReferenceFromObjMetadata(id),
+						Strategy:        actuation.ActuationStrategyApply,
+						Actuation:       actuation.ActuationSucceeded,
+						Reconcile:       actuation.ReconcileSucceeded,
+					})
+				}
+				inv := factory(newShardedInv("test-id"))
+				require.NoError(t, inv.Store(objs, status))
+				require.NoError(t, inv.Apply(dc, mapper, StatusPolicyAll))
+			}
+			store(tc.objs)
+			if tc.updatedObjs != nil {
+				expected = tc.updatedObjs
+				store(tc.updatedObjs)
+			}
+
+			// The inventory object lists the shards and stores no data.
+			live, err := dc.Resource(configMapGVR).Namespace("test-ns").
+				Get(context.TODO(), "inventory", metav1.GetOptions{})
+			require.NoError(t, err)
+			_, found, _ := unstructured.NestedFieldNoCopy(live.Object, "data")
+			assert.False(t, found)
+			shardNames := splitShardNames(live.GetAnnotations()[ShardsAnnotation])
+			assert.Len(t, shardNames, tc.expectedShards)
+
+			// Stale shards are deleted.
+			shardGVR := configMapGVR
+			if tc.kind == ShardSecret {
+				shardGVR = secretGVR
+			}
+			list, err := dc.Resource(shardGVR).Namespace("test-ns").List(context.TODO(),
+				metav1.ListOptions{LabelSelector: ShardLabel + "=test-id"})
+			require.NoError(t, err)
+			var listed []string
+			for _, item := range list.Items {
+				listed = append(listed, item.GetName())
+			}
+			assert.ElementsMatch(t, shardNames, listed)
+
+			loaded, err := factory(live).Load()
+			require.NoError(t, err)
+			assert.ElementsMatch(t, expected, loaded)
+			status, err := loadStatus(factory(live))
+			require.NoError(t, err)
+			assert.Len(t, status, len(expected))
+		})
+	}
+}
+
+func TestShardedConfigMapLoadUnsharded(t *testing.T) {
+	obj := object.ObjMetadata{
+		GroupKind: schema.GroupKind{Kind: "ConfigMap"},
+		Namespace: "test-ns",
+		Name:      "cm",
+	}
+	inv := newShardedInv("test-id")
+	inv.Object["data"] = map[string]interface{}{
+		obj.String(): "",
+	}
+	storage := ShardedStorageFactory(nil, nil, ShardOptions{Kind: ShardConfigMap})(inv)
+	loaded, err := storage.Load()
+	require.NoError(t, err)
+	assert.Equal(t, object.ObjMetadataSet{obj}, loaded)
+}
+
+func TestShardedConfigMapLoadMissingShard(t *testing.T) {
+	dc := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	mapper := testutil.NewFakeRESTMapper(ConfigMapGVK)
+	inv := newShardedInv("test-id")
+	inv.SetAnnotations(map[string]string{ShardsAnnotation: "inventory-0123456789"})
+	storage := ShardedStorageFactory(dc, mapper, ShardOptions{Kind: ShardConfigMap})(inv)
+	_, err := storage.Load()
+	assert.ErrorContains(t, err, `failed to get inventory shard "inventory-0123456789"`)
+}
+
+func TestShardedConfigMapKindChanged(t *testing.T) {
+	dc := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			configMapGVR: "ConfigMapList",
+			secretGVR:    "SecretList",
+		})
+	mapper := testutil.NewFakeRESTMapper(ConfigMapGVK,
+		schema.GroupVersionKind{Version: "v1", Kind: "Secret"})
+	secretFactory := ShardedStorageFactory(dc, mapper, ShardOptions{Kind: ShardSecret})
+	configMapFactory := ShardedStorageFactory(dc, mapper, ShardOptions{Kind: ShardConfigMap})
+	objs := shardedTestObjs(10)
+	invs := dc.Resource(configMapGVR).Namespace("test-ns")
+	listShards := func(gvr schema.GroupVersionResource) int {
+		list, err := dc.Resource(gvr).Namespace("test-ns").List(context.TODO(),
+			metav1.ListOptions{LabelSelector: ShardLabel + "=test-id"})
+		require.NoError(t, err)
+		return len(list.Items)
+	}
+
+	inv := secretFactory(newShardedInv("test-id"))
+	require.NoError(t, inv.Store(objs, nil))
+	require.NoError(t, inv.Apply(dc, mapper, StatusPolicyAll))
+	live, err := invs.Get(context.TODO(), "inventory", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, string(ShardSecret), live.GetAnnotations()[ShardKindAnnotation])
+
+	// The shards are read with the recorded kind.
+	loaded, err := configMapFactory(live).Load()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, objs, loaded)
+
+	// Storing with another kind migrates the shards.
+	inv = configMapFactory(live)
+	require.NoError(t, inv.Store(objs, nil))
+	require.NoError(t, inv.Apply(dc, mapper, StatusPolicyAll))
+	live, err = invs.Get(context.TODO(), "inventory", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, string(ShardConfigMap), live.GetAnnotations()[ShardKindAnnotation])
+	assert.Equal(t, 0, listShards(secretGVR))
+	assert.Equal(t, 1, listShards(configMapGVR))
+	loaded, err = secretFactory(live).Load()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, objs, loaded)
+}
+
+func TestShardedConfigMapUpdateConflict(t *testing.T) {
+	dc := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			configMapGVR: "ConfigMapList",
+		})
+	mapper := testutil.NewFakeRESTMapper(ConfigMapGVK)
+	dc.PrependReactor("update", "configmaps", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewConflict(configMapGVR.GroupResource(), "inventory", errors.New("modified"))
+	})
+
+	inv := ShardedStorageFactory(dc, mapper, ShardOptions{Kind: ShardConfigMap, MaxSize: 500})(newShardedInv("test-id"))
+	require.NoError(t, inv.Store(shardedTestObjs(20), nil))
+	err := inv.Apply(dc, mapper, StatusPolicyAll)
+	assert.True(t, apierrors.IsConflict(err), "expected conflict, got %v", err)
+
+	// The shards created for the failed update are deleted.
+	list, err := dc.Resource(configMapGVR).Namespace("test-ns").List(context.TODO(),
+		metav1.ListOptions{LabelSelector: ShardLabel + "=test-id"})
+	require.NoError(t, err)
+	assert.Empty(t, list.Items)
+}
@@ -0,0 +1,403 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+//
+// Introduces the inventory lock, which prevents concurrent applies and
+// destroys of the same inventory. The lock is a coordination.k8s.io Lease in
+// the namespace of the inventory object.
+
+package inventory
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fluxcd/cli-utils/pkg/common"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/klog/v2"
+)
+
+// DefaultLockDuration is the default duration of the inventory lock. The lock
+// is renewed while it is held, so it only expires if the holder exits without
+// releasing it.
+const DefaultLockDuration = time.Minute
+
+// LeaseGVK is the GroupVersionKind of the Lease storing the inventory lock.
+var LeaseGVK = schema.GroupVersionKind{
+	Group:   "coordination.k8s.io",
+	Version: "v1",
+	Kind:    "Lease",
+}
+
+// LockOptions configures the inventory lock.
+type LockOptions struct {
+	// Holder identifies the holder of the lock, e.g. the hostname and
+	// process id. A random token is appended for each acquisition, so
+	// concurrent runs with the same Holder exclude each other. The lock is
+	// disabled if empty.
+	Holder string
+	// Duration is how long the lock is valid without being renewed.
+	// Defaults to DefaultLockDuration.
+	Duration time.Duration
+}
+
+// Enabled returns true if the inventory is locked.
+func (lo LockOptions) Enabled() bool {
+	return lo.Holder != ""
+}
+
+// LockHeldError is returned when the inventory lock is held by another holder.
+type LockHeldError struct {
+	Namespace string
+	Name      string
+	Holder    string
+	Expires   time.Time
+}
+
+func (e *LockHeldError) Error() string {
+	return fmt.Sprintf("inventory is locked by %q until %s (lease: %s/%s)",
+		e.Holder, e.Expires.UTC().Format(time.RFC3339), e.Namespace, e.Name)
+}
+
+// LockLostError is the cause of the cancellation of the context of a Lock,
+// which could not be renewed.
+type LockLostError struct {
+	Err error
+}
+
+func (e *LockLostError) Error() string {
+	return fmt.Sprintf("inventory lock lost: %v", e.Err)
+}
+
+func (e *LockLostError) Unwrap() error {
+	return e.Err
+}
+
+// Lock is an acquired inventory lock. It is renewed until it is released.
+type Lock struct {
+	client dynamic.ResourceInterface
+	inv    Info
+	name   string
+	opts   LockOptions
+	// identity is the holder identity of the Lease, which is unique to this
+	// acquisition.
+	identity string
+	// held is false while the namespace of the inventory does not exist,
+	// e.g. before the first apply creates it. The Lease is created by the
+	// first renewal after the namespace was created.
+	held bool
+	// expiry is when the Lease expires, unless it is renewed.
+	expiry   time.Time
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// AcquireLock acquires the lock of the inventory, or returns a LockHeldError
+// if another holder holds it. An expired lock is taken over. If the namespace
+// of the inventory does not exist yet, no one else can hold the lock, and the
+// Lease is created once the namespace exists.
+func AcquireLock(ctx context.Context, dc dynamic.Interface, mapper meta.RESTMapper,
+	inv Info, opts LockOptions) (*Lock, error) {
+	if opts.Duration <= 0 {
+		opts.Duration = DefaultLockDuration
+	}
+	client, err := leaseClient(dc, mapper, inv)
+	if err != nil {
+		return nil, err
+	}
+	name := LockName(inv)
+	identity := fmt.Sprintf("%s/%s", opts.Holder, utilrand.String(8))
+	now := time.Now()
+	held, err := tryLock(ctx, client, inv, name, identity, opts.Duration, now)
+	if err != nil {
+		return nil, err
+	}
+	if held {
+		klog.V(4).Infof("acquired inventory lock: %s/%s", inv.Namespace(), name)
+	} else {
+		klog.V(4).Infof("namespace of inventory lock %s/%s not found, creating it once the namespace exists",
+			inv.Namespace(), name)
+	}
+
+	lockCtx, cancel := context.WithCancelCause(ctx)
+	l := &Lock{
+		client:   client,
+		inv:      inv,
+		name:     name,
+		opts:     opts,
+		identity: identity,
+		held:     held,
+		expiry:   now.Add(opts.Duration),
+		ctx:      lockCtx,
+		cancel:   cancel,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go l.renew()
+	return l, nil
+}
+
+// Context returns a context, which is cancelled with a LockLostError, if the
+// lock could not be renewed.
+func (l *Lock) Context() context.Context {
+	return l.ctx
+}
+
+// Release stops renewing the lock and deletes the Lease, unless another
+// holder has taken it over.
+func (l *Lock) Release() error {
+	l.stopOnce.Do(func() { close(l.stop) })
+	<-l.done
+	defer l.cancel(nil)
+	if !l.held {
+		return nil
+	}
+
+	lease, err := l.client.Get(context.TODO(), l.name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if holder, _, _ := unstructured.NestedString(lease.Object, "spec", "holderIdentity"); holder != l.identity {
+		return nil
+	}
+	rv := lease.GetResourceVersion()
+	err = l.client.Delete(context.TODO(), l.name, metav1.DeleteOptions{
+		Preconditions: &metav1.Preconditions{ResourceVersion: &rv},
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	klog.V(4).Infof("released inventory lock: %s/%s", lease.GetNamespace(), l.name)
+	return nil
+}
+
+// renew renews the lock at a third of its duration, until it is released.
+// Failed renewals are retried until the Lease expires. The context of the
+// lock is cancelled, if the Lease expired or was taken over by another holder.
+func (l *Lock) renew() {
+	defer close(l.done)
+	interval := l.opts.Duration / 3
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-l.ctx.Done():
+			return
+		case <-timer.C:
+		}
+		now := time.Now()
+		lost, err := l.renewOnce(now)
+		switch {
+		case err == nil:
+			l.expiry = now.Add(l.opts.Duration)
+			timer.Reset(interval)
+		case lost || !time.Now().Before(l.expiry):
+			klog.Warningf("failed to renew inventory lock %q: %v", l.name, err)
+			l.cancel(&LockLostError{Err: err})
+			return
+		default:
+			// Retry more often, but not after the Lease expired.
+			retry := interval / 4
+			if untilExpiry := time.Until(l.expiry); untilExpiry < retry {
+				retry = untilExpiry
+			}
+			klog.V(4).Infof("failed to renew inventory lock %q, retrying in %v: %v", l.name, retry, err)
+			timer.Reset(retry)
+		}
+	}
+}
+
+// renewOnce updates the renew time of the Lease, or creates it if it is not
+// held yet. Returns true, if the Lease was deleted or taken over by another
+// holder.
+func (l *Lock) renewOnce(now time.Time) (bool, error) {
+	if !l.held {
+		held, err := tryLock(context.TODO(), l.client, l.inv, l.name, l.identity, l.opts.Duration, now)
+		var heldErr *LockHeldError
+		if errors.As(err, &heldErr) {
+			return true, err
+		}
+		if held {
+			klog.V(4).Infof("acquired inventory lock: %s/%s", l.inv.Namespace(), l.name)
+		}
+		l.held = held
+		return false, err
+	}
+	lease, err := l.client.Get(context.TODO(), l.name, metav1.GetOptions{})
+	if err != nil {
+		return apierrors.IsNotFound(err), err
+	}
+	holder, _, _ := unstructured.NestedString(lease.Object, "spec", "holderIdentity")
+	if holder != l.identity {
+		return true, fmt.Errorf("taken over by %q", holder)
+	}
+	if err := unstructured.SetNestedField(lease.Object, microTime(now), "spec", "renewTime"); err != nil {
+		return false, err
+	}
+	_, err = l.client.Update(context.TODO(), lease, metav1.UpdateOptions{})
+	return false, err
+}
+
+// ForceUnlock deletes the lock of the inventory, regardless of its holder,
+// e.g. after the holder exited without releasing it. Returns the holder of
+// the deleted lock, or an empty string if the inventory was not locked.
+func ForceUnlock(ctx context.Context, dc dynamic.Interface, mapper meta.RESTMapper, inv Info) (string, error) {
+	client, err := leaseClient(dc, mapper, inv)
+	if err != nil {
+		return "", err
+	}
+	name := LockName(inv)
+	lease, err := client.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	holder, _, _ := unstructured.NestedString(lease.Object, "spec", "holderIdentity")
+	if err := client.Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return "", err
+	}
+	return holder, nil
+}
+
+// LockName returns the name of the Lease storing the lock of the inventory,
+// derived from the inventory id, or the name if the inventory has no id.
+func LockName(inv Info) string {
+	key := inv.ID()
+	if key == "" {
+		key = inv.Name()
+	}
+	return fmt.Sprintf("inventory-lock-%x", sha256.Sum256([]byte(key)))[:len("inventory-lock-")+16]
+}
+
+// tryLock creates the Lease, or takes it over if it expired. The
+// resourceVersion of the Lease guarantees that only one of multiple concurrent
+// holders succeeds. Returns false, if the namespace of the inventory does not
+// exist.
+func tryLock(ctx context.Context, client dynamic.ResourceInterface, inv Info, name, identity string,
+	duration time.Duration, now time.Time) (bool, error) {
+	lease, err := client.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return false, err
+		}
+		lease = &unstructured.Unstructured{}
+		lease.SetGroupVersionKind(LeaseGVK)
+		lease.SetNamespace(inv.Namespace())
+		lease.SetName(name)
+		if inv.ID() != "" {
+			lease.SetLabels(map[string]string{common.InventoryLabel: inv.ID()})
+		}
+		setLeaseSpec(lease, identity, duration, now)
+		_, err = client.Create(ctx, lease, metav1.CreateOptions{})
+		switch {
+		case err == nil:
+			return true, nil
+		case isNamespaceNotFound(err):
+			return false, nil
+		case apierrors.IsAlreadyExists(err):
+			return false, heldError(ctx, client, inv, name)
+		default:
+			return false, err
+		}
+	}
+
+	holder, _, _ := unstructured.NestedString(lease.Object, "spec", "holderIdentity")
+	if holder != "" && now.Before(leaseExpiry(lease)) {
+		return false, lockHeldError(inv, lease)
+	}
+	klog.V(4).Infof("taking over expired inventory lock %q from %q", name, holder)
+	setLeaseSpec(lease, identity, duration, now)
+	_, err = client.Update(ctx, lease, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		return false, heldError(ctx, client, inv, name)
+	}
+	return err == nil, err
+}
+
+// isNamespaceNotFound returns true, if the error is returned for an object
+// created in a namespace that does not exist.
+func isNamespaceNotFound(err error) bool {
+	if !apierrors.IsNotFound(err) {
+		return false
+	}
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) || status.Status().Details == nil {
+		return false
+	}
+	return status.Status().Details.Kind == "namespaces"
+}
+
+// heldError returns the LockHeldError of the Lease created or updated by
+// another holder concurrently.
+func heldError(ctx context.Context, client dynamic.ResourceInterface, inv Info, name string) error {
+	lease, err := client.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("inventory lock %q acquired concurrently: %w", name, err)
+	}
+	return lockHeldError(inv, lease)
+}
+
+func lockHeldError(inv Info, lease *unstructured.Unstructured) error {
+	holder, _, _ := unstructured.NestedString(lease.Object, "spec", "holderIdentity")
+	return &LockHeldError{
+		Namespace: inv.Namespace(),
+		Name:      lease.GetName(),
+		Holder:    holder,
+		Expires:   leaseExpiry(lease),
+	}
+}
+
+func setLeaseSpec(lease *unstructured.Unstructured, identity string, duration time.Duration, now time.Time) {
+	lease.Object["spec"] = map[string]interface{}{
+		"holderIdentity":       identity,
+		"leaseDurationSeconds": int64(duration.Round(time.Second) / time.Second),
+		"acquireTime":          microTime(now),
+		"renewTime":            microTime(now),
+	}
+}
+
+// leaseExpiry returns the time the Lease expires, if it is not renewed.
+func leaseExpiry(lease *unstructured.Unstructured) time.Time {
+	renewTime, _, _ := unstructured.NestedString(lease.Object, "spec", "renewTime")
+	duration, _, _ := unstructured.NestedInt64(lease.Object, "spec", "leaseDurationSeconds")
+	t, err := time.Parse(metav1.RFC3339Micro, renewTime)
+	if err != nil {
+		// Leases without a valid renew time are expired.
+		return time.Time{}
+	}
+	return t.Add(time.Duration(duration) * time.Second)
+}
+
+func microTime(t time.Time) string {
+	return t.UTC().Format(metav1.RFC3339Micro)
+}
+
+func leaseClient(dc dynamic.Interface, mapper meta.RESTMapper, inv Info) (dynamic.ResourceInterface, error) {
+	if inv == nil {
+		return nil, fmt.Errorf("inventoryInfo must be specified")
+	}
+	mapping, err := mapper.RESTMapping(LeaseGVK.GroupKind(), LeaseGVK.Version)
+	if err != nil {
+		return nil, err
+	}
+	return dc.Resource(mapping.Resource).Namespace(inv.Namespace()), nil
+}
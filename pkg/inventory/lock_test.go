@@ -0,0 +1,277 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package inventory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fluxcd/cli-utils/pkg/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+var leaseGVR = schema.GroupVersionResource{Group: "coordination.k8s.io", Version: "v1", Resource: "leases"}
+
+func newLease(inv Info, holder string, renewTime time.Time) *unstructured.Unstructured {
+	lease := &unstructured.Unstructured{}
+	lease.SetGroupVersionKind(LeaseGVK)
+	lease.SetNamespace(inv.Namespace())
+	lease.SetName(LockName(inv))
+	lease.Object["spec"] = map[string]interface{}{
+		"holderIdentity":       holder,
+		"leaseDurationSeconds": int64(60),
+		"acquireTime":          microTime(renewTime),
+		"renewTime":            microTime(renewTime),
+	}
+	return lease
+}
+
+func getLeaseHolder(t *testing.T, leases dynamic.ResourceInterface, name string) string {
+	lease, err := leases.Get(context.TODO(), name, metav1.GetOptions{})
+	require.NoError(t, err)
+	holder, _, _ := unstructured.NestedString(lease.Object, "spec", "holderIdentity")
+	return holder
+}
+
+// assertHeldBy asserts that the holder identity of the Lease is an identity
+// of the holder.
+func assertHeldBy(t *testing.T, holder, identity string) {
+	assert.True(t, strings.HasPrefix(identity, holder+"/"),
+		"expected lease held by %q, got %q", holder, identity)
+}
+
+func TestAcquireLock(t *testing.T) {
+	inv := WrapInventoryInfoObj(newShardedInv("test-id"))
+	lockName := LockName(inv)
+
+	testCases := map[string]struct {
+		lease          *unstructured.Unstructured
+		expectedHolder string
+		expectedError  error
+	}{
+		"not locked": {
+			expectedHolder: "me",
+		},
+		"locked by same holder": {
+			lease:          newLease(inv, "me/abc", time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)),
+			expectedHolder: "me/abc",
+			expectedError: &LockHeldError{
+				Namespace: "test-ns",
+				Name:      lockName,
+				Holder:    "me/abc",
+				Expires:   time.Date(2100, 1, 1, 0, 1, 0, 0, time.UTC),
+			},
+		},
+		"locked by other holder": {
+			lease:          newLease(inv, "other", time.Date(2100, 1, 1, 0, 0, 0, 0, time.UTC)),
+			expectedHolder: "other",
+			expectedError: &LockHeldError{
+				Namespace: "test-ns",
+				Name:      lockName,
+				Holder:    "other",
+				Expires:   time.Date(2100, 1, 1, 0, 1, 0, 0, time.UTC),
+			},
+		},
+		"expired lock of other holder is taken over": {
+			lease:          newLease(inv, "other", time.Now().Add(-2*time.Minute)),
+			expectedHolder: "me",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			var objs []runtime.Object
+			if tc.lease != nil {
+				objs = append(objs, tc.lease)
+			}
+			dc := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objs...)
+			mapper := testutil.NewFakeRESTMapper(LeaseGVK)
+			leases := dc.Resource(leaseGVR).Namespace("test-ns")
+
+			lock, err := AcquireLock(context.TODO(), dc, mapper, inv, LockOptions{Holder: "me"})
+			if tc.expectedError != nil {
+				assert.Equal(t, tc.expectedError, err)
+				assert.Equal(t, tc.expectedHolder, getLeaseHolder(t, leases, lockName))
+				return
+			}
+			require.NoError(t, err)
+			assertHeldBy(t, tc.expectedHolder, getLeaseHolder(t, leases, lockName))
+
+			require.NoError(t, lock.Release())
+			_, err = leases.Get(context.TODO(), lockName, metav1.GetOptions{})
+			assert.True(t, apierrors.IsNotFound(err), "expected lease to be deleted")
+			assert.Equal(t, context.Canceled, context.Cause(lock.Context()))
+		})
+	}
+}
+
+func TestLockLost(t *testing.T) {
+	inv := WrapInventoryInfoObj(newShardedInv("test-id"))
+	dc := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	mapper := testutil.NewFakeRESTMapper(LeaseGVK)
+	leases := dc.Resource(leaseGVR).Namespace("test-ns")
+
+	lock, err := AcquireLock(context.TODO(), dc, mapper, inv, LockOptions{
+		Holder:   "me",
+		Duration: 30 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	// Another holder takes over the lock, e.g. after a force unlock.
+	holder, err := ForceUnlock(context.TODO(), dc, mapper, inv)
+	require.NoError(t, err)
+	assertHeldBy(t, "me", holder)
+	_, err = leases.Create(context.TODO(), newLease(inv, "other", time.Now()), metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	select {
+	case <-lock.Context().Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the lock to be lost")
+	}
+	var lostErr *LockLostError
+	assert.True(t, errors.As(context.Cause(lock.Context()), &lostErr))
+
+	// Releasing a lost lock keeps the lock of the other holder.
+	require.NoError(t, lock.Release())
+	assert.Equal(t, "other", getLeaseHolder(t, leases, LockName(inv)))
+}
+
+func TestAcquireLockSameHolder(t *testing.T) {
+	inv := WrapInventoryInfoObj(newShardedInv("test-id"))
+	dc := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	mapper := testutil.NewFakeRESTMapper(LeaseGVK)
+	leases := dc.Resource(leaseGVR).Namespace("test-ns")
+
+	lock, err := AcquireLock(context.TODO(), dc, mapper, inv, LockOptions{Holder: "me"})
+	require.NoError(t, err)
+	identity := getLeaseHolder(t, leases, LockName(inv))
+
+	// A concurrent run with the same holder does not get the lock.
+	_, err = AcquireLock(context.TODO(), dc, mapper, inv, LockOptions{Holder: "me"})
+	var heldErr *LockHeldError
+	require.True(t, errors.As(err, &heldErr), "expected LockHeldError, got %v", err)
+	assert.Equal(t, identity, heldErr.Holder)
+
+	require.NoError(t, lock.Release())
+	_, err = leases.Get(context.TODO(), LockName(inv), metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err), "expected lease to be deleted")
+}
+
+func TestAcquireLockNamespaceNotFound(t *testing.T) {
+	inv := WrapInventoryInfoObj(newShardedInv("test-id"))
+	dc := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	mapper := testutil.NewFakeRESTMapper(LeaseGVK)
+	leases := dc.Resource(leaseGVR).Namespace("test-ns")
+
+	// The namespace is created after the lock is acquired, e.g. by the
+	// first apply of the package.
+	namespaceCreated := make(chan struct{})
+	dc.PrependReactor("create", "leases", func(clienttesting.Action) (bool, runtime.Object, error) {
+		select {
+		case <-namespaceCreated:
+			return false, nil, nil
+		default:
+			return true, nil, apierrors.NewNotFound(schema.GroupResource{Resource: "namespaces"}, "test-ns")
+		}
+	})
+
+	lock, err := AcquireLock(context.TODO(), dc, mapper, inv, LockOptions{
+		Holder:   "me",
+		Duration: 30 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	_, err = leases.Get(context.TODO(), LockName(inv), metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err), "expected lease not to be created")
+
+	// The lease is created by a renewal, once the namespace exists.
+	close(namespaceCreated)
+	err = wait.PollUntilContextTimeout(context.TODO(), 10*time.Millisecond, 5*time.Second, true,
+		func(ctx context.Context) (bool, error) {
+			_, err := leases.Get(ctx, LockName(inv), metav1.GetOptions{})
+			return err == nil, nil
+		})
+	require.NoError(t, err)
+	assertHeldBy(t, "me", getLeaseHolder(t, leases, LockName(inv)))
+	assert.NoError(t, context.Cause(lock.Context()))
+
+	require.NoError(t, lock.Release())
+	_, err = leases.Get(context.TODO(), LockName(inv), metav1.GetOptions{})
+	assert.True(t, apierrors.IsNotFound(err), "expected lease to be deleted")
+}
+
+func TestForceUnlockNotLocked(t *testing.T) {
+	inv := WrapInventoryInfoObj(newShardedInv("test-id"))
+	dc := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	holder, err := ForceUnlock(context.TODO(), dc, testutil.NewFakeRESTMapper(LeaseGVK), inv)
+	require.NoError(t, err)
+	assert.Empty(t, holder)
+}
+
+func TestLockRenewRetries(t *testing.T) {
+	duration := 300 * time.Millisecond
+
+	testCases := map[string]struct {
+		// failFor is how long renewals fail after the lock is acquired.
+		failFor      time.Duration
+		expectedLost bool
+	}{
+		"transient renewal failures are retried": {
+			failFor: duration / 2,
+		},
+		"lock lost when lease expired": {
+			failFor:      time.Hour,
+			expectedLost: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			inv := WrapInventoryInfoObj(newShardedInv("test-id"))
+			dc := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+			mapper := testutil.NewFakeRESTMapper(LeaseGVK)
+
+			start := time.Now()
+			dc.PrependReactor("update", "leases", func(clienttesting.Action) (bool, runtime.Object, error) {
+				if time.Since(start) < tc.failFor {
+					return true, nil, apierrors.NewServiceUnavailable("unavailable")
+				}
+				return false, nil, nil
+			})
+
+			lock, err := AcquireLock(context.TODO(), dc, mapper, inv, LockOptions{
+				Holder:   "me",
+				Duration: duration,
+			})
+			require.NoError(t, err)
+			defer func() {
+				require.NoError(t, lock.Release())
+			}()
+
+			select {
+			case <-lock.Context().Done():
+				require.True(t, tc.expectedLost, "unexpected lost lock: %v", context.Cause(lock.Context()))
+				// The lock is only lost when the lease expired.
+				assert.GreaterOrEqual(t, time.Since(start), duration)
+				var lostErr *LockLostError
+				assert.True(t, errors.As(context.Cause(lock.Context()), &lostErr))
+			case <-time.After(3 * duration):
+				require.False(t, tc.expectedLost, "timed out waiting for the lock to be lost")
+			}
+		})
+	}
+}
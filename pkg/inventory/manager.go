@@ -5,15 +5,19 @@ package inventory
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/fluxcd/cli-utils/pkg/apis/actuation"
 	"github.com/fluxcd/cli-utils/pkg/object"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 // Manager wraps an Inventory with convenience methods that use ObjMetadata.
+// Manager is thread-safe.
 type Manager struct {
+	mu        sync.RWMutex
 	inventory *actuation.Inventory
 }
 
@@ -25,13 +29,25 @@ func NewManager() *Manager {
 }
 
 // Inventory returns the in-memory version of the managed inventory.
+// The returned inventory is not protected from concurrent updates.
 func (tc *Manager) Inventory() *actuation.Inventory {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
 	return tc.inventory
 }
 
 // ObjectStatus retrieves the status of an object with the specified ID.
-// The returned status is a pointer and can be updated in-place for efficiency.
+// The returned status is a pointer and can be updated in-place for efficiency,
+// but in-place updates are not protected from concurrent access.
 func (tc *Manager) ObjectStatus(id object.ObjMetadata) (*actuation.ObjectStatus, bool) {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	return tc.objectStatus(id)
+}
+
+// objectStatus retrieves the status of an object with the specified ID.
+// The caller must hold the lock.
+func (tc *Manager) objectStatus(id object.ObjMetadata) (*actuation.ObjectStatus, bool) {
 	ref := ObjectReferenceFromObjMetadata(id)
 	for i, objStatus := range tc.inventory.Status.Objects {
 		if objStatus.ObjectReference == ref {
@@ -44,6 +60,8 @@ func (tc *Manager) ObjectStatus(id object.ObjMetadata) (*actuation.ObjectStatus,
 // ObjectsWithActuationStatus retrieves the set of objects with the
 // specified actuation strategy and status.
 func (tc *Manager) ObjectsWithActuationStatus(strategy actuation.ActuationStrategy, status actuation.ActuationStatus) object.ObjMetadataSet {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
 	var ids object.ObjMetadataSet
 	for _, objStatus := range tc.inventory.Status.Objects {
 		if objStatus.Strategy == strategy && objStatus.Actuation == status {
@@ -56,6 +74,8 @@ func (tc *Manager) ObjectsWithActuationStatus(strategy actuation.ActuationStrate
 // ObjectsWithActuationStatus retrieves the set of objects with the
 // specified reconcile status, regardless of actuation strategy.
 func (tc *Manager) ObjectsWithReconcileStatus(status actuation.ReconcileStatus) object.ObjMetadataSet {
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
 	var ids object.ObjMetadataSet
 	for _, objStatus := range tc.inventory.Status.Objects {
 		if objStatus.Reconcile == status {
@@ -67,6 +87,8 @@ func (tc *Manager) ObjectsWithReconcileStatus(status actuation.ReconcileStatus)
 
 // SetObjectStatus updates or adds an ObjectStatus record to the inventory.
 func (tc *Manager) SetObjectStatus(newObjStatus actuation.ObjectStatus) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
 	for i, oldObjStatus := range tc.inventory.Status.Objects {
 		if oldObjStatus.ObjectReference == newObjStatus.ObjectReference {
 			tc.inventory.Status.Objects[i] = newObjStatus
@@ -78,7 +100,9 @@ func (tc *Manager) SetObjectStatus(newObjStatus actuation.ObjectStatus) {
 
 // IsSuccessfulApply returns true if the object apply was successful
 func (tc *Manager) IsSuccessfulApply(id object.ObjMetadata) bool {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return false
 	}
@@ -100,6 +124,20 @@ func (tc *Manager) AddSuccessfulApply(id object.ObjMetadata, uid types.UID, gen
 	})
 }
 
+// SetAppliedHash records the hash of the content of a successfully applied
+// object, and when it was applied.
+func (tc *Manager) SetAppliedHash(id object.ObjMetadata, hash string, appliedTime *metav1.Time) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	objStatus, found := tc.objectStatus(id)
+	if !found {
+		return fmt.Errorf("object not in inventory: %q", id)
+	}
+	objStatus.Hash = hash
+	objStatus.AppliedTime = appliedTime
+	return nil
+}
+
 // SuccessfulApplies returns all the objects (as ObjMetadata) that
 // were added as applied resources to the Manager.
 func (tc *Manager) SuccessfulApplies() object.ObjMetadataSet {
@@ -109,15 +147,21 @@ func (tc *Manager) SuccessfulApplies() object.ObjMetadataSet {
 
 // AppliedResourceUID looks up the UID of a successfully applied resource
 func (tc *Manager) AppliedResourceUID(id object.ObjMetadata) (types.UID, bool) {
-	objStatus, found := tc.ObjectStatus(id)
-	return objStatus.UID, found &&
-		objStatus.Strategy == actuation.ActuationStrategyApply &&
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	objStatus, found := tc.objectStatus(id)
+	if !found {
+		return "", false
+	}
+	return objStatus.UID, objStatus.Strategy == actuation.ActuationStrategyApply &&
 		objStatus.Actuation == actuation.ActuationSucceeded
 }
 
 // AppliedResourceUIDs returns a set with the UIDs of all the
 // successfully applied resources.
 func (tc *Manager) AppliedResourceUIDs() sets.String { // nolint:staticcheck
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
 	uids := sets.NewString()
 	for _, objStatus := range tc.inventory.Status.Objects {
 		if objStatus.Strategy == actuation.ActuationStrategyApply &&
@@ -133,7 +177,9 @@ func (tc *Manager) AppliedResourceUIDs() sets.String { // nolint:staticcheck
 // AppliedGeneration looks up the generation of the given resource
 // after it was applied.
 func (tc *Manager) AppliedGeneration(id object.ObjMetadata) (int64, bool) {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return 0, false
 	}
@@ -142,7 +188,9 @@ func (tc *Manager) AppliedGeneration(id object.ObjMetadata) (int64, bool) {
 
 // IsSuccessfulDelete returns true if the object delete was successful
 func (tc *Manager) IsSuccessfulDelete(id object.ObjMetadata) bool {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return false
 	}
@@ -174,7 +222,9 @@ func (tc *Manager) SuccessfulDeletes() object.ObjMetadataSet {
 
 // IsFailedApply returns true if the object failed to apply
 func (tc *Manager) IsFailedApply(id object.ObjMetadata) bool {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return false
 	}
@@ -199,7 +249,9 @@ func (tc *Manager) FailedApplies() object.ObjMetadataSet {
 
 // IsFailedDelete returns true if the object failed to delete
 func (tc *Manager) IsFailedDelete(id object.ObjMetadata) bool {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return false
 	}
@@ -225,7 +277,9 @@ func (tc *Manager) FailedDeletes() object.ObjMetadataSet {
 
 // IsSkippedApply returns true if the object apply was skipped
 func (tc *Manager) IsSkippedApply(id object.ObjMetadata) bool {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return false
 	}
@@ -250,7 +304,9 @@ func (tc *Manager) SkippedApplies() object.ObjMetadataSet {
 
 // IsSkippedDelete returns true if the object delete was skipped
 func (tc *Manager) IsSkippedDelete(id object.ObjMetadata) bool {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return false
 	}
@@ -276,7 +332,9 @@ func (tc *Manager) SkippedDeletes() object.ObjMetadataSet {
 
 // IsSuccessfulReconcile returns true if the object is reconciled
 func (tc *Manager) IsSuccessfulReconcile(id object.ObjMetadata) bool {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return false
 	}
@@ -285,7 +343,9 @@ func (tc *Manager) IsSuccessfulReconcile(id object.ObjMetadata) bool {
 
 // SetSuccessfulReconcile registers that the object is reconciled
 func (tc *Manager) SetSuccessfulReconcile(id object.ObjMetadata) error {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return fmt.Errorf("object not in inventory: %q", id)
 	}
@@ -300,7 +360,9 @@ func (tc *Manager) SuccessfulReconciles() object.ObjMetadataSet {
 
 // IsFailedReconcile returns true if the object failed to reconcile
 func (tc *Manager) IsFailedReconcile(id object.ObjMetadata) bool {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return false
 	}
@@ -309,7 +371,9 @@ func (tc *Manager) IsFailedReconcile(id object.ObjMetadata) bool {
 
 // SetFailedReconcile registers that the object failed to reconcile
 func (tc *Manager) SetFailedReconcile(id object.ObjMetadata) error {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return fmt.Errorf("object not in inventory: %q", id)
 	}
@@ -324,7 +388,9 @@ func (tc *Manager) FailedReconciles() object.ObjMetadataSet {
 
 // IsSkippedReconcile returns true if the object reconcile was skipped
 func (tc *Manager) IsSkippedReconcile(id object.ObjMetadata) bool {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return false
 	}
@@ -333,7 +399,9 @@ func (tc *Manager) IsSkippedReconcile(id object.ObjMetadata) bool {
 
 // SetSkippedReconcile registers that the object reconcile was skipped
 func (tc *Manager) SetSkippedReconcile(id object.ObjMetadata) error {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return fmt.Errorf("object not in inventory: %q", id)
 	}
@@ -348,7 +416,9 @@ func (tc *Manager) SkippedReconciles() object.ObjMetadataSet {
 
 // IsTimeoutReconcile returns true if the object reconcile was skipped
 func (tc *Manager) IsTimeoutReconcile(id object.ObjMetadata) bool {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return false
 	}
@@ -357,7 +427,9 @@ func (tc *Manager) IsTimeoutReconcile(id object.ObjMetadata) bool {
 
 // SetTimeoutReconcile registers that the object reconcile was skipped
 func (tc *Manager) SetTimeoutReconcile(id object.ObjMetadata) error {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return fmt.Errorf("object not in inventory: %q", id)
 	}
@@ -372,7 +444,9 @@ func (tc *Manager) TimeoutReconciles() object.ObjMetadataSet {
 
 // IsPendingReconcile returns true if the object reconcile is pending
 func (tc *Manager) IsPendingReconcile(id object.ObjMetadata) bool {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return false
 	}
@@ -381,7 +455,9 @@ func (tc *Manager) IsPendingReconcile(id object.ObjMetadata) bool {
 
 // SetPendingReconcile registers that the object reconcile is pending
 func (tc *Manager) SetPendingReconcile(id object.ObjMetadata) error {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return fmt.Errorf("object not in inventory: %q", id)
 	}
@@ -396,7 +472,9 @@ func (tc *Manager) PendingReconciles() object.ObjMetadataSet {
 
 // IsPendingApply returns true if the object pending apply
 func (tc *Manager) IsPendingApply(id object.ObjMetadata) bool {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return false
 	}
@@ -422,7 +500,9 @@ func (tc *Manager) PendingApplies() object.ObjMetadataSet {
 
 // IsPendingDelete returns true if the object pending delete
 func (tc *Manager) IsPendingDelete(id object.ObjMetadata) bool {
-	objStatus, found := tc.ObjectStatus(id)
+	tc.mu.RLock()
+	defer tc.mu.RUnlock()
+	objStatus, found := tc.objectStatus(id)
 	if !found {
 		return false
 	}
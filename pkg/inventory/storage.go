@@ -40,14 +40,34 @@ type Storage interface {
 	GetObject() (*unstructured.Unstructured, error)
 	// Apply applies the inventory object. This utility function is used
 	// in InventoryClient.Merge and merges the metadata, spec and status.
+	// Afterwards, GetObject returns the resourceVersion of the applied object.
 	Apply(dynamic.Interface, meta.RESTMapper, StatusPolicy) error
 	// ApplyWithPrune applies the inventory object with a set of pruneIDs of
 	// objects to be pruned (object.ObjMetadataSet). This function is used in
 	// InventoryClient.Replace. pruneIDs are required for enabling custom logic
-	// handling of multiple ResourceGroup inventories.
+	// handling of multiple ResourceGroup inventories. Like Apply, GetObject
+	// returns the resourceVersion of the applied object afterwards.
 	ApplyWithPrune(dynamic.Interface, meta.RESTMapper, StatusPolicy, object.ObjMetadataSet) error
 }
 
+// StatusStorage is implemented by a Storage, which loads the object status
+// persisted in the inventory object. The status of a Storage that does not
+// implement it can not be loaded.
+type StatusStorage interface {
+	// LoadStatus retrieves the object status persisted in the inventory object.
+	// Objects without a persisted status are omitted.
+	LoadStatus() ([]actuation.ObjectStatus, error)
+}
+
+// loadStatus returns the object status persisted in the inventory object, or
+// nil if the Storage does not implement StatusStorage.
+func loadStatus(s Storage) ([]actuation.ObjectStatus, error) {
+	if ss, ok := s.(StatusStorage); ok {
+		return ss.LoadStatus()
+	}
+	return nil, nil
+}
+
 // StorageFactoryFunc creates the object which implements the Inventory
 // interface from the passed info object.
 type StorageFactoryFunc func(*unstructured.Unstructured) Storage
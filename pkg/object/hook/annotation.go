@@ -0,0 +1,105 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package hook
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+)
+
+const (
+	Annotation             = "config.kubernetes.io/hook"
+	DeletePolicyAnnotation = "config.kubernetes.io/hook-delete-policy"
+)
+
+// IsHook returns true if the config.kubernetes.io/hook annotation is present,
+// false if not.
+func IsHook(u *unstructured.Unstructured) bool {
+	if u == nil {
+		return false
+	}
+	_, found := u.GetAnnotations()[Annotation]
+	return found
+}
+
+// ReadAnnotation reads the hook annotation and parses the comma-separated
+// list of phases, e.g. "pre-apply,pre-prune". Returns nil if the annotation
+// is not present.
+func ReadAnnotation(u *unstructured.Unstructured) ([]Phase, error) {
+	if u == nil {
+		return nil, nil
+	}
+	phasesStr, found := u.GetAnnotations()[Annotation]
+	if !found {
+		return nil, nil
+	}
+	klog.V(5).Infof("hook annotation found for %s/%s: %q",
+		u.GetNamespace(), u.GetName(), phasesStr)
+
+	var phases []Phase
+	for _, s := range splitList(phasesStr) {
+		phase := Phase(s)
+		switch phase {
+		case PreApply, PostApply, PrePrune:
+			phases = append(phases, phase)
+		default:
+			return nil, object.InvalidAnnotationError{
+				Annotation: Annotation,
+				Cause:      fmt.Errorf("unknown hook phase: %q", s),
+			}
+		}
+	}
+	if len(phases) == 0 {
+		return nil, object.InvalidAnnotationError{
+			Annotation: Annotation,
+			Cause:      fmt.Errorf("hook phase is required"),
+		}
+	}
+	return phases, nil
+}
+
+// ReadDeletePolicyAnnotation reads the hook-delete-policy annotation and
+// parses the comma-separated list of policies. Defaults to
+// before-hook-creation if the annotation is not present. An empty annotation
+// keeps the hook objects.
+func ReadDeletePolicyAnnotation(u *unstructured.Unstructured) (DeletePolicies, error) {
+	if u == nil {
+		return nil, nil
+	}
+	policiesStr, found := u.GetAnnotations()[DeletePolicyAnnotation]
+	if !found {
+		return DeletePolicies{BeforeHookCreation}, nil
+	}
+	klog.V(5).Infof("hook-delete-policy annotation found for %s/%s: %q",
+		u.GetNamespace(), u.GetName(), policiesStr)
+
+	var policies DeletePolicies
+	for _, s := range splitList(policiesStr) {
+		policy := DeletePolicy(s)
+		switch policy {
+		case BeforeHookCreation, HookSucceeded, HookFailed:
+			policies = append(policies, policy)
+		default:
+			return nil, object.InvalidAnnotationError{
+				Annotation: DeletePolicyAnnotation,
+				Cause:      fmt.Errorf("unknown hook delete policy: %q", s),
+			}
+		}
+	}
+	return policies, nil
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,109 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package hook
+
+import (
+	"testing"
+
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func withAnnotations(annotations map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("batch/v1")
+	u.SetKind("Job")
+	u.SetNamespace("default")
+	u.SetName("job")
+	u.SetAnnotations(annotations)
+	return u
+}
+
+func TestReadAnnotation(t *testing.T) {
+	testCases := map[string]struct {
+		obj       *unstructured.Unstructured
+		expected  []Phase
+		expectErr bool
+	}{
+		"nil object": {
+			obj:      nil,
+			expected: nil,
+		},
+		"no annotation": {
+			obj:      withAnnotations(nil),
+			expected: nil,
+		},
+		"single phase": {
+			obj:      withAnnotations(map[string]string{Annotation: "pre-apply"}),
+			expected: []Phase{PreApply},
+		},
+		"multiple phases": {
+			obj:      withAnnotations(map[string]string{Annotation: "post-apply, pre-prune"}),
+			expected: []Phase{PostApply, PrePrune},
+		},
+		"unknown phase": {
+			obj:       withAnnotations(map[string]string{Annotation: "pre-apply,post-delete"}),
+			expectErr: true,
+		},
+		"empty annotation": {
+			obj:       withAnnotations(map[string]string{Annotation: ""}),
+			expectErr: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			assert.Equal(t, tc.obj != nil && tc.obj.GetAnnotations() != nil, IsHook(tc.obj))
+			phases, err := ReadAnnotation(tc.obj)
+			if tc.expectErr {
+				assert.ErrorAs(t, err, &object.InvalidAnnotationError{})
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, phases)
+		})
+	}
+}
+
+func TestReadDeletePolicyAnnotation(t *testing.T) {
+	testCases := map[string]struct {
+		obj       *unstructured.Unstructured
+		expected  DeletePolicies
+		expectErr bool
+	}{
+		"nil object": {
+			obj:      nil,
+			expected: nil,
+		},
+		"default policy": {
+			obj:      withAnnotations(nil),
+			expected: DeletePolicies{BeforeHookCreation},
+		},
+		"multiple policies": {
+			obj:      withAnnotations(map[string]string{DeletePolicyAnnotation: "hook-succeeded,hook-failed"}),
+			expected: DeletePolicies{HookSucceeded, HookFailed},
+		},
+		"empty annotation": {
+			obj:      withAnnotations(map[string]string{DeletePolicyAnnotation: ""}),
+			expected: nil,
+		},
+		"unknown policy": {
+			obj:       withAnnotations(map[string]string{DeletePolicyAnnotation: "never"}),
+			expectErr: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			policies, err := ReadDeletePolicyAnnotation(tc.obj)
+			if tc.expectErr {
+				assert.ErrorAs(t, err, &object.InvalidAnnotationError{})
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, policies)
+		})
+	}
+}
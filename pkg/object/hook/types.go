@@ -0,0 +1,46 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package hook
+
+// Phase is a point in the apply process at which hook objects are run.
+type Phase string
+
+const (
+	// PreApply hooks run before the objects are applied.
+	PreApply Phase = "pre-apply"
+	// PostApply hooks run after the objects are applied and reconciled.
+	PostApply Phase = "post-apply"
+	// PrePrune hooks run before the objects are pruned, if there are any
+	// objects to prune.
+	PrePrune Phase = "pre-prune"
+)
+
+// Phases are the hook phases, in the order they run.
+var Phases = []Phase{PreApply, PostApply, PrePrune}
+
+// DeletePolicy defines when hook objects are deleted.
+type DeletePolicy string
+
+const (
+	// BeforeHookCreation deletes a hook object left by a previous run before
+	// the hook is applied again. This is the default policy.
+	BeforeHookCreation DeletePolicy = "before-hook-creation"
+	// HookSucceeded deletes a hook object after it completed.
+	HookSucceeded DeletePolicy = "hook-succeeded"
+	// HookFailed deletes a hook object after it failed or timed out.
+	HookFailed DeletePolicy = "hook-failed"
+)
+
+// DeletePolicies is a set of delete policies.
+type DeletePolicies []DeletePolicy
+
+// Contains returns true if the set contains the policy.
+func (dps DeletePolicies) Contains(policy DeletePolicy) bool {
+	for _, dp := range dps {
+		if dp == policy {
+			return true
+		}
+	}
+	return false
+}
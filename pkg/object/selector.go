@@ -0,0 +1,52 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package object
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// Selector selects objects by identifier and labels. An object is selected,
+// if it matches all of the criteria that are set. The zero value selects all
+// objects.
+type Selector struct {
+	// GroupKinds selects objects of any of these kinds.
+	GroupKinds []schema.GroupKind
+	// Namespaces selects objects in any of these namespaces. Use the empty
+	// string to select cluster-scoped objects.
+	Namespaces []string
+	// LabelSelector selects objects with matching labels.
+	LabelSelector labels.Selector
+	// Objects selects any of these objects.
+	Objects ObjMetadataSet
+}
+
+// Matches returns true if the object is selected.
+func (s Selector) Matches(obj *unstructured.Unstructured) bool {
+	if !s.MatchesIdentifier(UnstructuredToObjMetadata(obj)) {
+		return false
+	}
+	if s.LabelSelector != nil && !s.LabelSelector.Matches(labels.Set(obj.GetLabels())) {
+		return false
+	}
+	return true
+}
+
+// MatchesIdentifier returns true if the object identifier is selected,
+// ignoring the LabelSelector.
+func (s Selector) MatchesIdentifier(id ObjMetadata) bool {
+	if len(s.GroupKinds) > 0 && !sets.New(s.GroupKinds...).Has(id.GroupKind) {
+		return false
+	}
+	if len(s.Namespaces) > 0 && !sets.New(s.Namespaces...).Has(id.Namespace) {
+		return false
+	}
+	if len(s.Objects) > 0 && !s.Objects.Contains(id) {
+		return false
+	}
+	return true
+}
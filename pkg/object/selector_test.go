@@ -0,0 +1,81 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestSelector_Matches(t *testing.T) {
+	deployment := &unstructured.Unstructured{}
+	deployment.SetAPIVersion("apps/v1")
+	deployment.SetKind("Deployment")
+	deployment.SetNamespace("default")
+	deployment.SetName("dep")
+	deployment.SetLabels(map[string]string{"app": "web"})
+
+	testCases := map[string]struct {
+		selector Selector
+		expected bool
+	}{
+		"zero value selects all": {
+			selector: Selector{},
+			expected: true,
+		},
+		"matching group kind": {
+			selector: Selector{
+				GroupKinds: []schema.GroupKind{{Kind: "Pod"}, {Group: "apps", Kind: "Deployment"}},
+			},
+			expected: true,
+		},
+		"other group kind": {
+			selector: Selector{
+				GroupKinds: []schema.GroupKind{{Group: "apps", Kind: "StatefulSet"}},
+			},
+			expected: false,
+		},
+		"matching namespace": {
+			selector: Selector{Namespaces: []string{"default"}},
+			expected: true,
+		},
+		"other namespace": {
+			selector: Selector{Namespaces: []string{""}},
+			expected: false,
+		},
+		"matching labels": {
+			selector: Selector{LabelSelector: labels.SelectorFromSet(labels.Set{"app": "web"})},
+			expected: true,
+		},
+		"other labels": {
+			selector: Selector{LabelSelector: labels.SelectorFromSet(labels.Set{"app": "db"})},
+			expected: false,
+		},
+		"matching object": {
+			selector: Selector{Objects: ObjMetadataSet{objMeta3, objMeta1}},
+			expected: true,
+		},
+		"other object": {
+			selector: Selector{Objects: ObjMetadataSet{objMeta2}},
+			expected: false,
+		},
+		"all criteria must match": {
+			selector: Selector{
+				Namespaces:    []string{"default"},
+				LabelSelector: labels.SelectorFromSet(labels.Set{"app": "db"}),
+			},
+			expected: false,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.selector.Matches(deployment))
+		})
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package waitfor
+
+import (
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+)
+
+const (
+	Annotation = "cli-utils.sigs.k8s.io/wait-for"
+)
+
+// HasAnnotation returns true if the cli-utils.sigs.k8s.io/wait-for annotation
+// is present, false if not.
+func HasAnnotation(u *unstructured.Unstructured) bool {
+	if u == nil {
+		return false
+	}
+	_, found := u.GetAnnotations()[Annotation]
+	return found
+}
+
+// ReadAnnotation reads the wait-for annotation and parses the condition.
+// Returns nil if the annotation is not present.
+func ReadAnnotation(u *unstructured.Unstructured) (Condition, error) {
+	if u == nil {
+		return nil, nil
+	}
+	condStr, found := u.GetAnnotations()[Annotation]
+	if !found {
+		return nil, nil
+	}
+	klog.V(5).Infof("wait-for annotation found for %s/%s: %q",
+		u.GetNamespace(), u.GetName(), condStr)
+
+	cond, err := ParseCondition(condStr)
+	if err != nil {
+		return nil, object.InvalidAnnotationError{
+			Annotation: Annotation,
+			Cause:      err,
+		}
+	}
+	return cond, nil
+}
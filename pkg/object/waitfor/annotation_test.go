@@ -0,0 +1,56 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package waitfor
+
+import (
+	"testing"
+
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func withAnnotations(annotations map[string]string) *unstructured.Unstructured {
+	u := pod.DeepCopy()
+	u.SetAnnotations(annotations)
+	return u
+}
+
+func TestReadAnnotation(t *testing.T) {
+	testCases := map[string]struct {
+		obj       *unstructured.Unstructured
+		expected  Condition
+		expectErr bool
+	}{
+		"nil object": {
+			obj:      nil,
+			expected: nil,
+		},
+		"no annotation": {
+			obj:      withAnnotations(nil),
+			expected: nil,
+		},
+		"valid annotation": {
+			obj:      withAnnotations(map[string]string{Annotation: "condition=Ready"}),
+			expected: StatusCondition{Type: "Ready", Status: "True"},
+		},
+		"invalid annotation": {
+			obj:       withAnnotations(map[string]string{Annotation: "ready"}),
+			expectErr: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			assert.Equal(t, tc.obj != nil && tc.obj.GetAnnotations()[Annotation] != "", HasAnnotation(tc.obj))
+			cond, err := ReadAnnotation(tc.obj)
+			if tc.expectErr {
+				assert.ErrorAs(t, err, &object.InvalidAnnotationError{})
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cond)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package waitfor
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	conditionPrefix = "condition="
+	jsonPathPrefix  = "jsonpath="
+)
+
+// ParseCondition parses a Condition from a string, in one of the formats:
+//
+//	current
+//	complete
+//	none
+//	condition=${type}[=${status}]
+//	jsonpath={${expression}}[=${value}]
+//
+// The status of a status condition defaults to "True". JSONPath range
+// expressions are not supported.
+func ParseCondition(s string) (Condition, error) {
+	switch {
+	case s == Current{}.String():
+		return Current{}, nil
+	case s == Complete{}.String():
+		return Complete{}, nil
+	case s == None{}.String():
+		return None{}, nil
+	case strings.HasPrefix(s, conditionPrefix):
+		return parseStatusCondition(strings.TrimPrefix(s, conditionPrefix))
+	case strings.HasPrefix(s, jsonPathPrefix):
+		return parseJSONPath(strings.TrimPrefix(s, jsonPathPrefix))
+	default:
+		return nil, fmt.Errorf("unknown wait condition: %q", s)
+	}
+}
+
+func parseStatusCondition(s string) (Condition, error) {
+	condType, condStatus, found := strings.Cut(s, "=")
+	if condType == "" {
+		return nil, fmt.Errorf("condition type is required")
+	}
+	if !found {
+		condStatus = "True"
+	} else if condStatus == "" {
+		return nil, fmt.Errorf("condition status is empty: %q", s)
+	}
+	return StatusCondition{
+		Type:   condType,
+		Status: condStatus,
+	}, nil
+}
+
+func parseJSONPath(s string) (Condition, error) {
+	expression, value := s, ""
+	if i := strings.Index(s, "}="); i >= 0 {
+		expression, value = s[:i+1], s[i+2:]
+		if value == "" {
+			return nil, fmt.Errorf("jsonpath value is empty: %q", s)
+		}
+	}
+	if !strings.HasPrefix(expression, "{") || !strings.HasSuffix(expression, "}") {
+		return nil, fmt.Errorf("jsonpath expression must be enclosed in braces: %q", expression)
+	}
+	cond, err := NewJSONPath(expression, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jsonpath expression %q: %w", expression, err)
+	}
+	return cond, nil
+}
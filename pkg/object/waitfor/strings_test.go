@@ -0,0 +1,107 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package waitfor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustJSONPath(expression, value string) JSONPath {
+	cond, err := NewJSONPath(expression, value)
+	if err != nil {
+		panic(err)
+	}
+	return cond
+}
+
+func TestParseCondition(t *testing.T) {
+	testCases := map[string]struct {
+		value     string
+		expected  Condition
+		expectErr bool
+	}{
+		"current": {
+			value:    "current",
+			expected: Current{},
+		},
+		"complete": {
+			value:    "complete",
+			expected: Complete{},
+		},
+		"none": {
+			value:    "none",
+			expected: None{},
+		},
+		"condition with default status": {
+			value:    "condition=Ready",
+			expected: StatusCondition{Type: "Ready", Status: "True"},
+		},
+		"condition with status": {
+			value:    "condition=Stalled=False",
+			expected: StatusCondition{Type: "Stalled", Status: "False"},
+		},
+		"condition without type": {
+			value:     "condition=",
+			expectErr: true,
+		},
+		"condition with empty status": {
+			value:     "condition=Ready=",
+			expectErr: true,
+		},
+		"jsonpath with value": {
+			value:    "jsonpath={.status.phase}=Running",
+			expected: mustJSONPath("{.status.phase}", "Running"),
+		},
+		"jsonpath with filter and value": {
+			value:    `jsonpath={.status.conditions[?(@.type=="Ready")].status}=True`,
+			expected: mustJSONPath(`{.status.conditions[?(@.type=="Ready")].status}`, "True"),
+		},
+		"jsonpath without value": {
+			value:    "jsonpath={.status.loadBalancer.ingress}",
+			expected: mustJSONPath("{.status.loadBalancer.ingress}", ""),
+		},
+		"jsonpath without braces": {
+			value:     "jsonpath=.status.phase=Running",
+			expectErr: true,
+		},
+		"jsonpath with empty value": {
+			value:     "jsonpath={.status.phase}=",
+			expectErr: true,
+		},
+		"invalid jsonpath": {
+			value:     "jsonpath={.status[}",
+			expectErr: true,
+		},
+		"jsonpath with range": {
+			value:     "jsonpath={range .status.conditions[*]}{.type}{end}",
+			expectErr: true,
+		},
+		"unknown": {
+			value:     "delete",
+			expectErr: true,
+		},
+		"empty": {
+			value:     "",
+			expectErr: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			cond, err := ParseCondition(tc.value)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cond)
+			// String must round-trip
+			cond, err = ParseCondition(cond.String())
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cond)
+		})
+	}
+}
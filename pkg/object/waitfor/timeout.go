@@ -0,0 +1,43 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package waitfor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+)
+
+const (
+	TimeoutAnnotation = "config.kubernetes.io/reconcile-timeout"
+)
+
+// ReadTimeoutAnnotation reads the reconcile-timeout annotation and parses
+// the duration, e.g. "10m". Returns zero if the annotation is not present.
+func ReadTimeoutAnnotation(u *unstructured.Unstructured) (time.Duration, error) {
+	if u == nil {
+		return 0, nil
+	}
+	timeoutStr, found := u.GetAnnotations()[TimeoutAnnotation]
+	if !found {
+		return 0, nil
+	}
+	klog.V(5).Infof("reconcile-timeout annotation found for %s/%s: %q",
+		u.GetNamespace(), u.GetName(), timeoutStr)
+
+	timeout, err := time.ParseDuration(timeoutStr)
+	if err == nil && timeout <= 0 {
+		err = fmt.Errorf("timeout must be positive: %q", timeoutStr)
+	}
+	if err != nil {
+		return 0, object.InvalidAnnotationError{
+			Annotation: TimeoutAnnotation,
+			Cause:      err,
+		}
+	}
+	return timeout, nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package waitfor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fluxcd/cli-utils/pkg/object"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestReadTimeoutAnnotation(t *testing.T) {
+	testCases := map[string]struct {
+		obj       *unstructured.Unstructured
+		expected  time.Duration
+		expectErr bool
+	}{
+		"nil object": {
+			obj: nil,
+		},
+		"no annotation": {
+			obj: withAnnotations(nil),
+		},
+		"valid annotation": {
+			obj:      withAnnotations(map[string]string{TimeoutAnnotation: "10m"}),
+			expected: 10 * time.Minute,
+		},
+		"invalid annotation": {
+			obj:       withAnnotations(map[string]string{TimeoutAnnotation: "10"}),
+			expectErr: true,
+		},
+		"negative timeout": {
+			obj:       withAnnotations(map[string]string{TimeoutAnnotation: "-1m"}),
+			expectErr: true,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			timeout, err := ReadTimeoutAnnotation(tc.obj)
+			if tc.expectErr {
+				assert.ErrorAs(t, err, &object.InvalidAnnotationError{})
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, timeout)
+		})
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package waitfor
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/fluxcd/cli-utils/pkg/kstatus/status"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// Condition is a condition that an applied object must meet to be
+// considered reconciled.
+type Condition interface {
+	// Met returns true if the object, with the specified computed status,
+	// meets the condition. The object is nil if it was not found.
+	Met(obj *unstructured.Unstructured, s status.Status) bool
+	// String returns the condition in the format of the annotation.
+	String() string
+}
+
+// Current is met when the object has the Current status. This is the
+// default condition for applied objects.
+type Current struct{}
+
+var _ Condition = Current{}
+
+func (Current) Met(_ *unstructured.Unstructured, s status.Status) bool {
+	return s == status.CurrentStatus
+}
+
+func (Current) String() string {
+	return "current"
+}
+
+// Complete is met when the object has run to completion: a Job when its
+// Complete condition is true, a Pod when its phase is Succeeded. Other
+// objects complete when they have the Current status. This is the default
+// condition for hook objects.
+type Complete struct{}
+
+var _ Condition = Complete{}
+
+func (Complete) Met(obj *unstructured.Unstructured, s status.Status) bool {
+	if obj == nil {
+		return false
+	}
+	switch obj.GroupVersionKind().GroupKind() {
+	case schema.GroupKind{Group: "batch", Kind: "Job"}:
+		return StatusCondition{Type: "Complete", Status: "True"}.Met(obj, s)
+	case schema.GroupKind{Kind: "Pod"}:
+		phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+		return phase == "Succeeded"
+	default:
+		return s == status.CurrentStatus
+	}
+}
+
+func (Complete) String() string {
+	return "complete"
+}
+
+// None is always met, which means the object is not waited on
+// (fire-and-forget).
+type None struct{}
+
+var _ Condition = None{}
+
+func (None) Met(*unstructured.Unstructured, status.Status) bool {
+	return true
+}
+
+func (None) String() string {
+	return "none"
+}
+
+// StatusCondition is met when the object has a status condition of the
+// specified Type with the specified Status. Both are compared ignoring case.
+type StatusCondition struct {
+	Type   string
+	Status string
+}
+
+var _ Condition = StatusCondition{}
+
+func (c StatusCondition) Met(obj *unstructured.Unstructured, _ status.Status) bool {
+	if obj == nil {
+		return false
+	}
+	conditions, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil || !found {
+		return false
+	}
+	for _, item := range conditions {
+		cond, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		condType, _, _ := unstructured.NestedString(cond, "type")
+		if !strings.EqualFold(condType, c.Type) {
+			continue
+		}
+		condStatus, _, _ := unstructured.NestedString(cond, "status")
+		return strings.EqualFold(condStatus, c.Status)
+	}
+	return false
+}
+
+func (c StatusCondition) String() string {
+	return fmt.Sprintf("condition=%s=%s", c.Type, c.Status)
+}
+
+// JSONPath is met when the JSONPath expression matches a field of the
+// object. If Value is empty, any value matches, otherwise the field must be
+// equal to Value. Use NewJSONPath to parse the expression once, instead of
+// whenever the condition is checked.
+type JSONPath struct {
+	// Expression is the JSONPath expression, e.g. "{.status.phase}".
+	Expression string
+	Value      string
+
+	// parsed is the parsed Expression, set by NewJSONPath.
+	parsed *jsonpath.JSONPath
+}
+
+var _ Condition = JSONPath{}
+
+// NewJSONPath returns a JSONPath condition with the parsed expression, or an
+// error if the expression is invalid.
+func NewJSONPath(expression, value string) (JSONPath, error) {
+	parsed, err := parseJSONPathExpression(expression)
+	if err != nil {
+		return JSONPath{}, err
+	}
+	return JSONPath{
+		Expression: expression,
+		Value:      value,
+		parsed:     parsed,
+	}, nil
+}
+
+func (c JSONPath) Met(obj *unstructured.Unstructured, _ status.Status) bool {
+	if obj == nil {
+		return false
+	}
+	j := c.parsed
+	if j == nil {
+		var err error
+		if j, err = parseJSONPathExpression(c.Expression); err != nil {
+			return false
+		}
+	}
+	results, err := j.FindResults(obj.Object)
+	if err != nil {
+		return false
+	}
+	for _, result := range results {
+		for _, value := range result {
+			if c.Value == "" {
+				return true
+			}
+			var buf bytes.Buffer
+			if err := j.PrintResults(&buf, []reflect.Value{value}); err != nil {
+				continue
+			}
+			if buf.String() == c.Value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseJSONPathExpression parses the JSONPath expression of the condition.
+// Range expressions are not supported, because finding their results
+// modifies the parsed expression, which must not change when reused.
+func parseJSONPathExpression(expression string) (*jsonpath.JSONPath, error) {
+	parser, err := jsonpath.Parse("wait-for", expression)
+	if err != nil {
+		return nil, err
+	}
+	if hasRange(parser.Root) {
+		return nil, fmt.Errorf("range is not supported")
+	}
+	j := jsonpath.New("wait-for")
+	if err := j.Parse(expression); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func hasRange(list *jsonpath.ListNode) bool {
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *jsonpath.ListNode:
+			if hasRange(n) {
+				return true
+			}
+		case *jsonpath.IdentifierNode:
+			if n.Name == "range" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c JSONPath) String() string {
+	if c.Value == "" {
+		return fmt.Sprintf("jsonpath=%s", c.Expression)
+	}
+	return fmt.Sprintf("jsonpath=%s=%s", c.Expression, c.Value)
+}
@@ -0,0 +1,196 @@
+// Copyright 2021 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package waitfor
+
+import (
+	"testing"
+
+	"github.com/fluxcd/cli-utils/pkg/kstatus/status"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var pod = &unstructured.Unstructured{
+	Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":      "pod",
+			"namespace": "default",
+		},
+		"status": map[string]interface{}{
+			"phase": "Running",
+			"conditions": []interface{}{
+				map[string]interface{}{
+					"type":   "Ready",
+					"status": "True",
+				},
+				map[string]interface{}{
+					"type":   "PodScheduled",
+					"status": "False",
+				},
+			},
+		},
+	},
+}
+
+var job = &unstructured.Unstructured{
+	Object: map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata": map[string]interface{}{
+			"name":      "job",
+			"namespace": "default",
+		},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{
+					"type":   "Complete",
+					"status": "True",
+				},
+			},
+		},
+	},
+}
+
+func TestConditionMet(t *testing.T) {
+	runningJob := job.DeepCopy()
+	unstructured.RemoveNestedField(runningJob.Object, "status", "conditions")
+	configMap := &unstructured.Unstructured{}
+	configMap.SetAPIVersion("v1")
+	configMap.SetKind("ConfigMap")
+	succeededPod := pod.DeepCopy()
+	_ = unstructured.SetNestedField(succeededPod.Object, "Succeeded", "status", "phase")
+
+	testCases := map[string]struct {
+		condition Condition
+		obj       *unstructured.Unstructured
+		status    status.Status
+		expected  bool
+	}{
+		"current with current status": {
+			condition: Current{},
+			obj:       pod,
+			status:    status.CurrentStatus,
+			expected:  true,
+		},
+		"current with in-progress status": {
+			condition: Current{},
+			obj:       pod,
+			status:    status.InProgressStatus,
+			expected:  false,
+		},
+		"complete job": {
+			condition: Complete{},
+			obj:       job,
+			status:    status.CurrentStatus,
+			expected:  true,
+		},
+		"running job": {
+			condition: Complete{},
+			obj:       runningJob,
+			status:    status.CurrentStatus,
+			expected:  false,
+		},
+		"succeeded pod": {
+			condition: Complete{},
+			obj:       succeededPod,
+			status:    status.CurrentStatus,
+			expected:  true,
+		},
+		"running pod": {
+			condition: Complete{},
+			obj:       pod,
+			status:    status.CurrentStatus,
+			expected:  false,
+		},
+		"other object with current status": {
+			condition: Complete{},
+			obj:       configMap,
+			status:    status.CurrentStatus,
+			expected:  true,
+		},
+		"complete of missing object": {
+			condition: Complete{},
+			status:    status.NotFoundStatus,
+			expected:  false,
+		},
+		"none with unknown status": {
+			condition: None{},
+			status:    status.UnknownStatus,
+			expected:  true,
+		},
+		"condition is true": {
+			condition: StatusCondition{Type: "Ready", Status: "True"},
+			obj:       pod,
+			status:    status.InProgressStatus,
+			expected:  true,
+		},
+		"condition matches ignoring case": {
+			condition: StatusCondition{Type: "ready", Status: "true"},
+			obj:       pod,
+			status:    status.InProgressStatus,
+			expected:  true,
+		},
+		"condition has other status": {
+			condition: StatusCondition{Type: "PodScheduled", Status: "True"},
+			obj:       pod,
+			status:    status.InProgressStatus,
+			expected:  false,
+		},
+		"condition not found": {
+			condition: StatusCondition{Type: "Initialized", Status: "True"},
+			obj:       pod,
+			status:    status.InProgressStatus,
+			expected:  false,
+		},
+		"condition of missing object": {
+			condition: StatusCondition{Type: "Ready", Status: "True"},
+			status:    status.NotFoundStatus,
+			expected:  false,
+		},
+		"jsonpath matches value": {
+			condition: JSONPath{Expression: "{.status.phase}", Value: "Running"},
+			obj:       pod,
+			status:    status.InProgressStatus,
+			expected:  true,
+		},
+		"jsonpath with filter matches value": {
+			condition: JSONPath{Expression: `{.status.conditions[?(@.type=="Ready")].status}`, Value: "True"},
+			obj:       pod,
+			status:    status.InProgressStatus,
+			expected:  true,
+		},
+		"parsed jsonpath matches value": {
+			condition: mustJSONPath("{.status.phase}", "Running"),
+			obj:       pod,
+			status:    status.InProgressStatus,
+			expected:  true,
+		},
+		"jsonpath has other value": {
+			condition: JSONPath{Expression: "{.status.phase}", Value: "Succeeded"},
+			obj:       pod,
+			status:    status.InProgressStatus,
+			expected:  false,
+		},
+		"jsonpath exists": {
+			condition: JSONPath{Expression: "{.status.phase}"},
+			obj:       pod,
+			status:    status.InProgressStatus,
+			expected:  true,
+		},
+		"jsonpath does not exist": {
+			condition: JSONPath{Expression: "{.status.podIP}"},
+			obj:       pod,
+			status:    status.InProgressStatus,
+			expected:  false,
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.condition.Met(tc.obj, tc.status))
+		})
+	}
+}
@@ -18,6 +18,8 @@ type Formatter interface {
 	FormatPruneEvent(pe event.PruneEvent) error
 	FormatDeleteEvent(de event.DeleteEvent) error
 	FormatWaitEvent(we event.WaitEvent) error
+	FormatRollbackEvent(re event.RollbackEvent) error
+	FormatTransferEvent(te event.TransferEvent) error
 	FormatErrorEvent(ee event.ErrorEvent) error
 	FormatActionGroupEvent(
 		age event.ActionGroupEvent,
@@ -98,6 +100,14 @@ func (b *BaseListPrinter) Print(ch <-chan event.Event, previewStrategy common.Dr
 			if err := formatter.FormatWaitEvent(e.WaitEvent); err != nil {
 				return err
 			}
+		case event.RollbackType:
+			if err := formatter.FormatRollbackEvent(e.RollbackEvent); err != nil {
+				return err
+			}
+		case event.TransferType:
+			if err := formatter.FormatTransferEvent(e.TransferEvent); err != nil {
+				return err
+			}
 		case event.ActionGroupType:
 			if err := formatter.FormatActionGroupEvent(
 				e.ActionGroupEvent,
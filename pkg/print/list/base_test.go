@@ -34,6 +34,8 @@ type countingFormatter struct {
 	pruneEvents      []event.PruneEvent
 	deleteEvents     []event.DeleteEvent
 	waitEvents       []event.WaitEvent
+	rollbackEvents   []event.RollbackEvent
+	transferEvents   []event.TransferEvent
 	errorEvent       event.ErrorEvent
 	actionGroupEvent []event.ActionGroupEvent
 }
@@ -68,6 +70,16 @@ func (c *countingFormatter) FormatWaitEvent(e event.WaitEvent) error {
 	return nil
 }
 
+func (c *countingFormatter) FormatRollbackEvent(e event.RollbackEvent) error {
+	c.rollbackEvents = append(c.rollbackEvents, e)
+	return nil
+}
+
+func (c *countingFormatter) FormatTransferEvent(e event.TransferEvent) error {
+	c.transferEvents = append(c.transferEvents, e)
+	return nil
+}
+
 func (c *countingFormatter) FormatErrorEvent(e event.ErrorEvent) error {
 	c.errorEvent = e
 	return nil
@@ -5,6 +5,7 @@ package stats
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/fluxcd/cli-utils/pkg/apply/event"
 )
@@ -13,15 +14,21 @@ import (
 // reconciliation of resources. Each item in a stats list represents the stats
 // from all the events in a single action group.
 type Stats struct {
-	ApplyStats  ApplyStats
-	PruneStats  PruneStats
-	DeleteStats DeleteStats
-	WaitStats   WaitStats
+	ApplyStats    ApplyStats
+	PruneStats    PruneStats
+	DeleteStats   DeleteStats
+	WaitStats     WaitStats
+	RollbackStats RollbackStats
+	TransferStats TransferStats
+	// DurationStats summarizes how long objects took to actuate and to
+	// reconcile.
+	DurationStats DurationStats
 }
 
 // FailedActuationSum returns the number of resources that failed actuation.
 func (s *Stats) FailedActuationSum() int {
-	return s.ApplyStats.Failed + s.PruneStats.Failed + s.DeleteStats.Failed
+	return s.ApplyStats.Failed + s.PruneStats.Failed + s.DeleteStats.Failed +
+		s.RollbackStats.Failed + s.TransferStats.Failed
 }
 
 // FailedReconciliationSum returns the number of resources that failed reconciliation.
@@ -34,12 +41,23 @@ func (s *Stats) Handle(e event.Event) {
 	switch e.Type {
 	case event.ApplyType:
 		s.ApplyStats.Inc(e.ApplyEvent.Status)
+		if e.ApplyEvent.Migrated {
+			s.ApplyStats.Migrated++
+		}
+		s.DurationStats.Apply.ObserveTiming(e.ApplyEvent.Timing)
 	case event.PruneType:
 		s.PruneStats.Inc(e.PruneEvent.Status)
+		s.DurationStats.Prune.ObserveTiming(e.PruneEvent.Timing)
 	case event.DeleteType:
 		s.DeleteStats.Inc(e.DeleteEvent.Status)
+		s.DurationStats.Delete.ObserveTiming(e.DeleteEvent.Timing)
 	case event.WaitType:
 		s.WaitStats.Inc(e.WaitEvent.Status)
+		s.DurationStats.ObserveWait(e.WaitEvent)
+	case event.RollbackType:
+		s.RollbackStats.Inc(e.RollbackEvent.Status)
+	case event.TransferType:
+		s.TransferStats.Inc(e.TransferEvent.Status)
 	}
 }
 
@@ -47,6 +65,13 @@ type ApplyStats struct {
 	Successful int
 	Skipped    int
 	Failed     int
+	// Unchanged is the number of objects not applied, because they were
+	// unchanged since the last apply.
+	Unchanged int
+	// Migrated is the number of objects whose fields managed by
+	// client-side apply were migrated to server-side apply. Not included
+	// in the Sum, because migrated objects are also counted by status.
+	Migrated int
 }
 
 func (a *ApplyStats) Inc(op event.ApplyEventStatus) {
@@ -55,8 +80,12 @@ func (a *ApplyStats) Inc(op event.ApplyEventStatus) {
 		a.Successful++
 	case event.ApplySkipped:
 		a.Skipped++
-	case event.ApplyFailed:
+	case event.ApplyUnchanged:
+		a.Unchanged++
+	case event.ApplyFailed, event.ApplyConflict:
 		a.Failed++
+	case event.ApplyRetrying:
+		// ignore - should be followed by one of the others after the last attempt
 	default:
 		panic(fmt.Errorf("invalid apply status %s", op.String()))
 	}
@@ -67,7 +96,7 @@ func (a *ApplyStats) IncFailed() {
 }
 
 func (a *ApplyStats) Sum() int {
-	return a.Successful + a.Skipped + a.Failed
+	return a.Successful + a.Skipped + a.Failed + a.Unchanged
 }
 
 type PruneStats struct {
@@ -84,6 +113,8 @@ func (p *PruneStats) Inc(op event.PruneEventStatus) {
 		p.Skipped++
 	case event.PruneFailed:
 		p.Failed++
+	case event.PruneRetrying:
+		// ignore - should be followed by one of the others after the last attempt
 	default:
 		panic(fmt.Errorf("invalid prune status %s", op.String()))
 	}
@@ -111,6 +142,8 @@ func (d *DeleteStats) Inc(op event.DeleteEventStatus) {
 		d.Skipped++
 	case event.DeleteFailed:
 		d.Failed++
+	case event.DeleteRetrying:
+		// ignore - should be followed by one of the others after the last attempt
 	default:
 		panic(fmt.Errorf("invalid delete status %s", op.String()))
 	}
@@ -151,3 +184,125 @@ func (w *WaitStats) Inc(status event.WaitEventStatus) {
 func (w *WaitStats) Sum() int {
 	return w.Successful + w.Skipped + w.Failed + w.Timeout
 }
+
+type RollbackStats struct {
+	Successful int
+	Skipped    int
+	Failed     int
+}
+
+func (r *RollbackStats) Inc(status event.RollbackEventStatus) {
+	switch status {
+	case event.RollbackSuccessful:
+		r.Successful++
+	case event.RollbackSkipped:
+		r.Skipped++
+	case event.RollbackFailed:
+		r.Failed++
+	default:
+		panic(fmt.Errorf("invalid rollback status %s", status.String()))
+	}
+}
+
+func (r *RollbackStats) Sum() int {
+	return r.Successful + r.Skipped + r.Failed
+}
+
+type TransferStats struct {
+	Successful int
+	Skipped    int
+	Failed     int
+}
+
+func (t *TransferStats) Inc(status event.TransferEventStatus) {
+	switch status {
+	case event.TransferSuccessful:
+		t.Successful++
+	case event.TransferSkipped:
+		t.Skipped++
+	case event.TransferFailed:
+		t.Failed++
+	default:
+		panic(fmt.Errorf("invalid transfer status %s", status.String()))
+	}
+}
+
+func (t *TransferStats) Sum() int {
+	return t.Successful + t.Skipped + t.Failed
+}
+
+// DurationBuckets are the upper bounds of the buckets of a DurationHistogram.
+var DurationBuckets = [...]time.Duration{
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	1 * time.Minute,
+	5 * time.Minute,
+}
+
+// DurationHistogram summarizes the durations of an operation on objects.
+type DurationHistogram struct {
+	// Counts is the number of durations in each of the DurationBuckets.
+	// A duration is counted in the first bucket it does not exceed. The
+	// last count is for durations that exceed all the buckets.
+	Counts [len(DurationBuckets) + 1]int
+	Count  int
+	Sum    time.Duration
+	Max    time.Duration
+}
+
+// Observe adds a duration to the histogram.
+func (h *DurationHistogram) Observe(d time.Duration) {
+	i := 0
+	for i < len(DurationBuckets) && d > DurationBuckets[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Count++
+	h.Sum += d
+	if d > h.Max {
+		h.Max = d
+	}
+}
+
+// ObserveTiming adds the duration of an operation to the histogram, if the
+// operation has ended.
+func (h *DurationHistogram) ObserveTiming(t event.Timing) {
+	if t.Start.IsZero() || t.End.IsZero() {
+		return
+	}
+	h.Observe(t.Duration())
+}
+
+// Mean returns the average duration, or zero if none were observed.
+func (h *DurationHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+	return h.Sum / time.Duration(h.Count)
+}
+
+// DurationStats summarizes the durations of the operations on objects.
+// Reconcile is the time from actuation to reconciliation. Unreconciled is
+// the time from actuation until the object failed or timed out reconciling.
+type DurationStats struct {
+	Apply        DurationHistogram
+	Prune        DurationHistogram
+	Delete       DurationHistogram
+	Reconcile    DurationHistogram
+	Unreconciled DurationHistogram
+}
+
+// ObserveWait adds the reconcile duration of a wait event to the histogram
+// of its status. Skipped and pending objects are not observed.
+func (d *DurationStats) ObserveWait(e event.WaitEvent) {
+	switch e.Status {
+	case event.ReconcileSuccessful:
+		d.Reconcile.ObserveTiming(e.Timing)
+	case event.ReconcileFailed, event.ReconcileTimeout:
+		d.Unreconciled.ObserveTiming(e.Timing)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2022 The Kubernetes Authors.
+// SPDX-License-Identifier: Apache-2.0
+
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDurationHistogram_Observe(t *testing.T) {
+	var h DurationHistogram
+	assert.Equal(t, time.Duration(0), h.Mean())
+
+	h.Observe(50 * time.Millisecond)
+	h.Observe(100 * time.Millisecond)
+	h.Observe(2 * time.Second)
+	h.Observe(10 * time.Minute)
+
+	assert.Equal(t, [len(DurationBuckets) + 1]int{2, 0, 0, 1, 0, 0, 0, 0, 1}, h.Counts)
+	assert.Equal(t, 4, h.Count)
+	assert.Equal(t, 10*time.Minute+2150*time.Millisecond, h.Sum)
+	assert.Equal(t, 10*time.Minute, h.Max)
+	assert.Equal(t, h.Sum/4, h.Mean())
+}
+
+func TestStats_Handle_Durations(t *testing.T) {
+	start := time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+	applied := start.Add(time.Second)
+	reconciled := applied.Add(time.Minute)
+
+	var s Stats
+	for _, e := range []event.Event{
+		{
+			Type: event.ApplyType,
+			ApplyEvent: event.ApplyEvent{
+				Status: event.ApplySuccessful,
+				Timing: event.Timing{Start: start, End: applied},
+			},
+		},
+		{
+			// skipped objects are not timed
+			Type: event.ApplyType,
+			ApplyEvent: event.ApplyEvent{
+				Status: event.ApplySkipped,
+			},
+		},
+		{
+			// pending objects are not counted until they are reconciled
+			Type: event.WaitType,
+			WaitEvent: event.WaitEvent{
+				Status: event.ReconcilePending,
+				Timing: event.Timing{Start: applied},
+			},
+		},
+		{
+			Type: event.WaitType,
+			WaitEvent: event.WaitEvent{
+				Status: event.ReconcileSuccessful,
+				Timing: event.Timing{Start: applied, End: reconciled},
+			},
+		},
+	} {
+		s.Handle(e)
+	}
+
+	assert.Equal(t, 1, s.DurationStats.Apply.Count)
+	assert.Equal(t, time.Second, s.DurationStats.Apply.Sum)
+	assert.Equal(t, 1, s.DurationStats.Reconcile.Count)
+	assert.Equal(t, time.Minute, s.DurationStats.Reconcile.Sum)
+	assert.Equal(t, DurationHistogram{}, s.DurationStats.Prune)
+	assert.Equal(t, DurationHistogram{}, s.DurationStats.Delete)
+}
+
+func TestStats_Handle_ReconcileDurations(t *testing.T) {
+	applied := time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var s Stats
+	for _, e := range []event.Event{
+		{
+			Type: event.WaitType,
+			WaitEvent: event.WaitEvent{
+				Status: event.ReconcileSuccessful,
+				Timing: event.Timing{Start: applied, End: applied.Add(time.Second)},
+			},
+		},
+		{
+			Type: event.WaitType,
+			WaitEvent: event.WaitEvent{
+				Status: event.ReconcileTimeout,
+				Timing: event.Timing{Start: applied, End: applied.Add(time.Minute)},
+			},
+		},
+		{
+			// skipped objects are not timed
+			Type: event.WaitType,
+			WaitEvent: event.WaitEvent{
+				Status: event.ReconcileSkipped,
+				Timing: event.Timing{Start: applied, End: applied.Add(time.Hour)},
+			},
+		},
+	} {
+		s.Handle(e)
+	}
+
+	// Only the reconciled object counts towards the reconcile durations.
+	assert.Equal(t, 1, s.DurationStats.Reconcile.Count)
+	assert.Equal(t, time.Second, s.DurationStats.Reconcile.Sum)
+	assert.Equal(t, 1, s.DurationStats.Unreconciled.Count)
+	assert.Equal(t, time.Minute, s.DurationStats.Unreconciled.Sum)
+	assert.Equal(t, 1, s.WaitStats.Successful)
+	assert.Equal(t, 1, s.WaitStats.Timeout)
+}
+
+func TestStats_Handle_Migrated(t *testing.T) {
+	var s Stats
+	for _, e := range []event.Event{
+		{
+			Type: event.ApplyType,
+			ApplyEvent: event.ApplyEvent{
+				Status:   event.ApplySuccessful,
+				Migrated: true,
+			},
+		},
+		{
+			Type: event.ApplyType,
+			ApplyEvent: event.ApplyEvent{
+				Status: event.ApplySuccessful,
+			},
+		},
+	} {
+		s.Handle(e)
+	}
+
+	assert.Equal(t, ApplyStats{Successful: 2, Migrated: 1}, s.ApplyStats)
+	assert.Equal(t, 2, s.ApplyStats.Sum())
+}
+
+func TestApplyStats_Unchanged(t *testing.T) {
+	var s ApplyStats
+	s.Inc(event.ApplySuccessful)
+	s.Inc(event.ApplyUnchanged)
+	s.Inc(event.ApplyUnchanged)
+
+	assert.Equal(t, ApplyStats{Successful: 1, Unchanged: 2}, s)
+	assert.Equal(t, 3, s.Sum())
+}
@@ -6,6 +6,7 @@ package events
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/fluxcd/cli-utils/pkg/apply/event"
 	"github.com/fluxcd/cli-utils/pkg/common"
@@ -61,12 +62,20 @@ func (ef *formatter) FormatValidationEvent(ve event.ValidationEvent) error {
 func (ef *formatter) FormatApplyEvent(e event.ApplyEvent) error {
 	gk := e.Identifier.GroupKind
 	name := e.Identifier.Name
+	if e.Status == event.ApplyRetrying {
+		ef.print("%s apply retrying (%d/%d): %s", resourceIDToString(gk, name),
+			e.Attempt, e.MaxAttempts, e.Error.Error())
+		return nil
+	}
+	status := strings.ToLower(e.Status.String())
+	if e.Migrated {
+		status += " (migrated from client-side apply)"
+	}
 	if e.Error != nil {
 		ef.print("%s apply %s: %s", resourceIDToString(gk, name),
-			strings.ToLower(e.Status.String()), e.Error.Error())
+			status, e.Error.Error())
 	} else {
-		ef.print("%s apply %s", resourceIDToString(gk, name),
-			strings.ToLower(e.Status.String()))
+		ef.print("%s apply %s", resourceIDToString(gk, name), status)
 	}
 	return nil
 }
@@ -80,6 +89,11 @@ func (ef *formatter) FormatStatusEvent(se event.StatusEvent) error {
 func (ef *formatter) FormatPruneEvent(e event.PruneEvent) error {
 	gk := e.Identifier.GroupKind
 	name := e.Identifier.Name
+	if e.Status == event.PruneRetrying {
+		ef.print("%s prune retrying (%d/%d): %s", resourceIDToString(gk, name),
+			e.Attempt, e.MaxAttempts, e.Error.Error())
+		return nil
+	}
 	if e.Error != nil {
 		ef.print("%s prune %s: %s", resourceIDToString(gk, name),
 			strings.ToLower(e.Status.String()), e.Error.Error())
@@ -93,6 +107,11 @@ func (ef *formatter) FormatPruneEvent(e event.PruneEvent) error {
 func (ef *formatter) FormatDeleteEvent(e event.DeleteEvent) error {
 	gk := e.Identifier.GroupKind
 	name := e.Identifier.Name
+	if e.Status == event.DeleteRetrying {
+		ef.print("%s delete retrying (%d/%d): %s", resourceIDToString(gk, name),
+			e.Attempt, e.MaxAttempts, e.Error.Error())
+		return nil
+	}
 	if e.Error != nil {
 		ef.print("%s delete %s: %s", resourceIDToString(gk, name),
 			strings.ToLower(e.Status.String()), e.Error.Error())
@@ -106,8 +125,46 @@ func (ef *formatter) FormatDeleteEvent(e event.DeleteEvent) error {
 func (ef *formatter) FormatWaitEvent(e event.WaitEvent) error {
 	gk := e.Identifier.GroupKind
 	name := e.Identifier.Name
-	ef.print("%s reconcile %s", resourceIDToString(gk, name),
+	msg := fmt.Sprintf("%s reconcile %s", resourceIDToString(gk, name),
 		strings.ToLower(e.Status.String()))
+	if e.Condition != "" {
+		msg += fmt.Sprintf(" (%s)", e.Condition)
+	}
+	switch {
+	case len(e.RemovedFinalizers) > 0:
+		msg += fmt.Sprintf(" (removed finalizers: %s)", strings.Join(e.RemovedFinalizers, ", "))
+	case len(e.Finalizers) > 0:
+		msg += fmt.Sprintf(" (stuck for %s on finalizers: %s)", e.StuckFor.Round(time.Second),
+			strings.Join(e.Finalizers, ", "))
+	}
+	ef.print("%s", msg)
+	return nil
+}
+
+func (ef *formatter) FormatRollbackEvent(e event.RollbackEvent) error {
+	gk := e.Identifier.GroupKind
+	name := e.Identifier.Name
+	if e.Error != nil {
+		ef.print("%s rollback %s %s: %s", resourceIDToString(gk, name),
+			strings.ToLower(e.Operation.String()), strings.ToLower(e.Status.String()),
+			e.Error.Error())
+	} else {
+		ef.print("%s rollback %s %s", resourceIDToString(gk, name),
+			strings.ToLower(e.Operation.String()), strings.ToLower(e.Status.String()))
+	}
+	return nil
+}
+
+func (ef *formatter) FormatTransferEvent(e event.TransferEvent) error {
+	gk := e.Identifier.GroupKind
+	name := e.Identifier.Name
+	if e.Error != nil {
+		ef.print("%s transfer %s: %s", resourceIDToString(gk, name),
+			strings.ToLower(e.Status.String()), e.Error.Error())
+	} else {
+		ef.print("%s transfer %s", resourceIDToString(gk, name),
+			strings.ToLower(e.Status.String()))
+	}
 	return nil
 }
 
@@ -132,6 +189,10 @@ func (ef *formatter) FormatActionGroupEvent(
 		ef.print("reconcile phase %s", strings.ToLower(age.Status.String()))
 	case event.InventoryAction:
 		ef.print("inventory update %s", strings.ToLower(age.Status.String()))
+	case event.RollbackAction:
+		ef.print("rollback phase %s", strings.ToLower(age.Status.String()))
+	case event.TransferAction:
+		ef.print("transfer phase %s", strings.ToLower(age.Status.String()))
 	default:
 		return fmt.Errorf("invalid action group action: %+v", age)
 	}
@@ -141,8 +202,16 @@ func (ef *formatter) FormatActionGroupEvent(
 func (ef *formatter) FormatSummary(s stats.Stats) error {
 	if s.ApplyStats != (stats.ApplyStats{}) {
 		as := s.ApplyStats
-		ef.print("apply result: %d attempted, %d successful, %d skipped, %d failed",
+		var sb strings.Builder
+		_, _ = fmt.Fprintf(&sb, "apply result: %d attempted, %d successful, %d skipped, %d failed",
 			as.Sum(), as.Successful, as.Skipped, as.Failed)
+		if as.Unchanged > 0 {
+			_, _ = fmt.Fprintf(&sb, ", %d unchanged", as.Unchanged)
+		}
+		if as.Migrated > 0 {
+			_, _ = fmt.Fprintf(&sb, ", %d migrated", as.Migrated)
+		}
+		ef.print(sb.String())
 	}
 	if s.PruneStats != (stats.PruneStats{}) {
 		ps := s.PruneStats
@@ -159,6 +228,16 @@ func (ef *formatter) FormatSummary(s stats.Stats) error {
 		ef.print("reconcile result: %d attempted, %d successful, %d skipped, %d failed, %d timed out",
 			ws.Sum(), ws.Successful, ws.Skipped, ws.Failed, ws.Timeout)
 	}
+	if s.RollbackStats != (stats.RollbackStats{}) {
+		rs := s.RollbackStats
+		ef.print("rollback result: %d attempted, %d successful, %d skipped, %d failed",
+			rs.Sum(), rs.Successful, rs.Skipped, rs.Failed)
+	}
+	if s.TransferStats != (stats.TransferStats{}) {
+		ts := s.TransferStats
+		ef.print("transfer result: %d attempted, %d successful, %d skipped, %d failed",
+			ts.Sum(), ts.Successful, ts.Skipped, ts.Failed)
+	}
 	return nil
 }
 
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/fluxcd/cli-utils/pkg/apply/event"
 	"github.com/fluxcd/cli-utils/pkg/common"
@@ -55,6 +56,15 @@ func TestFormatter_FormatApplyEvent(t *testing.T) {
 			},
 			expected: "cronjob.batch/my-cron apply successful",
 		},
+		"resource migrated from client-side apply": {
+			previewStrategy: common.DryRunNone,
+			event: event.ApplyEvent{
+				Status:     event.ApplySuccessful,
+				Identifier: createIdentifier("apps", "Deployment", "default", "my-dep"),
+				Migrated:   true,
+			},
+			expected: "deployment.apps/my-dep apply successful (migrated from client-side apply)",
+		},
 		"apply event with error should display the error": {
 			previewStrategy: common.DryRunServer,
 			event: event.ApplyEvent{
@@ -73,6 +83,17 @@ func TestFormatter_FormatApplyEvent(t *testing.T) {
 			},
 			expected: "deployment.apps/my-dep apply skipped: this is a test error",
 		},
+		"apply event retrying should display the attempt": {
+			previewStrategy: common.DryRunNone,
+			event: event.ApplyEvent{
+				Status:      event.ApplyRetrying,
+				Identifier:  createIdentifier("apps", "Deployment", "", "my-dep"),
+				Error:       fmt.Errorf("this is a test error"),
+				Attempt:     2,
+				MaxAttempts: 5,
+			},
+			expected: "deployment.apps/my-dep apply retrying (2/5): this is a test error",
+		},
 	}
 
 	for tn, tc := range testCases {
@@ -179,6 +200,17 @@ func TestFormatter_FormatPruneEvent(t *testing.T) {
 			},
 			expected: "cronjob.batch/my-cron prune skipped: this is a test",
 		},
+		"resource with prune retrying": {
+			previewStrategy: common.DryRunNone,
+			event: event.PruneEvent{
+				Status:      event.PruneRetrying,
+				Identifier:  createIdentifier("apps", "Deployment", "", "my-dep"),
+				Error:       fmt.Errorf("this is a test"),
+				Attempt:     3,
+				MaxAttempts: 3,
+			},
+			expected: "deployment.apps/my-dep prune retrying (3/3): this is a test",
+		},
 	}
 
 	for tn, tc := range testCases {
@@ -238,6 +270,17 @@ func TestFormatter_FormatDeleteEvent(t *testing.T) {
 			},
 			expected: "cronjob.batch/my-cron delete skipped: this is a test",
 		},
+		"resource with delete retrying": {
+			previewStrategy: common.DryRunNone,
+			event: event.DeleteEvent{
+				Status:      event.DeleteRetrying,
+				Identifier:  createIdentifier("apps", "Deployment", "", "my-dep"),
+				Error:       fmt.Errorf("this is a test"),
+				Attempt:     2,
+				MaxAttempts: 4,
+			},
+			expected: "deployment.apps/my-dep delete retrying (2/4): this is a test",
+		},
 	}
 
 	for tn, tc := range testCases {
@@ -295,6 +338,28 @@ func TestFormatter_FormatWaitEvent(t *testing.T) {
 			},
 			expected: "deployment.apps/my-dep reconcile timeout",
 		},
+		"resource stuck on finalizers": {
+			previewStrategy: common.DryRunNone,
+			event: event.WaitEvent{
+				GroupName:  "wait-1",
+				Identifier: createIdentifier("apps", "Deployment", "default", "my-dep"),
+				Status:     event.ReconcilePending,
+				Finalizers: []string{"example.com/a", "example.com/b"},
+				StuckFor:   90 * time.Second,
+			},
+			expected: "deployment.apps/my-dep reconcile pending (stuck for 1m30s on finalizers: example.com/a, example.com/b)",
+		},
+		"resource finalizers removed": {
+			previewStrategy: common.DryRunNone,
+			event: event.WaitEvent{
+				GroupName:         "wait-1",
+				Identifier:        createIdentifier("apps", "Deployment", "default", "my-dep"),
+				Status:            event.ReconcilePending,
+				StuckFor:          5 * time.Minute,
+				RemovedFinalizers: []string{"example.com/a"},
+			},
+			expected: "deployment.apps/my-dep reconcile pending (removed finalizers: example.com/a)",
+		},
 		"resource reconcile timeout (client-side dry-run)": {
 			previewStrategy: common.DryRunClient,
 			event: event.WaitEvent{
@@ -363,6 +428,86 @@ func TestFormatter_FormatWaitEvent(t *testing.T) {
 	}
 }
 
+func TestFormatter_FormatRollbackEvent(t *testing.T) {
+	testCases := map[string]struct {
+		previewStrategy common.DryRunStrategy
+		event           event.RollbackEvent
+		expected        string
+	}{
+		"resource restored": {
+			previewStrategy: common.DryRunNone,
+			event: event.RollbackEvent{
+				GroupName:  "rollback-0",
+				Operation:  event.RollbackRestore,
+				Status:     event.RollbackSuccessful,
+				Identifier: createIdentifier("apps", "Deployment", "default", "my-dep"),
+			},
+			expected: "deployment.apps/my-dep rollback restore successful",
+		},
+		"resource with rollback delete error": {
+			previewStrategy: common.DryRunNone,
+			event: event.RollbackEvent{
+				GroupName:  "rollback-0",
+				Operation:  event.RollbackDelete,
+				Status:     event.RollbackFailed,
+				Identifier: createIdentifier("apps", "Deployment", "default", "my-dep"),
+				Error:      fmt.Errorf("this is a test"),
+			},
+			expected: "deployment.apps/my-dep rollback delete failed: this is a test",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			ioStreams, _, out, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+			formatter := NewFormatter(ioStreams, tc.previewStrategy)
+			err := formatter.FormatRollbackEvent(tc.event)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.expected, strings.TrimSpace(out.String()))
+		})
+	}
+}
+
+func TestFormatter_FormatTransferEvent(t *testing.T) {
+	testCases := map[string]struct {
+		previewStrategy common.DryRunStrategy
+		event           event.TransferEvent
+		expected        string
+	}{
+		"resource transferred": {
+			previewStrategy: common.DryRunNone,
+			event: event.TransferEvent{
+				GroupName:  "transfer-0",
+				Status:     event.TransferSuccessful,
+				Identifier: createIdentifier("apps", "Deployment", "default", "my-dep"),
+			},
+			expected: "deployment.apps/my-dep transfer successful",
+		},
+		"resource transfer skipped": {
+			previewStrategy: common.DryRunNone,
+			event: event.TransferEvent{
+				GroupName:  "transfer-0",
+				Status:     event.TransferSkipped,
+				Identifier: createIdentifier("apps", "Deployment", "default", "my-dep"),
+				Error:      fmt.Errorf("this is a test"),
+			},
+			expected: "deployment.apps/my-dep transfer skipped: this is a test",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			ioStreams, _, out, _ := genericclioptions.NewTestIOStreams() //nolint:dogsled
+			formatter := NewFormatter(ioStreams, tc.previewStrategy)
+			err := formatter.FormatTransferEvent(tc.event)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.expected, strings.TrimSpace(out.String()))
+		})
+	}
+}
+
 func TestFormatter_FormatValidationEvent(t *testing.T) {
 	testCases := map[string]struct {
 		previewStrategy common.DryRunStrategy
@@ -16,6 +16,8 @@
 //   - prune - PruneEvent
 //   - delete - DeleteEvent
 //   - wait - WaitEvent
+//   - rollback - RollbackEvent
+//   - transfer - TransferEvent
 //   - status - StatusEvent
 //   - summary - aggregate stats collected by the printer
 //
@@ -43,15 +45,16 @@
 // * error (string)  - a fatal error message
 //
 // Group events correspond to a group of events of the same type: apply, prune,
-// delete, or wait.
+// delete, wait, rollback, or transfer.
 //
 // Group events have the following fields:
-// * action (string) - One of: "Apply", "Prune", "Delete", or "Wait".
-// * status (string) - One of: "Started" or "Finished"
+// * action (string) - One of: "Apply", "Prune", "Delete", "Wait", "Rollback",
+// "Transfer", or "Inventory".
+// * status (string) - One of: "Started", "Finished", or "Skipped"
 // * timestamp (string) - ISO-8601 format
 // * type (string) - "group"
 //
-// Operation events (apply, prune, delete, and wait) corespond to an operation
+// Operation events (apply, prune, delete, wait, rollback, and transfer) corespond to an operation
 // performed on a single object. For these events, the
 // group, kind, name, and namespace fields identify the object.
 //
@@ -60,11 +63,43 @@
 //   - kind (string) - The object's kind.
 //   - name (string) - The object's name.
 //   - namespace (string, optional) - The object's namespace.
-//   - status (string) - One of: "Pending", "Successful", "Skipped", "Failed", or
-//     "Timeout".
+//   - status (string) - One of: "Pending", "Successful", "Skipped", "Failed",
+//     "Retrying", "Conflict", "Unchanged", or "Timeout".
 //   - timestamp (string) - ISO-8601 format
-//   - type (string) - "apply", "prune", "delete", or "wait"
+//   - type (string) - "apply", "prune", "delete", "wait", "rollback", or
+//     "transfer"
 //   - error (string, optional) - A non-fatal error message specific to this object
+//   - condition (string, optional) - The custom condition a wait event's object
+//     is waiting on, e.g. "condition=Ready".
+//   - finalizers (array, optional) - The finalizers of a wait event's object,
+//     that is stuck terminating.
+//   - removedFinalizers (array, optional) - The finalizers removed from a wait
+//     event's object, that was stuck terminating.
+//   - stuckFor (number, optional) - Seconds since the finalizers of a wait
+//     event's terminating object last changed.
+//   - operation (string, optional) - How a rollback event's object is rolled
+//     back: "Restore" or "Delete".
+//   - attempt (number, optional) - The next attempt of a retrying apply, prune,
+//     or delete event.
+//   - maxAttempts (number, optional) - The maximum number of attempts of a
+//     retrying apply, prune, or delete event.
+//   - conflicts (array, optional) - The fields of an apply conflict event's
+//     object owned by other field managers. Each entry has a field (string)
+//     and a manager (string).
+//   - unmanagedObjects (array, optional) - The objects not in the inventory,
+//     that a skipped delete event's namespace still contains. Each entry has
+//     a group (string), kind (string), namespace (string), and name (string).
+//   - migrated (boolean, optional) - True if the fields of an apply event's
+//     object were migrated from client-side apply to server-side apply.
+//   - startTime (string, optional) - RFC3339-formatted timestamp describing
+//     when the operation started. For wait events, when the object was
+//     applied or deleted.
+//   - endTime (string, optional) - RFC3339-formatted timestamp describing
+//     when the operation ended. For wait events, when the object was
+//     reconciled, failed, or timed out.
+//   - duration (number, optional) - Seconds between startTime and endTime.
+//   - latency (number, optional) - Seconds spent waiting for the cluster to
+//     respond to an apply, prune, or delete event, excluding retry delays.
 //
 // Status types are asynchronous events that correspond to status updates for
 // a specific object.
@@ -83,15 +118,28 @@
 // Summary types are a meta-event sent by the printer to summarize some stats
 // that have been collected from other events. For these events, the action
 // field corresponds to the event type being summarized: Apply, Prune, Delete,
-// and Wait.
+// Wait, Rollback, and Transfer.
 //
 // Summary events have the following fields:
-// * action (string) - One of: "Apply", "Prune", "Delete", or "Wait".
+// * action (string) - One of: "Apply", "Prune", "Delete", "Wait", "Rollback",
+// or "Transfer".
 // * count (number) - Total number of objects attempted for this action
 // * successful (number) - Number of objects for which the action was successful.
 // * skipped (number) - Number of objects for which the action was skipped.
 // * failed (number) - Number of objects for which the action failed.
 // * timeout (number, optional) - Number of objects for which the action timed out.
+// * unchanged (number, optional) - Number of objects not applied, because
+// they were unchanged since the last apply.
+// * migrated (number, optional) - Number of applied objects whose fields were
+// migrated from client-side apply to server-side apply.
 // * timestamp (string) - ISO-8601 format
 // * type (string) - "summary"
+// * durations (object, optional) - Histogram of the durations of the action,
+// in seconds. For Wait, the time from actuation to reconciliation.
+//   - count (number) - Number of durations observed.
+//   - sum (number) - Sum of the durations.
+//   - mean (number) - Average duration.
+//   - max (number) - Longest duration.
+//   - buckets (object) - Number of durations in each bucket, keyed by the
+//     upper bound of the bucket, e.g. "500ms", "1s", or "+Inf".
 package json
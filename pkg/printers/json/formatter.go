@@ -5,10 +5,13 @@ package json
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	applyerror "github.com/fluxcd/cli-utils/pkg/apply/error"
 	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/apply/filter"
 	"github.com/fluxcd/cli-utils/pkg/common"
 	"github.com/fluxcd/cli-utils/pkg/object"
 	"github.com/fluxcd/cli-utils/pkg/object/validation"
@@ -56,6 +59,25 @@ func (jf *formatter) FormatApplyEvent(e event.ApplyEvent) error {
 		eventInfo["error"] = e.Error.Error()
 	}
 	eventInfo["status"] = e.Status.String()
+	if e.Status == event.ApplyRetrying {
+		eventInfo["attempt"] = e.Attempt
+		eventInfo["maxAttempts"] = e.MaxAttempts
+	}
+	var conflictErr *applyerror.ConflictError
+	if errors.As(e.Error, &conflictErr) {
+		conflicts := make([]map[string]interface{}, len(conflictErr.Conflicts))
+		for i, c := range conflictErr.Conflicts {
+			conflicts[i] = map[string]interface{}{
+				"field":   c.Field,
+				"manager": c.Manager,
+			}
+		}
+		eventInfo["conflicts"] = conflicts
+	}
+	if e.Migrated {
+		eventInfo["migrated"] = true
+	}
+	jf.addTiming(eventInfo, e.Timing)
 	return jf.printEvent("apply", eventInfo)
 }
 
@@ -76,6 +98,11 @@ func (jf *formatter) FormatPruneEvent(e event.PruneEvent) error {
 		eventInfo["error"] = e.Error.Error()
 	}
 	eventInfo["status"] = e.Status.String()
+	if e.Status == event.PruneRetrying {
+		eventInfo["attempt"] = e.Attempt
+		eventInfo["maxAttempts"] = e.MaxAttempts
+	}
+	jf.addTiming(eventInfo, e.Timing)
 	return jf.printEvent("prune", eventInfo)
 }
 
@@ -85,15 +112,60 @@ func (jf *formatter) FormatDeleteEvent(e event.DeleteEvent) error {
 		eventInfo["error"] = e.Error.Error()
 	}
 	eventInfo["status"] = e.Status.String()
+	if e.Status == event.DeleteRetrying {
+		eventInfo["attempt"] = e.Attempt
+		eventInfo["maxAttempts"] = e.MaxAttempts
+	}
+	var notEmptyErr *filter.NamespaceNotEmptyError
+	if errors.As(e.Error, &notEmptyErr) {
+		var objs []map[string]interface{}
+		for _, id := range notEmptyErr.Objects {
+			objs = append(objs, jf.baseResourceEvent(id))
+		}
+		eventInfo["unmanagedObjects"] = objs
+	}
+	jf.addTiming(eventInfo, e.Timing)
 	return jf.printEvent("delete", eventInfo)
 }
 
 func (jf *formatter) FormatWaitEvent(e event.WaitEvent) error {
 	eventInfo := jf.baseResourceEvent(e.Identifier)
 	eventInfo["status"] = e.Status.String()
+	if e.Condition != "" {
+		eventInfo["condition"] = e.Condition
+	}
+	if len(e.Finalizers) > 0 {
+		eventInfo["finalizers"] = e.Finalizers
+	}
+	if len(e.RemovedFinalizers) > 0 {
+		eventInfo["removedFinalizers"] = e.RemovedFinalizers
+	}
+	if e.StuckFor > 0 {
+		eventInfo["stuckFor"] = e.StuckFor.Seconds()
+	}
+	jf.addTiming(eventInfo, e.Timing)
 	return jf.printEvent("wait", eventInfo)
 }
 
+func (jf *formatter) FormatRollbackEvent(e event.RollbackEvent) error {
+	eventInfo := jf.baseResourceEvent(e.Identifier)
+	if e.Error != nil {
+		eventInfo["error"] = e.Error.Error()
+	}
+	eventInfo["operation"] = e.Operation.String()
+	eventInfo["status"] = e.Status.String()
+	return jf.printEvent("rollback", eventInfo)
+}
+
+func (jf *formatter) FormatTransferEvent(e event.TransferEvent) error {
+	eventInfo := jf.baseResourceEvent(e.Identifier)
+	if e.Error != nil {
+		eventInfo["error"] = e.Error.Error()
+	}
+	eventInfo["status"] = e.Status.String()
+	return jf.printEvent("transfer", eventInfo)
+}
+
 func (jf *formatter) FormatErrorEvent(e event.ErrorEvent) error {
 	return jf.printEvent("error", map[string]interface{}{
 		"error": e.Err.Error(),
@@ -119,6 +191,12 @@ func (jf *formatter) FormatActionGroupEvent(
 			content["successful"] = as.Successful
 			content["skipped"] = as.Skipped
 			content["failed"] = as.Failed
+			if as.Unchanged > 0 {
+				content["unchanged"] = as.Unchanged
+			}
+			if as.Migrated > 0 {
+				content["migrated"] = as.Migrated
+			}
 		}
 	case event.PruneAction:
 		if age.Status == event.Finished {
@@ -145,6 +223,22 @@ func (jf *formatter) FormatActionGroupEvent(
 			content["failed"] = ws.Failed
 			content["timeout"] = ws.Timeout
 		}
+	case event.RollbackAction:
+		if age.Status == event.Finished {
+			rs := s.RollbackStats
+			content["count"] = rs.Sum()
+			content["successful"] = rs.Successful
+			content["skipped"] = rs.Skipped
+			content["failed"] = rs.Failed
+		}
+	case event.TransferAction:
+		if age.Status == event.Finished {
+			ts := s.TransferStats
+			content["count"] = ts.Sum()
+			content["successful"] = ts.Successful
+			content["skipped"] = ts.Skipped
+			content["failed"] = ts.Failed
+		}
 	case event.InventoryAction:
 		// no extra content
 	default:
@@ -157,52 +251,103 @@ func (jf *formatter) FormatActionGroupEvent(
 func (jf *formatter) FormatSummary(s stats.Stats) error {
 	if s.ApplyStats != (stats.ApplyStats{}) {
 		as := s.ApplyStats
-		err := jf.printEvent("summary", map[string]interface{}{
+		content := map[string]interface{}{
 			"action":     event.ApplyAction.String(),
 			"count":      as.Sum(),
 			"successful": as.Successful,
 			"skipped":    as.Skipped,
 			"failed":     as.Failed,
-		})
+		}
+		if as.Unchanged > 0 {
+			content["unchanged"] = as.Unchanged
+		}
+		if as.Migrated > 0 {
+			content["migrated"] = as.Migrated
+		}
+		if h := s.DurationStats.Apply; h.Count > 0 {
+			content["durations"] = durationSummary(h)
+		}
+		err := jf.printEvent("summary", content)
 		if err != nil {
 			return err
 		}
 	}
 	if s.PruneStats != (stats.PruneStats{}) {
 		ps := s.PruneStats
-		err := jf.printEvent("summary", map[string]interface{}{
+		content := map[string]interface{}{
 			"action":     event.PruneAction.String(),
 			"count":      ps.Sum(),
 			"successful": ps.Successful,
 			"skipped":    ps.Skipped,
 			"failed":     ps.Failed,
-		})
+		}
+		if h := s.DurationStats.Prune; h.Count > 0 {
+			content["durations"] = durationSummary(h)
+		}
+		err := jf.printEvent("summary", content)
 		if err != nil {
 			return err
 		}
 	}
 	if s.DeleteStats != (stats.DeleteStats{}) {
 		ds := s.DeleteStats
-		err := jf.printEvent("summary", map[string]interface{}{
+		content := map[string]interface{}{
 			"action":     event.DeleteAction.String(),
 			"count":      ds.Sum(),
 			"successful": ds.Successful,
 			"skipped":    ds.Skipped,
 			"failed":     ds.Failed,
-		})
+		}
+		if h := s.DurationStats.Delete; h.Count > 0 {
+			content["durations"] = durationSummary(h)
+		}
+		err := jf.printEvent("summary", content)
 		if err != nil {
 			return err
 		}
 	}
 	if s.WaitStats != (stats.WaitStats{}) {
 		ws := s.WaitStats
-		err := jf.printEvent("summary", map[string]interface{}{
+		content := map[string]interface{}{
 			"action":     event.WaitAction.String(),
 			"count":      ws.Sum(),
 			"successful": ws.Successful,
 			"skipped":    ws.Skipped,
 			"failed":     ws.Failed,
 			"timeout":    ws.Timeout,
+		}
+		if h := s.DurationStats.Reconcile; h.Count > 0 {
+			content["durations"] = durationSummary(h)
+		}
+		if h := s.DurationStats.Unreconciled; h.Count > 0 {
+			content["unreconciledDurations"] = durationSummary(h)
+		}
+		err := jf.printEvent("summary", content)
+		if err != nil {
+			return err
+		}
+	}
+	if s.RollbackStats != (stats.RollbackStats{}) {
+		rs := s.RollbackStats
+		err := jf.printEvent("summary", map[string]interface{}{
+			"action":     event.RollbackAction.String(),
+			"count":      rs.Sum(),
+			"successful": rs.Successful,
+			"skipped":    rs.Skipped,
+			"failed":     rs.Failed,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if s.TransferStats != (stats.TransferStats{}) {
+		ts := s.TransferStats
+		err := jf.printEvent("summary", map[string]interface{}{
+			"action":     event.TransferAction.String(),
+			"count":      ts.Sum(),
+			"successful": ts.Successful,
+			"skipped":    ts.Skipped,
+			"failed":     ts.Failed,
 		})
 		if err != nil {
 			return err
@@ -211,6 +356,43 @@ func (jf *formatter) FormatSummary(s stats.Stats) error {
 	return nil
 }
 
+// addTiming adds the timing of the operation on an object to the event.
+// Times are RFC3339-formatted, durations are in seconds.
+func (jf *formatter) addTiming(eventInfo map[string]interface{}, t event.Timing) {
+	if t.Start.IsZero() {
+		return
+	}
+	eventInfo["startTime"] = t.Start.UTC().Format(time.RFC3339Nano)
+	if t.End.IsZero() {
+		return
+	}
+	eventInfo["endTime"] = t.End.UTC().Format(time.RFC3339Nano)
+	eventInfo["duration"] = t.Duration().Seconds()
+	if t.Latency > 0 {
+		eventInfo["latency"] = t.Latency.Seconds()
+	}
+}
+
+// durationSummary returns the histogram of durations, in seconds. The
+// buckets are keyed by their upper bound.
+func durationSummary(h stats.DurationHistogram) map[string]interface{} {
+	buckets := make(map[string]int, len(h.Counts))
+	for i, count := range h.Counts {
+		if i < len(stats.DurationBuckets) {
+			buckets[stats.DurationBuckets[i].String()] = count
+		} else {
+			buckets["+Inf"] = count
+		}
+	}
+	return map[string]interface{}{
+		"count":   h.Count,
+		"sum":     h.Sum.Seconds(),
+		"mean":    h.Mean().Seconds(),
+		"max":     h.Max.Seconds(),
+		"buckets": buckets,
+	}
+}
+
 func (jf *formatter) baseResourceEvent(identifier object.ObjMetadata) map[string]interface{} {
 	return map[string]interface{}{
 		"group":     identifier.GroupKind.Group,
@@ -10,7 +10,9 @@ import (
 	"testing"
 	"time"
 
+	applyerror "github.com/fluxcd/cli-utils/pkg/apply/error"
 	"github.com/fluxcd/cli-utils/pkg/apply/event"
+	"github.com/fluxcd/cli-utils/pkg/apply/filter"
 	"github.com/fluxcd/cli-utils/pkg/common"
 	pollevent "github.com/fluxcd/cli-utils/pkg/kstatus/polling/event"
 	"github.com/fluxcd/cli-utils/pkg/kstatus/status"
@@ -107,6 +109,81 @@ func TestFormatter_FormatApplyEvent(t *testing.T) {
 				},
 			},
 		},
+		"resource applied with timing": {
+			previewStrategy: common.DryRunNone,
+			event: event.ApplyEvent{
+				Status:     event.ApplySuccessful,
+				Identifier: createIdentifier("apps", "Deployment", "default", "my-dep"),
+				Timing: event.Timing{
+					Start:   time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC),
+					End:     time.Date(2022, 1, 1, 10, 0, 3, 0, time.UTC),
+					Latency: 2 * time.Second,
+				},
+			},
+			expected: []map[string]interface{}{
+				{
+					"group":     "apps",
+					"kind":      "Deployment",
+					"name":      "my-dep",
+					"namespace": "default",
+					"status":    "Successful",
+					"timestamp": "",
+					"type":      "apply",
+					"startTime": "2022-01-01T10:00:00Z",
+					"endTime":   "2022-01-01T10:00:03Z",
+					"duration":  3,
+					"latency":   2,
+				},
+			},
+		},
+		"resource apply conflict": {
+			previewStrategy: common.DryRunNone,
+			event: event.ApplyEvent{
+				Status:     event.ApplyConflict,
+				Identifier: createIdentifier("apps", "Deployment", "default", "my-dep"),
+				Error: applyerror.NewConflictError([]applyerror.FieldConflict{
+					{Field: ".spec.replicas", Manager: "kubectl"},
+				}, errors.New("example error")),
+			},
+			expected: []map[string]interface{}{
+				{
+					"group":     "apps",
+					"kind":      "Deployment",
+					"name":      "my-dep",
+					"namespace": "default",
+					"status":    "Conflict",
+					"timestamp": "",
+					"type":      "apply",
+					"error":     `1 field(s) owned by other field managers: .spec.replicas (manager: "kubectl")`,
+					"conflicts": []interface{}{
+						map[string]interface{}{
+							"field":   ".spec.replicas",
+							"manager": "kubectl",
+						},
+					},
+				},
+			},
+		},
+		"resource migrated from client-side apply": {
+			previewStrategy: common.DryRunNone,
+			event: event.ApplyEvent{
+				Status:     event.ApplySuccessful,
+				Identifier: createIdentifier("apps", "Deployment", "default", "my-dep"),
+				Migrated:   true,
+			},
+			expected: []map[string]interface{}{
+				{
+					"group":     "apps",
+					"kind":      "Deployment",
+					"name":      "my-dep",
+					"namespace": "default",
+					"status":    "Successful",
+					"timestamp": "",
+					"type":      "apply",
+					"migrated":  true,
+				},
+			},
+		},
 		"resource apply skip error": {
 			previewStrategy: common.DryRunNone,
 			event: event.ApplyEvent{
@@ -366,6 +443,37 @@ func TestFormatter_FormatDeleteEvent(t *testing.T) {
 				"error":     "example error",
 			},
 		},
+		"namespace delete skipped with unmanaged objects": {
+			previewStrategy: common.DryRunNone,
+			event: event.DeleteEvent{
+				Status:     event.DeleteSkipped,
+				Identifier: createIdentifier("", "Namespace", "", "my-ns"),
+				Error: &filter.NamespaceNotEmptyError{
+					Namespace: "my-ns",
+					Objects: object.ObjMetadataSet{
+						createIdentifier("apps", "Deployment", "my-ns", "my-dep"),
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"group":     "",
+				"kind":      "Namespace",
+				"name":      "my-ns",
+				"namespace": "",
+				"status":    "Skipped",
+				"timestamp": "",
+				"type":      "delete",
+				"error":     "namespace my-ns still contains 1 unmanaged objects: deployment.apps/my-dep",
+				"unmanagedObjects": []interface{}{
+					map[string]interface{}{
+						"group":     "apps",
+						"kind":      "Deployment",
+						"name":      "my-dep",
+						"namespace": "my-ns",
+					},
+				},
+			},
+		},
 	}
 
 	for tn, tc := range testCases {
@@ -438,6 +546,51 @@ func TestFormatter_FormatWaitEvent(t *testing.T) {
 				"type":      "wait",
 			},
 		},
+		"resource reconciled with timing": {
+			previewStrategy: common.DryRunNone,
+			event: event.WaitEvent{
+				GroupName:  "wait-1",
+				Status:     event.ReconcileSuccessful,
+				Identifier: createIdentifier("apps", "Deployment", "default", "my-dep"),
+				Timing: event.Timing{
+					Start: time.Date(2022, 1, 1, 10, 0, 3, 0, time.UTC),
+					End:   time.Date(2022, 1, 1, 10, 1, 3, 0, time.UTC),
+				},
+			},
+			expected: map[string]interface{}{
+				"group":     "apps",
+				"kind":      "Deployment",
+				"name":      "my-dep",
+				"namespace": "default",
+				"status":    "Successful",
+				"timestamp": "",
+				"type":      "wait",
+				"startTime": "2022-01-01T10:00:03Z",
+				"endTime":   "2022-01-01T10:01:03Z",
+				"duration":  60,
+			},
+		},
+		"resource reconcile pending with timing": {
+			previewStrategy: common.DryRunNone,
+			event: event.WaitEvent{
+				GroupName:  "wait-1",
+				Status:     event.ReconcilePending,
+				Identifier: createIdentifier("apps", "Deployment", "default", "my-dep"),
+				Timing: event.Timing{
+					Start: time.Date(2022, 1, 1, 10, 0, 3, 0, time.UTC),
+				},
+			},
+			expected: map[string]interface{}{
+				"group":     "apps",
+				"kind":      "Deployment",
+				"name":      "my-dep",
+				"namespace": "default",
+				"status":    "Pending",
+				"timestamp": "",
+				"type":      "wait",
+				"startTime": "2022-01-01T10:00:03Z",
+			},
+		},
 		"resource reconcile pending": {
 			previewStrategy: common.DryRunServer,
 			event: event.WaitEvent{
@@ -812,6 +965,28 @@ func TestFormatter_FormatSummary(t *testing.T) {
 		statsCollector stats.Stats
 		expected       []map[string]interface{}
 	}{
+		"apply unchanged and migrated": {
+			statsCollector: stats.Stats{
+				ApplyStats: stats.ApplyStats{
+					Successful: 1,
+					Unchanged:  2,
+					Migrated:   1,
+				},
+			},
+			expected: []map[string]interface{}{
+				{
+					"action":     "Apply",
+					"count":      float64(3),
+					"successful": float64(1),
+					"skipped":    float64(0),
+					"failed":     float64(0),
+					"unchanged":  float64(2),
+					"migrated":   float64(1),
+					"timestamp":  nowStr,
+					"type":       "summary",
+				},
+			},
+		},
 		"apply prune wait": {
 			statsCollector: stats.Stats{
 				ApplyStats: stats.ApplyStats{
@@ -862,6 +1037,85 @@ func TestFormatter_FormatSummary(t *testing.T) {
 				},
 			},
 		},
+		"apply wait with durations": {
+			statsCollector: stats.Stats{
+				ApplyStats: stats.ApplyStats{
+					Successful: 2,
+				},
+				WaitStats: stats.WaitStats{
+					Successful: 1,
+				},
+				DurationStats: stats.DurationStats{
+					Apply: stats.DurationHistogram{
+						Counts: [9]int{1, 0, 1},
+						Count:  2,
+						Sum:    1 * time.Second,
+						Max:    750 * time.Millisecond,
+					},
+					Reconcile: stats.DurationHistogram{
+						Counts: [9]int{0, 0, 0, 0, 0, 0, 0, 0, 1},
+						Count:  1,
+						Sum:    10 * time.Minute,
+						Max:    10 * time.Minute,
+					},
+				},
+			},
+			expected: []map[string]interface{}{
+				{
+					"action":     "Apply",
+					"count":      float64(2),
+					"successful": float64(2),
+					"skipped":    float64(0),
+					"failed":     float64(0),
+					"durations": map[string]interface{}{
+						"count": float64(2),
+						"sum":   float64(1),
+						"mean":  float64(0.5),
+						"max":   float64(0.75),
+						"buckets": map[string]interface{}{
+							"100ms": float64(1),
+							"500ms": float64(0),
+							"1s":    float64(1),
+							"5s":    float64(0),
+							"10s":   float64(0),
+							"30s":   float64(0),
+							"1m0s":  float64(0),
+							"5m0s":  float64(0),
+							"+Inf":  float64(0),
+						},
+					},
+					"timestamp": nowStr,
+					"type":      "summary",
+				},
+				{
+					"action":     "Wait",
+					"count":      float64(1),
+					"successful": float64(1),
+					"skipped":    float64(0),
+					"failed":     float64(0),
+					"timeout":    float64(0),
+					"durations": map[string]interface{}{
+						"count": float64(1),
+						"sum":   float64(600),
+						"mean":  float64(600),
+						"max":   float64(600),
+						"buckets": map[string]interface{}{
+							"100ms": float64(0),
+							"500ms": float64(0),
+							"1s":    float64(0),
+							"5s":    float64(0),
+							"10s":   float64(0),
+							"30s":   float64(0),
+							"1m0s":  float64(0),
+							"5m0s":  float64(0),
+							"+Inf":  float64(1),
+						},
+					},
+					"timestamp": nowStr,
+					"type":      "summary",
+				},
+			},
+		},
 	}
 
 	for tn, tc := range testCases {
@@ -25,8 +25,8 @@ func newResourceStateCollector(resourceGroups []event.ActionGroup) *resourceStat
 	for _, group := range resourceGroups {
 		action := group.Action
 		// Keep the action that describes the operation for the resource
-		// rather than that we will wait for it.
-		if action == event.WaitAction {
+		// rather than that we will wait for it or roll it back.
+		if action == event.WaitAction || action == event.RollbackAction {
 			continue
 		}
 		for _, identifier := range group.Identifiers {
@@ -100,6 +100,14 @@ type resourceInfo struct {
 	// WaitStatus contains the result after
 	// a wait operation on a resource
 	WaitStatus event.WaitEventStatus
+
+	// ActuationTiming contains the time spent applying,
+	// pruning or deleting the resource
+	ActuationTiming event.Timing
+
+	// ReconcileTiming contains the time from actuation
+	// until the resource was reconciled
+	ReconcileTiming event.Timing
 }
 
 // Identifier returns the identifier for the given resource.
@@ -200,6 +208,10 @@ func (r *resourceStateCollector) processEvent(ev event.Event) error {
 		r.processDeleteEvent(ev.DeleteEvent)
 	case event.WaitType:
 		r.processWaitEvent(ev.WaitEvent)
+	case event.RollbackType:
+		r.processRollbackEvent(ev.RollbackEvent)
+	case event.TransferType:
+		r.processTransferEvent(ev.TransferEvent)
 	case event.ErrorType:
 		return ev.ErrorEvent.Err
 	}
@@ -257,9 +269,19 @@ func (r *resourceStateCollector) processApplyEvent(e event.ApplyEvent) {
 	}
 	if e.Error != nil {
 		previous.Error = e.Error
+	} else if previous.ApplyStatus == event.ApplyRetrying {
+		// the retry succeeded
+		previous.Error = nil
+	}
+	if !e.Timing.End.IsZero() {
+		previous.ActuationTiming = e.Timing
 	}
 	previous.ApplyStatus = e.Status
 	r.stats.ApplyStats.Inc(e.Status)
+	if e.Migrated {
+		r.stats.ApplyStats.Migrated++
+	}
+	r.stats.DurationStats.Apply.ObserveTiming(e.Timing)
 }
 
 // processPruneEvent handles event related to prune operations.
@@ -273,9 +295,16 @@ func (r *resourceStateCollector) processPruneEvent(e event.PruneEvent) {
 	}
 	if e.Error != nil {
 		previous.Error = e.Error
+	} else if previous.PruneStatus == event.PruneRetrying {
+		// the retry succeeded
+		previous.Error = nil
+	}
+	if !e.Timing.End.IsZero() {
+		previous.ActuationTiming = e.Timing
 	}
 	previous.PruneStatus = e.Status
 	r.stats.PruneStats.Inc(e.Status)
+	r.stats.DurationStats.Prune.ObserveTiming(e.Timing)
 }
 
 // processDeleteEvent handles event related to delete operations.
@@ -289,9 +318,16 @@ func (r *resourceStateCollector) processDeleteEvent(e event.DeleteEvent) {
 	}
 	if e.Error != nil {
 		previous.Error = e.Error
+	} else if previous.DeleteStatus == event.DeleteRetrying {
+		// the retry succeeded
+		previous.Error = nil
+	}
+	if !e.Timing.End.IsZero() {
+		previous.ActuationTiming = e.Timing
 	}
 	previous.DeleteStatus = e.Status
 	r.stats.DeleteStats.Inc(e.Status)
+	r.stats.DurationStats.Delete.ObserveTiming(e.Timing)
 }
 
 // processPruneEvent handles event related to prune operations.
@@ -304,7 +340,39 @@ func (r *resourceStateCollector) processWaitEvent(e event.WaitEvent) {
 		return
 	}
 	previous.WaitStatus = e.Status
+	previous.ReconcileTiming = e.Timing
 	r.stats.WaitStats.Inc(e.Status)
+	r.stats.DurationStats.ObserveWait(e)
+}
+
+// processRollbackEvent handles event related to rollback operations.
+func (r *resourceStateCollector) processRollbackEvent(e event.RollbackEvent) {
+	identifier := e.Identifier
+	klog.V(7).Infof("processing rollback event for %s", identifier)
+	previous, found := r.resourceInfos[identifier]
+	if !found {
+		klog.V(4).Infof("%s rollback event not found in ResourceInfos; no processing", identifier)
+		return
+	}
+	if e.Error != nil {
+		previous.Error = e.Error
+	}
+	r.stats.RollbackStats.Inc(e.Status)
+}
+
+// processTransferEvent handles event related to inventory transfers.
+func (r *resourceStateCollector) processTransferEvent(e event.TransferEvent) {
+	identifier := e.Identifier
+	klog.V(7).Infof("processing transfer event for %s", identifier)
+	previous, found := r.resourceInfos[identifier]
+	if !found {
+		klog.V(4).Infof("%s transfer event not found in ResourceInfos; no processing", identifier)
+		return
+	}
+	if e.Error != nil {
+		previous.Error = e.Error
+	}
+	r.stats.TransferStats.Inc(e.Status)
 }
 
 // ResourceState contains the latest state for all the resources.
@@ -344,6 +412,9 @@ func (r *resourceStateCollector) LatestState() *ResourceState {
 			PruneStatus:    ri.PruneStatus,
 			DeleteStatus:   ri.DeleteStatus,
 			WaitStatus:     ri.WaitStatus,
+
+			ActuationTiming: ri.ActuationTiming,
+			ReconcileTiming: ri.ReconcileTiming,
 		})
 	}
 	sort.Sort(resourceInfos)
@@ -144,18 +144,75 @@ var (
 		},
 	}
 
+	actuationTimeColumnDef = table.ColumnDef{
+		// Column containing the time spent applying, pruning or
+		// deleting the resource.
+		ColumnName:   "actiontime",
+		ColumnHeader: "ACTION TIME",
+		ColumnWidth:  11,
+		PrintResourceFunc: func(w io.Writer, width int, r table.Resource) (
+			int,
+			error,
+		) {
+			resInfo, ok := r.(*resourceInfo)
+			if !ok {
+				return 0, nil
+			}
+			return printDuration(w, width, resInfo.ActuationTiming)
+		},
+	}
+
+	reconcileTimeColumnDef = table.ColumnDef{
+		// Column containing the time from actuation until the
+		// resource was reconciled.
+		ColumnName:   "reconciletime",
+		ColumnHeader: "RECONCILE TIME",
+		ColumnWidth:  14,
+		PrintResourceFunc: func(w io.Writer, width int, r table.Resource) (
+			int,
+			error,
+		) {
+			resInfo, ok := r.(*resourceInfo)
+			if !ok {
+				return 0, nil
+			}
+			return printDuration(w, width, resInfo.ReconcileTiming)
+		},
+	}
+
 	columns = []table.ColumnDefinition{
 		table.MustColumn("namespace"),
 		table.MustColumn("resource"),
 		actionColumnDef,
+		actuationTimeColumnDef,
 		table.MustColumn("status"),
 		reconciledColumnDef,
+		reconcileTimeColumnDef,
 		table.MustColumn("conditions"),
 		table.MustColumn("age"),
 		table.MustColumn("message"),
 	}
 )
 
+// printDuration prints the duration of the operation, if it has ended.
+func printDuration(w io.Writer, width int, timing event.Timing) (int, error) {
+	if timing.Start.IsZero() || timing.End.IsZero() {
+		return 0, nil
+	}
+	d := timing.Duration()
+	if d >= time.Second {
+		d = d.Round(100 * time.Millisecond)
+	} else {
+		d = d.Round(time.Millisecond)
+	}
+	text := d.String()
+	if len(text) > width {
+		text = text[:width]
+	}
+	_, err := fmt.Fprint(w, text)
+	return len(text), err
+}
+
 // runPrintLoop starts a new goroutine that will regularly fetch the
 // latest state from the collector and update the table.
 func (t *Printer) runPrintLoop(coll *resourceStateCollector, stop chan struct{}) chan struct{} {
@@ -6,6 +6,7 @@ package table
 import (
 	"bytes"
 	"testing"
+	"time"
 
 	"github.com/fluxcd/cli-utils/pkg/apply/event"
 	"github.com/fluxcd/cli-utils/pkg/print/table"
@@ -71,6 +72,87 @@ func TestActionColumnDef(t *testing.T) {
 	}
 }
 
+func TestTimeColumnDefs(t *testing.T) {
+	start := time.Date(2022, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	testCases := map[string]struct {
+		columnDef      table.ColumnDef
+		resource       table.Resource
+		columnWidth    int
+		expectedOutput string
+	}{
+		"unexpected implementation of Resource interface": {
+			columnDef:      actuationTimeColumnDef,
+			resource:       &subResourceInfo{},
+			columnWidth:    11,
+			expectedOutput: "",
+		},
+		"not actuated": {
+			columnDef:      actuationTimeColumnDef,
+			resource:       &resourceInfo{},
+			columnWidth:    11,
+			expectedOutput: "",
+		},
+		"applied": {
+			columnDef: actuationTimeColumnDef,
+			resource: &resourceInfo{
+				ActuationTiming: event.Timing{
+					Start: start,
+					End:   start.Add(123456 * time.Microsecond),
+				},
+			},
+			columnWidth:    11,
+			expectedOutput: "123ms",
+		},
+		"reconcile pending": {
+			columnDef: reconcileTimeColumnDef,
+			resource: &resourceInfo{
+				ReconcileTiming: event.Timing{
+					Start: start,
+				},
+			},
+			columnWidth:    14,
+			expectedOutput: "",
+		},
+		"reconciled": {
+			columnDef: reconcileTimeColumnDef,
+			resource: &resourceInfo{
+				ReconcileTiming: event.Timing{
+					Start: start,
+					End:   start.Add(2*time.Minute + 3456*time.Millisecond),
+				},
+			},
+			columnWidth:    14,
+			expectedOutput: "2m3.5s",
+		},
+		"trimmed output": {
+			columnDef: reconcileTimeColumnDef,
+			resource: &resourceInfo{
+				ReconcileTiming: event.Timing{
+					Start: start,
+					End:   start.Add(2*time.Minute + 3456*time.Millisecond),
+				},
+			},
+			columnWidth:    3,
+			expectedOutput: "2m3",
+		},
+	}
+
+	for tn, tc := range testCases {
+		t.Run(tn, func(t *testing.T) {
+			var buf bytes.Buffer
+			_, err := tc.columnDef.PrintResource(&buf, tc.columnWidth, tc.resource)
+			if err != nil {
+				t.Error(err)
+			}
+
+			if want, got := tc.expectedOutput, buf.String(); want != got {
+				t.Errorf("expected %q, but got %q", want, got)
+			}
+		})
+	}
+}
+
 func TestPrint(t *testing.T) {
 	printertesting.PrintResultErrorTest(t, func() printer.Printer {
 		ioStreams, _, _, _ := genericclioptions.NewTestIOStreams()
@@ -26,6 +26,8 @@ type ExpEvent struct {
 	DeleteEvent      *ExpDeleteEvent
 	WaitEvent        *ExpWaitEvent
 	ValidationEvent  *ExpValidationEvent
+	RollbackEvent    *ExpRollbackEvent
+	TransferEvent    *ExpTransferEvent
 }
 
 type ExpInitEvent struct {
@@ -74,6 +76,22 @@ type ExpWaitEvent struct {
 	GroupName  string
 	Status     event.WaitEventStatus
 	Identifier object.ObjMetadata
+	Condition  string
+}
+
+type ExpRollbackEvent struct {
+	GroupName  string
+	Operation  event.RollbackOperation
+	Status     event.RollbackEventStatus
+	Identifier object.ObjMetadata
+	Error      error
+}
+
+type ExpTransferEvent struct {
+	GroupName  string
+	Status     event.TransferEventStatus
+	Identifier object.ObjMetadata
+	Error      error
 }
 
 type ExpValidationEvent struct {
@@ -275,6 +293,12 @@ func isMatch(ee ExpEvent, e event.Event) bool {
 		if wee.Status != we.Status {
 			return false
 		}
+
+		if wee.Condition != "" {
+			if wee.Condition != we.Condition {
+				return false
+			}
+		}
 		return true
 
 	case event.ValidationType:
@@ -295,6 +319,66 @@ func isMatch(ee ExpEvent, e event.Event) bool {
 		}
 		return ve.Error == nil
 
+	case event.RollbackType:
+		ree := ee.RollbackEvent
+		if ree == nil {
+			return true
+		}
+		re := e.RollbackEvent
+
+		if ree.Identifier != object.NilObjMetadata {
+			if ree.Identifier != re.Identifier {
+				return false
+			}
+		}
+
+		if ree.GroupName != "" {
+			if ree.GroupName != re.GroupName {
+				return false
+			}
+		}
+
+		if ree.Operation != re.Operation {
+			return false
+		}
+
+		if ree.Status != re.Status {
+			return false
+		}
+
+		if ree.Error != nil {
+			return re.Error != nil
+		}
+		return re.Error == nil
+
+	case event.TransferType:
+		tee := ee.TransferEvent
+		if tee == nil {
+			return true
+		}
+		te := e.TransferEvent
+
+		if tee.Identifier != object.NilObjMetadata {
+			if tee.Identifier != te.Identifier {
+				return false
+			}
+		}
+
+		if tee.GroupName != "" {
+			if tee.GroupName != te.GroupName {
+				return false
+			}
+		}
+
+		if tee.Status != te.Status {
+			return false
+		}
+
+		if tee.Error != nil {
+			return te.Error != nil
+		}
+		return te.Error == nil
+
 	default:
 		return true
 	}
@@ -387,6 +471,7 @@ func EventToExpEvent(e event.Event) ExpEvent {
 				GroupName:  e.WaitEvent.GroupName,
 				Identifier: e.WaitEvent.Identifier,
 				Status:     e.WaitEvent.Status,
+				Condition:  e.WaitEvent.Condition,
 			},
 		}
 
@@ -398,6 +483,29 @@ func EventToExpEvent(e event.Event) ExpEvent {
 				Error:       e.ValidationEvent.Error,
 			},
 		}
+
+	case event.RollbackType:
+		return ExpEvent{
+			EventType: event.RollbackType,
+			RollbackEvent: &ExpRollbackEvent{
+				GroupName:  e.RollbackEvent.GroupName,
+				Identifier: e.RollbackEvent.Identifier,
+				Operation:  e.RollbackEvent.Operation,
+				Status:     e.RollbackEvent.Status,
+				Error:      e.RollbackEvent.Error,
+			},
+		}
+
+	case event.TransferType:
+		return ExpEvent{
+			EventType: event.TransferType,
+			TransferEvent: &ExpTransferEvent{
+				GroupName:  e.TransferEvent.GroupName,
+				Identifier: e.TransferEvent.Identifier,
+				Status:     e.TransferEvent.Status,
+				Error:      e.TransferEvent.Error,
+			},
+		}
 	}
 	return ExpEvent{}
 }
@@ -455,6 +563,10 @@ func (ape GroupedEventsByID) Less(i, j int) bool {
 		// Prune events are predictably ordered in reverse apply order.
 	case event.DeleteType:
 		// Delete events are predictably ordered in reverse apply order.
+	case event.RollbackType:
+		// Rollback events are predictably ordered in reverse apply order.
+	case event.TransferType:
+		// Transfer events are predictably ordered by input object set order.
 	case event.WaitType:
 		// Wait events are unpredictably ordered, because the status may
 		// reconcile before or after the WaitTask starts, and status event
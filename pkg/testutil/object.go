@@ -10,6 +10,8 @@ import (
 
 	"github.com/fluxcd/cli-utils/pkg/object"
 	"github.com/fluxcd/cli-utils/pkg/object/dependson"
+	"github.com/fluxcd/cli-utils/pkg/object/hook"
+	"github.com/fluxcd/cli-utils/pkg/object/waitfor"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -160,3 +162,78 @@ func (d dependsOnMutator) Mutate(u *unstructured.Unstructured) {
 		d.t.FailNow()
 	}
 }
+
+// AddWaitFor returns a testutil.Mutator which adds the passed value as a
+// wait-for annotation to the object which is mutated. The value is not
+// validated, so invalid annotations can be tested.
+func AddWaitFor(value string) Mutator {
+	return waitForMutator{
+		value: value,
+	}
+}
+
+// waitForMutator encapsulates fields for adding wait-for annotation
+// to a test object. Implements the Mutator interface.
+type waitForMutator struct {
+	value string
+}
+
+// Mutate writes a wait-for annotation on the supplied object.
+func (w waitForMutator) Mutate(u *unstructured.Unstructured) {
+	annos := u.GetAnnotations()
+	if annos == nil {
+		annos = map[string]string{}
+	}
+	annos[waitfor.Annotation] = w.value
+	u.SetAnnotations(annos)
+}
+
+// AddReconcileTimeout returns a testutil.Mutator which adds the passed value
+// as a reconcile-timeout annotation to the object which is mutated. The
+// value is not validated, so invalid annotations can be tested.
+func AddReconcileTimeout(value string) Mutator {
+	return reconcileTimeoutMutator{
+		value: value,
+	}
+}
+
+// reconcileTimeoutMutator encapsulates fields for adding reconcile-timeout
+// annotation to a test object. Implements the Mutator interface.
+type reconcileTimeoutMutator struct {
+	value string
+}
+
+// Mutate writes a reconcile-timeout annotation on the supplied object.
+func (r reconcileTimeoutMutator) Mutate(u *unstructured.Unstructured) {
+	annos := u.GetAnnotations()
+	if annos == nil {
+		annos = map[string]string{}
+	}
+	annos[waitfor.TimeoutAnnotation] = r.value
+	u.SetAnnotations(annos)
+}
+
+// AddHook returns a testutil.Mutator which adds the passed value as a hook
+// annotation to the object which is mutated. The value is not validated, so
+// invalid annotations can be tested.
+func AddHook(value string) Mutator {
+	return hookMutator{
+		value: value,
+	}
+}
+
+// hookMutator encapsulates fields for adding hook annotation to a test
+// object. Implements the Mutator interface.
+type hookMutator struct {
+	value string
+}
+
+// Mutate writes a hook annotation on the supplied object.
+func (h hookMutator) Mutate(u *unstructured.Unstructured) {
+	annos := u.GetAnnotations()
+	if annos == nil {
+		annos = map[string]string{}
+	}
+	annos[hook.Annotation] = h.value
+	u.SetAnnotations(annos)
+}
@@ -17,6 +17,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/kubectl/pkg/cmd/util"
 )
@@ -82,6 +83,10 @@ spec:
                       type: string
                     reconcile:
                       type: string
+                    uid:
+                      type: string
+                    generation:
+                      type: integer
                   required:
                   - group
                   - kind
@@ -189,6 +194,50 @@ func (i InventoryCustomType) Load() (object.ObjMetadataSet, error) {
 	return inv, nil
 }
 
+func (i InventoryCustomType) LoadStatus() ([]actuation.ObjectStatus, error) {
+	var status []actuation.ObjectStatus
+	s, found, err := unstructured.NestedSlice(i.inv.Object, "status", "objects")
+	if err != nil {
+		return status, err
+	}
+	if !found {
+		return status, nil
+	}
+	for _, item := range s {
+		m := item.(map[string]interface{})
+		namespace, _, _ := unstructured.NestedString(m, "namespace")
+		name, _, _ := unstructured.NestedString(m, "name")
+		group, _, _ := unstructured.NestedString(m, "group")
+		kind, _, _ := unstructured.NestedString(m, "kind")
+		strategy, _, _ := unstructured.NestedString(m, "strategy")
+		act, _, _ := unstructured.NestedString(m, "actuation")
+		reconcile, _, _ := unstructured.NestedString(m, "reconcile")
+		uid, _, _ := unstructured.NestedString(m, "uid")
+		generation, _, _ := unstructured.NestedInt64(m, "generation")
+		objStatus := actuation.ObjectStatus{
+			ObjectReference: actuation.ObjectReference{
+				Group:     group,
+				Kind:      kind,
+				Namespace: namespace,
+				Name:      name,
+			},
+			UID:        types.UID(uid),
+			Generation: generation,
+		}
+		if objStatus.Strategy, err = actuation.ParseActuationStrategy(strategy); err != nil {
+			return status, err
+		}
+		if objStatus.Actuation, err = actuation.ParseActuationStatus(act); err != nil {
+			return status, err
+		}
+		if objStatus.Reconcile, err = actuation.ParseReconcileStatus(reconcile); err != nil {
+			return status, err
+		}
+		status = append(status, objStatus)
+	}
+	return status, nil
+}
+
 func (i InventoryCustomType) Store(objs object.ObjMetadataSet, status []actuation.ObjectStatus) error {
 	var specObjs []interface{}
 	for _, obj := range objs {
@@ -202,13 +251,15 @@ func (i InventoryCustomType) Store(objs object.ObjMetadataSet, status []actuatio
 	var statusObjs []interface{}
 	for _, objStatus := range status {
 		statusObjs = append(statusObjs, map[string]interface{}{
-			"group":     objStatus.Group,
-			"kind":      objStatus.Kind,
-			"namespace": objStatus.Namespace,
-			"name":      objStatus.Name,
-			"strategy":  objStatus.Strategy.String(),
-			"actuation": objStatus.Actuation.String(),
-			"reconcile": objStatus.Reconcile.String(),
+			"group":      objStatus.Group,
+			"kind":       objStatus.Kind,
+			"namespace":  objStatus.Namespace,
+			"name":       objStatus.Name,
+			"strategy":   objStatus.Strategy.String(),
+			"actuation":  objStatus.Actuation.String(),
+			"reconcile":  objStatus.Reconcile.String(),
+			"uid":        string(objStatus.UID),
+			"generation": objStatus.Generation,
 		})
 	}
 	if len(specObjs) > 0 {
@@ -263,8 +314,12 @@ func (i InventoryCustomType) Apply(dc dynamic.Interface, mapper meta.RESTMapper,
 
 	// Update status.
 	invInfo.SetResourceVersion(appliedObj.GetResourceVersion())
-	_, err = namespacedClient.UpdateStatus(context.TODO(), invInfo, metav1.UpdateOptions{})
-	return err
+	appliedObj, err = namespacedClient.UpdateStatus(context.TODO(), invInfo, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	invInfo.SetResourceVersion(appliedObj.GetResourceVersion())
+	return nil
 }
 
 func (i InventoryCustomType) ApplyWithPrune(dc dynamic.Interface, mapper meta.RESTMapper, _ inventory.StatusPolicy, _ object.ObjMetadataSet) error {
@@ -281,8 +336,12 @@ func (i InventoryCustomType) ApplyWithPrune(dc dynamic.Interface, mapper meta.RE
 
 	// Update status.
 	invInfo.SetResourceVersion(appliedObj.GetResourceVersion())
-	_, err = namespacedClient.UpdateStatus(context.TODO(), invInfo, metav1.UpdateOptions{})
-	return err
+	appliedObj, err = namespacedClient.UpdateStatus(context.TODO(), invInfo, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	invInfo.SetResourceVersion(appliedObj.GetResourceVersion())
+	return nil
 }
 
 func (i InventoryCustomType) getNamespacedClient(dc dynamic.Interface, mapper meta.RESTMapper) (*unstructured.Unstructured, dynamic.ResourceInterface, error) {